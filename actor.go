@@ -0,0 +1,18 @@
+package rcon
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the identity of the end user a command is executed on behalf of, for example
+// the admin using a web panel. Commands executed with the context record the actor in Stats and in log entries about
+// them.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor attached to ctx with WithActor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
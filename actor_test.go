@@ -0,0 +1,74 @@
+package rcon_test
+
+import (
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/events"
+	"testing"
+)
+
+func TestActor(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("WithActor", func() {
+		g.It("Should be read back by ActorFromContext", func() {
+			actor, ok := rcon.ActorFromContext(rcon.WithActor(context.Background(), "alice"))
+			Expect(ok).To(BeTrue())
+			Expect(actor).To(Equal("alice"))
+
+			_, ok = rcon.ActorFromContext(context.Background())
+			Expect(ok).To(BeFalse())
+		})
+
+		g.It("Should reach middleware, log entries and audit entries", func() {
+			config := &rcon.Config{}
+			newTestServer(t, config)
+
+			logger := &recordingLogger{}
+			client := rcon.NewClient(config, logger)
+			defer client.Close()
+
+			actors := make(chan string, 1)
+			client.Use(func(next rcon.ExecFunc) rcon.ExecFunc {
+				return func(ctx context.Context, command string) (string, error) {
+					actor, _ := rcon.ActorFromContext(ctx)
+					actors <- actor
+
+					return next(ctx, command)
+				}
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommandContext(rcon.WithActor(context.Background(), "alice"), "status")
+			Expect(err).To(BeNil())
+
+			Expect(actors).To(Receive(Equal("alice")))
+			Expect(logger.Entries()).To(ContainElement("DEBUG: Executing command on behalf of alice: status"))
+
+			slowest := client.Stats().Slowest
+			Expect(slowest).To(HaveLen(1))
+
+			entry := events.FromCommandTiming(slowest[0])
+			Expect(entry.Actor).To(Equal("alice"))
+			Expect(entry.Command).To(Equal("status"))
+		})
+
+		g.It("Should leave the actor empty for commands without one", func() {
+			_, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+
+			slowest := client.Stats().Slowest
+			Expect(slowest).To(HaveLen(1))
+			Expect(events.FromCommandTiming(slowest[0]).Actor).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package rcon
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindowSize is the number of recent response latencies kept for adaptive timeouts.
+	latencyWindowSize = 64
+
+	// minLatencySamples is the number of samples required before adaptive timeouts kick in. Until then
+	// QueueReadTimeout is used.
+	minLatencySamples = 8
+
+	// adaptiveTimeoutFactor is the headroom applied on top of the observed latency percentile.
+	adaptiveTimeoutFactor = 2
+)
+
+// latencyWindow is a fixed size ring buffer of recent response latencies.
+type latencyWindow struct {
+	lock    sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of the recorded latencies. The second return value is false if
+// not enough samples have been recorded yet.
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.lock.Lock()
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	w.lock.Unlock()
+
+	if len(sorted) < minLatencySamples {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+
+	return sorted[idx], true
+}
+
+// readTimeout returns the timeout to use when waiting for a command response. If AdaptiveTimeout is disabled or not
+// enough latencies have been observed, QueueReadTimeout is returned.
+func (c *Client) readTimeout() time.Duration {
+	if !c.AdaptiveTimeout {
+		return c.QueueReadTimeout
+	}
+
+	p, ok := c.latencies.percentile(c.AdaptiveTimeoutPercentile)
+	if !ok {
+		return c.QueueReadTimeout
+	}
+
+	timeout := p * adaptiveTimeoutFactor
+	if timeout < c.AdaptiveTimeoutMin {
+		timeout = c.AdaptiveTimeoutMin
+	} else if timeout > c.AdaptiveTimeoutMax {
+		timeout = c.AdaptiveTimeoutMax
+	}
+
+	return timeout
+}
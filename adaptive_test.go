@@ -0,0 +1,93 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/errs"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Adaptive timeouts", func() {
+		var server *demo.Server
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = demo.NewServer("password")
+
+			// sleep answers after the given number of milliseconds.
+			server.Handle("sleep", func(args string) string {
+				ms, _ := strconv.Atoi(args)
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+				return "slept"
+			})
+
+			host, port, err := server.Start()
+			Expect(err).To(BeNil())
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:               host,
+				Port:               port,
+				Password:           "password",
+				QueueReadTimeout:   time.Second,
+				AdaptiveTimeout:    true,
+				AdaptiveTimeoutMin: time.Millisecond * 100,
+				AdaptiveTimeoutMax: time.Millisecond * 250,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		// observe executes n commands answered after ms milliseconds, which the client records as latencies.
+		observe := func(n int, ms int) {
+			for i := 0; i < n; i++ {
+				_, err := client.ExecCommand("sleep " + strconv.Itoa(ms))
+				Expect(err).To(BeNil())
+			}
+		}
+
+		g.It("Should use QueueReadTimeout until enough latencies were observed", func() {
+			observe(3, 0)
+
+			res, err := client.ExecCommand("sleep 400")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("slept"))
+		})
+
+		g.It("Should time out commands much slower than observed, but not after AdaptiveTimeoutMin", func() {
+			observe(8, 0)
+
+			res, err := client.ExecCommand("sleep 50")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("slept"))
+
+			start := time.Now()
+			_, err = client.ExecCommand("sleep 400")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*350))
+		})
+
+		g.It("Should not wait longer than AdaptiveTimeoutMax", func() {
+			observe(8, 200)
+
+			start := time.Now()
+			_, err := client.ExecCommand("sleep 330")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*330))
+		})
+	})
+}
@@ -0,0 +1,148 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"time"
+)
+
+// PendingResponse is the future result of a command executed with ExecCommandAsync.
+type PendingResponse struct {
+	c       *Client
+	command string
+	id      int32
+	start   time.Time
+
+	// timerLock guards timer, which may fire before it was assigned.
+	timerLock sync.Mutex
+	timer     *time.Timer
+
+	cancel func()
+	track  func(err error)
+
+	once sync.Once
+	done chan struct{}
+	res  string
+	err  error
+}
+
+// Done returns a channel which is closed once the result is available.
+func (r *PendingResponse) Done() <-chan struct{} {
+	return r.done
+}
+
+// Result waits for the command to complete and returns its response, like ExecCommand.
+func (r *PendingResponse) Result() (string, error) {
+	<-r.done
+	return r.res, r.err
+}
+
+// Cancel stops waiting for the response. Result then returns context.Canceled wrapped, unless the response arrived
+// first. A response arriving later is counted as a late response. Cancel is safe to call more than once.
+func (r *PendingResponse) Cancel() {
+	r.cancel()
+}
+
+func (r *PendingResponse) finish(res string, err error) {
+	r.once.Do(func() {
+		r.timerLock.Lock()
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		r.timerLock.Unlock()
+
+		r.res, r.err = res, err
+		r.track(err)
+		close(r.done)
+	})
+}
+
+// ExecCommandAsync sends command and returns without waiting for the response. Errors which occur before the command
+// was queued, such as a closed client, are returned immediately; all others are returned by the PendingResponse.
+//
+// Commands sent over a Source RCON connection which answers in single packets don't hold a goroutine while waiting for
+// their response, so callers can fire many commands and collect the results later. In all other modes, while the
+// client is paused, and if middleware was added with Use, the command is executed by a goroutine of its own, passing
+// through the middleware.
+func (c *Client) ExecCommandAsync(command string) (*PendingResponse, error) {
+	r := &PendingResponse{
+		c:       c,
+		command: command,
+		done:    make(chan struct{}),
+	}
+
+	ctx := context.Background()
+	r.track = c.trackCommand(ctx, command, true)
+
+	if err := c.checkCommandSize(command); err != nil {
+		r.finish("", err)
+		return nil, err
+	}
+
+	if err := c.checkClosing(); err != nil {
+		r.finish("", err)
+		return nil, err
+	}
+
+	if c.Transport != nil || c.Protocol == ProtocolBattlEye || c.ConnectionPerCommand || c.multiPacket() ||
+		c.pauseChan() != nil || c.hasMiddleware() {
+		ctx, cancel := context.WithCancel(ctx)
+		r.cancel = cancel
+
+		exec := c.wrap(c.execCommand)
+		go func() {
+			r.finish(exec(ctx, command))
+		}()
+
+		return r, nil
+	}
+
+	p := c.newClientPacket(packet.TypeCommand, command)
+	r.id = p.ID()
+	r.cancel = func() {
+		r.finish("", fmt.Errorf("command cancelled: %w", context.Canceled))
+		c.abandonMailbox(r.id)
+	}
+
+	c.log.Debug("Executing command asynchronously: ", command)
+
+	if err := c.enqueuePacket(ctx, p, 1); err != nil {
+		err = fmt.Errorf("could not enqueue command packet: %w", err)
+		r.finish("", err)
+		return nil, err
+	}
+
+	r.start = time.Now()
+	r.timerLock.Lock()
+	r.timer = time.AfterFunc(c.readTimeout(), func() {
+		r.finish("", &errs.ResponseTimeoutError{PacketID: r.id, Timeout: c.readTimeout()})
+		c.abandonMailbox(r.id)
+	})
+	r.timerLock.Unlock()
+
+	if !c.mailboxes.watch(r.id, r.delivered) {
+		r.finish("", fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed))
+	}
+
+	return r, nil
+}
+
+// delivered is the mailbox watcher of an asynchronous command.
+func (r *PendingResponse) delivered(ok bool) {
+	mailbox := r.c.mailboxes.get(r.id)
+	if !ok || mailbox == nil {
+		r.finish("", fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed))
+		return
+	}
+
+	// The watcher is called after the response was put into the mailbox, so this never blocks.
+	p := <-mailbox
+	r.c.removeMailbox(r.id)
+	r.c.latencies.add(time.Since(r.start))
+
+	body := p.Body()
+	r.finish(r.c.checkResponse(r.command, string(body[:len(body)-1])))
+}
@@ -0,0 +1,148 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+	"time"
+)
+
+func TestExecCommandAsync(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ExecCommandAsync()", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.Handle("echo", func(args string) string { return args })
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 200)
+				return "done"
+			})
+			host, port := server.Addr()
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second,
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		connect := func() {
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+		}
+
+		g.It("Should collect the responses of many commands later", func() {
+			connect()
+
+			pending := make([]*rcon.PendingResponse, 50)
+			for i := range pending {
+				var err error
+				pending[i], err = client.ExecCommandAsync(fmt.Sprintf("echo %d", i))
+				Expect(err).To(BeNil())
+			}
+
+			for i, r := range pending {
+				Eventually(r.Done()).Should(BeClosed())
+
+				res, err := r.Result()
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(fmt.Sprint(i)))
+			}
+		})
+
+		g.It("Should return server command errors", func() {
+			config.ResponseErrorChecker = func(command, response string) bool {
+				return response == "denied"
+			}
+			server.SetResponse("ban", "denied")
+			connect()
+
+			r, err := client.ExecCommandAsync("ban")
+			Expect(err).To(BeNil())
+
+			_, err = r.Result()
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+		})
+
+		g.It("Should time out after QueueReadTimeout", func() {
+			config.QueueReadTimeout = time.Millisecond * 100
+			connect()
+
+			r, err := client.ExecCommandAsync("slow")
+			Expect(err).To(BeNil())
+
+			_, err = r.Result()
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+		})
+
+		g.It("Should stop waiting when cancelled", func() {
+			connect()
+
+			r, err := client.ExecCommandAsync("slow")
+			Expect(err).To(BeNil())
+
+			r.Cancel()
+			r.Cancel()
+
+			_, err = r.Result()
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Eventually(func() uint64 { return client.Stats().LateResponses }).Should(BeEquivalentTo(1))
+		})
+
+		g.It("Should send commands once a paused client is resumed", func() {
+			connect()
+			client.Pause()
+
+			r, err := client.ExecCommandAsync("echo resumed")
+			Expect(err).To(BeNil())
+			Consistently(r.Done(), time.Millisecond*100).ShouldNot(BeClosed())
+
+			client.Resume()
+
+			res, err := r.Result()
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("resumed"))
+		})
+
+		g.It("Should fail right away on a closing client", func() {
+			connect()
+
+			slow, err := client.ExecCommandAsync("slow")
+			Expect(err).To(BeNil())
+			Eventually(server.Commands).Should(ContainElement("slow"))
+
+			go func() { _ = client.Close() }()
+			Eventually(client.Status).Should(Equal(rcon.StateClosing))
+
+			_, err = client.ExecCommandAsync("echo late")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+
+			// Close waits for the asynchronous command like for any other in flight.
+			res, err := slow.Result()
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("done"))
+		})
+	})
+}
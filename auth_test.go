@@ -0,0 +1,78 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+	"testing"
+)
+
+// wrongAuthCodec is a Source codec which answers authentication requests with a response value packet carrying body,
+// like servers speaking a different protocol.
+type wrongAuthCodec struct {
+	*packet.SourceCodec
+	body string
+}
+
+func (c wrongAuthCodec) Encode(p packet.Packet) ([]byte, error) {
+	if p.Type() == packet.TypeAuthRes {
+		p = packet.NewPacketWithID(c.Mode, p.ID(), packet.TypeCommandRes, c.body)
+	}
+
+	return c.SourceCodec.Encode(p)
+}
+
+func TestAuthentication(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// connect returns the error of connecting to a server which answers authentication with body.
+	connect := func(body string) error {
+		server, client := newTestClient(t, &rcon.Config{})
+		server.SetCodec(wrongAuthCodec{SourceCodec: packet.NewSourceCodec(endian.Little), body: body})
+
+		return client.Connect()
+	}
+
+	g.Describe("Authentication", func() {
+		g.It("Should return the unexpected packet a server answered with", func() {
+			err := connect("Unknown request 3")
+
+			var unexpected *errs.UnexpectedAuthPacketError
+			Expect(errors.As(err, &unexpected)).To(BeTrue())
+			Expect(unexpected.Type).To(Equal(int32(packet.TypeCommandRes)))
+			Expect(unexpected.ID).ToNot(Equal(packet.AuthFailedID))
+			Expect(unexpected.BodyExcerpt).To(Equal("Unknown request 3"))
+
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(`type: 0`))
+		})
+
+		g.It("Should truncate long bodies of unexpected packets", func() {
+			err := connect(strings.Repeat("x", errs.MaxBodyExcerpt*2))
+
+			var unexpected *errs.UnexpectedAuthPacketError
+			Expect(errors.As(err, &unexpected)).To(BeTrue())
+			Expect(unexpected.BodyExcerpt).To(Equal(strings.Repeat("x", errs.MaxBodyExcerpt) + "..."))
+		})
+
+		g.It("Should not return an unexpected packet error for a wrong password", func() {
+			config := &rcon.Config{}
+			newTestServer(t, config)
+			config.Password = "wrong"
+
+			err := rcon.NewClient(config, nil).Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+
+			var unexpected *errs.UnexpectedAuthPacketError
+			Expect(errors.As(err, &unexpected)).To(BeFalse())
+		})
+	})
+}
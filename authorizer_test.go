@@ -0,0 +1,85 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorizer(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Per-user command policies", func() {
+		var server *rcontest.Server
+
+		// connect connects a client authenticating with password, which treats denied commands as server errors.
+		connect := func(password string) (*rcon.Client, error) {
+			host, port := server.Addr()
+			client := rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         password,
+				QueueReadTimeout: time.Millisecond * 200,
+				ResponseErrorChecker: func(command, response string) bool {
+					return strings.HasPrefix(response, "Permission denied")
+				},
+			}, nil)
+			t.Cleanup(func() { _ = client.Close() })
+
+			return client, client.Connect()
+		}
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.Handle("kick", func(args string) string { return "Kicked " + args })
+			server.Handle("quit", func(string) string { return "Bye" })
+			server.AddUser("moderator", "modpass")
+			server.SetAuthorizer(rcontest.CommandPolicies{
+				"moderator": {Allow: []string{"kick"}},
+			})
+		})
+
+		g.It("Should allow the commands of the user's policy", func() {
+			moderator, err := connect("modpass")
+			Expect(err).To(BeNil())
+
+			res, err := moderator.ExecCommand("kick Alice")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Kicked Alice"))
+		})
+
+		g.It("Should deny commands outside the user's policy", func() {
+			moderator, err := connect("modpass")
+			Expect(err).To(BeNil())
+
+			_, err = moderator.ExecCommand("quit")
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+			Expect(serverErr.Message).To(Equal("Permission denied: quit"))
+		})
+
+		g.It("Should not restrict users without a policy", func() {
+			admin, err := connect("password")
+			Expect(err).To(BeNil())
+
+			res, err := admin.ExecCommand("quit")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Bye"))
+		})
+
+		g.It("Should reject unknown credentials", func() {
+			_, err := connect("wrong")
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,152 @@
+package rcon
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BandwidthConfig struct {
+	// ReadBytesPerSecond caps the rate data is read from the server at. Once a second's worth of data was read, further
+	// reads are delayed, which makes the server back off through TCP flow control. Zero means no limit.
+	ReadBytesPerSecond int
+
+	// WriteBytesPerSecond caps the rate data is written to the server at. Zero means no limit.
+	WriteBytesPerSecond int
+}
+
+// BandwidthUsage is the number of bytes a client exchanged with the server over all of its connections.
+type BandwidthUsage struct {
+	BytesRead    uint64
+	BytesWritten uint64
+}
+
+// BandwidthUsage returns the number of bytes read and written so far. Only Source RCON connections are accounted.
+func (c *Client) BandwidthUsage() BandwidthUsage {
+	return BandwidthUsage{
+		BytesRead:    atomic.LoadUint64(&c.bytesRead),
+		BytesWritten: atomic.LoadUint64(&c.bytesWritten),
+	}
+}
+
+// byteBudget is a token bucket refilled at rate bytes per second, holding at most one second's worth of bytes. It is
+// shared by all connections of a client so that reconnecting doesn't reset it.
+type byteBudget struct {
+	lock   sync.Mutex
+	rate   int
+	tokens float64
+	last   time.Time
+}
+
+func newByteBudget(rate int) *byteBudget {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &byteBudget{rate: rate, tokens: float64(rate), last: time.Now()}
+}
+
+// take withdraws n bytes from the budget and returns how long the caller must wait until the budget is no longer
+// overdrawn.
+func (b *byteBudget) take(n int) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * float64(b.rate)
+	if b.tokens > float64(b.rate) {
+		b.tokens = float64(b.rate)
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / float64(b.rate) * float64(time.Second))
+}
+
+// meteredConn counts the bytes exchanged over a connection and enforces the client's bandwidth caps.
+type meteredConn struct {
+	Conn
+	c *Client
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (c *Client) meterConn(conn Conn) Conn {
+	return &meteredConn{Conn: conn, c: c, closed: make(chan struct{})}
+}
+
+// wait blocks for d or until the connection is closed.
+func (m *meteredConn) wait(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-m.closed:
+	}
+}
+
+func (m *meteredConn) Read(p []byte) (int, error) {
+	budget := m.c.readBudget
+
+	// A single read never exceeds the budget of a second, so that a large response can't bypass the cap.
+	if budget != nil && len(p) > budget.rate {
+		p = p[:budget.rate]
+	}
+
+	n, err := m.Conn.Read(p)
+	atomic.AddUint64(&m.c.bytesRead, uint64(n))
+
+	if budget != nil && n > 0 {
+		m.wait(budget.take(n))
+	}
+
+	return n, err
+}
+
+func (m *meteredConn) Write(p []byte) (int, error) {
+	budget := m.c.writeBudget
+	if budget == nil {
+		n, err := m.Conn.Write(p)
+		atomic.AddUint64(&m.c.bytesWritten, uint64(n))
+
+		return n, err
+	}
+
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > budget.rate {
+			chunk = chunk[:budget.rate]
+		}
+
+		m.wait(budget.take(len(chunk)))
+
+		n, err := m.Conn.Write(chunk)
+		atomic.AddUint64(&m.c.bytesWritten, uint64(n))
+		written += n
+
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+func (m *meteredConn) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+
+	return m.Conn.Close()
+}
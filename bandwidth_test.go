@@ -0,0 +1,90 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBandwidth(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Bandwidth", func() {
+		// The budget holds a second's worth of bytes, so writing one and a half seconds' worth takes half a second.
+		large := "say " + strings.Repeat("a", 1500)
+
+		g.It("Should not throttle writes without a limit", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			start := time.Now()
+			Expect(client.ExecCommandNoResponse(large)).To(BeNil())
+
+			Eventually(server.Commands).Should(ContainElement(large))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*300))
+		})
+
+		g.It("Should throttle writes to the configured rate", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Bandwidth: rcon.BandwidthConfig{WriteBytesPerSecond: 1000},
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			start := time.Now()
+			Expect(client.ExecCommandNoResponse(large)).To(BeNil())
+
+			Eventually(server.Commands, time.Second*2).Should(ContainElement(large))
+
+			// The auth packet and the command exceed the budget by roughly 550 bytes.
+			elapsed := time.Since(start)
+			Expect(elapsed).To(BeNumerically(">=", time.Millisecond*450))
+			Expect(elapsed).To(BeNumerically("<", time.Millisecond*1500))
+
+			usage := client.BandwidthUsage()
+			Expect(usage.BytesWritten).To(BeNumerically(">=", len(large)))
+		})
+
+		g.It("Should interrupt a command waiting for the budget when its context is done", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Bandwidth: rcon.BandwidthConfig{WriteBytesPerSecond: 1000},
+			})
+			server.SetResponse("status", "ok")
+			Expect(client.Connect()).To(BeNil())
+
+			Expect(client.ExecCommandNoResponse(large + large)).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*500))
+			Expect(server.Commands()).ToNot(ContainElement("status"))
+		})
+
+		g.It("Should interrupt a writer waiting for the budget when the client closes", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Bandwidth: rcon.BandwidthConfig{WriteBytesPerSecond: 1000},
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			// Waiting for the remainder of this command takes about two seconds.
+			Expect(client.ExecCommandNoResponse(large + large)).To(BeNil())
+			Eventually(func() uint64 { return client.BandwidthUsage().BytesWritten }).Should(BeNumerically(">", 1000))
+
+			start := time.Now()
+			Expect(client.Close()).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*500))
+			Expect(server.Commands()).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,364 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"net"
+	"sync"
+	"time"
+)
+
+// Protocol selects the wire protocol the client speaks.
+type Protocol int
+
+const (
+	// ProtocolSource is Valve's Source RCON protocol over TCP, which most games implement.
+	ProtocolSource Protocol = iota
+
+	// ProtocolBattlEye is the BattlEye RCON protocol over UDP used by Arma and DayZ servers. EndianMode, packet ID
+	// related options, MultiPacketResponses, ConnectionPerCommand and Reconnect do not apply to it.
+	ProtocolBattlEye
+)
+
+// DefaultBattlEyeKeepAliveInterval is the keepalive interval used for BattlEye when KeepAlive.Interval is not set.
+// BattlEye servers drop clients which have not sent a command for 45 seconds.
+const DefaultBattlEyeKeepAliveInterval = time.Second * 30
+
+// battlEyeMaxDatagram is the largest UDP datagram the reader accepts.
+const battlEyeMaxDatagram = 65535
+
+type battlEyeSession struct {
+	conn      *net.UDPConn
+	writeLock sync.Mutex
+	terminate chan uint8
+
+	seqLock sync.Mutex
+	seq     byte
+
+	pendingLock sync.Mutex
+	pending     map[byte]*battlEyePending
+
+	// lastMessage is the sequence number of the last acknowledged server message. Servers resend messages until they
+	// are acknowledged, so duplicates are acknowledged again but not dispatched.
+	lastMessage    byte
+	hasLastMessage bool
+}
+
+// battlEyePending collects the response to a command, which may be split across multiple packets.
+type battlEyePending struct {
+	parts    [][]byte
+	received int
+	done     chan string
+}
+
+func (s *battlEyeSession) nextSeq() byte {
+	s.seqLock.Lock()
+	defer s.seqLock.Unlock()
+
+	seq := s.seq
+	s.seq++
+
+	return seq
+}
+
+func (s *battlEyeSession) send(p *packet.BattlEyePacket) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	_, err := s.conn.Write(p.Build())
+	return err
+}
+
+// battlEye returns the current BattlEye session, or nil if the client is not connected.
+func (c *Client) battlEye() *battlEyeSession {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	return c.be
+}
+
+func (c *Client) connectBattlEye() error {
+	conn, err := net.DialTimeout("udp", fmt.Sprintf("%s:%d", c.Host, c.Port), c.ConnTimeout)
+	if err != nil {
+		return fmt.Errorf("udp dial failure: %w", err)
+	}
+
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		_ = conn.Close()
+		return fmt.Errorf("udp dial failure: unexpected connection type %T", conn)
+	}
+
+	s := &battlEyeSession{
+		conn:      udpConn,
+		terminate: make(chan uint8),
+		pending:   map[byte]*battlEyePending{},
+	}
+
+	c.transition(StateAuthenticating, StateConnecting)
+
+	if err := c.loginBattlEye(s); err != nil {
+		_ = udpConn.Close()
+		return err
+	}
+
+	c.sessionLock.Lock()
+	c.be = s
+	c.terminate = s.terminate
+	c.sessionLock.Unlock()
+
+	interval := c.KeepAlive.Interval
+	if interval <= 0 {
+		interval = DefaultBattlEyeKeepAliveInterval
+	}
+
+	c.wgLock.Lock()
+	c.waitGroup.Add(2)
+	c.wgLock.Unlock()
+
+	go c.startBattlEyeReader(s)
+	go c.startBattlEyeKeepAlive(s, interval)
+
+	return nil
+}
+
+func (c *Client) loginBattlEye(s *battlEyeSession) error {
+	if err := s.send(&packet.BattlEyePacket{Type: packet.BattlEyeTypeLogin, Payload: []byte(c.Password)}); err != nil {
+		return fmt.Errorf("could not send login packet: %w", err)
+	}
+
+	if err := s.conn.SetReadDeadline(time.Now().Add(c.ConnTimeout)); err != nil {
+		return fmt.Errorf("could not set read deadline: %w", err)
+	}
+	defer s.conn.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, battlEyeMaxDatagram)
+
+	for {
+		n, err := s.conn.Read(buf)
+		if err != nil {
+			return fmt.Errorf("could not get login response: %w", err)
+		}
+
+		res, err := packet.DecodeBattlEyePacket(buf[:n])
+		if err != nil {
+			c.log.Debug("Discarding invalid datagram during login. Error: ", err)
+			continue
+		}
+
+		if res.Type != packet.BattlEyeTypeLogin {
+			continue
+		}
+
+		if len(res.Payload) < 1 || res.Payload[0] != 0x01 {
+			return fmt.Errorf("authentication failed: %w", errs.ErrAuthentication)
+		}
+
+		c.log.Debug("Authenticated successfully")
+
+		return nil
+	}
+}
+
+func (c *Client) startBattlEyeReader(s *battlEyeSession) {
+	defer func() {
+		c.wgLock.Lock()
+		c.waitGroup.Done()
+		c.wgLock.Unlock()
+		c.log.Debug("Reader routine terminated")
+	}()
+
+	buf := make([]byte, battlEyeMaxDatagram)
+
+	for {
+		n, err := s.conn.Read(buf)
+
+		select {
+		case <-s.terminate:
+			return
+		default:
+		}
+
+		if err != nil {
+			c.log.Debug("Reader error: ", err)
+			continue
+		}
+
+		p, err := packet.DecodeBattlEyePacket(buf[:n])
+		if err != nil {
+			c.log.Debug("Discarding invalid datagram. Error: ", err)
+			continue
+		}
+
+		switch p.Type {
+		case packet.BattlEyeTypeMessage:
+			c.handleBattlEyeMessage(s, p)
+		case packet.BattlEyeTypeCommand:
+			c.handleBattlEyeResponse(s, p)
+		}
+	}
+}
+
+func (c *Client) handleBattlEyeMessage(s *battlEyeSession, p *packet.BattlEyePacket) {
+	if err := s.send(&packet.BattlEyePacket{Type: packet.BattlEyeTypeMessage, Seq: p.Seq}); err != nil {
+		c.log.Error("Could not acknowledge server message ", p.Seq, ". Error: ", err)
+	}
+
+	if s.hasLastMessage && s.lastMessage == p.Seq {
+		c.log.Debug("Server message ", p.Seq, " was a duplicate")
+		return
+	}
+
+	s.lastMessage = p.Seq
+	s.hasLastMessage = true
+
+	c.dispatchBroadcast(BroadcastSourceRCON, string(p.Payload), nil)
+}
+
+func (c *Client) handleBattlEyeResponse(s *battlEyeSession, p *packet.BattlEyePacket) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	pending, ok := s.pending[p.Seq]
+	if !ok {
+		c.log.Debug("Response ", p.Seq, " was unexpected (no pending command)")
+		return
+	}
+
+	part, multi := p.Multipart()
+	if !multi {
+		pending.done <- string(p.Payload)
+		delete(s.pending, p.Seq)
+		return
+	}
+
+	if part.Total == 0 || part.Index >= part.Total {
+		c.log.Debug("Discarding invalid multipart response ", p.Seq)
+		return
+	}
+
+	if pending.parts == nil {
+		pending.parts = make([][]byte, part.Total)
+	}
+
+	if part.Total != len(pending.parts) || pending.parts[part.Index] != nil {
+		return
+	}
+
+	pending.parts[part.Index] = append([]byte{}, part.Data...)
+	pending.received++
+
+	if pending.received == len(pending.parts) {
+		var body []byte
+		for _, data := range pending.parts {
+			body = append(body, data...)
+		}
+
+		pending.done <- string(body)
+		delete(s.pending, p.Seq)
+	}
+}
+
+// execBattlEye sends command and waits for its response.
+func (c *Client) execBattlEye(ctx context.Context, command string) (string, error) {
+	s := c.battlEye()
+	if s == nil {
+		return "", errs.ErrNotConnected
+	}
+
+	seq := s.nextSeq()
+	pending := &battlEyePending{done: make(chan string, 1)}
+
+	s.pendingLock.Lock()
+	s.pending[seq] = pending
+	s.pendingLock.Unlock()
+
+	defer func() {
+		s.pendingLock.Lock()
+		if s.pending[seq] == pending {
+			delete(s.pending, seq)
+		}
+		s.pendingLock.Unlock()
+	}()
+
+	if err := s.send(&packet.BattlEyePacket{Type: packet.BattlEyeTypeCommand, Seq: seq, Payload: []byte(command)}); err != nil {
+		return "", fmt.Errorf("could not send command packet: %w", err)
+	}
+
+	start := time.Now()
+
+	select {
+	case res := <-pending.done:
+		c.latencies.add(time.Since(start))
+		return res, nil
+	case <-time.After(c.readTimeout()):
+		return "", &errs.ResponseTimeoutError{PacketID: int32(seq), Timeout: c.readTimeout()}
+	case <-s.terminate:
+		return "", fmt.Errorf("connection closed before a response arrived: %w", errs.ErrNotConnected)
+	case <-ctx.Done():
+		return "", fmt.Errorf("command cancelled: %w", ctx.Err())
+	}
+}
+
+// startBattlEyeKeepAlive sends an empty command every interval, as required by BattlEye servers. If KeepAlive.MaxMissed
+// consecutive pings go unanswered, the session is closed and the DisconnectHandler is called.
+func (c *Client) startBattlEyeKeepAlive(s *battlEyeSession, interval time.Duration) {
+	defer func() {
+		c.wgLock.Lock()
+		c.waitGroup.Done()
+		c.wgLock.Unlock()
+		c.log.Debug("Keepalive routine terminated")
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.terminate:
+			return
+		}
+
+		_, err := c.execBattlEye(context.Background(), "")
+		if err == nil {
+			missed = 0
+			continue
+		}
+
+		if errors.Is(err, errs.ErrNotConnected) {
+			return
+		}
+
+		missed++
+		c.log.Debug("Keepalive ping missed (", missed, "/", c.KeepAlive.MaxMissed, "). Error: ", err)
+
+		if missed >= c.KeepAlive.MaxMissed {
+			c.log.Error("Server stopped answering keepalive pings, dropping connection")
+			c.closeBattlEye(s, fmt.Errorf("%d pings missed: %w", missed, errs.ErrKeepAliveTimeout))
+			return
+		}
+	}
+}
+
+// closeBattlEye ends s and notifies the DisconnectHandler. It returns false if s was already closed.
+func (c *Client) closeBattlEye(s *battlEyeSession, err error) bool {
+	if !c.endSession(s.terminate) {
+		return false
+	}
+
+	c.sessionLock.Lock()
+	c.be = nil
+	c.sessionLock.Unlock()
+
+	_ = s.conn.Close()
+
+	c.notifyDisconnect(err)
+
+	return true
+}
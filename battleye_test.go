@@ -0,0 +1,239 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// battlEyeServer is a fake BattlEye server on a loopback UDP socket. It answers logins, answers commands with the
+// parts returned by respond and records the acknowledgements of the messages it sends.
+type battlEyeServer struct {
+	conn     *net.UDPConn
+	password string
+
+	lock     sync.Mutex
+	client   *net.UDPAddr
+	commands []string
+	respond  func(command string) [][]byte
+
+	acks chan byte
+}
+
+func startBattlEyeServer(t *testing.T, password string) *battlEyeServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &battlEyeServer{
+		conn:     conn,
+		password: password,
+		respond: func(command string) [][]byte {
+			return [][]byte{[]byte(command)}
+		},
+		acks: make(chan byte, 64),
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go s.serve()
+
+	return s
+}
+
+func (s *battlEyeServer) addr() (string, uint16) {
+	addr := s.conn.LocalAddr().(*net.UDPAddr)
+	return addr.IP.String(), uint16(addr.Port)
+}
+
+func (s *battlEyeServer) serve() {
+	buf := make([]byte, 65535)
+
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		p, err := packet.DecodeBattlEyePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		switch p.Type {
+		case packet.BattlEyeTypeLogin:
+			s.lock.Lock()
+			s.client = addr
+			s.lock.Unlock()
+
+			result := byte(0x00)
+			if string(p.Payload) == s.password {
+				result = 0x01
+			}
+			s.send(&packet.BattlEyePacket{Type: packet.BattlEyeTypeLogin, Payload: []byte{result}})
+		case packet.BattlEyeTypeCommand:
+			s.lock.Lock()
+			s.commands = append(s.commands, string(p.Payload))
+			respond := s.respond
+			s.lock.Unlock()
+
+			for _, part := range respond(string(p.Payload)) {
+				s.send(&packet.BattlEyePacket{Type: packet.BattlEyeTypeCommand, Seq: p.Seq, Payload: part})
+			}
+		case packet.BattlEyeTypeMessage:
+			s.acks <- p.Seq
+		}
+	}
+}
+
+func (s *battlEyeServer) send(p *packet.BattlEyePacket) {
+	s.lock.Lock()
+	client := s.client
+	s.lock.Unlock()
+
+	_, _ = s.conn.WriteToUDP(p.Build(), client)
+}
+
+// setRespond replaces the function answering commands. Returning no parts leaves a command unanswered.
+func (s *battlEyeServer) setRespond(respond func(command string) [][]byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.respond = respond
+}
+
+func (s *battlEyeServer) received() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.commands...)
+}
+
+// multipart splits body into parts with BattlEye's multipart header.
+func multipart(body string, total int) [][]byte {
+	var parts [][]byte
+	size := (len(body) + total - 1) / total
+
+	for i := 0; i < total; i++ {
+		end := (i + 1) * size
+		if end > len(body) {
+			end = len(body)
+		}
+
+		parts = append(parts, append([]byte{0x00, byte(total), byte(i)}, body[i*size:end]...))
+	}
+
+	return parts
+}
+
+func TestBattlEye(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("BattlEye", func() {
+		var server *battlEyeServer
+		var config *rcon.Config
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = startBattlEyeServer(t, "password")
+			host, port := server.addr()
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				Protocol:         rcon.ProtocolBattlEye,
+				ConnTimeout:      time.Millisecond * 500,
+				QueueReadTimeout: time.Millisecond * 200,
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+		})
+
+		g.It("Should log in and execute commands", func() {
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("players")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("players"))
+		})
+
+		g.It("Should return ErrAuthentication for a wrong password", func() {
+			config.Password = "wrong"
+			client = rcon.NewClient(config, nil)
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should assemble multipart responses received out of order", func() {
+			body := "Players on server:\n[#] [IP Address]:[Port] [Ping] [GUID] [Name]\n0 203.0.113.5:2304 42 abc(OK) Survivor"
+
+			server.setRespond(func(command string) [][]byte {
+				parts := multipart(body, 3)
+				return [][]byte{parts[2], parts[0], parts[1]}
+			})
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("players")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(body))
+		})
+
+		g.It("Should acknowledge server messages and dispatch each once", func() {
+			broadcasts := make(chan string, 8)
+			config.BroadcastHandler = func(message string) { broadcasts <- message }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			message := &packet.BattlEyePacket{
+				Type:    packet.BattlEyeTypeMessage,
+				Seq:     0,
+				Payload: []byte("RCon admin #0 (127.0.0.1:2306) logged in"),
+			}
+
+			// Servers resend messages which were not acknowledged in time.
+			server.send(message)
+			Eventually(server.acks).Should(Receive(Equal(byte(0))))
+			server.send(message)
+			Eventually(server.acks).Should(Receive(Equal(byte(0))))
+
+			Eventually(broadcasts).Should(Receive(Equal("RCon admin #0 (127.0.0.1:2306) logged in")))
+			Consistently(broadcasts, time.Millisecond*100).ShouldNot(Receive())
+		})
+
+		g.It("Should send keepalive pings and drop the connection once they go unanswered", func() {
+			disconnected := make(chan error, 1)
+			config.KeepAlive = rcon.KeepAliveConfig{Interval: time.Millisecond * 50, MaxMissed: 2}
+			config.DisconnectHandler = func(err error, expected bool) { disconnected <- err }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			Eventually(server.received).Should(ContainElement(""))
+			Consistently(disconnected, time.Millisecond*200).ShouldNot(Receive())
+
+			server.setRespond(func(command string) [][]byte { return nil })
+
+			var err error
+			Eventually(disconnected, time.Second*2).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrKeepAliveTimeout)).To(BeTrue())
+		})
+	})
+}
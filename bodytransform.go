@@ -0,0 +1,53 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/packet"
+)
+
+// BodyTransformer transforms the body of a packet, without its null terminator.
+type BodyTransformer func(body []byte) ([]byte, error)
+
+// BodyTransform wraps packet bodies on the wire, for servers which protect RCON bodies with a custom scheme such as a
+// shared-key cipher. Packet headers are not transformed. Since trailing null bytes are stripped from received bodies,
+// Encode should produce bodies which don't end in one, for example by encoding ciphertext as base64.
+type BodyTransform struct {
+	// Encode transforms the bodies of outgoing packets before they are written, including authentication packets.
+	Encode BodyTransformer
+
+	// Decode transforms the bodies of incoming packets after they are read, before they are routed. An error is
+	// treated like any other read failure.
+	Decode BodyTransformer
+}
+
+// encodeBody returns p with its body transformed by the dialect's BodyTransform.
+func (c *Client) encodeBody(p packet.Packet) (packet.Packet, error) {
+	encode := c.Features().BodyTransform.Encode
+	if encode == nil {
+		return p, nil
+	}
+
+	body := p.Body()
+	encoded, err := encode(body[:len(body)-1])
+	if err != nil {
+		return nil, fmt.Errorf("could not encode packet body: %w", err)
+	}
+
+	return packet.NewPacketWithID(c.EndianMode, p.ID(), p.Type(), string(encoded)), nil
+}
+
+// decodeBody returns p with its body transformed back by the dialect's BodyTransform.
+func (c *Client) decodeBody(p packet.Packet) (packet.Packet, error) {
+	decode := c.Features().BodyTransform.Decode
+	if decode == nil {
+		return p, nil
+	}
+
+	body := p.Body()
+	decoded, err := decode(body[:len(body)-1])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode packet body: %w", err)
+	}
+
+	return packet.NewPacketWithID(c.EndianMode, p.ID(), p.Type(), string(decoded)), nil
+}
@@ -0,0 +1,83 @@
+package rcon_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/presets"
+	"testing"
+	"time"
+)
+
+func TestBodyTransform(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	key := []byte("secret")
+	xor := func(body []byte) []byte {
+		out := make([]byte, len(body))
+		for i := range body {
+			out[i] = body[i] ^ key[i%len(key)]
+		}
+		return out
+	}
+	transform := rcon.BodyTransform{
+		Encode: func(body []byte) ([]byte, error) {
+			return []byte(hex.EncodeToString(xor(body))), nil
+		},
+		Decode: func(body []byte) ([]byte, error) {
+			decoded, err := hex.DecodeString(string(body))
+			return xor(decoded), err
+		},
+	}
+
+	g.Describe("Body transforms", func() {
+		g.It("Should transform packet bodies", func() {
+			var wire []byte
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect: presets.NewDialect("xor", rcon.Features{Broadcasts: true, BodyTransform: transform}),
+				PacketHooks: rcon.PacketHooks{
+					OnReceive: func(_ packet.Packet, raw []byte) { wire = raw },
+				},
+			})
+			server.SetBodyTransform(transform)
+			server.Handle("echo", func(args string) string { return args })
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hello"))
+			Expect(string(wire)).NotTo(ContainSubstring("hello"))
+		})
+
+		g.It("Should fail commands whose body can't be encoded", func() {
+			failing := rcon.BodyTransform{
+				Encode: func(body []byte) ([]byte, error) {
+					if string(body) == "forbidden" {
+						return nil, errors.New("can't encode")
+					}
+					return transform.Encode(body)
+				},
+				Decode: transform.Decode,
+			}
+
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect: presets.NewDialect("xor", rcon.Features{Broadcasts: true, BodyTransform: failing}),
+			})
+			server.SetBodyTransform(transform)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("forbidden")
+			Expect(errors.Is(err, errs.ErrMailboxClosed)).To(BeTrue())
+			Consistently(server.Commands, time.Millisecond*100).ShouldNot(ContainElement("forbidden"))
+		})
+	})
+}
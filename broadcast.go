@@ -0,0 +1,312 @@
+package rcon
+
+import (
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"time"
+)
+
+// BroadcastSourceRCON is the source tag of broadcasts received over the RCON connection.
+const BroadcastSourceRCON = "rcon"
+
+// DefaultSubscriptionBuffer is the default channel capacity of subscriptions created with Subscribe.
+const DefaultSubscriptionBuffer = 64
+
+// Broadcast is a broadcast message delivered to subscribers.
+type Broadcast struct {
+	// Source tags where the broadcast came from. It is BroadcastSourceRCON for broadcasts received from the server.
+	Source string
+
+	Message string
+
+	// Channel is the channel the broadcast was classified into by the BroadcastChannel function, or empty if none is
+	// configured.
+	Channel string
+
+	// Packet is the packet the broadcast was received in. For broadcasts which did not arrive as a Source RCON
+	// packet, such as injected broadcasts or those received over another protocol, it is a packet carrying Message
+	// with an ID of zero.
+	Packet packet.Packet
+
+	// Time is the local time the broadcast was received at.
+	Time time.Time
+
+	// ServerTime is the time the server emitted the broadcast at, on the server's clock. It is read from the message
+	// if the dialect declares a BroadcastTime parser. Otherwise, for broadcasts received over RCON, it is estimated
+	// from Time and the most recent clock offset estimate (see EstimateClockOffset), compensating for half the round
+	// trip of the measurement as transit time. It is zero if neither is available.
+	ServerTime time.Time
+
+	// ServerTimeAccuracy is the accuracy of an estimated ServerTime: half the round trip of the clock offset
+	// measurement. It is zero if ServerTime was read from the message or is unknown.
+	ServerTimeAccuracy time.Duration
+
+	// Labels are the Labels of the client which received the broadcast. The map is shared and must not be modified.
+	Labels map[string]string
+}
+
+// BroadcastTimeParser reads the server's timestamp from a broadcast message. It returns false if the message carries no
+// timestamp.
+type BroadcastTimeParser func(message string) (time.Time, bool)
+
+// BroadcastFilter selects the broadcasts a subscription receives.
+type BroadcastFilter func(p packet.Packet) bool
+
+// BroadcastChannelFunc classifies a broadcast into a channel, such as "chat" or "login", which subscribers and
+// RecentBroadcasts can filter by.
+type BroadcastChannelFunc func(p packet.Packet) string
+
+type subscription struct {
+	filter BroadcastFilter
+	ch     chan Broadcast
+}
+
+// subscriptions holds the broadcast subscriptions of a client.
+type subscriptions struct {
+	lock   sync.RWMutex
+	nextID int
+	subs   map[int]*subscription
+
+	// history is a ring buffer of the most recent broadcasts. next is the index the next broadcast is stored at.
+	history []Broadcast
+	next    int
+	full    bool
+}
+
+// record adds b to the history. It must be called with the lock held.
+func (s *subscriptions) record(b Broadcast, size int) {
+	if size <= 0 {
+		return
+	}
+
+	if len(s.history) != size {
+		s.history = make([]Broadcast, size)
+		s.next = 0
+		s.full = false
+	}
+
+	s.history[s.next] = b
+	s.next = (s.next + 1) % size
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// recent returns the recorded broadcasts in the given channel received at or after since, oldest first. An empty
+// channel matches all broadcasts and a zero since matches any time. It must be called with the lock held.
+func (s *subscriptions) recent(channel string, since time.Time) []Broadcast {
+	var ordered []Broadcast
+	if s.full {
+		ordered = append(ordered, s.history[s.next:]...)
+	}
+	ordered = append(ordered, s.history[:s.next]...)
+
+	var matches []Broadcast
+	for _, b := range ordered {
+		if channel != "" && b.Channel != channel {
+			continue
+		}
+
+		if !since.IsZero() && b.Time.Before(since) {
+			continue
+		}
+
+		matches = append(matches, b)
+	}
+
+	return matches
+}
+
+// Subscribe registers a subscription receiving every broadcast for which filter returns true. A nil filter receives
+// all broadcasts. The returned function cancels the subscription and closes the channel; it is safe to call more than
+// once.
+//
+// Each subscription is buffered independently. Broadcasts are never allowed to block the client: if a subscriber falls
+// behind and its buffer is full, broadcasts for it are dropped. BroadcastHandler keeps receiving every broadcast.
+func (c *Client) Subscribe(filter BroadcastFilter) (<-chan Broadcast, func()) {
+	return c.SubscribeBuffered(filter, DefaultSubscriptionBuffer)
+}
+
+// SubscribeBuffered is like Subscribe, but with a channel capacity of size.
+func (c *Client) SubscribeBuffered(filter BroadcastFilter, size int) (<-chan Broadcast, func()) {
+	return c.subscribe(filter, size, nil)
+}
+
+// SubscribeReplay is like Subscribe, but the channel first receives the recorded broadcasts received at or after since
+// which match filter. No broadcast is missed or delivered twice between the replay and live broadcasts, which lets a
+// reconnecting consumer, such as a web UI, backfill recent chat. Broadcasts are only recorded if BroadcastHistory is
+// set.
+func (c *Client) SubscribeReplay(filter BroadcastFilter, since time.Time) (<-chan Broadcast, func()) {
+	return c.subscribe(filter, DefaultSubscriptionBuffer, &since)
+}
+
+// subscribe registers a subscription. If since is not nil, the recorded broadcasts received at or after it are
+// replayed into the subscription first; its capacity grows to hold them.
+func (c *Client) subscribe(filter BroadcastFilter, size int, since *time.Time) (<-chan Broadcast, func()) {
+	c.subscriptions.lock.Lock()
+
+	var replay []Broadcast
+	if since != nil {
+		for _, b := range c.subscriptions.recent("", *since) {
+			if filter == nil || filter(b.Packet) {
+				replay = append(replay, b)
+			}
+		}
+	}
+
+	s := &subscription{
+		filter: filter,
+		ch:     make(chan Broadcast, size+len(replay)),
+	}
+
+	for _, b := range replay {
+		s.ch <- b
+	}
+
+	if c.subscriptions.subs == nil {
+		c.subscriptions.subs = map[int]*subscription{}
+	}
+	id := c.subscriptions.nextID
+	c.subscriptions.nextID++
+	c.subscriptions.subs[id] = s
+	c.subscriptions.lock.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			c.subscriptions.lock.Lock()
+			delete(c.subscriptions.subs, id)
+			c.subscriptions.lock.Unlock()
+
+			close(s.ch)
+		})
+	}
+
+	return s.ch, cancel
+}
+
+// RecentBroadcasts returns the recorded broadcasts in channel received at or after since, oldest first. An empty
+// channel matches all channels and a zero since matches any time. Broadcasts are only recorded if BroadcastHistory is
+// set.
+func (c *Client) RecentBroadcasts(channel string, since time.Time) []Broadcast {
+	c.subscriptions.lock.RLock()
+	defer c.subscriptions.lock.RUnlock()
+
+	return c.subscriptions.recent(channel, since)
+}
+
+// ChannelFilter returns a filter matching the broadcasts the BroadcastChannel function classifies into any of
+// channels.
+func (c *Client) ChannelFilter(channels ...string) BroadcastFilter {
+	return func(p packet.Packet) bool {
+		channel := c.broadcastChannel(p)
+
+		for _, ch := range channels {
+			if ch == channel {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func (c *Client) broadcastChannel(p packet.Packet) string {
+	if c.BroadcastChannel == nil {
+		return ""
+	}
+
+	return c.BroadcastChannel(p)
+}
+
+// InjectBroadcast delivers message to the broadcast handler and subscribers as if it had been received from the
+// server. source tags where the broadcast came from, for example "log" for broadcasts backfilled from a tailed log
+// file.
+func (c *Client) InjectBroadcast(source string, message string) {
+	c.dispatchBroadcast(source, message, nil)
+}
+
+// stampServerTime sets the server emission time of b, if it is known or can be estimated.
+func (c *Client) stampServerTime(b *Broadcast) {
+	if parse := c.Features().BroadcastTime; parse != nil {
+		if t, ok := parse(b.Message); ok {
+			b.ServerTime = t
+			return
+		}
+	}
+
+	if b.Source != BroadcastSourceRCON {
+		return
+	}
+
+	offset, ok := c.ClockOffset()
+	if !ok {
+		return
+	}
+
+	transit := offset.RoundTrip / 2
+	b.ServerTime = b.Time.Add(offset.Offset - transit)
+	b.ServerTimeAccuracy = transit
+}
+
+// dispatchBroadcast delivers a broadcast to the BroadcastHandler and all matching subscriptions. p is the packet the
+// broadcast was received in, or nil if it did not arrive as a Source RCON packet.
+func (c *Client) dispatchBroadcast(source string, message string, p packet.Packet) {
+	c.deliverBroadcast(Broadcast{
+		Source:  source,
+		Message: message,
+		Packet:  p,
+	})
+}
+
+// deliverBroadcast fills in the packet, channel, time and server time of b, unless they are already set, and delivers
+// it like dispatchBroadcast.
+func (c *Client) deliverBroadcast(b Broadcast) {
+	c.log.Debug("Dispatching broadcast from source ", b.Source)
+
+	c.metrics().BroadcastReceived()
+
+	if c.BroadcastHandler != nil {
+		c.BroadcastHandler(b.Message)
+	}
+
+	if b.Packet == nil {
+		b.Packet = packet.NewPacketWithID(c.EndianMode, 0, packet.TypeCommandRes, b.Message)
+	}
+
+	if b.Channel == "" {
+		b.Channel = c.broadcastChannel(b.Packet)
+	}
+
+	if b.Time.IsZero() {
+		b.Time = c.Clock()
+	}
+
+	if b.Labels == nil {
+		b.Labels = c.labels
+	}
+
+	if b.ServerTime.IsZero() {
+		c.stampServerTime(&b)
+	}
+
+	p := b.Packet
+
+	// The write lock makes recording and delivery atomic with respect to SubscribeReplay.
+	c.subscriptions.lock.Lock()
+	defer c.subscriptions.lock.Unlock()
+
+	c.subscriptions.record(b, c.BroadcastHistory)
+
+	for _, s := range c.subscriptions.subs {
+		if s.filter != nil && !s.filter(p) {
+			continue
+		}
+
+		select {
+		case s.ch <- b:
+		default:
+			c.log.Debug("Subscriber buffer full, dropping broadcast")
+		}
+	}
+}
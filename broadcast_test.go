@@ -0,0 +1,180 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcast(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Broadcast delivery", func() {
+		now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		var client *Client
+
+		g.BeforeEach(func() {
+			client = NewClient(&Config{
+				Clock:            func() time.Time { return now },
+				BroadcastChannel: func(packet.Packet) string { return "chat" },
+			}, nil)
+		})
+
+		g.It("Should fill in the channel and time", func() {
+			broadcasts, unsubscribe := client.Subscribe(nil)
+			defer unsubscribe()
+
+			client.deliverBroadcast(Broadcast{Source: BroadcastSourceRCON, Message: "hello"})
+
+			var b Broadcast
+			Eventually(broadcasts).Should(Receive(&b))
+			Expect(b.Channel).To(Equal("chat"))
+			Expect(b.Time).To(Equal(now))
+		})
+
+		g.It("Should keep a channel and time which are already set", func() {
+			broadcasts, unsubscribe := client.Subscribe(nil)
+			defer unsubscribe()
+
+			received := now.Add(-time.Minute)
+			client.deliverBroadcast(Broadcast{
+				Source:  BroadcastSourceLog,
+				Message: "hello",
+				Channel: "log",
+				Time:    received,
+			})
+
+			var b Broadcast
+			Eventually(broadcasts).Should(Receive(&b))
+			Expect(b.Channel).To(Equal("log"))
+			Expect(b.Time).To(Equal(received))
+		})
+	})
+
+	g.Describe("Broadcast history", func() {
+		start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+		var client *Client
+
+		// messages returns the messages of broadcasts.
+		messages := func(broadcasts []Broadcast) []string {
+			var m []string
+			for _, b := range broadcasts {
+				m = append(m, b.Message)
+			}
+			return m
+		}
+
+		// receive reads n broadcasts from ch.
+		receive := func(ch <-chan Broadcast, n int) []string {
+			var m []string
+			for i := 0; i < n; i++ {
+				var b Broadcast
+				Eventually(ch).Should(Receive(&b))
+				m = append(m, b.Message)
+			}
+			return m
+		}
+
+		g.BeforeEach(func() {
+			// Every broadcast is received a minute after the previous one. Messages are "<channel>: <text>".
+			var lock sync.Mutex
+			now := start
+			client = NewClient(&Config{
+				BroadcastHistory: 3,
+				Clock: func() time.Time {
+					lock.Lock()
+					defer lock.Unlock()
+
+					now = now.Add(time.Minute)
+					return now
+				},
+				BroadcastChannel: func(p packet.Packet) string {
+					return strings.SplitN(string(p.Body()), ":", 2)[0]
+				},
+			}, nil)
+		})
+
+		g.It("Should only keep the most recent BroadcastHistory broadcasts, oldest first", func() {
+			for i := 1; i <= 5; i++ {
+				client.InjectBroadcast("test", fmt.Sprintf("chat: %d", i))
+			}
+
+			recent := client.RecentBroadcasts("", time.Time{})
+			Expect(messages(recent)).To(Equal([]string{"chat: 3", "chat: 4", "chat: 5"}))
+		})
+
+		g.It("Should filter recent broadcasts by channel and time", func() {
+			client.InjectBroadcast("test", "chat: 1")
+			client.InjectBroadcast("test", "login: 2")
+			client.InjectBroadcast("test", "chat: 3")
+
+			Expect(messages(client.RecentBroadcasts("chat", time.Time{}))).To(Equal([]string{"chat: 1", "chat: 3"}))
+			recent := client.RecentBroadcasts("", start.Add(time.Minute*2))
+			Expect(messages(recent)).To(Equal([]string{"login: 2", "chat: 3"}))
+			Expect(client.RecentBroadcasts("kill", time.Time{})).To(BeEmpty())
+		})
+
+		g.It("Should not record broadcasts without a BroadcastHistory", func() {
+			client.BroadcastHistory = 0
+			client.InjectBroadcast("test", "chat: 1")
+
+			Expect(client.RecentBroadcasts("", time.Time{})).To(BeEmpty())
+		})
+
+		g.It("Should replay matching recorded broadcasts before live ones", func() {
+			client.InjectBroadcast("test", "chat: 1")
+			client.InjectBroadcast("test", "login: 2")
+			client.InjectBroadcast("test", "chat: 3")
+
+			broadcasts, unsubscribe := client.SubscribeReplay(client.ChannelFilter("chat"), start.Add(time.Minute*2))
+			defer unsubscribe()
+
+			client.InjectBroadcast("test", "login: 4")
+			client.InjectBroadcast("test", "chat: 5")
+
+			Expect(receive(broadcasts, 2)).To(Equal([]string{"chat: 3", "chat: 5"}))
+			Consistently(broadcasts).ShouldNot(Receive())
+		})
+
+		g.It("Should neither skip nor repeat broadcasts delivered while subscribing", func() {
+			const total = 200
+			client.BroadcastHistory = total
+
+			injected := make(chan struct{})
+			halfway := make(chan struct{})
+			go func() {
+				defer close(injected)
+
+				for i := 0; i < total; i++ {
+					if i == total/2 {
+						close(halfway)
+					}
+
+					client.InjectBroadcast("test", fmt.Sprintf("chat: %d", i))
+					time.Sleep(time.Microsecond * 50)
+				}
+			}()
+
+			<-halfway
+			broadcasts, unsubscribe := client.SubscribeReplay(nil, time.Time{})
+			defer unsubscribe()
+
+			expected := make([]string, total)
+			for i := range expected {
+				expected[i] = fmt.Sprintf("chat: %d", i)
+			}
+
+			Expect(receive(broadcasts, total)).To(Equal(expected))
+			<-injected
+			Expect(broadcasts).NotTo(Receive())
+		})
+	})
+}
@@ -0,0 +1,113 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"time"
+)
+
+type CanaryConfig struct {
+	// Command is executed after every successful re-authentication, before the client reports StateConnected and
+	// starts writing queued commands. Some servers accept authentication before they process commands; the canary
+	// keeps a burst of queued commands from hitting such a server. Empty disables the canary.
+	Command string
+
+	// Check reports whether the canary's response is sane. If nil, every response which is not identified as an
+	// error by the ResponseErrorChecker is accepted.
+	Check func(response string) bool
+
+	// Attempts is the number of times the canary is executed on a new connection before the reconnect attempt is
+	// considered failed.
+	//
+	// Default: 3
+	Attempts int
+
+	// Delay is the time between canary attempts.
+	//
+	// Default: 1s
+	Delay time.Duration
+}
+
+// runCanary executes the canary command over the freshly authenticated connection until it passes, before the reader
+// and writer routines are started. It returns an error wrapping errs.ErrCanaryFailed if every attempt failed, or
+// errs.ErrNotConnected if stop is closed while waiting between attempts.
+func (c *Client) runCanary(stop chan struct{}) error {
+	canary := c.Reconnect.Canary
+	if canary.Command == "" {
+		return nil
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= canary.Attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(canary.Delay):
+			case <-stop:
+				return fmt.Errorf("reconnect cancelled: %w", errs.ErrNotConnected)
+			}
+		}
+
+		if err = c.execCanary(canary); err == nil {
+			return nil
+		}
+
+		c.log.Debug("Canary attempt ", attempt, " failed. Error: ", err)
+	}
+
+	return fmt.Errorf("command %q failed: %v: %w", canary.Command, err, errs.ErrCanaryFailed)
+}
+
+func (c *Client) execCanary(canary CanaryConfig) error {
+	p := c.newClientPacket(packet.TypeCommand, canary.Command)
+
+	if err := c.sendPacket(p); err != nil {
+		return fmt.Errorf("could not send canary packet: %w", err)
+	}
+
+	var res packet.Packet
+	var err error
+
+	if c.multiPacket() {
+		res, err = c.readMultiPacket(p)
+	} else {
+		res, err = c.readCanaryResponse(p)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	body := res.Body()
+	response, err := c.checkResponse(canary.Command, string(body[:len(body)-1]))
+	if err != nil {
+		return err
+	}
+
+	if canary.Check != nil && !canary.Check(response) {
+		return fmt.Errorf("unexpected response %q", response)
+	}
+
+	return nil
+}
+
+// readCanaryResponse reads packets until the response to p arrives. Broadcasts received in the meantime are
+// dispatched; other packets are discarded.
+func (c *Client) readCanaryResponse(p packet.Packet) (packet.Packet, error) {
+	for {
+		res, err := c.readPacketTimeout()
+		if err != nil {
+			return nil, fmt.Errorf("could not get canary response: %w", err)
+		}
+
+		if res.ID() == p.ID() {
+			return res, nil
+		}
+
+		if c.isBroadcast(res) {
+			body := res.Body()
+			c.dispatchBroadcast(BroadcastSourceRCON, string(body[:len(body)-1]), res)
+		}
+	}
+}
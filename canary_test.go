@@ -0,0 +1,145 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCanary(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Reconnect canary", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+		var disconnects chan error
+		var notReady int32
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.SetResponse("status", "ok")
+
+			// ready fails while notReady is positive, counting it down with every call.
+			atomic.StoreInt32(&notReady, 0)
+			server.Handle("ready", func(args string) string {
+				if atomic.AddInt32(&notReady, -1) >= 0 {
+					return "starting"
+				}
+				return "ready"
+			})
+
+			host, port := server.Addr()
+			disconnects = make(chan error, 4)
+
+			config = &rcon.Config{
+				Host:     host,
+				Port:     port,
+				Password: "password",
+
+				// Commands written to the dropped connection fail fast.
+				QueueReadTimeout: time.Millisecond * 200,
+				Reconnect: rcon.ReconnectConfig{
+					Enabled: true,
+					Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+					Canary: rcon.CanaryConfig{
+						Command: "ready",
+						Check: func(response string) bool {
+							return response == "ready"
+						},
+						Delay: time.Millisecond * 10,
+					},
+				},
+				DisconnectHandler: func(err error, expected bool) {
+					disconnects <- err
+				},
+			}
+		})
+
+		// commandCount returns how often command was received by the server.
+		commandCount := func(command string) int {
+			n := 0
+			for _, cmd := range server.Commands() {
+				if cmd == command {
+					n++
+				}
+			}
+			return n
+		}
+
+		g.It("Should not run the canary on the initial connection", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(server.Commands()).To(Equal([]string{"status"}))
+		})
+
+		g.It("Should run the canary before resuming commands after a reconnect", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			server.DisconnectAll()
+
+			Eventually(func() error {
+				_, err := client.ExecCommand("status")
+				return err
+			}, time.Second*3).Should(BeNil())
+
+			Expect(server.Commands()[0]).To(Equal("ready"))
+			Expect(commandCount("ready")).To(Equal(1))
+		})
+
+		g.It("Should retry the canary until Check accepts the response", func() {
+			atomic.StoreInt32(&notReady, 2)
+
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			server.DisconnectAll()
+
+			Eventually(func() error {
+				_, err := client.ExecCommand("status")
+				return err
+			}, time.Second*3).Should(BeNil())
+
+			// All three canaries ran over the same connection.
+			Expect(commandCount("ready")).To(Equal(3))
+			_, ok := server.AuthAttempts()
+			Expect(ok).To(Equal(2))
+		})
+
+		g.It("Should fail the reconnect attempt with ErrCanaryFailed if every canary failed", func() {
+			atomic.StoreInt32(&notReady, 100)
+			config.Reconnect.MaxAttempts = 2
+			config.Reconnect.Canary.Attempts = 2
+
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			server.DisconnectAll()
+
+			var err error
+			Eventually(disconnects, time.Second*3).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrCanaryFailed)).To(BeTrue())
+
+			// Each failed attempt closed its connection and the next one authenticated again.
+			Expect(commandCount("ready")).To(Equal(4))
+			_, ok := server.AuthAttempts()
+			Expect(ok).To(Equal(3))
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package rcon
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Capability is an optional protocol feature the client relies on which a server may silently not support.
+type Capability string
+
+const (
+	// CapabilityMultiPacket is the echo of the empty sentinel packet used to assemble multi-packet responses. A server
+	// which answers commands but never echoes the sentinel degrades it; commands are then read as single packets.
+	CapabilityMultiPacket Capability = "multi-packet"
+
+	// CapabilityKeepAlive is the answer to keepalive pings. A server which keeps sending other packets but never
+	// answers pings degrades it; keepalive is then stopped instead of dropping a healthy connection.
+	CapabilityKeepAlive Capability = "keepalive"
+
+	// CapabilityListen is the answer to the dialect's ListenCommand. A server which answers other commands but never
+	// answers the listen command degrades it; Listen then fails right away with errs.ErrListenUnsupported instead of
+	// timing out, and channels are no longer listened to again after reconnecting.
+	CapabilityListen Capability = "listen"
+)
+
+// CapabilityEvent describes a capability the client disabled because the server did not support it.
+type CapabilityEvent struct {
+	Capability Capability
+
+	// Reason describes what the client observed.
+	Reason string
+
+	// Failures is the number of consecutive failures which led to the capability being disabled.
+	Failures int
+
+	Time time.Time
+}
+
+// CapabilityHandler is called when the client disables a capability.
+type CapabilityHandler func(event CapabilityEvent)
+
+// capabilities tracks the failures of each capability. Degraded capabilities stay disabled for the lifetime of the
+// client, including across reconnects, since they describe the server rather than the connection.
+type capabilities struct {
+	lock     sync.Mutex
+	failures map[Capability]int
+	degraded map[Capability]bool
+}
+
+// capabilityDegraded returns true if capability was disabled.
+func (c *Client) capabilityDegraded(capability Capability) bool {
+	c.capabilities.lock.Lock()
+	defer c.capabilities.lock.Unlock()
+
+	return c.capabilities.degraded[capability]
+}
+
+// capabilitySucceeded resets the consecutive failures of capability.
+func (c *Client) capabilitySucceeded(capability Capability) {
+	c.capabilities.lock.Lock()
+	defer c.capabilities.lock.Unlock()
+
+	delete(c.capabilities.failures, capability)
+}
+
+// capabilityFailed records a failure of capability. Once DegradeAfter consecutive failures were recorded, the
+// capability is disabled and the CapabilityHandler is called. It returns true if the capability is disabled.
+func (c *Client) capabilityFailed(capability Capability, reason string) bool {
+	c.capabilities.lock.Lock()
+
+	if c.capabilities.degraded[capability] {
+		c.capabilities.lock.Unlock()
+		return true
+	}
+
+	if c.capabilities.failures == nil {
+		c.capabilities.failures = map[Capability]int{}
+		c.capabilities.degraded = map[Capability]bool{}
+	}
+
+	c.capabilities.failures[capability]++
+	failures := c.capabilities.failures[capability]
+
+	if failures < c.DegradeAfter {
+		c.capabilities.lock.Unlock()
+		c.log.Debug("Capability ", capability, " failed (", failures, "/", c.DegradeAfter, "): ", reason)
+		return false
+	}
+
+	c.capabilities.degraded[capability] = true
+	delete(c.capabilities.failures, capability)
+	c.capabilities.lock.Unlock()
+
+	c.log.Info("Server does not appear to support ", capability, ", disabling it: ", reason)
+
+	if c.CapabilityHandler != nil {
+		c.CapabilityHandler(CapabilityEvent{
+			Capability: capability,
+			Reason:     reason,
+			Failures:   failures,
+			Time:       c.Clock(),
+		})
+	}
+
+	return true
+}
+
+// DegradedCapabilities returns the capabilities the client disabled because the server did not support them, sorted
+// by name.
+func (c *Client) DegradedCapabilities() []Capability {
+	c.capabilities.lock.Lock()
+	defer c.capabilities.lock.Unlock()
+
+	var degraded []Capability
+	for capability := range c.capabilities.degraded {
+		degraded = append(degraded, capability)
+	}
+
+	sort.Slice(degraded, func(i, j int) bool {
+		return degraded[i] < degraded[j]
+	})
+
+	return degraded
+}
+
+// multiPacket returns true if responses should be assembled from multiple packets.
+func (c *Client) multiPacket() bool {
+	return c.MultiPacketResponses && !c.capabilityDegraded(CapabilityMultiPacket)
+}
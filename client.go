@@ -1,42 +1,145 @@
 package rcon
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"github.com/pkg/errors"
 	"github.com/refractorgscm/rcon/endian"
 	"github.com/refractorgscm/rcon/errs"
 	"github.com/refractorgscm/rcon/packet"
 	"io"
-	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Client struct {
 	*Config
-	conn     *net.TCPConn
-	connLock sync.Mutex
-	log      Logger
+	conn          Conn
+	reader        *bufio.Reader
+	wireCodec     packet.Codec
+	endpoint      string
+	connStateLock sync.Mutex
+	connLock      sync.Mutex
+	log           Logger
+
+	sessionLock sync.Mutex
+	terminate   chan uint8
+	be          *battlEyeSession
 
-	terminate  chan uint8
 	waitGroup  *sync.WaitGroup
 	wqLock     sync.Mutex
-	rqLock     sync.Mutex
 	wgLock     sync.Mutex
-	writeQueue chan packet.Packet
-	readQueue  map[int32]chan packet.Packet
+	writeQueue chan queuedPacket
+	mailboxes  *mailboxes
+
+	macroLock sync.RWMutex
+	macros    map[string][]string
+
+	clockLock   sync.RWMutex
+	clockOffset *ClockOffset
+
+	perCommandLock      sync.Mutex
+	perCommandConnected bool
+
+	reconnectLock sync.Mutex
+	reconnecting  bool
+	stopReconnect chan struct{}
+
+	latencies latencyWindow
+
+	groupsLock sync.Mutex
+	groups     map[string]*commandGroup
+
+	oversizePackets uint64
+
+	drainUntil    int64
+	pendingWrites int64
+
+	bytesRead    uint64
+	bytesWritten uint64
+	readBudget   *byteBudget
+	writeBudget  *byteBudget
+	limiter      *commandLimiter
+
+	state              int32
+	disconnectNotified int32
+
+	errLock sync.Mutex
+	err     error
+
+	ids *packet.IDGenerator
+
+	pauseLock sync.Mutex
+	resumed   chan struct{}
+
+	ready int32
+
+	subscriptions subscriptions
+	listens       listens
+
+	stats          commandStats
+	recentCommands recentCommands
+
+	idempotencyLock sync.Mutex
+	idempotencyKeys map[string]*idempotentCall
+
+	packetHandlersLock sync.RWMutex
+	packetHandlers     map[packet.PacketType]PacketHandler
+
+	capabilities capabilities
+
+	queued       queuedPackets
+	recentErrors recentErrors
+
+	middlewareLock sync.RWMutex
+	middleware     []Middleware
+	execChain      ExecFunc
+
+	broadcastHeaderChecker BroadcastHeaderChecker
+
+	// labels is a copy of Config.Labels taken by NewClient, shared read-only by everything the labels are attached to.
+	labels map[string]string
 }
 
 type BroadcastHandler func(string)
 type BroadcastMessageChecker func(p packet.Packet) bool
 type DisconnectHandler func(error, bool)
+type ResponseErrorChecker func(command, response string) bool
+type ResponseFilter func(command, response string) string
 
 type Config struct {
 	Host     string
 	Port     uint16
 	Password string
 
-	// ConnTimeout is the timeout for TCP connection read/write operations with a deadline.
+	// Hosts lists the endpoints of a clustered game server in failover order, as host:port or as host alone, in which
+	// case Port is used. If set, it replaces Host. Connecting and every reconnect attempt try the endpoints in order
+	// with the same credentials until one can be reached. Only Source RCON connections support failover.
+	Hosts []string
+
+	// EndpointHandler is called whenever the client connects to a different endpoint than before.
+	EndpointHandler EndpointHandler
+
+	// Protocol is the wire protocol spoken by the server.
+	//
+	// Default: ProtocolSource
+	Protocol Protocol
+
+	// Transport, if set, replaces the built in protocols. See CommandTransport.
+	Transport CommandTransport
+
+	// StreamTransport opens the connections Source RCON is spoken over. See Transport.
+	//
+	// Default: &TLSTransport{Config: TLSConfig} if TLSConfig is set, &TCPTransport{} otherwise
+	StreamTransport Transport
+
+	// TLSConfig enables connecting to TLS-wrapped RCON endpoints. It is ignored if StreamTransport is set.
+	TLSConfig *tls.Config
+
+	// ConnTimeout is the timeout for dialing and for connection read/write operations with a deadline.
 	ConnTimeout time.Duration
 
 	// QueueWriteTimeout is the timeout for writing to the internal packet queues. Higher values can cause delays if
@@ -45,20 +148,69 @@ type Config struct {
 	// Default: 250ms
 	QueueWriteTimeout time.Duration
 
+	// WriteQueueSize is the number of packets which can wait to be written. With the default of zero, every command
+	// waits until the writer picks up its packet, so a brief write stall blocks all callers for up to
+	// QueueWriteTimeout.
+	WriteQueueSize int
+
+	// WriteQueuePolicy determines what happens when a packet is queued while WriteQueueSize packets are already
+	// waiting. It only applies if WriteQueueSize is greater than zero.
+	//
+	// Default: WriteQueueBlock
+	WriteQueuePolicy WriteQueuePolicy
+
+	// RateLimit caps the rate commands are written at, protecting against servers which throttle or drop clients
+	// flooding RCON. Commands executed with a context created by WithPriority, such as keepalive pings, bypass it.
+	RateLimit RateLimitConfig
+
 	// QueueReadTimeout is the timeout for reading from the internal packet queues.
 	//
 	// Default: 2s
 	QueueReadTimeout time.Duration
 
+	// AdaptiveTimeout enables deriving the queue read timeout of each command from a percentile of recently observed
+	// response latencies, bounded by AdaptiveTimeoutMin and AdaptiveTimeoutMax. This reduces false timeouts on servers
+	// whose responsiveness varies, for example with player count. QueueReadTimeout is used until enough responses
+	// have been observed.
+	AdaptiveTimeout bool
+
+	// AdaptiveTimeoutMin is the lower bound of adaptive timeouts.
+	//
+	// Default: 250ms
+	AdaptiveTimeoutMin time.Duration
+
+	// AdaptiveTimeoutMax is the upper bound of adaptive timeouts.
+	//
+	// Default: QueueReadTimeout
+	AdaptiveTimeoutMax time.Duration
+
+	// AdaptiveTimeoutPercentile is the latency percentile, between 0 and 1, adaptive timeouts are derived from.
+	//
+	// Default: 0.99
+	AdaptiveTimeoutPercentile float64
+
 	// EndianMode represents the byte order being used by whatever game you're using this library with. Valve games
 	// typically use little endian, but other games may use big endian. You can switch this as needed.
+	//
+	// Deprecated: declare EndianMode in the Features of the Dialect instead. If set, it overrides the dialect.
 	EndianMode endian.Mode
 
-	// BroadcastHandler is a function which will be called with a message whenever a broadcast message is received.
+	// BroadcastHandler is a function which will be called with a message whenever a broadcast message is received. To
+	// let multiple consumers receive broadcasts independently, use Subscribe instead.
 	BroadcastHandler BroadcastHandler
 
+	// BroadcastChannel, if set, classifies broadcasts into channels which subscriptions and RecentBroadcasts can
+	// filter by. See the presets package for the channels of supported games.
+	BroadcastChannel BroadcastChannelFunc
+
+	// BroadcastHistory is the number of most recent broadcasts recorded for RecentBroadcasts and SubscribeReplay. Zero
+	// disables recording.
+	BroadcastHistory int
+
 	// BroadcastChecker is a function which should be implemented. It is used to check if a packet is a broadcast.
 	// If BroadcastChecker returns true, the packet will be treated as a broadcast.
+	//
+	// Deprecated: declare BroadcastChecker in the Features of the Dialect instead. If set, it overrides the dialect.
 	BroadcastChecker BroadcastMessageChecker
 
 	// RestrictedPacketIDs is a slice of int32s which cannot be used as packet IDs. Some games use certain packet IDs to
@@ -66,10 +218,236 @@ type Config struct {
 	//
 	// The special packet IDs of whatever game you're using this library with should be put within this slice to ensure
 	// that the received and sent data is as you'd expect and to avoid potential client/server confusion.
+	//
+	// Deprecated: declare RestrictedPacketIDs in the Features of the Dialect instead. If set, it overrides the
+	// dialect.
 	RestrictedPacketIDs []int32
 
-	// DisconnectHandler is a function which will be called when the client gets disconnected.
+	// DisconnectHandler is a function which will be called when the client gets disconnected. If automatic
+	// reconnection is enabled, it is only called once all reconnection attempts have failed.
 	DisconnectHandler DisconnectHandler
+
+	// StatusChangeHandler is called whenever the connection state of the client changes, for example to reflect the
+	// health of the connection in a dashboard.
+	StatusChangeHandler StatusChangeHandler
+
+	// Reconnect configures automatic reconnection after the server drops the connection.
+	Reconnect ReconnectConfig
+
+	// Retry configures automatic retries of commands executed using ExecCommand and ExecCommandContext which failed
+	// with a transient error.
+	Retry RetryConfig
+
+	// Bandwidth caps the rate data is exchanged with the server at, protecting metered links from runaway commands
+	// and broadcast floods. Only Source RCON connections are capped.
+	Bandwidth BandwidthConfig
+
+	// KeepAlive configures periodic pings which keep idle connections open and detect servers which stopped
+	// answering.
+	KeepAlive KeepAliveConfig
+
+	// Dialect describes the game's flavour of the RCON protocol. If set, its features enable or disable client
+	// subsystems automatically. See the presets package for dialects of supported games.
+	Dialect Dialect
+
+	// MultiPacketResponses enables assembly of responses which the server splits across multiple packets, as Source
+	// servers do for responses larger than ~4096 bytes. After every command an empty sentinel packet is sent, and all
+	// response fragments received before the sentinel's echo are concatenated.
+	//
+	// Only enable this for servers which echo empty response value packets, such as Source engine servers.
+	MultiPacketResponses bool
+
+	// ConnectionPerCommand should be enabled for minimal RCON implementations which close the TCP connection after
+	// every response. In this mode the client transparently dials and authenticates a fresh connection for each
+	// executed command. Broadcasts are not supported in this mode.
+	ConnectionPerCommand bool
+
+	// ResponseErrorChecker is an optional function used to detect error messages in command responses. Many games
+	// return errors as plain text (e.g. "Player not found"). If ResponseErrorChecker returns true, ExecCommand returns
+	// an *errs.ServerCommandError containing the response instead of returning it as a successful result.
+	ResponseErrorChecker ResponseErrorChecker
+
+	// PacketHandlers handle server packets of custom types, keyed by type. Packets of types other than the response
+	// value and auth response types which have no handler are dropped and reported to the UnhandledPacketHandler.
+	// Handlers declared by the dialect are used for types not configured here.
+	PacketHandlers map[packet.PacketType]PacketHandler
+
+	// UnhandledPacketHandler, if set, is called with every packet the client could not route, including its raw bytes.
+	UnhandledPacketHandler UnhandledPacketHandler
+
+	// LateResponseGrace is how long the ID of a command which was cancelled or timed out is remembered. Responses
+	// arriving in that time are dropped and counted in Stats as late responses rather than reported as unexpected.
+	//
+	// Default: 30s
+	LateResponseGrace time.Duration
+
+	// MailboxTTL is how long a command's mailbox may stay open. Commands remove their mailbox once they stop waiting,
+	// so a mailbox open for longer belongs to a caller which never collected its response. A background janitor removes
+	// such mailboxes and counts them in Stats. It should be well above the longest time a command waits for a response.
+	//
+	// Default: 5m
+	MailboxTTL time.Duration
+
+	// ResponseFilter, if set, rewrites every command response before it is checked for errors and returned, for
+	// example to strip formatting codes.
+	ResponseFilter ResponseFilter
+
+	// CapabilityHandler, if set, is called when the client disables a capability the server turned out not to
+	// support, such as multi-packet sentinels or keepalive pings. See Capability.
+	CapabilityHandler CapabilityHandler
+
+	// DegradeAfter is the number of consecutive failures of a capability after which the client disables it.
+	//
+	// Default: 3
+	DegradeAfter int
+
+	// ResyncStrategy determines how the client recovers when the incoming packet stream loses framing.
+	//
+	// Default: ResyncNone
+	ResyncStrategy ResyncStrategy
+
+	// TeeHandler, if set, receives a copy of every decoded inbound packet without affecting how the packet is routed.
+	// It can be used for live protocol inspection and traffic recording.
+	TeeHandler TeeHandler
+
+	// TeeOutbound additionally mirrors outbound packets to TeeHandler. The body of authentication packets is removed
+	// before they are mirrored.
+	TeeOutbound bool
+
+	// PacketHooks receive every packet sent or received together with its raw bytes, for debugging new game
+	// integrations and building packet capture tools.
+	PacketHooks PacketHooks
+
+	// TraceWire logs a hex dump of every packet sent and received, annotated with its ID, type and size, to help with
+	// reverse-engineering non-standard servers. Dumps are logged at Trace level if the Logger implements TraceLogger,
+	// and at Debug level otherwise. The body of authentication packets is never dumped.
+	//
+	// Default: false
+	TraceWire bool
+
+	// BodyPreallocation is the maximum number of bytes allocated up front when reading a packet body. Packets declaring
+	// a larger size are read in stages as bytes arrive, bounding the memory a server declaring inflated sizes can make
+	// the client allocate. Such packets are counted and can be retrieved with OversizePackets.
+	//
+	// Default: packet.DefaultBodyPreallocation
+	BodyPreallocation int
+
+	// MaxPacketSize is the largest size, as declared by the size field, of the packets the client sends and accepts.
+	// Received packets declaring a larger size are rejected with errs.ErrMalformedPacket before their body is read, and
+	// handled according to the ResyncStrategy. Commands which don't fit are rejected with errs.ErrCommandTooLarge
+	// before being sent, unless SplitCommands is enabled.
+	//
+	// Default: packet.DefaultMaxPacketSize
+	MaxPacketSize int
+
+	// SplitCommands makes the client split commands which don't fit into a single packet, because of MaxPacketSize or
+	// the dialect's MaxBodySize, at the ";" separators of console command lists, outside of double quotes. Each part
+	// is sent in its own packet and executed as a separate command, like the game's console would, and the responses
+	// are joined with newlines. Commands which can't be split into parts that fit are still rejected with
+	// errs.ErrCommandTooLarge. Only enable this for games whose console accepts ";"-separated command lists, such as
+	// Source engine games.
+	//
+	// Default: false
+	SplitCommands bool
+
+	// Codec frames packets on the wire, for games which extend the Source packet format with extra header fields,
+	// checksums or length prefixes. BroadcastHeaderChecker and ResyncScan only work with Source framing. A
+	// *packet.SourceCodec is used with its MaxPacketSize replaced by the MaxPacketSize of the config; other codecs
+	// must enforce a maximum packet size themselves.
+	//
+	// Default: a packet.SourceCodec using EndianMode, BodyPreallocation and Strict
+	Codec packet.Codec
+
+	// PausePolicy determines whether commands executed while the client is paused wait for it to be resumed or fail
+	// immediately.
+	//
+	// Default: PauseBuffer
+	PausePolicy PausePolicy
+
+	// ValidationRetries is the number of times ExecValidated re-executes a command whose response was rejected by its
+	// validator.
+	//
+	// Default: 0
+	ValidationRetries int
+
+	// ValidationRetryDelay is the wait between ExecValidated attempts.
+	//
+	// Default: 250ms
+	ValidationRetryDelay time.Duration
+
+	// SelfTest is a sequence of commands executed after every successful connect and reconnect. The client only
+	// reports Ready once all of them succeeded, which catches credentials with insufficient permissions at startup
+	// rather than mid-operation. A command fails if it returns an error, including a response identified by the
+	// ResponseErrorChecker.
+	SelfTest []string
+
+	// SelfTestFailureHandler is called with the first self-test command which failed.
+	SelfTestFailureHandler SelfTestFailureHandler
+
+	// SelfTestDisconnect closes the connection if the self-test fails.
+	SelfTestDisconnect bool
+
+	// IdempotencyWindow is how long the result of ExecCommandIdempotent is remembered for its idempotency key.
+	//
+	// Default: 10m
+	IdempotencyWindow time.Duration
+
+	// Strict makes the client report protocol anomalies instead of tolerating them: packets of an unknown type, bodies
+	// with wrong terminators and packets which don't belong to any pending command. Packets violating the protocol are
+	// dropped and reported to the ProtocolViolationHandler. Since the stream can't be trusted after a malformed packet,
+	// the connection is then closed like one dropped by the server, and during authentication the violation is returned
+	// as the error. Packets which don't belong to any pending command are only reported. This is useful when developing
+	// a dialect for a game with an undocumented RCON implementation.
+	Strict bool
+
+	// ProtocolViolationHandler is called with every protocol violation detected in Strict mode.
+	ProtocolViolationHandler ProtocolViolationHandler
+
+	// CloseDrainTimeout is how long Close waits for commands which are in flight to complete before closing the
+	// connection.
+	//
+	// Default: QueueReadTimeout
+	CloseDrainTimeout time.Duration
+
+	// CloseGracePeriod is how long Close keeps reading after it was called, so that final messages the server sends,
+	// such as shutdown notices, are delivered to their mailboxes and broadcast handlers before the DisconnectHandler is
+	// called. Commands cannot be executed during the grace period. Zero closes the connection immediately. It only
+	// applies to Source RCON connections which are kept open between commands.
+	CloseGracePeriod time.Duration
+
+	// SlowCommandThreshold is the duration after which a command is considered slow. Slow commands are logged and
+	// counted in Stats. Zero disables slow command detection.
+	SlowCommandThreshold time.Duration
+
+	// SlowCommandCount is the number of slowest recent commands reported by Stats.
+	//
+	// Default: DefaultSlowCommandCount
+	SlowCommandCount int
+
+	// CommandRedactor, if set, rewrites command text before it is logged as a slow command or recorded in Stats.
+	CommandRedactor CommandRedactor
+
+	// IDSeed is the packet ID the client's ID sequence starts after. Packet IDs are generated per client, so clients
+	// with the same seed and restricted IDs send the same IDs, which keeps recorded sessions reproducible.
+	//
+	// Default: 0, the first packet ID is 1
+	IDSeed int32
+
+	// Clock returns the current time used for the timestamps of broadcasts and recorded commands, and for expiring
+	// idempotency keys. Tests can inject a fixed clock to make recorded sessions reproducible. Timeouts and latencies
+	// always use the real time.
+	//
+	// Default: time.Now
+	Clock func() time.Time
+
+	// Metrics, if set, receives measurements of the commands, broadcasts, errors and reconnects of this client.
+	Metrics Metrics
+
+	// Labels are arbitrary key/value pairs identifying this client, for example a tenant or server name, so that
+	// operators running many clients can segment their telemetry. They are appended to every log entry, passed to
+	// Metrics implementing LabeledMetrics, and attached to broadcasts, recorded commands and stats snapshots, and so to
+	// the events encoded from them.
+	Labels map[string]string
 }
 
 const DefaultTimeout = time.Second * 2
@@ -79,14 +457,56 @@ func NewClient(config *Config, logger Logger) *Client {
 		Config:     config,
 		log:        &DefaultLogger{},
 		waitGroup:  &sync.WaitGroup{},
-		terminate:  make(chan uint8),
-		writeQueue: make(chan packet.Packet),
-		readQueue:  map[int32]chan packet.Packet{},
+		writeQueue: make(chan queuedPacket, config.WriteQueueSize),
+		mailboxes:  newMailboxes(),
+		macros:     map[string][]string{},
+		groups:     map[string]*commandGroup{},
+
+		idempotencyKeys: map[string]*idempotentCall{},
 	}
 
 	if logger != nil {
 		c.log = logger
 	}
+	if len(c.Config.Labels) > 0 {
+		c.labels = make(map[string]string, len(c.Config.Labels))
+		for k, v := range c.Config.Labels {
+			c.labels[k] = v
+		}
+	}
+
+	c.log = newLabeledLogger(c.log, c.labels)
+	c.log = &errorRecorder{Logger: c.log, recent: &c.recentErrors}
+
+	c.warnDeprecated()
+	c.applyDialect()
+	c.initPacketHandlers()
+
+	c.ids = packet.NewSeededIDGenerator(c.IDSeed, c.RestrictedPacketIDs)
+
+	if m, ok := c.Metrics.(LabeledMetrics); ok && c.labels != nil {
+		m.SetLabels(c.Labels())
+	}
+
+	if c.Clock == nil {
+		c.Clock = time.Now
+	}
+
+	c.readBudget = newByteBudget(c.Bandwidth.ReadBytesPerSecond)
+	c.writeBudget = newByteBudget(c.Bandwidth.WriteBytesPerSecond)
+
+	if c.RateLimit.Burst <= 0 {
+		c.RateLimit.Burst = 1
+	}
+	c.limiter = newCommandLimiter(c.RateLimit)
+
+	if c.StreamTransport == nil {
+		if c.TLSConfig != nil {
+			c.StreamTransport = &TLSTransport{Config: c.TLSConfig}
+		} else {
+			c.StreamTransport = &TCPTransport{}
+		}
+	}
 
 	if c.EndianMode == nil {
 		c.EndianMode = endian.Little
@@ -97,9 +517,7 @@ func NewClient(config *Config, logger Logger) *Client {
 	}
 
 	if c.BroadcastChecker == nil {
-		c.BroadcastChecker = func(p packet.Packet) bool {
-			return false
-		}
+		c.BroadcastChecker = noBroadcasts
 	}
 
 	if c.QueueWriteTimeout <= 0 {
@@ -110,6 +528,78 @@ func NewClient(config *Config, logger Logger) *Client {
 		c.QueueReadTimeout = time.Second * 2
 	}
 
+	if c.BodyPreallocation <= 0 {
+		c.BodyPreallocation = packet.DefaultBodyPreallocation
+	}
+
+	if c.MaxPacketSize <= 0 {
+		c.MaxPacketSize = packet.DefaultMaxPacketSize
+	}
+
+	if c.Reconnect.Backoff == nil {
+		c.Reconnect.Backoff = ExponentialBackoff(time.Second, time.Second*30)
+	}
+
+	if c.Retry.Retryable == nil {
+		c.Retry.Retryable = IsTransient
+	}
+
+	if c.Retry.Backoff == nil {
+		c.Retry.Backoff = ExponentialBackoff(time.Millisecond*100, time.Second*2)
+	}
+
+	if c.SlowCommandCount <= 0 {
+		c.SlowCommandCount = DefaultSlowCommandCount
+	}
+
+	if c.ValidationRetryDelay <= 0 {
+		c.ValidationRetryDelay = time.Millisecond * 250
+	}
+
+	if c.IdempotencyWindow <= 0 {
+		c.IdempotencyWindow = time.Minute * 10
+	}
+
+	if c.LateResponseGrace <= 0 {
+		c.LateResponseGrace = time.Second * 30
+	}
+
+	if c.Reconnect.Canary.Attempts <= 0 {
+		c.Reconnect.Canary.Attempts = 3
+	}
+
+	if c.Reconnect.Canary.Delay <= 0 {
+		c.Reconnect.Canary.Delay = time.Second
+	}
+
+	if c.MailboxTTL <= 0 {
+		c.MailboxTTL = time.Minute * 5
+	}
+
+	if c.DegradeAfter <= 0 {
+		c.DegradeAfter = 3
+	}
+
+	if c.KeepAlive.MaxMissed <= 0 {
+		c.KeepAlive.MaxMissed = 2
+	}
+
+	if c.CloseDrainTimeout <= 0 {
+		c.CloseDrainTimeout = c.QueueReadTimeout
+	}
+
+	if c.AdaptiveTimeoutMin <= 0 {
+		c.AdaptiveTimeoutMin = time.Millisecond * 250
+	}
+
+	if c.AdaptiveTimeoutMax <= 0 {
+		c.AdaptiveTimeoutMax = c.QueueReadTimeout
+	}
+
+	if c.AdaptiveTimeoutPercentile <= 0 || c.AdaptiveTimeoutPercentile > 1 {
+		c.AdaptiveTimeoutPercentile = 0.99
+	}
+
 	return c
 }
 
@@ -125,52 +615,210 @@ func (c *Client) SetBroadcastChecker(checker BroadcastMessageChecker) {
 	c.BroadcastChecker = checker
 }
 
+func (c *Client) SetResponseErrorChecker(checker ResponseErrorChecker) {
+	c.ResponseErrorChecker = checker
+}
+
+// SetRestrictedPacketIDs atomically replaces the restricted packet IDs. The ID generator skips any newly restricted
+// IDs from now on. Commands which are still waiting for a response on a newly restricted ID fail with
+// errs.ErrMailboxClosed, since their response could no longer be told apart from the packets the game sends on that ID.
 func (c *Client) SetRestrictedPacketIDs(restrictedIDs []int32) {
-	c.RestrictedPacketIDs = restrictedIDs
+	// The config field isn't updated, since it may be read concurrently. Features reads the IDs from the generator.
+	c.ids.SetRestricted(restrictedIDs)
+
+	for _, id := range c.mailboxes.removeIf(c.ids.IsRestricted) {
+		c.log.Debug("Closed mailbox ", id, " since its ID became restricted")
+	}
 }
 
+// Connect connects and authenticates the client, then runs the configured self-test. If the self-test fails, an error
+// wrapping errs.ErrSelfTestFailed is returned; the client stays connected unless SelfTestDisconnect is set.
 func (c *Client) Connect() error {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", c.Host, c.Port), c.ConnTimeout)
-	if err != nil {
-		return errors.Wrap(err, "tcp dial failure")
+	c.setState(StateConnecting)
+
+	if err := c.connect(); err != nil {
+		c.transition(StateDisconnected, StateConnecting, StateAuthenticating)
+		return err
 	}
-	c.log.Debug("Dial successful, connection established.")
 
-	var ok bool
-	c.conn, ok = conn.(*net.TCPConn)
-	if !ok {
-		return errors.Wrap(err, "tcp dial failure")
+	c.markConnected()
+
+	return c.runSelfTest()
+}
+
+func (c *Client) connect() error {
+	if c.Transport != nil {
+		return c.connectTransport()
 	}
 
-	if err := c.conn.SetDeadline(time.Now().Add(c.ConnTimeout)); err != nil {
-		return errors.Wrap(err, "could not set tcp connection deadline")
+	if c.Protocol == ProtocolBattlEye {
+		return c.connectBattlEye()
 	}
 
-	if err := c.authenticate(); err != nil {
-		c.log.Debug("Authentication failed", err)
+	if err := c.dial(); err != nil {
 		return err
 	}
 
-	c.log.Debug("Starting writer routine")
-	go func() {
-		c.wgLock.Lock()
+	if c.ConnectionPerCommand {
+		// The credentials have been verified, so the connection isn't needed until the first command is executed.
+		c.closeConn()
+
+		c.perCommandLock.Lock()
+		c.perCommandConnected = true
+		c.perCommandLock.Unlock()
+
+		return nil
+	}
+
+	c.startRoutines()
+
+	return nil
+}
+
+// startRoutines starts the reader and writer routines for the current connection. Each set of routines gets its own
+// termination channel so that routines belonging to a previous connection can never pick up a new one.
+func (c *Client) startRoutines() {
+	terminate := make(chan uint8)
+
+	c.sessionLock.Lock()
+	c.terminate = terminate
+	c.sessionLock.Unlock()
+
+	keepAlive := c.keepAliveEnabled()
+
+	c.wgLock.Lock()
+	c.waitGroup.Add(3)
+	if keepAlive {
 		c.waitGroup.Add(1)
-		c.wgLock.Unlock()
-		c.startWriter()
-	}()
+	}
+	c.wgLock.Unlock()
+
+	c.log.Debug("Starting writer routine")
+	go c.startWriter(terminate)
 
 	c.log.Debug("Starting reader routine")
-	go func() {
-		c.wgLock.Lock()
-		c.waitGroup.Add(1)
-		c.wgLock.Unlock()
-		c.startReader()
-	}()
+	go c.startReader(terminate)
+
+	c.log.Debug("Starting janitor routine")
+	go c.startJanitor(terminate)
+
+	if keepAlive {
+		c.log.Debug("Starting keepalive routine")
+		go c.startKeepAlive(terminate)
+	}
+}
+
+// session returns the termination channel of the current connection.
+func (c *Client) session() chan uint8 {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	return c.terminate
+}
+
+// endSession closes terminate, making all routines of its connection return. It returns false if terminate does not
+// belong to the current connection or was already closed, in which case the caller must not tear down the connection
+// again. This allows the reader and keepalive routines to detect a dead connection at the same time.
+func (c *Client) endSession(terminate chan uint8) bool {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	if terminate == nil || terminate != c.terminate {
+		return false
+	}
+
+	select {
+	case <-terminate:
+		return false
+	default:
+	}
+
+	close(terminate)
+
+	return true
+}
+
+// dial opens the TCP connection and authenticates it. The endpoints are tried in failover order; the first one which
+// can be reached is used.
+func (c *Client) dial() error {
+	var conn Conn
+	var endpoint string
+	var err error
+
+	for _, endpoint = range c.endpoints() {
+		conn, err = c.dialEndpoint(endpoint)
+		if err == nil {
+			break
+		}
+
+		c.log.Debug("Could not reach endpoint ", endpoint, ". Error: ", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("dial failure: %w", err)
+	}
+	c.log.Debug("Dial successful, connection established.")
+
+	c.connStateLock.Lock()
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+	c.wireCodec = c.newCodec()
+	c.connStateLock.Unlock()
+
+	if err := conn.SetDeadline(time.Now().Add(c.ConnTimeout)); err != nil {
+		c.closeConn()
+		return fmt.Errorf("could not set connection deadline: %w", err)
+	}
+
+	// Connections opened while reconnecting or for a single command don't change the state.
+	c.transition(StateAuthenticating, StateConnecting)
+
+	if err := c.authenticate(); err != nil {
+		c.log.Debug("Authentication failed", err)
+		c.closeConn()
+		return err
+	}
+
+	c.setEndpoint(endpoint)
 
 	return nil
 }
 
-func (c *Client) startWriter() {
+func (c *Client) dialEndpoint(endpoint string) (Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.ConnTimeout)
+	defer cancel()
+
+	conn, err := c.StreamTransport.Dial(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.meterConn(conn), nil
+}
+
+// closeConn closes the underlying connection without notifying any handlers.
+func (c *Client) closeConn() {
+	c.connStateLock.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.reader = nil
+	c.wireCodec = nil
+	c.connStateLock.Unlock()
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// connection returns the current connection and its buffered reader, or nil values if the client is not connected.
+func (c *Client) connection() (Conn, *bufio.Reader) {
+	c.connStateLock.Lock()
+	defer c.connStateLock.Unlock()
+
+	return c.conn, c.reader
+}
+
+func (c *Client) startWriter(terminate chan uint8) {
 	defer func() {
 		c.wgLock.Lock()
 		c.waitGroup.Done()
@@ -179,20 +827,50 @@ func (c *Client) startWriter() {
 	}()
 
 	for {
+		// Stop dequeuing writes while paused.
+		if resumed := c.pauseChan(); resumed != nil {
+			select {
+			case <-resumed:
+				continue
+			case <-terminate:
+				c.log.Debug("Writer routine received termination signal")
+				return
+			}
+		}
+
 		select {
-		case p := <-c.writeQueue:
-			if err := c.sendPacket(p); err != nil {
+		case q := <-c.writeQueue:
+			c.queued.remove(q.packet.ID())
+
+			if wait := c.rateLimit(q); wait > 0 {
+				c.log.Debug("Rate limit reached, delaying packet ", q.packet.ID(), " by ", wait)
+
+				select {
+				case <-time.After(wait):
+				case <-terminate:
+					atomic.AddInt64(&c.pendingWrites, -1)
+					c.log.Debug("Writer routine received termination signal")
+					return
+				}
+			}
+
+			if err := c.sendPacket(q.packet); errors.Is(err, errs.ErrNotSent) {
+				// The packet can never be sent, so its command fails right away instead of timing out.
+				c.log.Error("Could not encode packet ", q.packet.ID(), ". Error: ", err)
+				c.removeMailbox(q.packet.ID())
+			} else if err != nil {
 				c.log.Debug("Could not write packet. Error: ", err)
 			}
+			atomic.AddInt64(&c.pendingWrites, -1)
 			break
-		case <-c.terminate:
+		case <-terminate:
 			c.log.Debug("Writer routine received termination signal")
 			return
 		}
 	}
 }
 
-func (c *Client) startReader() {
+func (c *Client) startReader(terminate chan uint8) {
 	defer func() {
 		c.wgLock.Lock()
 		c.waitGroup.Done()
@@ -200,8 +878,6 @@ func (c *Client) startReader() {
 		c.log.Debug("Reader routine terminated")
 	}()
 
-	terminate := false
-
 	readChan := make(chan packet.Packet)
 
 	// Start select routine
@@ -210,11 +886,9 @@ func (c *Client) startReader() {
 			// Add packet to mailbox
 			select {
 			case p := <-readChan:
-				c.readQueue[p.ID()] <- p
-				c.log.Debug("Packet added to mailbox ID: ", p.ID())
+				c.deliver(p)
 				break
-			case <-c.terminate:
-				terminate = true
+			case <-terminate:
 				c.log.Debug("Reader routine received termination signal")
 				return
 			}
@@ -222,27 +896,49 @@ func (c *Client) startReader() {
 	}()
 
 	for {
-		// Break out of the loop if we're meant to terminate this routine.
+		// Return if we're meant to terminate this routine.
 		// We can be sure that terminate will be reached beyond the blocking readPacket call because the connection
 		// was closed before we received the termination signal, so the blocking readPacket call will error out and
 		// not block the termination instruction.
-		if terminate {
-			break
+		select {
+		case <-terminate:
+			return
+		default:
 		}
 
 		p, err := c.readPacket()
 		if err != nil {
-			switch errors.Cause(err) {
-			case errs.ErrNotConnected:
+			if !errors.Is(err, errs.ErrNotConnected) {
+				c.metrics().ReadError()
+			}
+
+			// Any read error ends draining, whether the grace period passed or the server closed the connection.
+			if c.draining() {
+				c.log.Debug("Finished draining. Error: ", err)
+				c.disconnect(terminate, nil)
+				return
+			}
+
+			switch {
+			case errors.Is(err, errs.ErrNotConnected):
 				break
-			case io.EOF:
-				c.log.Error("Disconnected by the server. Error: ", err)
-				c.disconnect(err)
+			case errors.Is(err, errs.ErrMalformedPacket):
+				if c.resync(terminate, err) {
+					return
+				}
 				break
-			case io.ErrClosedPipe:
-				c.disconnect(err)
+			case errors.Is(err, packet.ErrProtocolViolation):
+				c.protocolViolation(err)
+				c.connectionLost(terminate, err)
+				return
+			case errors.Is(err, io.EOF):
+				c.log.Error("Disconnected by the server. Error: ", err)
+				c.connectionLost(terminate, &errs.ConnClosedError{Err: err})
+				return
+			case errors.Is(err, io.ErrClosedPipe):
 				c.log.Error("Attempted to read from a closed pipe. Error: ", err)
-				break
+				c.connectionLost(terminate, &errs.ConnClosedError{Err: err})
+				return
 			default:
 				c.log.Debug("Reader error: ", err)
 			}
@@ -252,17 +948,20 @@ func (c *Client) startReader() {
 
 		packetID := p.ID()
 
+		// Packets of custom types are never broadcasts or responses, even if their ID looks like one.
+		if c.handleCustomPacket(p) {
+			continue
+		}
+
 		// Check if this packet is a broadcast message
-		if c.BroadcastChecker(p) {
+		if c.isBroadcast(p) {
 			c.log.Debug("Packet ", packetID, " is a broadcast message")
 
 			// If this packet is a broadcast, notify broadcast listener and jump to next read.
-			if c.BroadcastHandler != nil {
-				newBody := p.Body()
-				newBody = newBody[:len(newBody)-1] // strip null terminator
+			newBody := p.Body()
+			newBody = newBody[:len(newBody)-1] // strip null terminator
 
-				c.BroadcastHandler(string(newBody))
-			}
+			c.dispatchBroadcast(BroadcastSourceRCON, string(newBody), p)
 
 			continue
 		} else {
@@ -272,56 +971,119 @@ func (c *Client) startReader() {
 			select {
 			case readChan <- p:
 				break
+			case <-terminate:
+				return
 			case <-time.After(c.QueueWriteTimeout):
 				c.log.Debug("Packet ", packetID, " was unexpected (no open mailbox)")
+				c.unexpectedPacket(p, "was unexpected (no open mailbox)")
 				break
 			}
 		}
 	}
 }
 
+// Close closes the connection. It stops accepting new commands, waits up to CloseDrainTimeout for commands which are
+// in flight to complete and for queued packets to be written, then closes the connection. Commands still waiting for a
+// response at that point fail right away with errs.ErrMailboxClosed.
+//
+// Close is safe to call more than once and concurrently with a lost connection; the DisconnectHandler is called exactly
+// once. Calling Close on a client which is already closing or closed returns errs.ErrNotConnected.
 func (c *Client) Close() error {
 	c.log.Debug("Close called")
 
-	if c.conn == nil {
+	if !c.beginClose() {
+		return fmt.Errorf("client is already closed: %w", errs.ErrNotConnected)
+	}
+	defer c.setState(StateClosed)
+	defer c.failPending()
+
+	c.setReady(false)
+
+	if !c.waitForInFlight() {
+		c.log.Info("Closing with commands still in flight after ", c.CloseDrainTimeout)
+	}
+
+	if c.ConnectionPerCommand {
+		return c.closePerCommand()
+	}
+
+	if c.Transport != nil {
+		err := c.Transport.Close()
+		c.notifyDisconnect(nil)
+
+		return err
+	}
+
+	if c.Protocol == ProtocolBattlEye {
+		s := c.battlEye()
+		if s == nil {
+			return errs.ErrNotConnected
+		}
+
+		c.closeBattlEye(s, nil)
+
+		return nil
+	}
+
+	if c.cancelReconnect() {
+		return nil
+	}
+
+	if conn, _ := c.connection(); conn == nil {
 		return errs.ErrNotConnected
 	}
 
-	c.disconnect(nil)
+	if c.CloseGracePeriod > 0 {
+		c.drainAndDisconnect(c.session())
+		return nil
+	}
+
+	c.disconnect(c.session(), nil)
 
 	return nil
 }
 
-func (c *Client) disconnect(err error) {
+func (c *Client) disconnect(terminate chan uint8, err error) {
 	// Closing the termination channel makes all routines return
-	close(c.terminate)
+	if !c.endSession(terminate) {
+		return
+	}
 
-	_ = c.conn.Close()
-	c.conn = nil
+	c.closeConn()
 
-	if c.DisconnectHandler != nil {
-		c.DisconnectHandler(err, err == nil)
-	}
+	c.notifyDisconnect(err)
 }
 
 func (c *Client) authenticate() error {
 	p := c.newClientPacket(packet.TypeAuth, c.Password)
 
 	if err := c.sendPacket(p); err != nil {
-		return errors.Wrap(err, "could not send packet")
+		return fmt.Errorf("could not send authentication packet: %w", err)
 	}
 
 	res, err := c.readPacketTimeout()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("server closed the connection instead of answering authentication: %w", errs.ErrBanned)
+	}
 	if err != nil {
-		return errors.Wrap(err, "could not get auth response")
+		return fmt.Errorf("could not get auth response: %w", err)
+	}
+
+	// Source servers send an empty response value packet immediately before the auth response, so skip over it.
+	if res.Type() == packet.TypeCommandRes && len(res.Body()) == 1 {
+		res, err = c.readPacketTimeout()
+		if err != nil {
+			return fmt.Errorf("could not get auth response: %w", err)
+		}
 	}
 
 	if res.Type() != packet.TypeAuthRes {
-		return errors.Wrap(err, "packet was not of the type auth response")
+		body := res.Body()
+		return errs.NewUnexpectedAuthPacketError(int32(res.Type()), res.ID(), body[:len(body)-1])
 	}
 
 	if res.ID() == packet.AuthFailedID {
-		return errors.Wrap(errs.ErrAuthentication, "authentication failed")
+		return fmt.Errorf("authentication failed: %w", errs.ErrAuthentication)
 	}
 
 	c.log.Debug("Authenticated successfully")
@@ -329,88 +1091,340 @@ func (c *Client) authenticate() error {
 	return nil
 }
 
+// Labels returns a copy of the labels configured for this client.
+func (c *Client) Labels() map[string]string {
+	labels := make(map[string]string, len(c.labels))
+	for k, v := range c.labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// OversizePackets returns the number of received packets whose body exceeded BodyPreallocation.
+func (c *Client) OversizePackets() uint64 {
+	return atomic.LoadUint64(&c.oversizePackets)
+}
+
 func (c *Client) WaitGroup() *sync.WaitGroup {
 	return c.waitGroup
 }
 
+// ExecCommand executes command and returns its response. It is safe to call ExecCommand and the other Exec methods from
+// multiple goroutines at once; every command gets its own mailbox, so responses are never mixed up.
 func (c *Client) ExecCommand(command string) (string, error) {
+	return c.ExecCommandContext(context.Background(), command)
+}
+
+// ExecCommandContext executes command and returns its response. If ctx is cancelled or its deadline passes before the
+// response arrives, the command's mailbox is removed and ctx.Err() is returned wrapped. QueueReadTimeout still applies
+// as an upper bound.
+//
+// In ConnectionPerCommand mode ctx is only checked before the connection is dialed; the exchange itself is bounded by
+// ConnTimeout.
+func (c *Client) ExecCommandContext(ctx context.Context, command string) (string, error) {
+	return c.chain()(ctx, command)
+}
+
+func (c *Client) execCommand(ctx context.Context, command string) (string, error) {
 	p := c.newClientPacket(packet.TypeCommand, command)
 
-	c.log.Debug("Executing command: ", command)
+	if actor, ok := ActorFromContext(ctx); ok {
+		c.log.Debug("Executing command on behalf of ", actor, ": ", command)
+	} else {
+		c.log.Debug("Executing command: ", command)
+	}
 
-	if err := c.enqueuePacket(p, true); err != nil {
-		return "", errors.Wrap(err, "could not enqueue command packet")
+	if err := ctx.Err(); err != nil {
+		return "", &errs.NotSentError{Err: fmt.Errorf("command cancelled: %w", err)}
 	}
 
-	res, err := c.getResponse(p.ID())
-	if err != nil {
-		return "", errors.Wrap(err, "could not get command response")
+	if err := c.checkCommandSize(command); err != nil {
+		if parts, ok := c.splitCommand(command); ok {
+			return c.execSplit(ctx, command, parts)
+		}
+
+		return "", &errs.NotSentError{Err: err}
+	}
+
+	if err := c.checkClosing(); err != nil {
+		return "", &errs.NotSentError{Err: err}
+	}
+
+	if err := c.waitIfPaused(ctx); err != nil {
+		return "", &errs.NotSentError{Err: err}
+	}
+
+	if c.Transport != nil {
+		res, err := c.execTransport(ctx, command)
+		if err != nil {
+			return "", err
+		}
+
+		return c.checkResponse(command, res)
+	}
+
+	if c.Protocol == ProtocolBattlEye {
+		res, err := c.execBattlEye(ctx, command)
+		if err != nil {
+			return "", err
+		}
+
+		return c.checkResponse(command, res)
+	}
+
+	var res packet.Packet
+	var err error
+
+	if c.ConnectionPerCommand {
+		res, err = c.execPerCommand(p)
+		if err != nil {
+			return "", err
+		}
+	} else if c.multiPacket() {
+		res, err = c.execMultiPacket(ctx, p)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if err := c.enqueuePacket(ctx, p, 1); err != nil {
+			return "", fmt.Errorf("could not enqueue command packet: %w", err)
+		}
+
+		res, err = c.getResponse(ctx, p.ID())
+		if err != nil {
+			return "", fmt.Errorf("could not get command response: %w", err)
+		}
 	}
 
 	// Trim off null terminator
 	body := res.Body()
 	body = body[:len(body)-1]
 
-	return string(body), nil
+	return c.checkResponse(command, string(body))
+}
+
+// answered returns true if err is an *errs.ServerCommandError, meaning the command was delivered and answered.
+func answered(err error) bool {
+	var serverErr *errs.ServerCommandError
+	return errors.As(err, &serverErr)
+}
+
+// checkResponse applies the configured ResponseFilter to response, then returns an *errs.ServerCommandError if the
+// configured ResponseErrorChecker identifies it as an error message.
+func (c *Client) checkResponse(command string, response string) (string, error) {
+	if c.ResponseFilter != nil {
+		response = c.ResponseFilter(command, response)
+	}
+
+	if c.ResponseErrorChecker != nil && c.ResponseErrorChecker(command, response) {
+		return "", &errs.ServerCommandError{
+			Command: command,
+			Message: response,
+		}
+	}
+
+	return response, nil
 }
 
+// ExecCommandNoResponse sends command without waiting for its response. The command passes through the middleware
+// added with Use, which is handed an empty response.
 func (c *Client) ExecCommandNoResponse(command string) error {
+	return c.ExecCommandNoResponseContext(context.Background(), command)
+}
+
+// ExecCommandNoResponseContext is like ExecCommandNoResponse, but returns once ctx is done instead of waiting for a
+// paused client, a full write queue or the response to be discarded. Commands already written may still be executed.
+func (c *Client) ExecCommandNoResponseContext(ctx context.Context, command string) error {
+	_, err := c.wrap(func(ctx context.Context, command string) (string, error) {
+		done := c.trackCommand(ctx, command, false)
+		err := c.execCommandNoResponse(ctx, command)
+		done(err)
+
+		return "", err
+	})(ctx, command)
+
+	return err
+}
+
+func (c *Client) execCommandNoResponse(ctx context.Context, command string) error {
 	p := c.newClientPacket(packet.TypeCommand, command)
 
 	c.log.Debug("Executing command (no response needed): ", command)
 
-	if err := c.enqueuePacket(p, true); err != nil {
-		return errors.Wrap(err, "could not enqueue command packet")
+	if err := c.checkCommandSize(command); err != nil {
+		return err
+	}
+
+	if err := c.checkClosing(); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &errs.NotSentError{Err: fmt.Errorf("command cancelled: %w", err)}
+	}
+
+	if err := c.waitIfPaused(ctx); err != nil {
+		return err
+	}
+
+	if c.Transport != nil {
+		_, err := c.execTransport(ctx, command)
+		if errors.Is(err, errs.ErrNotConnected) {
+			return err
+		}
+
+		return nil
+	}
+
+	if c.Protocol == ProtocolBattlEye {
+		// BattlEye acknowledges every command, so the response is read to keep the sequence number in use until then.
+		_, err := c.execBattlEye(ctx, command)
+		if errors.Is(err, errs.ErrNotConnected) {
+			return err
+		}
+
+		return nil
+	}
+
+	if c.ConnectionPerCommand {
+		// The response is read regardless since the server only closes the connection once it has answered.
+		_, err := c.execPerCommand(p)
+		if errors.Is(err, errs.ErrNotConnected) {
+			return err
+		}
+
+		return nil
+	}
+
+	if err := c.enqueuePacket(ctx, p, 1); err != nil {
+		return fmt.Errorf("could not enqueue command packet: %w", err)
 	}
 
 	// We still need to try to get the response or the connection will be put in a bad state.
 	// Since we're not actually expecting a response, we can just ignore it or any errors which occurred.
-	_, _ = c.getResponse(p.ID())
+	_, _ = c.getResponse(ctx, p.ID())
 
 	return nil
 }
 
-func (c *Client) enqueuePacket(p packet.Packet, createMailbox bool) error {
+// enqueuePacket puts p on the write queue. If mailboxSize is greater than zero, a mailbox able to hold that many
+// response packets is created for p.
+func (c *Client) enqueuePacket(ctx context.Context, p packet.Packet, mailboxSize int) error {
+	if mailboxSize > 0 {
+		// Create a mailbox for this packet before it is queued so that the response can never arrive before the
+		// mailbox exists. A mailbox is simply a channel which responses will be put on.
+		c.mailboxes.open(p.ID(), mailboxSize)
+	}
+
+	// SetRestrictedPacketIDs closes the mailboxes of IDs which became restricted after restricting them, so checking
+	// after opening the mailbox catches IDs which were issued before, but queued after, they became restricted.
+	if c.ids.IsRestricted(p.ID()) {
+		c.removeMailbox(p.ID())
+		return &errs.NotSentError{Err: fmt.Errorf("packet ID %d became restricted: %w", p.ID(), errs.ErrMailboxClosed)}
+	}
+
+	// The writer decrements pendingWrites once the packet was written, which lets Close flush the queue.
+	atomic.AddInt64(&c.pendingWrites, 1)
+	c.queued.add(c, p, isPriority(ctx))
+
+	if queued, err := c.tryEnqueue(queuedPacket{packet: p, priority: isPriority(ctx)}); queued {
+		c.log.Debug("Packet queued", " ID: ", p.ID())
+		return nil
+	} else if err != nil {
+		c.log.Debug("Packet queue full", " ID: ", p.ID())
+		atomic.AddInt64(&c.pendingWrites, -1)
+		c.queued.remove(p.ID())
+		c.removeMailbox(p.ID())
+		return err
+	}
+
 	// We use c.QueueWriteTimeout to set a timeout for packet queuing. If something happens and the packet cannot be put onto the
 	// queue within the set timeout, an error is returned.
 	select {
-	case c.writeQueue <- p:
+	case c.writeQueue <- queuedPacket{packet: p, priority: isPriority(ctx)}:
 		c.log.Debug("Packet queued", " ID: ", p.ID())
-
-		if createMailbox {
-			// Create a mailbox for this packet. A mailbox is simply a channel which responses will be put on.
-			c.readQueue[p.ID()] = make(chan packet.Packet)
-		}
-
 		return nil
 	case <-time.After(c.QueueWriteTimeout):
 		c.log.Debug("Packet queue timed out", " ID: ", p.ID())
-		return errors.Wrap(errs.ErrQueueTimeout, "packet queue operation timed out")
+		atomic.AddInt64(&c.pendingWrites, -1)
+		c.queued.remove(p.ID())
+		c.removeMailbox(p.ID())
+		return fmt.Errorf("packet queue operation timed out: %w", errs.ErrQueueTimeout)
+	case <-ctx.Done():
+		c.log.Debug("Packet queue cancelled", " ID: ", p.ID())
+		atomic.AddInt64(&c.pendingWrites, -1)
+		c.queued.remove(p.ID())
+		c.removeMailbox(p.ID())
+		return fmt.Errorf("packet queue operation cancelled: %w", ctx.Err())
 	}
 }
 
-func (c *Client) getResponse(packetID int32) (packet.Packet, error) {
-	defer func() {
-		// When read operation is complete, delete packet mailbox.
-		c.rqLock.Lock()
-		close(c.readQueue[packetID])
-		delete(c.readQueue, packetID)
-		c.rqLock.Unlock()
-	}()
+// deliver puts p into the mailbox with the matching ID. Mailboxes are buffered and only ever receive a single response,
+// so the send never blocks. Packets for which no mailbox exists, for example because the command was cancelled, are
+// dropped.
+func (c *Client) deliver(p packet.Packet) {
+	switch c.mailboxes.deliver(p) {
+	case delivered:
+		c.log.Debug("Packet added to mailbox ID: ", p.ID())
+	case noMailbox:
+		c.log.Debug("Packet ", p.ID(), " was unexpected (no open mailbox)")
+		c.unexpectedPacket(p, "was unexpected (no open mailbox)")
+	case mailboxFull:
+		c.log.Debug("Mailbox ", p.ID(), " already holds a response, dropping packet")
+		c.unexpectedPacket(p, "is a duplicate response")
+	case mailboxOverflow:
+		c.log.Error("Mailbox ", p.ID(), " is full, dropping response fragment")
+	case lateResponse:
+		c.log.Debug("Packet ", p.ID(), " is a late response to a cancelled command, dropping packet")
+		c.stats.lateResponse()
+		globalStats.lateResponse()
+	}
+}
+
+func (c *Client) removeMailbox(packetID int32) {
+	c.mailboxes.remove(packetID)
+}
+
+// abandonMailbox removes the mailbox of a command which stopped waiting for its response. Responses arriving within
+// LateResponseGrace are counted as late responses instead of being reported as unexpected.
+func (c *Client) abandonMailbox(packetID int32) {
+	c.mailboxes.abandon(packetID, time.Now().Add(c.LateResponseGrace))
+}
 
-	// We use c.QueueReadTimeout to set a timeout for response fetching. If something happens and no response can be pulled from
+func (c *Client) getResponse(ctx context.Context, packetID int32) (packet.Packet, error) {
+	// When read operation is complete, delete packet mailbox.
+	defer c.removeMailbox(packetID)
+
+	mailbox := c.mailboxes.get(packetID)
+	if mailbox == nil {
+		return nil, fmt.Errorf("no mailbox is open for the packet: %w", errs.ErrMailboxClosed)
+	}
+
+	// We use c.readTimeout() to set a timeout for response fetching. If something happens and no response can be pulled from
 	// the mailbox with the provided packet ID within the set timeout period, an error is returned.
+	start := time.Now()
+
 	select {
-	case p := <-c.readQueue[packetID]:
+	case p, ok := <-mailbox:
+		if !ok {
+			return nil, fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+		}
+
 		c.log.Debug("Packet removed from mailbox ID: ", packetID)
+		c.latencies.add(time.Since(start))
 		return p, nil
-	case <-time.After(c.QueueReadTimeout):
-		return nil, errors.Wrap(errs.ErrReadTimeout, "mailbox read operation timed out")
+	case <-time.After(c.readTimeout()):
+		c.abandonMailbox(packetID)
+		return nil, &errs.ResponseTimeoutError{PacketID: packetID, Timeout: c.readTimeout()}
+	case <-ctx.Done():
+		c.abandonMailbox(packetID)
+		return nil, fmt.Errorf("mailbox read operation cancelled: %w", ctx.Err())
 	}
 }
 
 // newClientPacket is a wrapper function for packet.NewClientPacket. It makes creating packets a bit easier by automatically
 // populating client-specific fields so that this doesn't need to be done manually.
 func (c *Client) newClientPacket(pType packet.PacketType, body string) packet.Packet {
-	return packet.NewClientPacket(c.EndianMode, pType, body, c.RestrictedPacketIDs)
+	return packet.NewPacketWithID(c.EndianMode, c.ids.Next(), pType, body)
 }
@@ -0,0 +1,13 @@
+module github.com/refractorgscm/rcon/cmd/fakeserver-mordhau
+
+go 1.16
+
+require (
+	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
+	github.com/onsi/gomega v1.16.0
+	github.com/refractorgscm/rcon v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+// The command is developed alongside the client in the same repository.
+replace github.com/refractorgscm/rcon => ../../
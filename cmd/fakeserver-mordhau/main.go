@@ -0,0 +1,46 @@
+// Command fakeserver-mordhau runs a fake Mordhau RCON server for developing admin tools without a game server:
+//
+//	fakeserver-mordhau -addr :7779 -P password -scenario scenario.yaml
+//
+// It answers playerlist, alive, listen, say, kick, ban and unban, and sends broadcasts on the Mordhau channel IDs. A
+// scenario file scripts further responses and periodic broadcasts; see demo.Scenario for its format.
+package main
+
+import (
+	"flag"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/shutdown"
+	"gopkg.in/yaml.v3"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7779", "address to listen on")
+	password := flag.String("P", "password", "rcon password")
+	scenario := flag.String("scenario", "", "path of a YAML scenario file")
+	flag.Parse()
+
+	var sc *demo.Scenario
+	if *scenario != "" {
+		var err error
+		if sc, err = demo.LoadScenario(*scenario, yaml.Unmarshal); err != nil {
+			shutdown.Exit(shutdown.ReasonConfig, err)
+		}
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	if err := demo.Run(demo.NewMordhauServer(*password), *addr, sc, stop); err != nil {
+		shutdown.Exit(shutdown.ReasonIO, err)
+	}
+
+	shutdown.Exit(shutdown.ReasonSignal, nil)
+}
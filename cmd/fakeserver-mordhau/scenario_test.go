@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScenario(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("YAML scenarios", func() {
+		g.It("Should decode every field", func() {
+			sc, err := demo.LoadScenario(filepath.Join("testdata", "scenario.yaml"), yaml.Unmarshal)
+			Expect(err).To(BeNil())
+
+			Expect(*sc).To(Equal(demo.Scenario{
+				Password:  "secret",
+				Responses: map[string]string{"playerlist": "There are currently no players present"},
+				Broadcasts: []demo.ScheduledBroadcast{{
+					ID:      demo.MordhauChatID,
+					Message: "Chat: 76561198000000001, Alice, (ALL) hi",
+					Every:   demo.Duration(time.Millisecond * 20),
+				}},
+				Users: []demo.ScenarioUser{{
+					User:          "moderator",
+					Password:      "modpass",
+					CommandPolicy: rcontest.CommandPolicy{Allow: []string{"playerlist", "kick", "ban"}, Deny: []string{"ban"}},
+				}},
+			}))
+		})
+
+		g.It("Should apply to the Mordhau server", func() {
+			sc, err := demo.LoadScenario(filepath.Join("testdata", "scenario.yaml"), yaml.Unmarshal)
+			Expect(err).To(BeNil())
+
+			server := demo.NewMordhauServer("password")
+			stop := make(chan struct{})
+			defer close(stop)
+			sc.Apply(server, stop)
+
+			broadcasts := make(chan string, 16)
+			client := rcon.NewClient(&rcon.Config{
+				StreamTransport:  server.Transport(),
+				Password:         "modpass",
+				QueueReadTimeout: time.Second,
+				BroadcastChecker: func(p packet.Packet) bool {
+					return p.ID() == demo.MordhauChatID
+				},
+				BroadcastHandler: func(message string) { broadcasts <- message },
+			}, nil)
+			defer client.Close()
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("playerlist")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("There are currently no players present"))
+
+			res, err = client.ExecCommand("ban 76561198000000002")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Permission denied: ban"))
+
+			Eventually(broadcasts).Should(Receive(Equal("Chat: 76561198000000001, Alice, (ALL) hi")))
+		})
+	})
+}
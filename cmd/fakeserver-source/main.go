@@ -0,0 +1,47 @@
+// Command fakeserver-source runs a fake Source engine RCON server for developing admin tools without a game server:
+//
+//	fakeserver-source -addr :27015 -P password -scenario scenario.yaml
+//
+// It answers status, echo, cvarlist and a few cvars, splits large responses across packets and sends the sentinel
+// trailer SRCDS sends. A scenario file scripts further responses and periodic broadcasts; see demo.Scenario for its
+// format.
+package main
+
+import (
+	"flag"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/shutdown"
+	"gopkg.in/yaml.v3"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:27015", "address to listen on")
+	password := flag.String("P", "password", "rcon password")
+	scenario := flag.String("scenario", "", "path of a YAML scenario file")
+	flag.Parse()
+
+	var sc *demo.Scenario
+	if *scenario != "" {
+		var err error
+		if sc, err = demo.LoadScenario(*scenario, yaml.Unmarshal); err != nil {
+			shutdown.Exit(shutdown.ReasonConfig, err)
+		}
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	if err := demo.Run(demo.NewSourceServer(*password), *addr, sc, stop); err != nil {
+		shutdown.Exit(shutdown.ReasonIO, err)
+	}
+
+	shutdown.Exit(shutdown.ReasonSignal, nil)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScenario(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("YAML scenarios", func() {
+		g.It("Should apply to the Source server", func() {
+			sc, err := demo.LoadScenario(filepath.Join("testdata", "scenario.yaml"), yaml.Unmarshal)
+			Expect(err).To(BeNil())
+			Expect(time.Duration(sc.Delay)).To(Equal(time.Millisecond * 10))
+
+			server := demo.NewSourceServer("password")
+			stop := make(chan struct{})
+			defer close(stop)
+			sc.Apply(server, stop)
+
+			client := rcon.NewClient(&rcon.Config{
+				StreamTransport:  server.Transport(),
+				Password:         "password",
+				QueueReadTimeout: time.Second,
+			}, nil)
+			defer client.Close()
+			Expect(client.Connect()).To(BeNil())
+
+			start := time.Now()
+			res, err := client.ExecCommand("sv_cheats")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(`"sv_cheats" = "1"`))
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*10))
+
+			_, err = client.ExecCommand("quit")
+			Expect(err).NotTo(BeNil())
+		})
+
+		g.It("Should still read JSON scenarios", func() {
+			path := filepath.Join(t.TempDir(), "scenario.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"failAuth": true, "delay": "1s"}`), 0o600)).To(BeNil())
+
+			sc, err := demo.LoadScenario(path, yaml.Unmarshal)
+			Expect(err).To(BeNil())
+			Expect(sc.FailAuth).To(BeTrue())
+			Expect(time.Duration(sc.Delay)).To(Equal(time.Second))
+		})
+	})
+}
@@ -0,0 +1,151 @@
+// Command rcdoctor prints the dialect based configuration equivalent to a client configured with the deprecated
+// EndianMode, RestrictedPacketIDs and BroadcastChecker config fields. Pass the values of the old fields as flags:
+//
+//	rcdoctor -restricted 54321,54322 -broadcast-checker presets.MordhauBroadcastChecker
+//
+// If the values match a game preset, the preset is suggested instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/presets"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes rcdoctor with the given arguments and returns its exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("rcdoctor", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	mode := flags.String("endian", "", "value of EndianMode (little or big)")
+	restricted := flags.String("restricted", "", "comma separated values of RestrictedPacketIDs")
+	checker := flags.String("broadcast-checker", "", "Go expression assigned to BroadcastChecker")
+	multiPacket := flags.Bool("multi-packet", false, "value of MultiPacketResponses")
+	perCommand := flags.Bool("connection-per-command", false, "value of ConnectionPerCommand")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ids, err := parseIDs(*restricted)
+	if err != nil {
+		fmt.Fprintln(stderr, "rcdoctor:", err)
+		return 2
+	}
+
+	if *mode != "" && *mode != "little" && *mode != "big" {
+		fmt.Fprintf(stderr, "rcdoctor: unknown endian mode %q\n", *mode)
+		return 2
+	}
+
+	f := features(*mode, ids, *multiPacket, *perCommand)
+
+	if *checker == "presets.MordhauBroadcastChecker" && reflect.DeepEqual(f, mordhauFeatures()) {
+		fmt.Fprintln(stdout, "// These settings match the Mordhau preset.")
+		fmt.Fprintln(stdout, "presets.MordhauGame(config)")
+		return 0
+	}
+
+	fmt.Fprint(stdout, dialect(f, *checker))
+
+	return 0
+}
+
+// features returns the features equivalent to the deprecated config fields, except for the BroadcastChecker, which
+// is only known as a Go expression.
+func features(mode string, ids []int32, multiPacket, perCommand bool) rcon.Features {
+	f := rcon.Features{
+		Broadcasts:           true,
+		MultiPacket:          multiPacket,
+		ConnectionPerCommand: perCommand,
+		RestrictedPacketIDs:  ids,
+	}
+
+	if mode == "big" {
+		f.EndianMode = endian.Big
+	}
+
+	return f
+}
+
+// mordhauFeatures returns the features the deprecated config fields of a Mordhau client translate to.
+func mordhauFeatures() rcon.Features {
+	return features("", presets.MordhauRestrictedPacketIDs, false, false)
+}
+
+func parseIDs(s string) ([]int32, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ids []int32
+	for _, field := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid restricted packet ID %q", field)
+		}
+
+		ids = append(ids, int32(id))
+	}
+
+	return ids, nil
+}
+
+// dialect renders a config using a custom dialect with the features f and the broadcast checker expression checker.
+func dialect(f rcon.Features, checker string) string {
+	// Fields are collected in order and aligned the way gofmt would align them.
+	fields := [][2]string{{"Broadcasts", strconv.FormatBool(f.Broadcasts)}}
+
+	if f.MultiPacket {
+		fields = append(fields, [2]string{"MultiPacket", "true"})
+	}
+
+	if f.ConnectionPerCommand {
+		fields = append(fields, [2]string{"ConnectionPerCommand", "true"})
+	}
+
+	if f.EndianMode == endian.Big {
+		fields = append(fields, [2]string{"EndianMode", "endian.Big"})
+	}
+
+	if len(f.RestrictedPacketIDs) > 0 {
+		values := make([]string, len(f.RestrictedPacketIDs))
+		for i, id := range f.RestrictedPacketIDs {
+			values[i] = strconv.Itoa(int(id))
+		}
+
+		fields = append(fields, [2]string{"RestrictedPacketIDs", "[]int32{" + strings.Join(values, ", ") + "}"})
+	}
+
+	if checker != "" {
+		fields = append(fields, [2]string{"BroadcastChecker", checker})
+	}
+
+	width := 0
+	for _, f := range fields {
+		if len(f[0]) > width {
+			width = len(f[0])
+		}
+	}
+
+	b := &strings.Builder{}
+
+	b.WriteString("config.Dialect = presets.NewDialect(\"custom\", rcon.Features{\n")
+	for _, f := range fields {
+		fmt.Fprintf(b, "\t%-*s %s,\n", width+1, f[0]+":", f[1])
+	}
+	b.WriteString("})\n")
+
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"go/format"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// noticeLogger is an rcon.Logger recording deprecation notices.
+type noticeLogger struct {
+	lock    sync.Mutex
+	notices []string
+}
+
+func (l *noticeLogger) Info(args ...interface{}) {
+	if message := fmt.Sprint(args...); strings.HasPrefix(message, "Deprecated config fields") {
+		l.lock.Lock()
+		l.notices = append(l.notices, message)
+		l.lock.Unlock()
+	}
+}
+
+func (l *noticeLogger) Error(...interface{}) {}
+func (l *noticeLogger) Debug(...interface{}) {}
+
+func (l *noticeLogger) Notices() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]string(nil), l.notices...)
+}
+
+func TestRcdoctor(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	rcdoctor := func(args ...string) (int, string, string) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		code := run(args, stdout, stderr)
+
+		return code, stdout.String(), stderr.String()
+	}
+
+	g.Describe("rcdoctor", func() {
+		g.It("Should suggest the Mordhau preset for its settings", func() {
+			code, out, _ := rcdoctor("-restricted", "54321,54322,54323,54324,54325,54326,54327,54328,54329,54330",
+				"-broadcast-checker", "presets.MordhauBroadcastChecker")
+
+			Expect(code).To(Equal(0))
+			Expect(out).To(Equal("// These settings match the Mordhau preset.\npresets.MordhauGame(config)\n"))
+		})
+
+		g.It("Should print a gofmt formatted dialect", func() {
+			code, out, _ := rcdoctor("-endian", "big", "-restricted", "7, 8", "-broadcast-checker", "myChecker",
+				"-multi-packet")
+
+			Expect(code).To(Equal(0))
+			Expect(out).To(Equal("config.Dialect = presets.NewDialect(\"custom\", rcon.Features{\n" +
+				"\tBroadcasts:          true,\n" +
+				"\tMultiPacket:         true,\n" +
+				"\tEndianMode:          endian.Big,\n" +
+				"\tRestrictedPacketIDs: []int32{7, 8},\n" +
+				"\tBroadcastChecker:    myChecker,\n" +
+				"})\n"))
+
+			source := "package p\n\nfunc f() {\n\t" + strings.ReplaceAll(strings.TrimSpace(out), "\n", "\n\t") + "\n}\n"
+			formatted, err := format.Source([]byte(source))
+			Expect(err).To(BeNil())
+			Expect(string(formatted)).To(Equal(source))
+		})
+
+		g.It("Should reject invalid flags", func() {
+			code, _, stderr := rcdoctor("-restricted", "1,x")
+			Expect(code).To(Equal(2))
+			Expect(stderr).To(Equal("rcdoctor: invalid restricted packet ID \"x\"\n"))
+
+			code, _, stderr = rcdoctor("-endian", "middle")
+			Expect(code).To(Equal(2))
+			Expect(stderr).To(Equal("rcdoctor: unknown endian mode \"middle\"\n"))
+
+			code, _, _ = rcdoctor("-no-such-flag")
+			Expect(code).To(Equal(2))
+		})
+
+		g.It("Should print a dialect which behaves like the deprecated fields against a server", func() {
+			ids := []int32{rcontest.BroadcastID, 7}
+
+			server := rcontest.StartServer(t, "password")
+			server.EndianMode = endian.Big
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			deprecated := &rcon.Config{
+				Host:                host,
+				Port:                port,
+				Password:            "password",
+				EndianMode:          endian.Big,
+				RestrictedPacketIDs: ids,
+				BroadcastChecker:    rcontest.BroadcastChecker,
+			}
+
+			f := features("big", ids, false, false)
+			f.BroadcastChecker = rcontest.BroadcastChecker
+
+			migrated := &rcon.Config{
+				Host:     host,
+				Port:     port,
+				Password: "password",
+				Dialect:  presets.NewDialect("custom", f),
+			}
+
+			tests := []struct {
+				config  *rcon.Config
+				notices int
+			}{
+				{config: deprecated, notices: 1},
+				{config: migrated, notices: 0},
+			}
+
+			for _, test := range tests {
+				config := test.config
+				logger := &noticeLogger{}
+				broadcasts := make(chan string, 1)
+				config.BroadcastHandler = func(message string) { broadcasts <- message }
+
+				client := rcon.NewClient(config, logger)
+				Expect(client.Connect()).To(BeNil())
+
+				res, err := client.ExecCommand("status")
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal("ok"))
+
+				server.Broadcast(rcontest.BroadcastID, "hello")
+				Eventually(broadcasts).Should(Receive(Equal("hello")))
+
+				Expect(client.Features().RestrictedPacketIDs).To(Equal(ids))
+				Expect(logger.Notices()).To(HaveLen(test.notices))
+
+				Expect(client.Close()).To(BeNil())
+			}
+		})
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const prompt = "> "
+
+// sessionCommands are the commands handled by the client itself, offered for completion after a dot.
+var sessionCommands = []string{".commands", ".debug", ".help", ".history", ".quit"}
+
+// console reads the lines of an interactive session and prints its output.
+type console interface {
+	// ReadLine prompts for and returns the next line. It returns io.EOF once the input ended.
+	ReadLine() (string, error)
+
+	// Printf prints output of the session.
+	Printf(format string, args ...interface{})
+
+	// Broadcast prints a broadcast, which may arrive while a line is being typed.
+	Broadcast(message string)
+
+	// Close restores the terminal.
+	Close() error
+}
+
+// newConsole returns a console supporting line editing, history and tab completion of known if stdin is a terminal.
+// Otherwise, for example if commands are piped in, lines are read as they are.
+func newConsole(known []string) (console, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return &plainConsole{scanner: bufio.NewScanner(os.Stdin)}, nil
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure terminal: %w", err)
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, prompt)
+
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		_ = t.SetSize(width, height)
+	}
+
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		newLine, newPos, matches := complete(known, line, pos)
+
+		// List the candidates if the line couldn't be completed any further.
+		if len(matches) > 1 && newLine == line {
+			fmt.Fprintln(t, strings.Join(matches, " "))
+		}
+
+		return newLine, newPos, true
+	}
+
+	return &termConsole{fd: fd, state: state, term: t}, nil
+}
+
+// complete completes the command name before pos in line from known, or from the session commands if it starts with a
+// dot. Matching ignores case. A single match is completed including a trailing space; several are completed up to
+// their longest common prefix. It returns the new line and cursor position and the sorted matches.
+func complete(known []string, line string, pos int) (string, int, []string) {
+	prefix := line[:pos]
+	if strings.Contains(prefix, " ") {
+		// Only command names are completed, not their arguments.
+		return line, pos, nil
+	}
+
+	candidates := known
+	if strings.HasPrefix(prefix, ".") {
+		candidates = sessionCommands
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if len(candidate) >= len(prefix) && strings.EqualFold(candidate[:len(prefix)], prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		return line, pos, nil
+	}
+
+	sort.Strings(matches)
+	rest := line[pos:]
+
+	if len(matches) == 1 {
+		word := matches[0]
+		if !strings.HasPrefix(rest, " ") {
+			word += " "
+		}
+
+		return word + rest, len(word), matches
+	}
+
+	common := matches[0]
+	for _, match := range matches[1:] {
+		n := 0
+		for n < len(common) && n < len(match) && strings.EqualFold(common[n:n+1], match[n:n+1]) {
+			n++
+		}
+		common = common[:n]
+	}
+
+	if len(common) <= len(prefix) {
+		return line, pos, matches
+	}
+
+	return common + rest, len(common), matches
+}
+
+// termConsole is a console reading from a terminal in raw mode.
+type termConsole struct {
+	fd    int
+	state *term.State
+	term  *term.Terminal
+}
+
+func (c *termConsole) ReadLine() (string, error) {
+	return c.term.ReadLine()
+}
+
+func (c *termConsole) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(c.term, format, args...)
+}
+
+func (c *termConsole) Broadcast(message string) {
+	// The terminal redraws the prompt and the line being typed below the broadcast.
+	fmt.Fprintf(c.term, "[broadcast] %s\n", message)
+}
+
+func (c *termConsole) Close() error {
+	return term.Restore(c.fd, c.state)
+}
+
+// plainConsole is a console reading lines from a pipe or file.
+type plainConsole struct {
+	scanner *bufio.Scanner
+}
+
+func (c *plainConsole) ReadLine() (string, error) {
+	fmt.Print(prompt)
+
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return "", err
+		}
+
+		return "", io.EOF
+	}
+
+	return c.scanner.Text(), nil
+}
+
+func (c *plainConsole) Printf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+func (c *plainConsole) Broadcast(message string) {
+	fmt.Printf("\r[broadcast] %s\n%s", message, prompt)
+}
+
+func (c *plainConsole) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	known := []string{"PlayerList", "Kick", "KillPlayer", "Say", "Ban"}
+
+	g.Describe("Tab completion", func() {
+		g.It("Should complete a unique command and add a space", func() {
+			line, pos, matches := complete(known, "pl", 2)
+			Expect(line).To(Equal("PlayerList "))
+			Expect(pos).To(Equal(11))
+			Expect(matches).To(Equal([]string{"PlayerList"}))
+		})
+
+		g.It("Should complete up to the common prefix of several commands", func() {
+			line, pos, matches := complete(known, "k", 1)
+			Expect(line).To(Equal("Ki"))
+			Expect(pos).To(Equal(2))
+			Expect(matches).To(Equal([]string{"Kick", "KillPlayer"}))
+		})
+
+		g.It("Should return the matches if the line can't be completed further", func() {
+			line, pos, matches := complete(known, "Ki", 2)
+			Expect(line).To(Equal("Ki"))
+			Expect(pos).To(Equal(2))
+			Expect(matches).To(Equal([]string{"Kick", "KillPlayer"}))
+		})
+
+		g.It("Should keep the rest of the line", func() {
+			line, pos, _ := complete(known, "Sa hello", 2)
+			Expect(line).To(Equal("Say hello"))
+			Expect(pos).To(Equal(3))
+		})
+
+		g.It("Should not complete arguments", func() {
+			line, pos, matches := complete(known, "Kick Pl", 7)
+			Expect(line).To(Equal("Kick Pl"))
+			Expect(pos).To(Equal(7))
+			Expect(matches).To(BeEmpty())
+		})
+
+		g.It("Should complete session commands after a dot", func() {
+			line, _, _ := complete(known, ".hi", 3)
+			Expect(line).To(Equal(".history "))
+
+			_, _, matches := complete(nil, ".", 1)
+			Expect(matches).To(Equal(sessionCommands))
+		})
+
+		g.It("Should leave unknown commands alone", func() {
+			line, pos, matches := complete(known, "xyz", 3)
+			Expect(line).To(Equal("xyz"))
+			Expect(pos).To(Equal(3))
+			Expect(matches).To(BeEmpty())
+		})
+	})
+}
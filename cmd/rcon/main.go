@@ -0,0 +1,200 @@
+// Command rcon is a command line RCON client. Given a command, it executes it and prints the response:
+//
+//	rcon -H 127.0.0.1 -p 7779 -P password "playerlist"
+//
+// Without a command, it starts an interactive session. Broadcasts received during the session are printed inline. In a
+// terminal, lines can be edited, earlier lines are recalled with the arrow keys and tab completes the known commands of
+// the game selected with -g. Lines starting with a dot are handled by the client itself:
+//
+//	.help             list the client's own commands
+//	.commands [pfx]   list the known commands of the game, optionally only those starting with pfx
+//	.history          list the commands executed in this session
+//	.debug            print a dump of the client's internals as JSON, for bug reports
+//	.quit             close the session
+//
+// A line of the form !n executes the nth command from the history again.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/shutdown"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs the client and returns the exit code. It is separate from main so that deferred calls, such as closing the
+// client, run before the process exits.
+func run() int {
+	host := flag.String("H", "127.0.0.1", "server host")
+	port := flag.Uint("p", 27015, "server port")
+	password := flag.String("P", "", "rcon password")
+	game := flag.String("g", "", "game preset (mordhau, source, minecraft)")
+	mode := flag.String("endian", "little", "endian mode (little or big)")
+	protocol := flag.String("protocol", "source", "protocol (source or battleye)")
+	useTLS := flag.Bool("tls", false, "connect using TLS")
+	timeout := flag.Duration("timeout", 5*time.Second, "connection timeout")
+	flag.Parse()
+
+	config, err := rcon.ParseURL(buildURL(*host, *port, *password, *game, *mode, *protocol, *useTLS, *timeout))
+	if err != nil {
+		return fail(shutdown.ConfigError(err))
+	}
+
+	if err := session(config, presets.KnownCommands[*game], flag.Args()); err != nil {
+		return fail(err)
+	}
+
+	return 0
+}
+
+// session executes command if given, or runs an interactive session otherwise. It returns once the client was closed
+// and the terminal restored, so that errors are printed on a usable terminal.
+func session(config *rcon.Config, known []string, command []string) error {
+	oneShot := len(command) > 0
+
+	var c console
+	if !oneShot {
+		var err error
+		if c, err = newConsole(known); err != nil {
+			return err
+		}
+		defer c.Close()
+
+		config.BroadcastHandler = func(message string) {
+			c.Broadcast(message)
+		}
+	}
+
+	client := rcon.NewClient(config, nil)
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if oneShot {
+		res, err := client.ExecCommand(strings.Join(command, " "))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(res)
+		return nil
+	}
+
+	repl(client, c, known)
+
+	return client.Err()
+}
+
+// buildURL builds the rcon URL for the given flags so that connecting behaves exactly like rcon.Dial.
+func buildURL(host string, port uint, password string, game string, mode string, protocol string, useTLS bool,
+	timeout time.Duration) string {
+	query := url.Values{}
+	query.Set("endian", mode)
+	query.Set("protocol", protocol)
+	query.Set("tls", strconv.FormatBool(useTLS))
+	query.Set("timeout", timeout.String())
+
+	if game != "" {
+		query.Set("game", game)
+	}
+
+	u := &url.URL{
+		Scheme:   "rcon",
+		User:     url.UserPassword("", password),
+		Host:     net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)),
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+func repl(client *rcon.Client, c console, known []string) {
+	var history []string
+
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				c.Printf("error: %v\n", err)
+			}
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "!") {
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(history) {
+				c.Printf("no such history entry\n")
+				continue
+			}
+
+			line = history[n-1]
+			c.Printf("%s\n", line)
+		}
+
+		switch {
+		case line == "":
+		case line == ".quit":
+			return
+		case line == ".help":
+			c.Printf(".help, .commands [prefix], .history, .debug, .quit, !n\n")
+		case line == ".debug":
+			out, _ := json.MarshalIndent(client.DebugDump(), "", "  ")
+			c.Printf("%s\n", out)
+		case line == ".history":
+			for i, command := range history {
+				c.Printf("%4d  %s\n", i+1, command)
+			}
+		case strings.HasPrefix(line, ".commands"):
+			printCommands(c, known, strings.TrimSpace(strings.TrimPrefix(line, ".commands")))
+		default:
+			history = append(history, line)
+
+			res, err := client.ExecCommand(line)
+			if err != nil {
+				c.Printf("error: %v\n", err)
+			} else {
+				c.Printf("%s\n", res)
+			}
+		}
+	}
+}
+
+func printCommands(c console, known []string, prefix string) {
+	if len(known) == 0 {
+		c.Printf("no known commands, use -g to select a game\n")
+		return
+	}
+
+	var matches []string
+	for _, command := range known {
+		if strings.HasPrefix(command, prefix) {
+			matches = append(matches, command)
+		}
+	}
+
+	sort.Strings(matches)
+	c.Printf("%s\n", strings.Join(matches, " "))
+}
+
+// fail prints err and returns the exit code of its shutdown reason, so that scripts can tell configuration errors,
+// rejected passwords and connection failures apart.
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "rcon:", err)
+	return shutdown.Classify(err).ExitCode()
+}
@@ -0,0 +1,110 @@
+package rcon_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"hash/crc32"
+	"io"
+	"testing"
+	"time"
+)
+
+// checksumCodec frames packets like the Source protocol, followed by a CRC32 checksum of the frame.
+type checksumCodec struct {
+	source *packet.SourceCodec
+}
+
+func (c checksumCodec) Encode(p packet.Packet) ([]byte, error) {
+	raw, err := c.source.Encode(p)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sum, crc32.ChecksumIEEE(raw))
+
+	return append(raw, sum...), nil
+}
+
+func (c checksumCodec) Decode(r io.Reader) (packet.Packet, error) {
+	raw := &bytes.Buffer{}
+	p, err := c.source.Decode(io.TeeReader(r, raw))
+	if err != nil {
+		return p, err
+	}
+
+	sum := make([]byte, 4)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint32(sum) != crc32.ChecksumIEEE(raw.Bytes()) {
+		return nil, &errs.MalformedPacketError{Reason: "checksum mismatch"}
+	}
+
+	return p, nil
+}
+
+func TestCodec(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	codec := checksumCodec{source: packet.NewSourceCodec(endian.Little)}
+
+	g.Describe("Codec", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Millisecond * 200,
+				ConnTimeout:      time.Millisecond * 200,
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should frame packets with a custom codec", func() {
+			var wire []byte
+			config.Codec = codec
+			config.PacketHooks.OnReceive = func(_ packet.Packet, raw []byte) { wire = raw }
+			client = rcon.NewClient(config, nil)
+
+			server.SetCodec(codec)
+			server.Handle("echo", func(args string) string { return args })
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hello"))
+			Expect(wire).To(HaveLen(4 + 4 + 4 + len("hello") + 2 + 4))
+		})
+
+		g.It("Should fail to authenticate when the server frames packets differently", func() {
+			client = rcon.NewClient(config, nil)
+			server.SetCodec(codec)
+
+			Expect(client.Connect()).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,10 @@
+package rcon
+
+// Commander is the command execution interface shared by Client and anything which proxies commands to one, such as
+// the sidecar client.
+type Commander interface {
+	ExecCommand(command string) (string, error)
+	ExecCommandNoResponse(command string) error
+}
+
+var _ Commander = (*Client)(nil)
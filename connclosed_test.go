@@ -0,0 +1,139 @@
+package rcon
+
+import (
+	"bufio"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnClosed(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ConnClosedError", func() {
+		var listener net.Listener
+		var accepted chan net.Conn
+
+		g.BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+
+			accepted = make(chan net.Conn, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err == nil {
+					accepted <- conn
+				}
+			}()
+		})
+
+		g.AfterEach(func() {
+			_ = listener.Close()
+		})
+
+		// connected returns a client whose connection to the listener is set up without starting its routines, so that
+		// the test is the only reader.
+		connected := func() (*Client, net.Conn) {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).To(BeNil())
+
+			c := NewClient(&Config{ConnTimeout: time.Second * 2}, nil)
+			c.connStateLock.Lock()
+			c.conn = conn
+			c.reader = bufio.NewReader(conn)
+			c.connStateLock.Unlock()
+
+			return c, conn
+		}
+
+		// blockedRead starts reading a packet which never arrives and returns the channel its error is sent on.
+		blockedRead := func(c *Client) chan error {
+			results := make(chan error, 1)
+			go func() {
+				_, err := c.readPacketTimeout()
+				results <- err
+			}()
+
+			Consistently(results, time.Millisecond*50).ShouldNot(Receive())
+
+			return results
+		}
+
+		g.It("Should be returned by reads blocked while the client closes the connection", func() {
+			c, _ := connected()
+			read := blockedRead(c)
+
+			c.closeConn()
+
+			var err error
+			Eventually(read).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrConnClosed)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+		})
+
+		g.It("Should be returned for reads on a closed connection which is still current", func() {
+			c, conn := connected()
+			read := blockedRead(c)
+
+			_ = conn.Close()
+
+			var err error
+			Eventually(read).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrConnClosed)).To(BeTrue())
+		})
+
+		g.It("Should be reported when the server closes the connection and unwrap to io.EOF", func() {
+			addr := listener.Addr().(*net.TCPAddr)
+
+			disconnects := make(chan error, 1)
+			c := NewClient(&Config{
+				Host:     addr.IP.String(),
+				Port:     uint16(addr.Port),
+				Password: "password",
+				DisconnectHandler: func(err error, expected bool) {
+					if !expected {
+						disconnects <- err
+					}
+				},
+			}, nil)
+			defer c.Close()
+
+			// Authenticate the client, then hang up.
+			go func() {
+				conn := <-accepted
+				codec := packet.NewSourceCodec(endian.Little)
+
+				auth, err := codec.Decode(conn)
+				if err != nil {
+					return
+				}
+
+				res, _ := codec.Encode(packet.NewPacketWithID(endian.Little, auth.ID(), packet.TypeAuthRes, ""))
+				_, _ = conn.Write(res)
+				_ = conn.Close()
+			}()
+
+			Expect(c.Connect()).To(BeNil())
+
+			var disconnectErr error
+			Eventually(disconnects).Should(Receive(&disconnectErr))
+			Expect(errors.Is(disconnectErr, errs.ErrConnClosed)).To(BeTrue())
+			Expect(errors.Is(disconnectErr, io.EOF)).To(BeTrue())
+
+			var closed *errs.ConnClosedError
+			Expect(errors.As(disconnectErr, &closed)).To(BeTrue())
+			Expect(errors.Is(closed.Err, io.EOF)).To(BeTrue())
+		})
+	})
+}
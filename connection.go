@@ -2,87 +2,234 @@ package rcon
 
 import (
 	"bufio"
-	"github.com/pkg/errors"
+	"bytes"
+	"errors"
+	"fmt"
 	"github.com/refractorgscm/rcon/errs"
 	"github.com/refractorgscm/rcon/packet"
-	"strings"
+	"io"
+	"net"
+	"sync/atomic"
 	"time"
 )
 
 func (c *Client) sendPacket(p packet.Packet) error {
-	out, err := p.Build()
+	wire, err := c.encodeBody(p)
 	if err != nil {
-		return errors.Wrap(err, "could not build packet")
+		return &errs.NotSentError{Err: err}
+	}
+
+	out, err := c.codec().Encode(wire)
+	if err != nil {
+		return &errs.NotSentError{Err: fmt.Errorf("could not build packet: %w", err)}
 	}
 
 	if err := c.write(out); err != nil {
-		return errors.Wrap(err, "could not send authentication packet")
+		return fmt.Errorf("could not write packet: %w", err)
 	}
 
+	c.teeOutbound(p)
+	c.hookSend(p, out)
+	c.traceWire(p, out, TeeOutbound)
+
 	return nil
 }
 
 func (c *Client) readPacket() (packet.Packet, error) {
-	if c.conn == nil {
+	conn, reader := c.connection()
+	if conn == nil {
 		return nil, errs.ErrNotConnected
 	}
 
-	if err := c.conn.SetDeadline(time.Time{}); err != nil {
-		if strings.HasSuffix(err.Error(), "use of closed network connection") {
-			return nil, errs.ErrNotConnected
+	// While draining, reads must end once the grace period passes.
+	if err := conn.SetDeadline(c.drainDeadline()); err != nil {
+		if c.closedLocally(conn, err) {
+			return nil, &errs.ConnClosedError{Err: errs.ErrNotConnected}
 		}
 
-		return nil, errors.Wrap(err, "could not set connection deadline")
+		return nil, fmt.Errorf("could not set connection deadline: %w", err)
 	}
 
-	reader := bufio.NewReader(c.conn)
-
-	res, err := packet.DecodeClientPacket(c.EndianMode, reader)
+	res, err := c.decodePacket(reader)
 	if err != nil {
-		if strings.HasSuffix(err.Error(), "use of closed network connection") {
-			return nil, errs.ErrNotConnected
+		if c.closedLocally(conn, err) {
+			return nil, &errs.ConnClosedError{Err: errs.ErrNotConnected}
 		}
 
-		return nil, errors.Wrap(err, "could not read packet")
+		return nil, fmt.Errorf("could not read packet: %w", err)
 	}
 
 	c.log.Debug("Read packet ID: ", res.ID(), ", Body: ", string(res.Body()))
 
-	return res, nil
+	c.teeInbound(res)
+
+	return c.trimNewlines(res), nil
+}
+
+// closedLocally reports whether an operation on conn failed with err because the client closed conn, as it does when
+// closing or reconnecting. The client replaces its current connection before closing it, so this doesn't depend on the
+// error's wording.
+func (c *Client) closedLocally(conn Conn, err error) bool {
+	current, _ := c.connection()
+	return current != conn || errors.Is(err, net.ErrClosed)
 }
 
 func (c *Client) readPacketTimeout() (packet.Packet, error) {
-	if c.conn == nil {
+	return c.readPacketDeadline(time.Now().Add(c.ConnTimeout))
+}
+
+// readPacketDeadline reads the next packet, failing if it hasn't arrived by deadline.
+func (c *Client) readPacketDeadline(deadline time.Time) (packet.Packet, error) {
+	conn, reader := c.connection()
+	if conn == nil {
 		return nil, errs.ErrNotConnected
 	}
 
-	if err := c.conn.SetDeadline(time.Now().Add(c.ConnTimeout)); err != nil {
-		if strings.HasSuffix(err.Error(), "use of closed network connection") {
-			return nil, errs.ErrNotConnected
+	if err := conn.SetDeadline(deadline); err != nil {
+		if c.closedLocally(conn, err) {
+			return nil, &errs.ConnClosedError{Err: errs.ErrNotConnected}
 		}
 
-		return nil, errors.Wrap(err, "could not set connection deadline")
+		return nil, fmt.Errorf("could not set connection deadline: %w", err)
 	}
 
-	reader := bufio.NewReader(c.conn)
+	res, err := c.decodePacket(reader)
+	if err != nil {
+		if c.closedLocally(conn, err) {
+			return nil, &errs.ConnClosedError{Err: errs.ErrNotConnected}
+		}
+
+		return nil, fmt.Errorf("could not read packet: %w", err)
+	}
 
-	res, err := packet.DecodeClientPacket(c.EndianMode, reader)
+	c.teeInbound(res)
+
+	return c.trimNewlines(res), nil
+}
+
+// codec returns the codec of the current connection, which is built by newCodec when the connection is set up. Without
+// a connection, a new codec is built.
+func (c *Client) codec() packet.Codec {
+	c.connStateLock.Lock()
+	codec := c.wireCodec
+	c.connStateLock.Unlock()
+
+	if codec != nil {
+		return codec
+	}
+
+	return c.newCodec()
+}
+
+// newCodec returns the configured Codec, or the Source codec matching the client's configuration. A configured
+// *packet.SourceCodec is copied so that it enforces MaxPacketSize.
+func (c *Client) newCodec() packet.Codec {
+	if source, ok := c.Codec.(*packet.SourceCodec); ok && source.MaxPacketSize != c.MaxPacketSize {
+		limited := *source
+		limited.MaxPacketSize = c.MaxPacketSize
+
+		return &limited
+	}
+
+	if c.Codec != nil {
+		return c.Codec
+	}
+
+	return &packet.SourceCodec{
+		Mode:              c.EndianMode,
+		BodyPreallocation: c.BodyPreallocation,
+		MaxPacketSize:     c.MaxPacketSize,
+		Strict:            c.Strict,
+	}
+}
+
+// sourceFraming returns true if packets are framed as in the Source protocol, so that their headers can be inspected
+// without the codec.
+func (c *Client) sourceFraming() bool {
+	_, ok := c.codec().(*packet.SourceCodec)
+	return ok
+}
+
+// decodePacket decodes the next packet from reader using the codec. In Strict mode, protocol violations are returned
+// as errors.
+func (c *Client) decodePacket(reader *bufio.Reader) (packet.Packet, error) {
+	codec := c.codec()
+
+	if _, ok := codec.(*packet.SourceCodec); ok {
+		if err := c.skipDroppedPackets(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	var raw *bytes.Buffer
+	var input io.Reader = reader
+	if c.PacketHooks.OnReceive != nil || c.TraceWire {
+		raw = &bytes.Buffer{}
+		input = io.TeeReader(reader, raw)
+	}
+
+	res, err := codec.Decode(input)
 	if err != nil {
-		if strings.HasSuffix(err.Error(), "use of closed network connection") {
-			return nil, errs.ErrNotConnected
+		if res == nil || !errors.Is(err, packet.ErrProtocolViolation) || isKnownType(res.Type()) {
+			return nil, err
 		}
 
-		return nil, errors.Wrap(err, "could not read packet")
+		// Custom types with a registered handler aren't violations, so they are routed to it.
+		if c.packetHandler(res.Type()) == nil {
+			return nil, err
+		}
+	}
+
+	if res, err = c.decodeBody(res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Body())-1 > c.BodyPreallocation {
+		atomic.AddUint64(&c.oversizePackets, 1)
+		c.log.Debug("Received oversize packet ID: ", res.ID(), ", Size: ", res.Size())
+	}
+
+	if raw != nil {
+		c.traceWire(res, raw.Bytes(), TeeInbound)
+
+		if c.PacketHooks.OnReceive != nil {
+			c.PacketHooks.OnReceive(res, raw.Bytes())
+		}
 	}
 
 	return res, nil
 }
 
+// trimNewlines strips leading and trailing newlines from the body of p. Many games terminate their responses with
+// newlines which are not meaningful to callers.
+func (c *Client) trimNewlines(p packet.Packet) packet.Packet {
+	// Fragments of multi-packet responses are trimmed once assembled.
+	if c.multiPacket() {
+		return p
+	}
+
+	body := p.Body()
+	body = body[:len(body)-1] // strip null terminator
+
+	trimmed := bytes.Trim(body, "\n")
+	if len(trimmed) == len(body) {
+		return p
+	}
+
+	return packet.NewPacketWithID(c.EndianMode, p.ID(), p.Type(), string(trimmed))
+}
+
 func (c *Client) write(data []byte) error {
 	c.connLock.Lock()
 	defer c.connLock.Unlock()
 
-	if _, err := c.conn.Write(data); err != nil {
+	conn, _ := c.connection()
+	if conn == nil {
+		return errs.ErrNotConnected
+	}
+
+	if _, err := conn.Write(data); err != nil {
+		c.metrics().WriteError()
 		return err
 	}
 
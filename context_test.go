@@ -0,0 +1,113 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/errs"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecCommandContext(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ExecCommandContext()", func() {
+		var server *demo.Server
+		var client *rcon.Client
+		var executed int32
+
+		connect := func(timeout time.Duration) {
+			host, port, err := server.Start()
+			Expect(err).To(BeNil())
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: timeout,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		}
+
+		g.BeforeEach(func() {
+			atomic.StoreInt32(&executed, 0)
+
+			server = demo.NewServer("password")
+			server.Handle("slow", func(string) string {
+				atomic.AddInt32(&executed, 1)
+				time.Sleep(time.Millisecond * 300)
+				return "done"
+			})
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should not send commands whose context is already cancelled", func() {
+			connect(time.Second * 2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.ExecCommandContext(ctx, "slow")
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Consistently(func() int32 { return atomic.LoadInt32(&executed) }, time.Millisecond*100).Should(BeZero())
+		})
+
+		g.It("Should return once the deadline passes while waiting for the response", func() {
+			connect(time.Second * 2)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.ExecCommandContext(ctx, "slow")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*250))
+
+			// The response arriving after the deadline is dropped rather than delivered to the next command.
+			res, err := client.ExecCommand("echo next")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("next"))
+		})
+
+		g.It("Should bound commands executed without a response", func() {
+			connect(time.Second * 2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := client.ExecCommandNoResponseContext(ctx, "slow")
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrNotSent)).To(BeTrue())
+
+			ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+
+			start := time.Now()
+			Expect(client.ExecCommandNoResponseContext(ctx, "slow")).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*250))
+			Eventually(func() int32 { return atomic.LoadInt32(&executed) }).Should(Equal(int32(1)))
+		})
+
+		g.It("Should still apply QueueReadTimeout as an upper bound", func() {
+			connect(time.Millisecond * 100)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+
+			_, err := client.ExecCommandContext(ctx, "slow")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+		})
+	})
+}
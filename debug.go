@@ -0,0 +1,263 @@
+package rcon
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/refractorgscm/rcon/packet"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentErrorsSize is the number of recently logged errors kept for DebugDump.
+const recentErrorsSize = 16
+
+// redactedPassword replaces the password wherever it appears in a DebugState.
+const redactedPassword = "[redacted]"
+
+// DebugState is a snapshot of a client's internals, returned by DebugDump. It is meant to be attached to bug reports,
+// for example about commands which randomly time out, and its layout may change between versions. The password is
+// replaced by "[redacted]" wherever it appears, such as in an error message echoing a command.
+type DebugState struct {
+	Time      time.Time
+	State     string
+	Endpoint  string
+	Paused    bool
+	LastError string
+
+	// RecentErrors are the most recently logged errors, oldest first.
+	RecentErrors []DebugError
+
+	// Queue are the packets waiting in the write queue, in no particular order. Command bodies are passed through the
+	// CommandRedactor, and authentication packet bodies are removed.
+	Queue         []DebugPacket
+	PendingWrites int64
+
+	// InFlight are the mailboxes of commands waiting for their response, ordered by ID.
+	InFlight []DebugMailbox
+
+	// Abandoned are the IDs of commands which stopped waiting for their response, mapped to the time until which a
+	// late response is expected.
+	Abandoned map[int32]time.Time
+
+	Timers DebugTimers
+
+	DegradedCapabilities []Capability
+	Stats                Stats
+	Bandwidth            BandwidthUsage
+	OversizePackets      uint64
+
+	// Goroutines are the stacks of all goroutines in the process, with identical stacks grouped.
+	Goroutines string
+}
+
+// DebugError is an error logged by the client.
+type DebugError struct {
+	Time    time.Time
+	Message string
+}
+
+// DebugPacket is a packet waiting in the write queue.
+type DebugPacket struct {
+	ID       int32
+	Type     packet.PacketType
+	Body     string
+	Priority bool
+	QueuedAt time.Time
+}
+
+// DebugMailbox is the mailbox of a command waiting for its response.
+type DebugMailbox struct {
+	ID       int32
+	OpenedAt time.Time
+
+	// Buffered is the number of responses delivered but not yet collected.
+	Buffered int
+}
+
+// DebugTimers are the effective timeouts and intervals of a client.
+type DebugTimers struct {
+	ReadTimeout       time.Duration
+	QueueWriteTimeout time.Duration
+	KeepAliveInterval time.Duration
+	MailboxTTL        time.Duration
+	LateResponseGrace time.Duration
+
+	// DrainUntil is the end of the drain grace period if the client is closing, or zero.
+	DrainUntil time.Time
+}
+
+// DebugDump returns a snapshot of the client's internals for troubleshooting.
+func (c *Client) DebugDump() DebugState {
+	state := DebugState{
+		Time:                 time.Now(),
+		State:                c.Status().String(),
+		Endpoint:             c.Endpoint(),
+		Paused:               c.Paused(),
+		RecentErrors:         c.recentErrors.list(),
+		Queue:                c.queued.list(),
+		PendingWrites:        atomic.LoadInt64(&c.pendingWrites),
+		DegradedCapabilities: c.DegradedCapabilities(),
+		Stats:                c.Stats(),
+		Bandwidth:            c.BandwidthUsage(),
+		OversizePackets:      c.OversizePackets(),
+		Timers: DebugTimers{
+			ReadTimeout:       c.readTimeout(),
+			QueueWriteTimeout: c.QueueWriteTimeout,
+			KeepAliveInterval: c.KeepAlive.Interval,
+			MailboxTTL:        c.MailboxTTL,
+			LateResponseGrace: c.LateResponseGrace,
+			DrainUntil:        c.drainDeadline(),
+		},
+	}
+
+	if err := c.Err(); err != nil {
+		state.LastError = err.Error()
+	}
+
+	state.InFlight, state.Abandoned = c.mailboxes.snapshot()
+
+	if c.Password != "" {
+		redact := func(s string) string {
+			return strings.ReplaceAll(s, c.Password, redactedPassword)
+		}
+
+		state.LastError = redact(state.LastError)
+		for i := range state.RecentErrors {
+			state.RecentErrors[i].Message = redact(state.RecentErrors[i].Message)
+		}
+		for i := range state.Queue {
+			state.Queue[i].Body = redact(state.Queue[i].Body)
+		}
+	}
+
+	stacks := &bytes.Buffer{}
+	if profile := pprof.Lookup("goroutine"); profile != nil {
+		_ = profile.WriteTo(stacks, 1)
+	}
+	state.Goroutines = stacks.String()
+
+	return state
+}
+
+// queuedPackets tracks the packets in the write queue, which can't be inspected without dequeuing them.
+type queuedPackets struct {
+	lock    sync.Mutex
+	packets map[int32]DebugPacket
+}
+
+func (q *queuedPackets) add(c *Client, p packet.Packet, priority bool) {
+	body := ""
+	if p.Type() != packet.TypeAuth {
+		b := p.Body()
+		body = c.redact(string(b[:len(b)-1]))
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.packets == nil {
+		q.packets = map[int32]DebugPacket{}
+	}
+
+	q.packets[p.ID()] = DebugPacket{
+		ID:       p.ID(),
+		Type:     p.Type(),
+		Body:     body,
+		Priority: priority,
+		QueuedAt: time.Now(),
+	}
+}
+
+func (q *queuedPackets) remove(id int32) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	delete(q.packets, id)
+}
+
+func (q *queuedPackets) list() []DebugPacket {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	list := make([]DebugPacket, 0, len(q.packets))
+	for _, p := range q.packets {
+		list = append(list, p)
+	}
+
+	return list
+}
+
+// recentErrors is a ring buffer of recently logged errors.
+type recentErrors struct {
+	lock   sync.Mutex
+	errors []DebugError
+	next   int
+}
+
+func (r *recentErrors) add(e DebugError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.errors) < recentErrorsSize {
+		r.errors = append(r.errors, e)
+		return
+	}
+
+	r.errors[r.next] = e
+	r.next = (r.next + 1) % recentErrorsSize
+}
+
+func (r *recentErrors) list() []DebugError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	list := append(append([]DebugError{}, r.errors[r.next:]...), r.errors[:r.next]...)
+
+	return list
+}
+
+// errorRecorder wraps a Logger and records every error it logs.
+type errorRecorder struct {
+	Logger
+	recent *recentErrors
+}
+
+func (l *errorRecorder) Error(args ...interface{}) {
+	l.recent.add(DebugError{Time: time.Now(), Message: fmt.Sprint(args...)})
+	l.Logger.Error(args...)
+}
+
+func (l *errorRecorder) Trace(args ...interface{}) { traceLog(l.Logger, args...) }
+
+// snapshot returns the open mailboxes ordered by ID and the abandoned IDs which are still expected to be answered.
+func (m *mailboxes) snapshot() ([]DebugMailbox, map[int32]time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	open := make([]DebugMailbox, 0, len(m.boxes))
+	for id, mailbox := range m.boxes {
+		open = append(open, DebugMailbox{
+			ID:       id,
+			OpenedAt: m.opened[id],
+			Buffered: len(mailbox),
+		})
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].ID < open[j].ID
+	})
+
+	now := time.Now()
+	abandoned := map[int32]time.Time{}
+	for id, until := range m.abandoned {
+		if now.Before(until) {
+			abandoned[id] = until
+		}
+	}
+
+	return open, abandoned
+}
@@ -0,0 +1,36 @@
+package rcon
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"testing"
+)
+
+func TestDebugDump(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("DebugDump()", func() {
+		g.It("Should redact the password", func() {
+			client := NewClient(&Config{Password: "hunter2"}, nil)
+
+			client.log.Error("Server echoed: unknown command hunter2")
+			client.queued.add(client, packet.NewPacketWithID(endian.Little, 1, packet.TypeCommand, "login hunter2"), false)
+			client.queued.add(client, packet.NewPacketWithID(endian.Little, 2, packet.TypeAuth, "hunter2"), false)
+
+			state := client.DebugDump()
+
+			Expect(state.RecentErrors).To(HaveLen(1))
+			Expect(state.RecentErrors[0].Message).To(Equal("Server echoed: unknown command [redacted]"))
+			bodies := map[int32]string{}
+			for _, p := range state.Queue {
+				bodies[p.ID] = p.Body
+			}
+			Expect(bodies).To(Equal(map[int32]string{1: "login [redacted]", 2: ""}))
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package rcon
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// broadcastDedup remembers hashes of recent broadcasts per cluster to drop duplicates received by sibling clients.
+type broadcastDedup struct {
+	lock     sync.Mutex
+	clusters map[string]map[uint64]*dedupEntry
+}
+
+// dedupEntry counts the recent occurrences of a broadcast within a cluster. The nth occurrence received by a client is
+// a duplicate if the cluster already passed on n occurrences, so repeated messages received by the same client, such as
+// a player saying "gg" twice, are not dropped.
+type dedupEntry struct {
+	at        time.Time
+	delivered int
+	received  map[string]int
+}
+
+func newBroadcastDedup() *broadcastDedup {
+	return &broadcastDedup{
+		clusters: map[string]map[uint64]*dedupEntry{},
+	}
+}
+
+// seen reports whether message, received by the client identified by source, was already received by another client
+// in cluster within window, and records it otherwise. Entries not updated within window are forgotten.
+func (d *broadcastDedup) seen(cluster string, source string, message string, window time.Duration) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(message))
+	sum := h.Sum64()
+
+	now := time.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	recent, ok := d.clusters[cluster]
+	if !ok {
+		recent = map[uint64]*dedupEntry{}
+		d.clusters[cluster] = recent
+	}
+
+	for hash, e := range recent {
+		if now.Sub(e.at) > window {
+			delete(recent, hash)
+		}
+	}
+
+	e, ok := recent[sum]
+	if !ok {
+		e = &dedupEntry{received: map[string]int{}}
+		recent[sum] = e
+	}
+
+	e.at = now
+	e.received[source]++
+
+	if e.received[source] <= e.delivered {
+		return true
+	}
+
+	e.delivered++
+
+	return false
+}
@@ -0,0 +1,45 @@
+package rcon
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Broadcast dedup", func() {
+		var dedup *broadcastDedup
+
+		g.BeforeEach(func() {
+			dedup = newBroadcastDedup()
+		})
+
+		g.It("Should collapse duplicates received by sibling clients", func() {
+			Expect(dedup.seen("cluster", "a", "gg", time.Minute)).To(BeFalse())
+			Expect(dedup.seen("cluster", "b", "gg", time.Minute)).To(BeTrue())
+			Expect(dedup.seen("other", "c", "gg", time.Minute)).To(BeFalse())
+		})
+
+		g.It("Should keep messages repeated on the server", func() {
+			Expect(dedup.seen("cluster", "a", "gg", time.Minute)).To(BeFalse())
+			Expect(dedup.seen("cluster", "a", "gg", time.Minute)).To(BeFalse())
+
+			// The sibling receives both messages as well, later.
+			Expect(dedup.seen("cluster", "b", "gg", time.Minute)).To(BeTrue())
+			Expect(dedup.seen("cluster", "b", "gg", time.Minute)).To(BeTrue())
+			Expect(dedup.seen("cluster", "b", "gg", time.Minute)).To(BeFalse())
+		})
+
+		g.It("Should forget broadcasts outside the window", func() {
+			Expect(dedup.seen("cluster", "a", "gg", time.Millisecond*10)).To(BeFalse())
+			time.Sleep(time.Millisecond * 20)
+			Expect(dedup.seen("cluster", "b", "gg", time.Millisecond*10)).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,111 @@
+package demo
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"sync"
+)
+
+// Mordhau broadcast packet IDs, matching presets.MordhauRestrictedPacketIDs.
+const (
+	MordhauMatchStateID = 54321
+	MordhauChatID       = 54325
+	MordhauLoginID      = 54326
+)
+
+// NewMordhauServer creates a server emulating a Mordhau server: it answers playerlist, alive, listen, say, kick, ban
+// and unban like Mordhau does, and greets listeners on the chat and login channels with a broadcast.
+func NewMordhauServer(password string) *rcontest.Server {
+	s := rcontest.NewServer(password)
+
+	players := []string{
+		"76561198000000001, Alice, 34 ms, team 0",
+		"76561198000000002, Bob, 51 ms, team 1",
+	}
+
+	s.Handle("playerlist", func(string) string { return strings.Join(players, "\n") })
+	s.Handle("alive", func(string) string { return "Alive" })
+	s.Handle("say", func(string) string { return "Message sent" })
+	s.Handle("kick", func(args string) string { return "Kicked player " + firstField(args) })
+	s.Handle("ban", func(args string) string { return "Banned player " + firstField(args) })
+	s.Handle("unban", func(args string) string { return "Unbanned player " + args })
+	s.Handle("listen", func(channel string) string {
+		switch channel {
+		case "chat":
+			go s.Broadcast(MordhauChatID, "Chat: 76561198000000001, Alice, (ALL) Hello from the fake server")
+		case "login":
+			go s.Broadcast(MordhauLoginID, "Login: 76561198000000002 (Bob) logged in")
+		case "matchstate":
+			go s.Broadcast(MordhauMatchStateID, "MatchState: In progress")
+		}
+
+		return "Listening to " + channel
+	})
+
+	return s
+}
+
+// NewSourceServer creates a server emulating a Source engine server: it answers status, echo and cvars, returns a
+// response spanning several packets for cvarlist and follows echoed sentinels with the trailer packet SRCDS sends.
+func NewSourceServer(password string) *rcontest.Server {
+	s := rcontest.NewServer(password)
+	s.SetSentinelTrailer(true)
+
+	var lock sync.Mutex
+	cvars := map[string]string{
+		"hostname":     "Fake Source Server",
+		"mp_roundtime": "5",
+		"sv_cheats":    "0",
+	}
+
+	s.Handle("echo", func(args string) string { return args })
+	s.Handle("status", func(string) string {
+		lock.Lock()
+		hostname := cvars["hostname"]
+		lock.Unlock()
+
+		return "hostname: " + hostname + "\n" +
+			"version : 1.38.0.5/13805 1233/8012 secure\n" +
+			"map     : de_dust2\n" +
+			"players : 1 humans, 1 bots (16/0 max) (not hibernating)\n\n" +
+			"# userid name uniqueid connected ping loss state rate adr\n" +
+			"#  2 1 \"Alice\" STEAM_1:0:12345 05:23 50 0 active 196608 127.0.0.1:27005\n" +
+			"# 3 \"BOT Bob\" BOT active 64\n" +
+			"#end\n"
+	})
+	s.Handle("cvarlist", func(string) string {
+		var b strings.Builder
+		for i := 0; i < 400; i++ {
+			fmt.Fprintf(&b, "fake_cvar_%03d : 0 : , \"sv\" : Fake cvar number %d\n", i, i)
+		}
+
+		return b.String()
+	})
+
+	for name := range cvars {
+		name := name
+		s.Handle(name, func(value string) string {
+			lock.Lock()
+			defer lock.Unlock()
+
+			if value == "" {
+				return fmt.Sprintf("\"%s\" = \"%s\"", name, cvars[name])
+			}
+
+			cvars[name] = strings.Trim(value, "\"")
+			return ""
+		})
+	}
+
+	return s
+}
+
+// firstField returns the first whitespace separated field of s, or s if it has none.
+func firstField(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return s
+}
@@ -0,0 +1,111 @@
+package demo_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// connect connects a client with config to s through an in-memory transport.
+func connect(t *testing.T, s *rcontest.Server, config *rcon.Config) *rcon.Client {
+	config.StreamTransport = s.Transport()
+	config.Password = "password"
+	config.QueueReadTimeout = time.Second
+
+	client := rcon.NewClient(config, nil)
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("could not connect: %v", err)
+	}
+
+	return client
+}
+
+func TestMordhauServer(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("NewMordhauServer()", func() {
+		g.It("Should answer Mordhau's commands", func() {
+			client := connect(t, demo.NewMordhauServer("password"), &rcon.Config{})
+
+			res, err := client.ExecCommand("playerlist")
+			Expect(err).To(BeNil())
+			Expect(strings.Split(res, "\n")).To(HaveLen(2))
+			Expect(res).To(HavePrefix("76561198000000001, Alice"))
+
+			res, err = client.ExecCommand("kick 76561198000000002 5 Spamming")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Kicked player 76561198000000002"))
+
+			res, err = client.ExecCommand("unban 76561198000000002")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Unbanned player 76561198000000002"))
+		})
+
+		g.It("Should greet listeners on their channel's packet ID", func() {
+			broadcasts := make(chan packet.Packet, 4)
+			client := connect(t, demo.NewMordhauServer("password"), &rcon.Config{
+				BroadcastChecker: func(p packet.Packet) bool {
+					switch p.ID() {
+					case demo.MordhauChatID, demo.MordhauLoginID, demo.MordhauMatchStateID:
+						broadcasts <- p
+						return true
+					}
+					return false
+				},
+			})
+
+			res, err := client.ExecCommand("listen login")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Listening to login"))
+
+			var p packet.Packet
+			Eventually(broadcasts).Should(Receive(&p))
+			Expect(p.ID()).To(Equal(int32(demo.MordhauLoginID)))
+			Expect(string(p.Body())).To(HavePrefix("Login: 76561198000000002 (Bob)"))
+		})
+	})
+}
+
+func TestSourceServer(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("NewSourceServer()", func() {
+		g.It("Should report and update cvars", func() {
+			client := connect(t, demo.NewSourceServer("password"), &rcon.Config{})
+
+			res, err := client.ExecCommand("hostname")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(`"hostname" = "Fake Source Server"`))
+
+			_, err = client.ExecCommand(`hostname "Renamed"`)
+			Expect(err).To(BeNil())
+
+			res, err = client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(HavePrefix("hostname: Renamed\n"))
+		})
+
+		g.It("Should split cvarlist across packets", func() {
+			client := connect(t, demo.NewSourceServer("password"), &rcon.Config{MultiPacketResponses: true})
+
+			res, err := client.ExecCommand("cvarlist")
+			Expect(err).To(BeNil())
+			Expect(len(res)).To(BeNumerically(">", rcontest.MaxResponseBody))
+			Expect(strings.Count(res, "fake_cvar_")).To(Equal(400))
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package demo
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/rcontest"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// Scenario customizes a fake game server. Scenarios are usually written in YAML:
+//
+//	password: secret
+//	delay: 50ms
+//	responses:
+//	  playerlist: There are currently no players present
+//	broadcasts:
+//	  - id: 54325
+//	    message: "Chat: 76561198000000001, Alice, (ALL) hi"
+//	    every: 10s
+//	users:
+//	  - user: moderator
+//	    password: modpass
+//	    allow: [kick, ban, say]
+//
+// The struct is tagged for both encoding/json and gopkg.in/yaml.v3, so this package does not depend on a YAML library
+// itself. Durations are Go duration strings.
+type Scenario struct {
+	// Password replaces the password passed on the command line if set.
+	Password string `json:"password" yaml:"password"`
+
+	// FailAuth makes the server reject every authentication attempt.
+	FailAuth bool `json:"failAuth" yaml:"failAuth"`
+
+	// Delay is how long the server waits before answering each command.
+	Delay Duration `json:"delay" yaml:"delay"`
+
+	// Responses script the responses to exact commands. They take precedence over the server's built in commands.
+	Responses map[string]string `json:"responses" yaml:"responses"`
+
+	// DisconnectOn lists commands the server answers by closing the connection.
+	DisconnectOn []string `json:"disconnectOn" yaml:"disconnectOn"`
+
+	// Broadcasts are sent to every authenticated client periodically.
+	Broadcasts []ScheduledBroadcast `json:"broadcasts" yaml:"broadcasts"`
+
+	// Users are additional credentials the server accepts, each restricted by its command policy.
+	Users []ScenarioUser `json:"users" yaml:"users"`
+}
+
+// ScenarioUser is a user of a Scenario, authenticating with its own password.
+type ScenarioUser struct {
+	User                   string `json:"user" yaml:"user"`
+	Password               string `json:"password" yaml:"password"`
+	rcontest.CommandPolicy `yaml:",inline"`
+}
+
+// ScheduledBroadcast is a broadcast a Scenario sends periodically.
+type ScheduledBroadcast struct {
+	ID      int32    `json:"id" yaml:"id"`
+	Message string   `json:"message" yaml:"message"`
+	Every   Duration `json:"every" yaml:"every"`
+}
+
+// Duration is a time.Duration which is written as a Go duration string, such as "1.5s". It implements
+// encoding.TextUnmarshaler, which both encoding/json and gopkg.in/yaml.v3 use for string values.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	parsed, err := time.ParseDuration(string(b))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Unmarshaler decodes a scenario file into v. json.Unmarshal and yaml.Unmarshal from gopkg.in/yaml.v3 both qualify;
+// since YAML is a superset of JSON, the latter reads JSON scenarios as well.
+type Unmarshaler func(data []byte, v interface{}) error
+
+// LoadScenario reads the scenario file at path and decodes it with unmarshal.
+func LoadScenario(path string, unmarshal Unmarshaler) (*Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read scenario: %w", err)
+	}
+
+	var scenario Scenario
+	if err := unmarshal(b, &scenario); err != nil {
+		return nil, fmt.Errorf("could not parse scenario: %w", err)
+	}
+
+	for i, b := range scenario.Broadcasts {
+		if b.Every <= 0 {
+			return nil, fmt.Errorf("broadcast %d has no interval", i)
+		}
+	}
+
+	return &scenario, nil
+}
+
+// Apply configures s for the scenario and starts sending its broadcasts until stop is closed.
+func (sc *Scenario) Apply(s *rcontest.Server, stop <-chan struct{}) {
+	if sc.Password != "" {
+		s.Password = sc.Password
+	}
+
+	s.SetFailAuth(sc.FailAuth)
+	s.SetDelay(time.Duration(sc.Delay))
+
+	for command, response := range sc.Responses {
+		s.SetResponse(command, response)
+	}
+
+	for _, command := range sc.DisconnectOn {
+		s.DisconnectOn(command)
+	}
+
+	if len(sc.Users) > 0 {
+		policies := rcontest.CommandPolicies{}
+		for _, u := range sc.Users {
+			s.AddUser(u.User, u.Password)
+			policies[u.User] = u.CommandPolicy
+		}
+
+		s.SetAuthorizer(policies)
+	}
+
+	for _, b := range sc.Broadcasts {
+		go func(b ScheduledBroadcast) {
+			ticker := time.NewTicker(time.Duration(b.Every))
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.Broadcast(b.ID, b.Message)
+				case <-stop:
+					return
+				}
+			}
+		}(b)
+	}
+}
+
+// Run starts s on addr, applies scenario if it is not nil and serves until stop is closed.
+func Run(s *rcontest.Server, addr string, scenario *Scenario, stop <-chan struct{}) error {
+	if scenario != nil {
+		scenario.Apply(s, stop)
+	}
+
+	host, port, err := s.Listen(addr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	log.Printf("Fake server listening on %s:%d\n", host, port)
+
+	<-stop
+
+	return nil
+}
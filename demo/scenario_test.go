@@ -0,0 +1,206 @@
+package demo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoadScenario(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "scenario.json")
+		if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	g.Describe("LoadScenario()", func() {
+		g.It("Should decode every field", func() {
+			sc, err := demo.LoadScenario(filepath.Join("testdata", "scenario.json"), json.Unmarshal)
+			Expect(err).To(BeNil())
+
+			Expect(*sc).To(Equal(demo.Scenario{
+				Password:     "secret",
+				Delay:        demo.Duration(time.Millisecond * 10),
+				Responses:    map[string]string{"playerlist": "There are currently no players present"},
+				DisconnectOn: []string{"crash"},
+				Broadcasts: []demo.ScheduledBroadcast{{
+					ID:      demo.MordhauChatID,
+					Message: "Chat: 76561198000000001, Alice, (ALL) hi",
+					Every:   demo.Duration(time.Millisecond * 20),
+				}},
+				Users: []demo.ScenarioUser{{
+					User:          "moderator",
+					Password:      "modpass",
+					CommandPolicy: rcontest.CommandPolicy{Allow: []string{"kick", "ban"}, Deny: []string{"ban"}},
+				}},
+			}))
+		})
+
+		g.It("Should reject durations which aren't Go duration strings", func() {
+			_, err := demo.LoadScenario(write(`{"delay": "soon"}`), json.Unmarshal)
+			Expect(err).To(MatchError(ContainSubstring("invalid duration")))
+
+			_, err = demo.LoadScenario(write(`{"delay": 50}`), json.Unmarshal)
+			Expect(err).NotTo(BeNil())
+		})
+
+		g.It("Should reject broadcasts without an interval", func() {
+			_, err := demo.LoadScenario(write(`{"broadcasts": [{"id": 1, "message": "hi"}]}`), json.Unmarshal)
+			Expect(err).To(MatchError("broadcast 0 has no interval"))
+		})
+
+		g.It("Should fail if the file does not exist", func() {
+			_, err := demo.LoadScenario(filepath.Join("testdata", "missing.json"), json.Unmarshal)
+			Expect(err).To(MatchError(ContainSubstring("could not read scenario")))
+		})
+	})
+}
+
+func TestRun(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// freePort returns a loopback address nothing is listening on.
+	freePort := func() (string, uint16) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		addr := l.Addr().(*net.TCPAddr)
+		return addr.IP.String(), uint16(addr.Port)
+	}
+
+	g.Describe("Run()", func() {
+		var sc *demo.Scenario
+		var host string
+		var port uint16
+		var stop chan struct{}
+		var done chan error
+
+		newClient := func(password string, broadcasts chan string) *rcon.Client {
+			client := rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         password,
+				QueueReadTimeout: time.Second,
+				BroadcastChecker: func(p packet.Packet) bool {
+					return p.ID() == demo.MordhauChatID
+				},
+				BroadcastHandler: func(message string) {
+					if broadcasts != nil {
+						broadcasts <- message
+					}
+				},
+			}, nil)
+			t.Cleanup(func() { _ = client.Close() })
+
+			return client
+		}
+
+		g.BeforeEach(func() {
+			var err error
+			sc, err = demo.LoadScenario(filepath.Join("testdata", "scenario.json"), json.Unmarshal)
+			Expect(err).To(BeNil())
+
+			host, port = freePort()
+			stop = make(chan struct{})
+			done = make(chan error, 1)
+		})
+
+		g.AfterEach(func() {
+			close(stop)
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		run := func() {
+			go func() {
+				done <- demo.Run(demo.NewMordhauServer("password"), net.JoinHostPort(host, strconv.Itoa(int(port))), sc, stop)
+			}()
+
+			Eventually(func() error {
+				conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+				if err == nil {
+					_ = conn.Close()
+				}
+				return err
+			}).Should(BeNil())
+		}
+
+		g.It("Should serve the scenario's password, responses and broadcasts", func() {
+			run()
+
+			broadcasts := make(chan string, 16)
+			client := newClient("secret", broadcasts)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("playerlist")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("There are currently no players present"))
+
+			res, err = client.ExecCommand("alive")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Alive"))
+
+			Eventually(broadcasts).Should(Receive(Equal("Chat: 76561198000000001, Alice, (ALL) hi")))
+			Eventually(broadcasts).Should(Receive(Equal("Chat: 76561198000000001, Alice, (ALL) hi")))
+		})
+
+		g.It("Should restrict the scenario's users to their policy", func() {
+			run()
+
+			client := newClient("modpass", nil)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("kick 76561198000000002")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Kicked player 76561198000000002"))
+
+			res, err = client.ExecCommand("ban 76561198000000002")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Permission denied: ban"))
+		})
+
+		g.It("Should disconnect on the scenario's commands", func() {
+			run()
+
+			client := newClient("secret", nil)
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("crash")
+			Expect(err).NotTo(BeNil())
+			Eventually(client.Status).Should(Equal(rcon.StateDisconnected))
+		})
+
+		g.It("Should reject every password if the scenario fails authentication", func() {
+			sc.FailAuth = true
+			run()
+
+			err := newClient("secret", nil).Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,42 @@
+// Package demo provides a small loopback RCON server which answers a handful of commands locally. It allows the
+// example program and new users to run the library end-to-end without a real game server.
+package demo
+
+import (
+	"github.com/refractorgscm/rcon/rcontest"
+	"strconv"
+	"time"
+)
+
+// ChatBroadcastID is the packet ID used for chat broadcasts. It matches Mordhau's chat channel so that the Mordhau
+// presets can be used against the demo server.
+const ChatBroadcastID = 54325
+
+// Server is a loopback RCON server speaking the Source RCON protocol. It is an rcontest.Server preloaded with a
+// small command set.
+type Server struct {
+	*rcontest.Server
+}
+
+// NewServer creates a demo server with the default command set: echo, ping, time, players and listen.
+func NewServer(password string) *Server {
+	s := &Server{
+		Server: rcontest.NewServer(password),
+	}
+
+	s.Handle("echo", func(args string) string { return args })
+	s.Handle("ping", func(string) string { return "pong" })
+	s.Handle("time", func(string) string { return strconv.FormatInt(time.Now().Unix(), 10) })
+	s.Handle("players", func(string) string { return "There are 0 players online." })
+	s.Handle("listen", func(args string) string {
+		// Greet listeners with a broadcast shortly after the response has been sent.
+		go func() {
+			time.Sleep(time.Millisecond * 100)
+			s.Broadcast(ChatBroadcastID, "Chat: 0, Demo, Welcome to the demo server!")
+		}()
+
+		return "Listening to " + args
+	})
+
+	return s
+}
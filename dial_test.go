@@ -0,0 +1,199 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+	"time"
+)
+
+type dialTestDialect struct{}
+
+func (dialTestDialect) Name() string { return "dialtest" }
+
+func (dialTestDialect) Features() rcon.Features {
+	return rcon.Features{Broadcasts: true, RestrictedPacketIDs: []int32{42}}
+}
+
+func TestParseURL(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	rcon.RegisterGame("dialtest", func(config *rcon.Config) {
+		config.Dialect = dialTestDialect{}
+	})
+
+	g.Describe("ParseURL()", func() {
+		g.It("Should parse valid URLs", func() {
+			tests := []struct {
+				url      string
+				expected rcon.Config
+			}{
+				{
+					url:      "rcon://:secret@127.0.0.1:7778",
+					expected: rcon.Config{Host: "127.0.0.1", Port: 7778, Password: "secret"},
+				},
+				{
+					url:      "rcon://secret@example.com",
+					expected: rcon.Config{Host: "example.com", Port: rcon.DefaultPort, Password: "secret"},
+				},
+				{
+					url:      "rcon://[::1]:27016",
+					expected: rcon.Config{Host: "::1", Port: 27016},
+				},
+				{
+					url: "rcon://:pw@host:1?timeout=2s&read_timeout=3s&write_timeout=4s",
+					expected: rcon.Config{
+						Host:              "host",
+						Port:              1,
+						Password:          "pw",
+						ConnTimeout:       time.Second * 2,
+						QueueReadTimeout:  time.Second * 3,
+						QueueWriteTimeout: time.Second * 4,
+					},
+				},
+				{
+					url:      "rcon://:pw@host:1?protocol=battleye&endian=little",
+					expected: rcon.Config{Host: "host", Port: 1, Password: "pw", Protocol: rcon.ProtocolBattlEye},
+				},
+			}
+
+			for _, test := range tests {
+				config, err := rcon.ParseURL(test.url)
+				Expect(err).To(BeNil(), test.url)
+				Expect(*config).To(Equal(test.expected), test.url)
+			}
+		})
+
+		g.It("Should reject invalid URLs", func() {
+			tests := []struct {
+				url string
+				err string
+			}{
+				{url: "tcp://host:1", err: `unsupported url scheme "tcp"`},
+				{url: "rcon://host:port", err: "invalid rcon url"},
+				{url: "rcon://host:70000", err: `invalid port "70000"`},
+				{url: "rcon://host:1?endian=middle", err: `unknown endian mode "middle"`},
+				{url: "rcon://host:1?protocol=quake", err: `unknown protocol "quake"`},
+				{url: "rcon://host:1?timeout=soon", err: "invalid timeout"},
+				{url: "rcon://host:1?read_timeout=1", err: "invalid read_timeout"},
+				{url: "rcon://host:1?tls=maybe", err: "invalid tls"},
+				{url: "rcon://host:1?game=nope", err: `game "nope"`},
+			}
+
+			for _, test := range tests {
+				_, err := rcon.ParseURL(test.url)
+				Expect(err).To(MatchError(ContainSubstring(test.err)), test.url)
+			}
+
+			_, err := rcon.ParseURL("rcon://host:1?game=nope")
+			Expect(errors.Is(err, errs.ErrUnknownGame)).To(BeTrue())
+		})
+
+		g.It("Should enable TLS", func() {
+			config, err := rcon.ParseURL("rcon://host:1?tls=true")
+			Expect(err).To(BeNil())
+			Expect(config.TLSConfig).NotTo(BeNil())
+
+			config, err = rcon.ParseURL("rcon://host:1?tls=false")
+			Expect(err).To(BeNil())
+			Expect(config.TLSConfig).To(BeNil())
+		})
+
+		g.It("Should set big endian through the dialect without a deprecation notice", func() {
+			config, err := rcon.ParseURL("rcon://host:1?endian=big")
+			Expect(err).To(BeNil())
+			Expect(config.EndianMode).To(BeNil())
+			Expect(config.Dialect.Features().EndianMode).To(Equal(endian.Big))
+			Expect(config.Dialect.Features().Broadcasts).To(BeTrue())
+
+			logger := &recordingLogger{}
+			client := rcon.NewClient(config, logger)
+			Expect(client.Features().EndianMode).To(Equal(endian.Big))
+
+			for _, entry := range logger.Entries() {
+				Expect(entry).NotTo(ContainSubstring("Deprecated"))
+			}
+		})
+
+		g.It("Should keep the game's dialect when overriding its byte order", func() {
+			config, err := rcon.ParseURL("rcon://host:1?game=dialtest&endian=big")
+			Expect(err).To(BeNil())
+
+			Expect(config.Dialect.Name()).To(Equal("dialtest"))
+			Expect(config.Dialect.Features().EndianMode).To(Equal(endian.Big))
+			Expect(config.Dialect.Features().RestrictedPacketIDs).To(Equal([]int32{42}))
+		})
+	})
+}
+
+func TestDial(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Dial()", func() {
+		g.It("Should connect to the server in the URL", func() {
+			server := newTestServer(t, &rcon.Config{})
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			client, err := rcon.Dial(context.Background(), fmt.Sprintf("rcon://:password@%s:%d?read_timeout=1s", host, port))
+			Expect(err).To(BeNil())
+			defer client.Close()
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+
+		g.It("Should talk to big endian servers", func() {
+			server := newTestServer(t, &rcon.Config{})
+			server.EndianMode = endian.Big
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			client, err := rcon.Dial(context.Background(), fmt.Sprintf("rcon://:password@%s:%d?endian=big", host, port))
+			Expect(err).To(BeNil())
+			defer client.Close()
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+
+		g.It("Should return the authentication error for a wrong password", func() {
+			server := newTestServer(t, &rcon.Config{})
+			host, port := server.Addr()
+
+			_, err := rcon.Dial(context.Background(), fmt.Sprintf("rcon://:wrong@%s:%d", host, port))
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should not connect with a cancelled context", func() {
+			server := newTestServer(t, &rcon.Config{})
+			host, port := server.Addr()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := rcon.Dial(ctx, fmt.Sprintf("rcon://:password@%s:%d", host, port))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(server.AuthAttempts()).To(Equal(0))
+		})
+
+		g.It("Should return URL errors without connecting", func() {
+			_, err := rcon.Dial(context.Background(), "http://host:1")
+			Expect(err).To(MatchError(ContainSubstring("unsupported url scheme")))
+		})
+	})
+}
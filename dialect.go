@@ -0,0 +1,266 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Features describes what a game's RCON implementation supports. The client uses them to enable or disable its
+// subsystems automatically, so that supporting a new game is a matter of declaring its features.
+type Features struct {
+	// Broadcasts is true if the server proactively sends broadcast messages. If false, no packet is ever treated as a
+	// broadcast, regardless of the configured BroadcastChecker.
+	Broadcasts bool
+
+	// MultiPacket is true if the server splits large responses across multiple packets and echoes empty response value
+	// packets, allowing them to be assembled. It enables MultiPacketResponses.
+	MultiPacket bool
+
+	// ConnectionPerCommand is true if the server closes the connection after every response. It enables
+	// ConnectionPerCommand.
+	ConnectionPerCommand bool
+
+	// KeepAliveRequired is true if the server drops idle connections. It enables KeepAlive with
+	// DefaultKeepAliveInterval unless an interval was configured.
+	KeepAliveRequired bool
+
+	// FragmentSize is the largest body the server puts in a single response packet. Multi-packet responses consist of
+	// fragments of exactly this size followed by a shorter one.
+	//
+	// Default: DefaultFragmentSize
+	FragmentSize int
+
+	// Sentinel describes how the server answers the empty sentinel packet sent after every command when MultiPacket is
+	// enabled.
+	//
+	// Default: SentinelEcho
+	Sentinel SentinelBehavior
+
+	// SentinelGrace is how long the client keeps waiting for further fragments after the sentinel's echo arrived when
+	// Sentinel is SentinelUnordered and the last fragment was full-size.
+	//
+	// Default: DefaultSentinelGrace
+	SentinelGrace time.Duration
+
+	// MaxBodySize is the largest command body the server accepts. Longer commands are rejected with
+	// errs.ErrCommandTooLarge before being sent. Zero means no limit.
+	MaxBodySize int
+
+	// EndianMode is the byte order of the game's packets.
+	//
+	// Default: endian.Little
+	EndianMode endian.Mode
+
+	// RestrictedPacketIDs are the packet IDs the server reserves for special messages such as broadcasts. The client
+	// never sends packets with these IDs.
+	RestrictedPacketIDs []int32
+
+	// BroadcastChecker reports whether a packet is a broadcast. It is only used if Broadcasts is true.
+	BroadcastChecker BroadcastMessageChecker
+
+	// BroadcastHeaderChecker classifies packets by their header before their body is read, and only passes those it
+	// can't decide on to the BroadcastChecker. It is only used if Broadcasts is true.
+	BroadcastHeaderChecker BroadcastHeaderChecker
+
+	// PacketHandlers handle the game's custom server packet types. See Config.PacketHandlers.
+	PacketHandlers map[packet.PacketType]PacketHandler
+
+	// BroadcastTime reads the time the server emitted a broadcast at from its message, for games which timestamp
+	// their broadcasts. See Broadcast.ServerTime.
+	BroadcastTime BroadcastTimeParser
+
+	// ListenCommand is the command subscribing the connection to a broadcast channel, with the channel substituted for
+	// %s, for example "listen %s". It enables Client.Listen.
+	ListenCommand string
+
+	// StopListenCommand is the command unsubscribing the connection from a broadcast channel, with the channel
+	// substituted for %s. If empty, channels stay subscribed on the server until the connection ends.
+	StopListenCommand string
+
+	// MaxListens is the number of broadcast channels the server can stream at once. Some servers corrupt the packet
+	// stream when more are subscribed, so further Listen calls wait until a channel is released. Zero means no limit.
+	MaxListens int
+
+	// BodyTransform wraps packet bodies for servers which encrypt or otherwise encode them. Hooks, tees and traces see
+	// the packets' plain bodies; only raw bytes passed to them are as on the wire.
+	BodyTransform BodyTransform
+
+	// TimeCommand is the command printing the server's current wall clock time, and TimeParser parses its response.
+	// Together they enable Client.SyncClock.
+	TimeCommand string
+	TimeParser  TimeParser
+}
+
+// Dialect describes a game's flavour of the RCON protocol.
+type Dialect interface {
+	Name() string
+	Features() Features
+}
+
+// Features returns the features of the configured dialect. If no dialect is configured, the features are derived from
+// the client's configuration, including the deprecated EndianMode, RestrictedPacketIDs and BroadcastChecker fields.
+func (c *Client) Features() Features {
+	if c.Dialect != nil {
+		return c.Dialect.Features()
+	}
+
+	// SetRestrictedPacketIDs only updates the ID generator.
+	restricted := c.RestrictedPacketIDs
+	if c.ids != nil {
+		restricted = c.ids.Restricted()
+	}
+
+	return Features{
+		Broadcasts:           true,
+		MultiPacket:          c.MultiPacketResponses,
+		ConnectionPerCommand: c.ConnectionPerCommand,
+		EndianMode:           c.EndianMode,
+		RestrictedPacketIDs:  restricted,
+		BroadcastChecker:     c.BroadcastChecker,
+	}
+}
+
+// noBroadcasts is the BroadcastChecker of clients which don't receive broadcasts.
+func noBroadcasts(packet.Packet) bool {
+	return false
+}
+
+// sameFunc reports whether a and b are the same function.
+func sameFunc(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// warnDeprecated logs a notice for every deprecated config field which is set. It must be called before applyDialect
+// fills the fields in from the dialect.
+//
+// A config used for an earlier client still holds the values which were filled in from its dialect or defaults. They
+// don't change anything, so they aren't reported.
+func (c *Client) warnDeprecated() {
+	var fields []string
+
+	var f Features
+	if c.Dialect != nil {
+		f = c.Dialect.Features()
+	}
+
+	mode := f.EndianMode
+	if mode == nil {
+		mode = endian.Little
+	}
+
+	if c.EndianMode != nil && c.EndianMode != mode {
+		fields = append(fields, "EndianMode")
+	}
+
+	if len(c.RestrictedPacketIDs) > 0 && !reflect.DeepEqual(c.RestrictedPacketIDs, f.RestrictedPacketIDs) {
+		fields = append(fields, "RestrictedPacketIDs")
+	}
+
+	if c.BroadcastChecker != nil && !sameFunc(c.BroadcastChecker, noBroadcasts) &&
+		(c.Dialect == nil || !sameFunc(c.BroadcastChecker, f.BroadcastChecker)) {
+		fields = append(fields, "BroadcastChecker")
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	if c.Dialect != nil {
+		c.log.Info("Deprecated config fields ", strings.Join(fields, ", "), " override the features of dialect ",
+			c.Dialect.Name(), ". Declare them in the dialect instead; run rcdoctor to print the equivalent configuration.")
+	} else {
+		c.log.Info("Deprecated config fields ", strings.Join(fields, ", "), " are set. Declare them in the Features of ",
+			"a Dialect instead; run rcdoctor to print the equivalent configuration.")
+	}
+}
+
+// fragmentation returns the fragment size, sentinel behavior and sentinel grace of the configured dialect, with
+// defaults filled in.
+func (c *Client) fragmentation() (int, SentinelBehavior, time.Duration) {
+	f := c.Features()
+
+	size := f.FragmentSize
+	if size <= 0 {
+		size = DefaultFragmentSize
+	}
+
+	grace := f.SentinelGrace
+	if grace <= 0 {
+		grace = DefaultSentinelGrace
+	}
+
+	return size, f.Sentinel, grace
+}
+
+// applyDialect enables or disables subsystems according to the configured dialect's features.
+func (c *Client) applyDialect() {
+	if c.Dialect == nil {
+		return
+	}
+
+	f := c.Dialect.Features()
+
+	// Deprecated config fields which are set take precedence over the dialect.
+	if c.EndianMode == nil {
+		c.EndianMode = f.EndianMode
+	}
+
+	if len(c.RestrictedPacketIDs) == 0 {
+		c.RestrictedPacketIDs = f.RestrictedPacketIDs
+	}
+
+	if c.BroadcastChecker == nil {
+		c.BroadcastChecker = f.BroadcastChecker
+	}
+
+	if f.Broadcasts {
+		c.broadcastHeaderChecker = f.BroadcastHeaderChecker
+	}
+
+	if f.MultiPacket {
+		c.MultiPacketResponses = true
+	}
+
+	if f.ConnectionPerCommand {
+		c.ConnectionPerCommand = true
+	}
+
+	if f.KeepAliveRequired && c.KeepAlive.Interval == 0 {
+		c.KeepAlive.Interval = DefaultKeepAliveInterval
+	}
+
+	if !f.Broadcasts {
+		c.BroadcastChecker = noBroadcasts
+	}
+
+	c.log.Debug("Using dialect ", c.Dialect.Name())
+}
+
+// packetOverhead is the number of bytes the size field of a Source packet counts besides the body: the ID, the type, the
+// body's null terminator and the end padding.
+const packetOverhead = 4 + 4 + 1 + 1
+
+// checkCommandSize returns errs.ErrCommandTooLarge if command exceeds the dialect's maximum body size, or its packet
+// exceeds MaxPacketSize.
+func (c *Client) checkCommandSize(command string) error {
+	if size := packetOverhead + len(command); c.MaxPacketSize > 0 && size > c.MaxPacketSize {
+		return fmt.Errorf("command packet is %d bytes, at most %d are allowed: %w", size, c.MaxPacketSize,
+			errs.ErrCommandTooLarge)
+	}
+
+	if c.Dialect == nil {
+		return nil
+	}
+
+	if max := c.Dialect.Features().MaxBodySize; max > 0 && len(command) > max {
+		return fmt.Errorf("command is %d bytes, %s accepts at most %d: %w", len(command),
+			c.Dialect.Name(), max, errs.ErrCommandTooLarge)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyDialect(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	broadcast := packet.NewPacketWithID(endian.Little, rcontest.BroadcastID, packet.TypeCommandRes, "hello")
+	everything := func(packet.Packet) bool { return true }
+
+	tests := []struct {
+		name     string
+		features rcon.Features
+		config   rcon.Config
+		check    func(config *rcon.Config)
+	}{
+		{
+			name: "an empty feature set",
+			check: func(config *rcon.Config) {
+				Expect(config.MultiPacketResponses).To(BeFalse())
+				Expect(config.ConnectionPerCommand).To(BeFalse())
+				Expect(config.KeepAlive.Interval).To(BeZero())
+				Expect(config.EndianMode).To(Equal(endian.Little))
+				Expect(config.RestrictedPacketIDs).To(BeEmpty())
+				Expect(config.BroadcastChecker(broadcast)).To(BeFalse())
+			},
+		},
+		{
+			name:     "MultiPacket",
+			features: rcon.Features{MultiPacket: true},
+			check: func(config *rcon.Config) {
+				Expect(config.MultiPacketResponses).To(BeTrue())
+			},
+		},
+		{
+			name:     "ConnectionPerCommand",
+			features: rcon.Features{ConnectionPerCommand: true},
+			check: func(config *rcon.Config) {
+				Expect(config.ConnectionPerCommand).To(BeTrue())
+			},
+		},
+		{
+			name:     "KeepAliveRequired",
+			features: rcon.Features{KeepAliveRequired: true},
+			check: func(config *rcon.Config) {
+				Expect(config.KeepAlive.Interval).To(Equal(rcon.DefaultKeepAliveInterval))
+			},
+		},
+		{
+			name:     "KeepAliveRequired with an interval",
+			features: rcon.Features{KeepAliveRequired: true},
+			config:   rcon.Config{KeepAlive: rcon.KeepAliveConfig{Interval: time.Second}},
+			check: func(config *rcon.Config) {
+				Expect(config.KeepAlive.Interval).To(Equal(time.Second))
+			},
+		},
+		{
+			name:     "EndianMode",
+			features: rcon.Features{EndianMode: endian.Big},
+			check: func(config *rcon.Config) {
+				Expect(config.EndianMode).To(Equal(endian.Big))
+			},
+		},
+		{
+			name:     "EndianMode overridden by the config",
+			features: rcon.Features{EndianMode: endian.Big},
+			config:   rcon.Config{EndianMode: endian.Little},
+			check: func(config *rcon.Config) {
+				Expect(config.EndianMode).To(Equal(endian.Little))
+			},
+		},
+		{
+			name:     "RestrictedPacketIDs",
+			features: rcon.Features{RestrictedPacketIDs: []int32{7}},
+			check: func(config *rcon.Config) {
+				Expect(config.RestrictedPacketIDs).To(Equal([]int32{7}))
+			},
+		},
+		{
+			name:     "RestrictedPacketIDs overridden by the config",
+			features: rcon.Features{RestrictedPacketIDs: []int32{7}},
+			config:   rcon.Config{RestrictedPacketIDs: []int32{8}},
+			check: func(config *rcon.Config) {
+				Expect(config.RestrictedPacketIDs).To(Equal([]int32{8}))
+			},
+		},
+		{
+			name:     "Broadcasts",
+			features: rcon.Features{Broadcasts: true, BroadcastChecker: rcontest.BroadcastChecker},
+			check: func(config *rcon.Config) {
+				Expect(config.BroadcastChecker(broadcast)).To(BeTrue())
+			},
+		},
+		{
+			name:     "Broadcasts with a BroadcastChecker in the config",
+			features: rcon.Features{Broadcasts: true, BroadcastChecker: rcontest.BroadcastChecker},
+			config:   rcon.Config{BroadcastChecker: everything},
+			check: func(config *rcon.Config) {
+				Expect(config.BroadcastChecker(packet.NewPacketWithID(endian.Little, 5, packet.TypeCommandRes, "ok"))).To(BeTrue())
+			},
+		},
+		{
+			name:     "no Broadcasts with a BroadcastChecker in the config",
+			features: rcon.Features{BroadcastChecker: rcontest.BroadcastChecker},
+			config:   rcon.Config{BroadcastChecker: everything},
+			check: func(config *rcon.Config) {
+				Expect(config.BroadcastChecker(broadcast)).To(BeFalse())
+			},
+		},
+	}
+
+	g.Describe("Dialect features", func() {
+		for _, test := range tests {
+			test := test
+
+			g.It("Should apply "+test.name, func() {
+				config := test.config
+				config.Dialect = presets.NewDialect("features", test.features)
+				rcon.NewClient(&config, nil)
+
+				test.check(&config)
+			})
+		}
+	})
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// notices returns the deprecation notices logged when creating a client from config.
+	notices := func(config *rcon.Config) []string {
+		logger := &recordingLogger{}
+		rcon.NewClient(config, logger)
+
+		var notices []string
+		for _, entry := range logger.Entries() {
+			if strings.Contains(entry, "Deprecated config fields") {
+				notices = append(notices, entry)
+			}
+		}
+
+		return notices
+	}
+
+	g.Describe("Deprecated config fields", func() {
+		g.It("Should list every deprecated field which is set", func() {
+			tests := []struct {
+				config *rcon.Config
+				fields string
+			}{
+				{
+					config: &rcon.Config{EndianMode: endian.Big},
+					fields: "EndianMode",
+				},
+				{
+					config: &rcon.Config{RestrictedPacketIDs: []int32{7}},
+					fields: "RestrictedPacketIDs",
+				},
+				{
+					config: &rcon.Config{BroadcastChecker: rcontest.BroadcastChecker},
+					fields: "BroadcastChecker",
+				},
+				{
+					config: &rcon.Config{
+						EndianMode:          endian.Big,
+						RestrictedPacketIDs: []int32{7},
+						BroadcastChecker:    rcontest.BroadcastChecker,
+					},
+					fields: "EndianMode, RestrictedPacketIDs, BroadcastChecker",
+				},
+			}
+
+			for _, test := range tests {
+				Expect(notices(test.config)).To(Equal([]string{
+					"INFO: Deprecated config fields " + test.fields + " are set. Declare them in the Features of a " +
+						"Dialect instead; run rcdoctor to print the equivalent configuration.",
+				}), test.fields)
+			}
+		})
+
+		g.It("Should name the dialect the fields override", func() {
+			Expect(notices(&rcon.Config{
+				Dialect:             dialTestDialect{},
+				RestrictedPacketIDs: []int32{7},
+			})).To(Equal([]string{
+				"INFO: Deprecated config fields RestrictedPacketIDs override the features of dialect dialtest. " +
+					"Declare them in the dialect instead; run rcdoctor to print the equivalent configuration.",
+			}))
+		})
+
+		g.It("Should not log a notice without deprecated fields", func() {
+			Expect(notices(&rcon.Config{})).To(BeEmpty())
+			Expect(notices(&rcon.Config{Dialect: dialTestDialect{}})).To(BeEmpty())
+			Expect(notices(&rcon.Config{RestrictedPacketIDs: []int32{}})).To(BeEmpty())
+		})
+
+		g.It("Should not log a notice for fields set to their default", func() {
+			Expect(notices(&rcon.Config{EndianMode: endian.Little})).To(BeEmpty())
+		})
+
+		g.It("Should not log a notice for fields filled in for an earlier client", func() {
+			quiet := (&rcon.GameProfile{Name: "quiet", EndianMode: endian.Big}).Dialect()
+
+			for _, config := range []*rcon.Config{{}, {Dialect: dialTestDialect{}}, {Dialect: quiet}} {
+				Expect(notices(config)).To(BeEmpty())
+
+				// A second client created from the same config doesn't mistake them for deprecated fields.
+				Expect(notices(config)).To(BeEmpty())
+			}
+		})
+	})
+}
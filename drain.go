@@ -0,0 +1,86 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"sync/atomic"
+	"time"
+)
+
+// drainDeadline returns the time until which the reader keeps reading after Close was called, or the zero time if
+// the client is not closing.
+func (c *Client) drainDeadline() time.Time {
+	until := atomic.LoadInt64(&c.drainUntil)
+	if until == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, until)
+}
+
+// draining returns true if Close is waiting for the server's final messages.
+func (c *Client) draining() bool {
+	return atomic.LoadInt64(&c.drainUntil) != 0
+}
+
+// drainAndDisconnect keeps the connection of terminate open for CloseGracePeriod so that the reader can deliver any
+// final messages and broadcasts the server sends, then disconnects it. The reader ends the session itself once its
+// read deadline passes or the server closes the connection, so every message read is delivered before the
+// DisconnectHandler is called.
+func (c *Client) drainAndDisconnect(terminate chan uint8) {
+	until := time.Now().Add(c.CloseGracePeriod)
+	atomic.StoreInt64(&c.drainUntil, until.UnixNano())
+	defer atomic.StoreInt64(&c.drainUntil, 0)
+
+	c.log.Debug("Draining connection for ", c.CloseGracePeriod)
+
+	if conn, _ := c.connection(); conn != nil {
+		// Unblock a read which was started without a deadline.
+		_ = conn.SetDeadline(until)
+	}
+
+	select {
+	case <-terminate:
+	case <-time.After(c.CloseGracePeriod + c.ConnTimeout):
+		c.log.Debug("Reader did not finish draining in time")
+	}
+
+	c.disconnect(terminate, nil)
+}
+
+// checkClosing returns errs.ErrNotConnected if Close was called, since commands sent while closing would not be
+// answered before the connection is closed.
+func (c *Client) checkClosing() error {
+	if c.Status() == StateClosing || c.draining() {
+		return fmt.Errorf("client is closing: %w", errs.ErrNotConnected)
+	}
+
+	return nil
+}
+
+// failPending closes the mailboxes of commands which are still waiting for a response after the connection was closed,
+// so that they fail right away instead of waiting for their timeout.
+func (c *Client) failPending() {
+	if ids := c.mailboxes.removeIf(func(int32) bool { return true }); len(ids) > 0 {
+		c.log.Debug("Closed ", len(ids), " mailboxes which were still waiting for a response")
+	}
+}
+
+// closeDrainPollInterval is how often Close checks whether in-flight commands completed.
+const closeDrainPollInterval = time.Millisecond * 10
+
+// waitForInFlight waits until all in-flight commands completed and all queued packets were written, or until
+// CloseDrainTimeout passed. It returns false on timeout.
+func (c *Client) waitForInFlight() bool {
+	deadline := time.Now().Add(c.CloseDrainTimeout)
+
+	for atomic.LoadInt64(&c.stats.inFlight) > 0 || atomic.LoadInt64(&c.pendingWrites) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(closeDrainPollInterval)
+	}
+
+	return true
+}
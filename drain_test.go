@@ -0,0 +1,176 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloseDrain(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	type result struct {
+		res string
+		err error
+	}
+
+	g.Describe("Close()", func() {
+		g.It("Should wait for commands in flight and reject new ones", func() {
+			server, client := newTestClient(t, &rcon.Config{QueueReadTimeout: time.Second})
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 200)
+				return "done"
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			results := make(chan result, 1)
+			go func() {
+				res, err := client.ExecCommand("slow")
+				results <- result{res, err}
+			}()
+			Eventually(server.Commands).Should(ContainElement("slow"))
+
+			closed := make(chan error, 1)
+			go func() { closed <- client.Close() }()
+
+			Eventually(client.Status).Should(Equal(rcon.StateClosing))
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrNotSent)).To(BeTrue())
+
+			Eventually(closed).Should(Receive(BeNil()))
+			Expect(results).To(Receive(Equal(result{res: "done"})))
+			Expect(server.Commands()).ToNot(ContainElement("status"))
+		})
+
+		g.It("Should give up waiting after CloseDrainTimeout", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				QueueReadTimeout:  time.Second * 2,
+				CloseDrainTimeout: time.Millisecond * 50,
+			})
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 500)
+				return "done"
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			results := make(chan result, 1)
+			go func() {
+				res, err := client.ExecCommand("slow")
+				results <- result{res, err}
+			}()
+			Eventually(server.Commands).Should(ContainElement("slow"))
+
+			start := time.Now()
+			Expect(client.Close()).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*300))
+
+			// The command fails as soon as the connection was closed rather than after QueueReadTimeout.
+			var r result
+			Eventually(results, time.Millisecond*100).Should(Receive(&r))
+			Expect(errors.Is(r.err, errs.ErrMailboxClosed)).To(BeTrue())
+		})
+
+		g.It("Should flush queued commands before closing the connection", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			for _, command := range []string{"say one", "say two", "say three"} {
+				Expect(client.ExecCommandNoResponse(command)).To(BeNil())
+			}
+			Expect(client.Close()).To(BeNil())
+
+			Eventually(server.Commands).Should(Equal([]string{"say one", "say two", "say three"}))
+		})
+	})
+
+	g.Describe("CloseGracePeriod", func() {
+		// newGraceClient returns a client which stops waiting for in-flight commands right away, so that only the grace
+		// period decides whether their responses are delivered.
+		newGraceClient := func(response time.Duration) (chan result, *rcon.Client) {
+			server, client := newTestClient(t, &rcon.Config{
+				QueueReadTimeout:  time.Second * 2,
+				CloseDrainTimeout: time.Millisecond * 10,
+				CloseGracePeriod:  time.Millisecond * 300,
+			})
+			server.Handle("slow", func(string) string {
+				time.Sleep(response)
+				return "done"
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			results := make(chan result, 1)
+			go func() {
+				res, err := client.ExecCommand("slow")
+				results <- result{res, err}
+			}()
+			Eventually(server.Commands).Should(ContainElement("slow"))
+
+			return results, client
+		}
+
+		g.It("Should deliver responses which arrive within the grace period", func() {
+			results, client := newGraceClient(time.Millisecond * 100)
+
+			Expect(client.Close()).To(BeNil())
+			Expect(results).To(Receive(Equal(result{res: "done"})))
+		})
+
+		g.It("Should fail commands whose response arrives after the grace period", func() {
+			results, client := newGraceClient(time.Millisecond * 800)
+
+			start := time.Now()
+			Expect(client.Close()).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*300))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*700))
+
+			var r result
+			Eventually(results, time.Millisecond*100).Should(Receive(&r))
+			Expect(errors.Is(r.err, errs.ErrMailboxClosed)).To(BeTrue())
+		})
+
+		g.It("Should deliver broadcasts sent within the grace period before the DisconnectHandler is called", func() {
+			var lock sync.Mutex
+			var events []string
+
+			record := func(event string) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				events = append(events, event)
+			}
+
+			server, client := newTestClient(t, &rcon.Config{
+				BroadcastChecker: rcontest.BroadcastChecker,
+				BroadcastHandler: func(message string) { record(message) },
+				CloseGracePeriod: time.Millisecond * 200,
+				DisconnectHandler: func(error, bool) {
+					record("disconnected")
+				},
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			go func() {
+				time.Sleep(time.Millisecond * 50)
+				server.Broadcast(rcontest.BroadcastID, "Server shutting down")
+			}()
+
+			Expect(client.Close()).To(BeNil())
+			Eventually(func() []string {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return append([]string(nil), events...)
+			}).Should(Equal([]string{"Server shutting down", "disconnected"}))
+		})
+	})
+}
@@ -1,8 +1,244 @@
 package errs
 
-import "github.com/pkg/errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var ErrNotConnected = errors.New("not connected")
 var ErrAuthentication = errors.New("authentication failed")
 var ErrQueueTimeout = errors.New("queue timeout")
+var ErrQueueFull = errors.New("queue full")
 var ErrReadTimeout = errors.New("read timeout")
+var ErrDesync = errors.New("packet stream desynchronized")
+var ErrUnknownMacro = errors.New("unknown macro")
+var ErrMacroArguments = errors.New("missing macro arguments")
+var ErrSaveNotConfirmed = errors.New("save not confirmed")
+var ErrUnknownClient = errors.New("unknown client")
+var ErrPaused = errors.New("client paused")
+var ErrCommandTooLarge = errors.New("command too large")
+var ErrMailboxClosed = errors.New("mailbox closed")
+var ErrKeepAliveTimeout = errors.New("keepalive timeout")
+var ErrSelfTestFailed = errors.New("self-test failed")
+var ErrCanaryFailed = errors.New("canary failed")
+var ErrUnknownGame = errors.New("unknown game")
+var ErrQuorumNotReached = errors.New("quorum not reached")
+var ErrListenUnsupported = errors.New("listen unsupported")
+var ErrNoPublicAddr = errors.New("no public address")
+var ErrResponseTruncated = errors.New("response truncated")
+var ErrTimeUnsupported = errors.New("time command unsupported")
+
+// ErrAuthFailed is ErrAuthentication under the naming of the rest of the error set. errors.Is matches either.
+var ErrAuthFailed = ErrAuthentication
+
+// ErrBanned is returned if the server closed the connection instead of answering the authentication request. Source
+// servers do so for addresses banned after too many failed authentication attempts.
+var ErrBanned = errors.New("banned")
+
+// ErrConnClosed is matched by errors returned because the connection was closed. See ConnClosedError.
+var ErrConnClosed = errors.New("connection closed")
+
+// ErrNotSent is matched by errors returned for commands which were certainly not written to the connection, so the
+// server can't have executed them. See NotSentError.
+var ErrNotSent = errors.New("command not sent")
+
+// ErrMalformedPacket is matched by errors returned for packets which can't possibly be valid. See
+// MalformedPacketError.
+var ErrMalformedPacket = errors.New("malformed packet")
+
+// ConnClosedError is returned when the connection was closed, by the server or by the client. It matches ErrConnClosed
+// with errors.Is and unwraps to the error the connection failed with, such as io.EOF, or to ErrNotConnected if the
+// client closed it.
+type ConnClosedError struct {
+	Err error
+}
+
+func (e *ConnClosedError) Error() string {
+	return "connection closed: " + e.Err.Error()
+}
+
+func (e *ConnClosedError) Is(target error) bool {
+	return target == ErrConnClosed
+}
+
+func (e *ConnClosedError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the error the connection failed with, for callers using errors.Cause.
+func (e *ConnClosedError) Cause() error {
+	return e.Err
+}
+
+// NotSentError wraps the error a command failed with before it was written to the connection. It matches ErrNotSent
+// with errors.Is and unwraps to the original error.
+type NotSentError struct {
+	Err error
+}
+
+func (e *NotSentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NotSentError) Is(target error) bool {
+	return target == ErrNotSent
+}
+
+func (e *NotSentError) Unwrap() error {
+	return e.Err
+}
+
+// PartialCommandError is returned when a command which was split into several packets failed after some of its parts
+// were executed. It unwraps to the error the failed part returned. Since the parts before it reached the server, the
+// command as a whole is never considered not sent, even if Err matches ErrNotSent.
+type PartialCommandError struct {
+	Command  string
+	Parts    int
+	Executed int
+	Err      error
+}
+
+func (e *PartialCommandError) Error() string {
+	return fmt.Sprintf("part %d of %d of the split command failed: %s", e.Executed+1, e.Parts, e.Err)
+}
+
+func (e *PartialCommandError) Unwrap() error {
+	return e.Err
+}
+
+// MalformedPacketError is returned for a packet which can't possibly be valid, usually because the stream lost framing.
+// It unwraps to ErrMalformedPacket.
+type MalformedPacketError struct {
+	Reason string
+}
+
+func (e *MalformedPacketError) Error() string {
+	return "malformed packet: " + e.Reason
+}
+
+func (e *MalformedPacketError) Unwrap() error {
+	return ErrMalformedPacket
+}
+
+// Cause returns ErrMalformedPacket, for callers using errors.Cause.
+func (e *MalformedPacketError) Cause() error {
+	return ErrMalformedPacket
+}
+
+// ResponseTimeoutError is returned when the response to a packet didn't arrive in time. It unwraps to ErrReadTimeout.
+type ResponseTimeoutError struct {
+	PacketID int32
+	Timeout  time.Duration
+}
+
+func (e *ResponseTimeoutError) Error() string {
+	return fmt.Sprintf("no response to packet %d within %s: %s", e.PacketID, e.Timeout, ErrReadTimeout)
+}
+
+func (e *ResponseTimeoutError) Unwrap() error {
+	return ErrReadTimeout
+}
+
+// Cause returns ErrReadTimeout, for callers using errors.Cause.
+func (e *ResponseTimeoutError) Cause() error {
+	return ErrReadTimeout
+}
+
+// ServerCommandError is returned when a command was delivered and answered by the server, but the response was
+// identified as an error message by the configured ResponseErrorChecker.
+type ServerCommandError struct {
+	Command string
+	Message string
+}
+
+func (e *ServerCommandError) Error() string {
+	return "server returned an error for command " + e.Command + ": " + e.Message
+}
+
+// UnexpectedAuthPacketError is returned when the server answers an authentication request with a packet which is not
+// an auth response. The offending packet is captured to help diagnose protocol mismatches. It unwraps to
+// ErrAuthentication.
+type UnexpectedAuthPacketError struct {
+	Type        int32
+	ID          int32
+	BodyExcerpt string
+}
+
+// MaxBodyExcerpt is the maximum number of body bytes captured in UnexpectedAuthPacketError.
+const MaxBodyExcerpt = 64
+
+// NewUnexpectedAuthPacketError creates an UnexpectedAuthPacketError, truncating body to MaxBodyExcerpt bytes.
+func NewUnexpectedAuthPacketError(pType int32, id int32, body []byte) *UnexpectedAuthPacketError {
+	excerpt := string(body)
+	if len(body) > MaxBodyExcerpt {
+		excerpt = string(body[:MaxBodyExcerpt]) + "..."
+	}
+
+	return &UnexpectedAuthPacketError{
+		Type:        pType,
+		ID:          id,
+		BodyExcerpt: excerpt,
+	}
+}
+
+func (e *UnexpectedAuthPacketError) Error() string {
+	return fmt.Sprintf("unexpected packet in response to authentication (type: %d, id: %d, body: %q)",
+		e.Type, e.ID, e.BodyExcerpt)
+}
+
+func (e *UnexpectedAuthPacketError) Unwrap() error {
+	return ErrAuthentication
+}
+
+// ValidationError is returned by ExecValidated when a response was received, but the command's validator rejected it.
+// It unwraps to the validator's error.
+type ValidationError struct {
+	Command  string
+	Response string
+	Err      error
+}
+
+func (e *ValidationError) Error() string {
+	return "response to command " + e.Command + " failed validation: " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// CertificateError is returned when the server's TLS certificate could not be verified. It unwraps to the underlying
+// verification error.
+type CertificateError struct {
+	Err error
+}
+
+func (e *CertificateError) Error() string {
+	return "tls certificate verification failed: " + e.Err.Error()
+}
+
+func (e *CertificateError) Unwrap() error {
+	return e.Err
+}
+
+// RetryCancelledError is returned when the context of a command was cancelled while waiting to retry it. It unwraps to
+// the context's error, so errors.Is matches context.Canceled or context.DeadlineExceeded, and also matches the error
+// the last attempt failed with.
+type RetryCancelledError struct {
+	Attempts int
+	LastErr  error
+	Err      error
+}
+
+func (e *RetryCancelledError) Error() string {
+	return fmt.Sprintf("command cancelled while waiting to retry after %d attempts (last error: %s): %s", e.Attempts,
+		e.LastErr, e.Err)
+}
+
+func (e *RetryCancelledError) Is(target error) bool {
+	return errors.Is(e.LastErr, target)
+}
+
+func (e *RetryCancelledError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,259 @@
+// Package events defines the canonical, versioned JSON serialization of the events a client produces: broadcasts,
+// audit entries of executed commands, connection state changes and statistics snapshots. Storage pipelines and
+// publishers should encode events with this package so that every consumer sees one consistent format.
+//
+// Every event is wrapped in an envelope naming its kind and schema version:
+//
+//	{"kind": "broadcast", "version": 1, "data": {"source": "rcon", "message": "...", ...}}
+//
+// Fields are only ever added within a version. Renaming or removing a field, or changing its meaning, increments the
+// version of that kind.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"time"
+)
+
+// Kinds of events.
+const (
+	KindBroadcast   = "broadcast"
+	KindAudit       = "audit"
+	KindStateChange = "state_change"
+	KindStats       = "stats"
+)
+
+// Schema versions of each kind.
+const (
+	BroadcastVersion   = 1
+	AuditVersion       = 1
+	StateChangeVersion = 1
+	StatsVersion       = 1
+)
+
+// ErrUnsupportedVersion is returned by Decode for events with an unknown kind or a newer schema version than this
+// package supports.
+var ErrUnsupportedVersion = errors.New("unsupported event kind or version")
+
+// Envelope wraps an encoded event.
+type Envelope struct {
+	Kind    string          `json:"kind"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Event is implemented by all event types.
+type Event interface {
+	// Kind returns the kind of the event.
+	Kind() string
+
+	// Version returns the schema version the event type implements.
+	Version() int
+}
+
+// Broadcast is a broadcast message. See rcon.Broadcast.
+type Broadcast struct {
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+	Channel  string `json:"channel,omitempty"`
+	PacketID int32  `json:"packet_id"`
+
+	Time time.Time `json:"time"`
+
+	ServerTime         *time.Time `json:"server_time,omitempty"`
+	ServerTimeAccuracy int64      `json:"server_time_accuracy_ns,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (Broadcast) Kind() string {
+	return KindBroadcast
+}
+
+func (Broadcast) Version() int {
+	return BroadcastVersion
+}
+
+// FromBroadcast converts b.
+func FromBroadcast(b rcon.Broadcast) Broadcast {
+	e := Broadcast{
+		Source:             b.Source,
+		Message:            b.Message,
+		Channel:            b.Channel,
+		Time:               b.Time,
+		ServerTimeAccuracy: int64(b.ServerTimeAccuracy),
+		Labels:             b.Labels,
+	}
+
+	if b.Packet != nil {
+		e.PacketID = b.Packet.ID()
+	}
+
+	if !b.ServerTime.IsZero() {
+		serverTime := b.ServerTime
+		e.ServerTime = &serverTime
+	}
+
+	return e
+}
+
+// AuditEntry records a command which was executed, and on whose behalf. See rcon.CommandTiming.
+type AuditEntry struct {
+	Command        string            `json:"command"`
+	Actor          string            `json:"actor,omitempty"`
+	At             time.Time         `json:"at"`
+	Duration       int64             `json:"duration_ns"`
+	Failed         bool              `json:"failed"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+func (AuditEntry) Kind() string {
+	return KindAudit
+}
+
+func (AuditEntry) Version() int {
+	return AuditVersion
+}
+
+// FromCommandTiming converts t.
+func FromCommandTiming(t rcon.CommandTiming) AuditEntry {
+	return AuditEntry{
+		Command:        t.Command,
+		Actor:          t.Actor,
+		At:             t.At,
+		Duration:       int64(t.Duration),
+		Failed:         t.Err,
+		IdempotencyKey: t.IdempotencyKey,
+		Labels:         t.Labels,
+	}
+}
+
+// StateChange is a change of a client's connection state, as reported to a rcon.StatusChangeHandler. Since the handler
+// isn't passed the client, Labels are left for the caller to set, usually to the client's Labels.
+type StateChange struct {
+	Old    string            `json:"old"`
+	New    string            `json:"new"`
+	Time   time.Time         `json:"time"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (StateChange) Kind() string {
+	return KindStateChange
+}
+
+func (StateChange) Version() int {
+	return StateChangeVersion
+}
+
+// FromStateChange converts a state change which happened at t.
+func FromStateChange(old, new rcon.State, t time.Time) StateChange {
+	return StateChange{
+		Old:  old.String(),
+		New:  new.String(),
+		Time: t,
+	}
+}
+
+// Stats is a snapshot of a client's command statistics. See rcon.Stats.
+type Stats struct {
+	InFlight         int64             `json:"in_flight"`
+	PeakInFlight     int64             `json:"peak_in_flight"`
+	Commands         uint64            `json:"commands"`
+	SlowCommands     uint64            `json:"slow_commands"`
+	LateResponses    uint64            `json:"late_responses"`
+	ExpiredMailboxes uint64            `json:"expired_mailboxes"`
+	Slowest          []AuditEntry      `json:"slowest,omitempty"`
+	Time             time.Time         `json:"time"`
+	Labels           map[string]string `json:"labels,omitempty"`
+}
+
+func (Stats) Kind() string {
+	return KindStats
+}
+
+func (Stats) Version() int {
+	return StatsVersion
+}
+
+// FromStats converts a snapshot taken at t.
+func FromStats(s rcon.Stats, t time.Time) Stats {
+	e := Stats{
+		InFlight:         s.InFlight,
+		PeakInFlight:     s.PeakInFlight,
+		Commands:         s.Commands,
+		SlowCommands:     s.SlowCommands,
+		LateResponses:    s.LateResponses,
+		ExpiredMailboxes: s.ExpiredMailboxes,
+		Time:             t,
+		Labels:           s.Labels,
+	}
+
+	for _, timing := range s.Slowest {
+		e.Slowest = append(e.Slowest, FromCommandTiming(timing))
+	}
+
+	return e
+}
+
+// Encode serializes e wrapped in its envelope.
+func Encode(e Event) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event: %w", err)
+	}
+
+	return json.Marshal(Envelope{
+		Kind:    e.Kind(),
+		Version: e.Version(),
+		Data:    data,
+	})
+}
+
+// Decode parses an encoded event. It returns a Broadcast, AuditEntry, StateChange or Stats, or ErrUnsupportedVersion
+// if the event's kind is unknown or its version is newer than this package supports. Events of older versions are
+// decoded into the current types.
+func Decode(b []byte) (Event, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return nil, fmt.Errorf("could not decode event envelope: %w", err)
+	}
+
+	var e Event
+	switch envelope.Kind {
+	case KindBroadcast:
+		e = &Broadcast{}
+	case KindAudit:
+		e = &AuditEntry{}
+	case KindStateChange:
+		e = &StateChange{}
+	case KindStats:
+		e = &Stats{}
+	default:
+		return nil, fmt.Errorf("kind %q: %w", envelope.Kind, ErrUnsupportedVersion)
+	}
+
+	if envelope.Version < 1 || envelope.Version > e.Version() {
+		return nil, fmt.Errorf("%s version %d: %w", envelope.Kind, envelope.Version, ErrUnsupportedVersion)
+	}
+
+	if err := json.Unmarshal(envelope.Data, e); err != nil {
+		return nil, fmt.Errorf("could not decode %s event: %w", envelope.Kind, err)
+	}
+
+	switch e := e.(type) {
+	case *Broadcast:
+		return *e, nil
+	case *AuditEntry:
+		return *e, nil
+	case *StateChange:
+		return *e, nil
+	case *Stats:
+		return *e, nil
+	}
+
+	return e, nil
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	at := time.Date(2021, 5, 4, 12, 0, 0, 0, time.UTC)
+
+	g.Describe("Encode()", func() {
+		g.It("Should produce the canonical format", func() {
+			b, err := Encode(FromCommandTiming(rcon.CommandTiming{
+				Command:  "kick Bob",
+				Actor:    "alice",
+				At:       at,
+				Duration: time.Millisecond,
+			}))
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(`{"kind":"audit","version":1,"data":{"command":"kick Bob","actor":"alice",` +
+				`"at":"2021-05-04T12:00:00Z","duration_ns":1000000,"failed":false}}`))
+		})
+	})
+
+	g.Describe("Decode()", func() {
+		g.It("Should decode encoded events", func() {
+			events := []Event{
+				FromBroadcast(rcon.Broadcast{Source: "rcon", Message: "hi", Channel: "chat", Time: at, ServerTime: at}),
+				FromStateChange(rcon.StateConnected, rcon.StateReconnecting, at),
+				FromStats(rcon.Stats{Commands: 3, Slowest: []rcon.CommandTiming{{Command: "a", At: at}}}, at),
+			}
+
+			for _, e := range events {
+				b, err := Encode(e)
+				Expect(err).To(BeNil())
+
+				decoded, err := Decode(b)
+				Expect(err).To(BeNil())
+				Expect(decoded).To(Equal(e))
+			}
+		})
+
+		g.It("Should reject newer versions and unknown kinds", func() {
+			_, err := Decode([]byte(`{"kind":"audit","version":2,"data":{}}`))
+			Expect(errors.Is(err, ErrUnsupportedVersion)).To(BeTrue())
+
+			_, err = Decode([]byte(`{"kind":"unknown","version":1,"data":{}}`))
+			Expect(errors.Is(err, ErrUnsupportedVersion)).To(BeTrue())
+		})
+	})
+}
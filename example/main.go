@@ -1,52 +1,66 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
 	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/shutdown"
+	"github.com/refractorgscm/rcon/smoke"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
 )
 
 func main() {
-	client := rcon.NewClient(&rcon.Config{
+	useDemo := flag.Bool("demo", false, "run against a built-in demo server instead of a real game server")
+	wait := flag.Duration("wait", 2*time.Second, "how long to listen for broadcasts")
+	verbose := flag.Bool("v", false, "log the client's debug output")
+	flag.Parse()
+
+	config := &rcon.Config{
 		Host:     "127.0.0.1",
 		Port:     7779,
 		Password: "RconPassword",
 		BroadcastHandler: func(msg string) {
 			fmt.Println("RECEIVED BROADCAST", msg)
 		},
-		RestrictedPacketIDs: presets.MordhauRestrictedPacketIDs,
-		BroadcastChecker:    presets.MordhauBroadcastChecker,
-		DisconnectHandler: func(err error, expected bool) {
-			if !expected {
-				log.Println("An unexpected disconnection has occurred. Error:", err)
-			} else {
-				log.Println("An expected disconnection has occurred.")
-			}
-		},
-	}, &presets.DebugLogger{})
+		Dialect:              presets.MordhauDialect,
+		ResponseErrorChecker: presets.MordhauResponseErrorChecker,
+	}
+
+	if *useDemo {
+		server := demo.NewServer(config.Password)
+
+		host, port, err := server.Start()
+		if err != nil {
+			log.Fatalf("Could not start demo server. Error: %v\n", err)
+		}
+		defer server.Close()
 
-	if err := client.Connect(); err != nil {
-		log.Fatalf("Could not connect. Error: %v\n", err)
+		config.Host = host
+		config.Port = port
 	}
 
-	res, err := client.ExecCommand("listen chat")
-	if err != nil {
-		log.Fatalf("Could not execute command. Error: %v\n", err)
+	var logger rcon.Logger
+	if *verbose {
+		logger = &presets.DebugLogger{}
+	}
+
+	// Connect, authenticate, execute an echo command and listen to chat for a while.
+	result := smoke.Run(smoke.Config{
+		Client:        config,
+		Logger:        logger,
+		Command:       "echo smoke",
+		Expect:        "smoke",
+		ListenCommand: "listen chat",
+		BroadcastWait: *wait,
+	})
+
+	_ = result.WriteJSON(os.Stdout)
+
+	if !result.OK {
+		os.Exit(shutdown.Classify(result.Err).ExitCode())
 	}
-	fmt.Println(res)
-
-	// Cleanup on CTRL+C
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGINT)
-	go func() {
-		<-c
-		log.Println("Shutting down...")
-		_ = client.Close()
-	}()
-
-	client.WaitGroup().Wait()
 }
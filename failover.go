@@ -0,0 +1,61 @@
+package rcon
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// EndpointHandler is called with the address of the endpoint the client connected to whenever it differs from the
+// previously active one, including on the first connect.
+type EndpointHandler func(endpoint string)
+
+// endpoints returns the addresses to connect to in failover order.
+func (c *Client) endpoints() []string {
+	return configEndpoints(c.Config)
+}
+
+// configEndpoints returns the addresses a client created from config connects to, in failover order.
+func configEndpoints(config *Config) []string {
+	if len(config.Hosts) == 0 {
+		return []string{fmt.Sprintf("%s:%d", config.Host, config.Port)}
+	}
+
+	addresses := make([]string, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(int(config.Port)))
+		}
+
+		addresses = append(addresses, host)
+	}
+
+	return addresses
+}
+
+// Endpoint returns the address of the endpoint the client is connected to, or last connected to if it is currently
+// disconnected.
+func (c *Client) Endpoint() string {
+	c.connStateLock.Lock()
+	defer c.connStateLock.Unlock()
+
+	return c.endpoint
+}
+
+// setEndpoint records endpoint as the active endpoint and notifies the EndpointHandler if it changed.
+func (c *Client) setEndpoint(endpoint string) {
+	c.connStateLock.Lock()
+	changed := c.endpoint != endpoint
+	c.endpoint = endpoint
+	c.connStateLock.Unlock()
+
+	if !changed {
+		return
+	}
+
+	c.log.Info("Active endpoint is ", endpoint)
+
+	if c.EndpointHandler != nil {
+		c.EndpointHandler(endpoint)
+	}
+}
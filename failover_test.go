@@ -0,0 +1,95 @@
+package rcon_test
+
+import (
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/rcontest"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	addr := func(server *rcontest.Server) string {
+		host, port := server.Addr()
+		return fmt.Sprintf("%s:%d", host, port)
+	}
+
+	// unreachable returns an address nothing listens on.
+	unreachable := func() string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		_ = listener.Close()
+
+		return listener.Addr().String()
+	}
+
+	g.Describe("Failover", func() {
+		var primary, secondary *rcontest.Server
+		var endpoints chan string
+
+		g.BeforeEach(func() {
+			primary = rcontest.StartServer(t, "password")
+			primary.SetResponse("whoami", "primary")
+			secondary = rcontest.StartServer(t, "password")
+			secondary.SetResponse("whoami", "secondary")
+
+			endpoints = make(chan string, 4)
+		})
+
+		connect := func(config *rcon.Config) *rcon.Client {
+			ch := endpoints
+			config.Password = "password"
+			config.ConnTimeout = time.Millisecond * 200
+			config.QueueReadTimeout = time.Millisecond * 200
+			config.EndpointHandler = func(endpoint string) { ch <- endpoint }
+
+			client := rcon.NewClient(config, nil)
+			t.Cleanup(func() { _ = client.Close() })
+			Expect(client.Connect()).To(BeNil())
+
+			return client
+		}
+
+		g.It("Should connect to the first reachable endpoint", func() {
+			client := connect(&rcon.Config{Hosts: []string{unreachable(), addr(primary), addr(secondary)}})
+
+			Expect(client.Endpoint()).To(Equal(addr(primary)))
+			Expect(endpoints).To(Receive(Equal(addr(primary))))
+			Expect(client.ExecCommand("whoami")).To(Equal("primary"))
+		})
+
+		g.It("Should fail over to the next endpoint when reconnecting", func() {
+			client := connect(&rcon.Config{
+				Hosts: []string{addr(primary), addr(secondary)},
+				Reconnect: rcon.ReconnectConfig{
+					Enabled: true,
+					Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+				},
+			})
+			Expect(endpoints).To(Receive(Equal(addr(primary))))
+
+			Expect(primary.Close()).To(BeNil())
+
+			Eventually(endpoints).Should(Receive(Equal(addr(secondary))))
+			Expect(client.Endpoint()).To(Equal(addr(secondary)))
+			Eventually(func() (string, error) {
+				return client.ExecCommand("whoami")
+			}).Should(Equal("secondary"))
+		})
+
+		g.It("Should use Port for hosts without a port", func() {
+			host, port := secondary.Addr()
+			client := connect(&rcon.Config{Hosts: []string{host}, Port: port})
+
+			Expect(client.Endpoint()).To(Equal(addr(secondary)))
+		})
+	})
+}
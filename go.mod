@@ -1,9 +1,8 @@
 module github.com/refractorgscm/rcon
 
-go 1.15
+go 1.16
 
 require (
 	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
 	github.com/onsi/gomega v1.16.0
-	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,53 @@
+package rcon
+
+import "sync"
+
+// commandGroup serializes the commands of a single concurrency group. refs counts the callers currently holding or
+// waiting for the group so that it can be removed once idle.
+type commandGroup struct {
+	sync.Mutex
+	refs int
+}
+
+func (c *Client) lockGroup(group string) {
+	c.groupsLock.Lock()
+	g, ok := c.groups[group]
+	if !ok {
+		g = &commandGroup{}
+		c.groups[group] = g
+	}
+	g.refs++
+	c.groupsLock.Unlock()
+
+	g.Lock()
+}
+
+func (c *Client) unlockGroup(group string) {
+	c.groupsLock.Lock()
+	g := c.groups[group]
+	g.refs--
+	if g.refs == 0 {
+		delete(c.groups, group)
+	}
+	c.groupsLock.Unlock()
+
+	g.Unlock()
+}
+
+// ExecCommandInGroup executes command as part of the named concurrency group. Commands in the same group are executed
+// one at a time in the order they acquire the group, while commands in different groups, or executed without a group,
+// run concurrently. This is useful for operations which must not interleave, such as map changes.
+func (c *Client) ExecCommandInGroup(group string, command string) (string, error) {
+	c.lockGroup(group)
+	defer c.unlockGroup(group)
+
+	return c.ExecCommand(command)
+}
+
+// ExecCommandNoResponseInGroup is the ExecCommandNoResponse equivalent of ExecCommandInGroup.
+func (c *Client) ExecCommandNoResponseInGroup(group string, command string) error {
+	c.lockGroup(group)
+	defer c.unlockGroup(group)
+
+	return c.ExecCommandNoResponse(command)
+}
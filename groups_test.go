@@ -0,0 +1,127 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroups(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Concurrency groups", func() {
+		var server *demo.Server
+		var client *rcon.Client
+		var held chan struct{}
+		var release chan struct{}
+
+		var lock sync.Mutex
+		var recorded []string
+
+		received := func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return append([]string(nil), recorded...)
+		}
+
+		g.BeforeEach(func() {
+			lock.Lock()
+			recorded = nil
+			lock.Unlock()
+
+			held = make(chan struct{}, 1)
+			release = make(chan struct{})
+			heldCh, releaseCh := held, release
+
+			server = demo.NewServer("password")
+			server.Handle("hold", func(string) string {
+				heldCh <- struct{}{}
+				<-releaseCh
+				return "released"
+			})
+			server.Handle("record", func(args string) string {
+				lock.Lock()
+				recorded = append(recorded, args)
+				lock.Unlock()
+				return args
+			})
+
+			host, port, err := server.Start()
+			Expect(err).To(BeNil())
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second * 2,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		// The demo server answers commands one at a time, so commands are recorded in the order they were sent once
+		// the held command is released.
+		g.It("Should hold back commands of the same group only", func() {
+			go func() { _, _ = client.ExecCommandInGroup("map", "hold") }()
+			Eventually(held).Should(Receive())
+
+			done := make(chan error, 3)
+			go func() {
+				_, err := client.ExecCommandInGroup("map", "record second")
+				done <- err
+			}()
+			time.Sleep(time.Millisecond * 50)
+
+			go func() {
+				_, err := client.ExecCommandInGroup("chat", "record other")
+				done <- err
+			}()
+			time.Sleep(time.Millisecond * 50)
+
+			go func() {
+				_, err := client.ExecCommand("record ungrouped")
+				done <- err
+			}()
+			time.Sleep(time.Millisecond * 50)
+
+			close(release)
+
+			for i := 0; i < 3; i++ {
+				Eventually(done).Should(Receive(BeNil()))
+			}
+			Expect(received()).To(Equal([]string{"other", "ungrouped", "second"}))
+		})
+
+		g.It("Should serialize commands executed without a response", func() {
+			go func() { _, _ = client.ExecCommandInGroup("map", "hold") }()
+			Eventually(held).Should(Receive())
+
+			done := make(chan error, 1)
+			go func() { done <- client.ExecCommandNoResponseInGroup("map", "record second") }()
+			time.Sleep(time.Millisecond * 50)
+
+			close(release)
+
+			res, err := client.ExecCommand("record other")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("other"))
+
+			Eventually(done).Should(Receive(BeNil()))
+			Eventually(received).Should(HaveLen(2))
+			Expect(strings.Join(received(), ",")).To(Equal("other,second"))
+		})
+	})
+}
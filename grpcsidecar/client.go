@@ -0,0 +1,130 @@
+package grpcsidecar
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/grpcsidecar/sidecarpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// Client executes commands through a connection owned by a sidecar Server. It implements rcon.Commander so it can be
+// used in place of an *rcon.Client.
+type Client struct {
+	name    string
+	conn    *grpc.ClientConn
+	sidecar sidecarpb.SidecarClient
+}
+
+var _ rcon.Commander = (*Client)(nil)
+var _ ContextCommander = (*Client)(nil)
+
+// Dial attaches to the sidecar listening on socketPath and returns a client which proxies commands to the connection
+// registered under name. The connection to the sidecar is established lazily, so Dial only fails for invalid
+// arguments.
+func Dial(socketPath, name string) (*Client, error) {
+	conn, err := grpc.Dial("unix:"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("could not dial sidecar: %w", err)
+	}
+
+	return &Client{
+		name:    name,
+		conn:    conn,
+		sidecar: sidecarpb.NewSidecarClient(conn),
+	}, nil
+}
+
+func (c *Client) ExecCommand(command string) (string, error) {
+	return c.ExecCommandContext(context.Background(), command)
+}
+
+// ExecCommandContext is like ExecCommand, but the call is bounded by ctx. Cancelling it also stops the sidecar from
+// waiting for the response, if the owned client is a ContextCommander.
+func (c *Client) ExecCommandContext(ctx context.Context, command string) (string, error) {
+	res, err := c.sidecar.Exec(ctx, &sidecarpb.ExecRequest{Client: c.name, Command: command})
+	if err != nil {
+		return "", fromStatus(err)
+	}
+
+	if res.ServerError != nil {
+		return "", &errs.ServerCommandError{
+			Command: res.ServerError.Command,
+			Message: res.ServerError.Message,
+		}
+	}
+
+	return res.Response, nil
+}
+
+func (c *Client) ExecCommandNoResponse(command string) error {
+	return c.ExecCommandNoResponseContext(context.Background(), command)
+}
+
+// ExecCommandNoResponseContext is like ExecCommandNoResponse, but the call is bounded by ctx.
+func (c *Client) ExecCommandNoResponseContext(ctx context.Context, command string) error {
+	_, err := c.sidecar.ExecNoResponse(ctx, &sidecarpb.ExecRequest{Client: c.name, Command: command})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	return nil
+}
+
+// Listen streams the broadcasts of the owned client and calls handler with each one until ctx is cancelled or the
+// stream fails. It returns nil if the sidecar stopped serving the client. Only broadcasts received after Listen was
+// called are delivered.
+func (c *Client) Listen(ctx context.Context, handler rcon.BroadcastHandler) error {
+	stream, err := c.sidecar.Subscribe(ctx, &sidecarpb.SubscribeRequest{Client: c.name})
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	for {
+		b, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+
+			return fromStatus(err)
+		}
+
+		handler(b.Message)
+	}
+}
+
+// Close detaches from the sidecar. The underlying RCON connection is left open.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// fromStatus converts a gRPC status error returned by the sidecar back to the matching error of the errs package.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("sidecar call failed: %w", err)
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return fmt.Errorf("%s: %w", st.Message(), errs.ErrUnknownClient)
+	case codes.Unavailable:
+		return fmt.Errorf("%s: %w", st.Message(), errs.ErrNotConnected)
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("%s: %w", st.Message(), errs.ErrReadTimeout)
+	case codes.Canceled:
+		return fmt.Errorf("%s: %w", st.Message(), context.Canceled)
+	}
+
+	return fmt.Errorf("sidecar call failed: %w", err)
+}
@@ -0,0 +1,14 @@
+module github.com/refractorgscm/rcon/grpcsidecar
+
+go 1.16
+
+require (
+	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
+	github.com/onsi/gomega v1.16.0
+	github.com/refractorgscm/rcon v0.0.0
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
+)
+
+// The sidecar is developed alongside the client in the same repository.
+replace github.com/refractorgscm/rcon => ../
@@ -0,0 +1,260 @@
+// Package grpcsidecar lets a single process own the physical RCON connections while other local processes attach to
+// them over gRPC on a Unix socket. This is useful for games which limit the number of concurrent RCON sessions, and for
+// fleets running several tools against the same servers. Processes written in any language can attach using stubs
+// generated from sidecarpb/sidecar.proto, and receive the broadcasts of clients which support subscriptions as a
+// stream:
+//
+//	server := grpcsidecar.NewServer()
+//	server.AddClient("eu-1", client)
+//	go server.ListenAndServe("/run/rcon.sock")
+//
+//	// In another process:
+//	client, err := grpcsidecar.Dial("/run/rcon.sock", "eu-1")
+//
+// It is a separate Go module, so that only programs using it depend on gRPC.
+package grpcsidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/grpcsidecar/sidecarpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"os"
+	"sync"
+)
+
+// Server owns a set of named RCON clients and serves them to attached processes.
+type Server struct {
+	grpc *grpc.Server
+
+	clientsLock sync.RWMutex
+	clients     map[string]*ownedClient
+}
+
+type ownedClient struct {
+	commander rcon.Commander
+
+	// removed is closed once the client is no longer served, which ends its broadcast streams.
+	removed chan struct{}
+}
+
+// NewServer creates a server. opts are passed to grpc.NewServer, for example to add interceptors.
+func NewServer(opts ...grpc.ServerOption) *Server {
+	s := &Server{
+		grpc:    grpc.NewServer(opts...),
+		clients: map[string]*ownedClient{},
+	}
+
+	sidecarpb.RegisterSidecarServer(s.grpc, &service{server: s})
+
+	return s
+}
+
+// Subscriber is implemented by clients whose broadcasts can be forwarded to attached processes, such as *rcon.Client.
+type Subscriber interface {
+	Subscribe(filter rcon.BroadcastFilter) (<-chan rcon.Broadcast, func())
+}
+
+// ContextCommander is implemented by clients which stop executing a command when the attached process cancels its
+// call or its deadline passes, such as *rcon.Client. Commands of other clients run to completion.
+type ContextCommander interface {
+	ExecCommandContext(ctx context.Context, command string) (string, error)
+	ExecCommandNoResponseContext(ctx context.Context, command string) error
+}
+
+var _ ContextCommander = (*rcon.Client)(nil)
+
+// AddClient makes client available to attached processes under name. If client implements Subscriber, attached
+// processes can subscribe to its broadcasts. If it implements ContextCommander, commands stop when the attached process
+// cancels its call.
+func (s *Server) AddClient(name string, client rcon.Commander) {
+	s.clientsLock.Lock()
+	prev := s.clients[name]
+	s.clients[name] = &ownedClient{
+		commander: client,
+		removed:   make(chan struct{}),
+	}
+	s.clientsLock.Unlock()
+
+	if prev != nil {
+		close(prev.removed)
+	}
+}
+
+// RemoveClient stops serving the client registered under name. Broadcast streams of the client are ended.
+func (s *Server) RemoveClient(name string) {
+	s.clientsLock.Lock()
+	owned := s.clients[name]
+	delete(s.clients, name)
+	s.clientsLock.Unlock()
+
+	if owned != nil {
+		close(owned.removed)
+	}
+}
+
+// exec executes command, bounded by ctx if the client supports it.
+func (c *ownedClient) exec(ctx context.Context, command string) (string, error) {
+	if commander, ok := c.commander.(ContextCommander); ok {
+		return commander.ExecCommandContext(ctx, command)
+	}
+
+	return c.commander.ExecCommand(command)
+}
+
+// execNoResponse executes command without a response, bounded by ctx if the client supports it.
+func (c *ownedClient) execNoResponse(ctx context.Context, command string) error {
+	if commander, ok := c.commander.(ContextCommander); ok {
+		return commander.ExecCommandNoResponseContext(ctx, command)
+	}
+
+	return c.commander.ExecCommandNoResponse(command)
+}
+
+func (s *Server) client(name string) (*ownedClient, error) {
+	s.clientsLock.RLock()
+	defer s.clientsLock.RUnlock()
+
+	client, ok := s.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("no client named %q: %w", name, errs.ErrUnknownClient)
+	}
+
+	return client, nil
+}
+
+// Serve accepts connections on listener and serves them until accepting fails or Stop is called. It returns the error
+// accepting failed with, or nil after Stop.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpc.Serve(listener)
+}
+
+// ListenAndServe listens on the Unix socket at socketPath and serves attached processes. Any stale socket file at
+// socketPath is removed first.
+func (s *Server) ListenAndServe(socketPath string) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("could not listen on socket: %w", err)
+	}
+	defer listener.Close()
+
+	return s.Serve(listener)
+}
+
+// Stop closes the listeners and all connections of attached processes. The owned clients are left connected.
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
+
+// service implements the gRPC API. It is kept separate from Server so that the RPC methods aren't part of Server's
+// API.
+type service struct {
+	sidecarpb.UnimplementedSidecarServer
+
+	server *Server
+}
+
+func (s *service) Exec(ctx context.Context, req *sidecarpb.ExecRequest) (*sidecarpb.ExecResponse, error) {
+	client, err := s.server.client(req.Client)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res, err := client.exec(ctx, req.Command)
+	if err != nil {
+		var serverErr *errs.ServerCommandError
+		if errors.As(err, &serverErr) {
+			return &sidecarpb.ExecResponse{
+				ServerError: &sidecarpb.ServerError{
+					Command: serverErr.Command,
+					Message: serverErr.Message,
+				},
+			}, nil
+		}
+
+		return nil, toStatus(err)
+	}
+
+	return &sidecarpb.ExecResponse{Response: res}, nil
+}
+
+func (s *service) ExecNoResponse(ctx context.Context, req *sidecarpb.ExecRequest) (*emptypb.Empty, error) {
+	client, err := s.server.client(req.Client)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := client.execNoResponse(ctx, req.Command); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) Subscribe(req *sidecarpb.SubscribeRequest, stream sidecarpb.Sidecar_SubscribeServer) error {
+	client, err := s.server.client(req.Client)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	subscriber, ok := client.commander.(Subscriber)
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition, "client %q does not support subscriptions", req.Client)
+	}
+
+	broadcasts, unsubscribe := subscriber.Subscribe(nil)
+	defer unsubscribe()
+
+	for {
+		select {
+		case b, ok := <-broadcasts:
+			if !ok {
+				return nil
+			}
+
+			err := stream.Send(&sidecarpb.Broadcast{
+				Message: b.Message,
+				Channel: b.Channel,
+				Time:    timestamppb.New(b.Time),
+			})
+			if err != nil {
+				return err
+			}
+		case <-client.removed:
+			return nil
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// toStatus converts err to a gRPC status error, so that attached processes can tell unknown clients and lost
+// connections apart from other failures.
+func toStatus(err error) error {
+	code := codes.Unknown
+
+	switch {
+	case errors.Is(err, errs.ErrUnknownClient):
+		code = codes.NotFound
+	case errors.Is(err, errs.ErrNotConnected), errors.Is(err, errs.ErrConnClosed):
+		code = codes.Unavailable
+	case errors.Is(err, errs.ErrReadTimeout), errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+
+	return status.Error(code, err.Error())
+}
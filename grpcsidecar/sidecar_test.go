@@ -0,0 +1,287 @@
+package grpcsidecar_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/grpcsidecar"
+	"github.com/refractorgscm/rcon/rcontest"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeCommander answers every command with the same response or error and doesn't support subscriptions.
+type fakeCommander struct {
+	res string
+	err error
+}
+
+func (c fakeCommander) ExecCommand(string) (string, error) {
+	return c.res, c.err
+}
+
+func (c fakeCommander) ExecCommandNoResponse(string) error {
+	return c.err
+}
+
+// blockingCommander never answers. Its commands wait until their context is done, then report the context's error on
+// done.
+type blockingCommander struct {
+	done chan error
+}
+
+func (c blockingCommander) ExecCommand(string) (string, error) {
+	return "", errors.New("executed without a context")
+}
+
+func (c blockingCommander) ExecCommandNoResponse(string) error {
+	return errors.New("executed without a context")
+}
+
+func (c blockingCommander) ExecCommandContext(ctx context.Context, _ string) (string, error) {
+	<-ctx.Done()
+	c.done <- ctx.Err()
+
+	return "", ctx.Err()
+}
+
+func (c blockingCommander) ExecCommandNoResponseContext(ctx context.Context, _ string) error {
+	<-ctx.Done()
+	c.done <- ctx.Err()
+
+	return ctx.Err()
+}
+
+func TestSidecar(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("gRPC sidecar", func() {
+		var server *rcontest.Server
+		var owner *rcon.Client
+		var sidecar *grpcsidecar.Server
+		var listener net.Listener
+		var socket string
+		var served chan error
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			owner = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Millisecond * 200,
+				BroadcastChecker: rcontest.BroadcastChecker,
+				ResponseErrorChecker: func(command, response string) bool {
+					return response == "denied"
+				},
+			}, nil)
+			Expect(owner.Connect()).To(BeNil())
+
+			sidecar = grpcsidecar.NewServer()
+			sidecar.AddClient("eu-1", owner)
+
+			socket = filepath.Join(t.TempDir(), "sidecar.sock")
+
+			var err error
+			listener, err = net.Listen("unix", socket)
+			Expect(err).To(BeNil())
+
+			served = make(chan error, 1)
+			go func(sidecar *grpcsidecar.Server, listener net.Listener, served chan error) {
+				served <- sidecar.Serve(listener)
+			}(sidecar, listener, served)
+		})
+
+		g.AfterEach(func() {
+			sidecar.Stop()
+			_ = owner.Close()
+			_ = server.Close()
+		})
+
+		attach := func(name string) *grpcsidecar.Client {
+			client, err := grpcsidecar.Dial(socket, name)
+			Expect(err).To(BeNil())
+
+			return client
+		}
+
+		// listen starts listening for broadcasts and returns the channels receiving them and the result of Listen.
+		listen := func(ctx context.Context, client *grpcsidecar.Client) (chan string, chan error) {
+			received := make(chan string, 16)
+			listened := make(chan error, 1)
+			go func() {
+				listened <- client.Listen(ctx, func(message string) { received <- message })
+			}()
+
+			// Give the stream time to subscribe before anything is broadcast.
+			time.Sleep(time.Millisecond * 100)
+
+			return received, listened
+		}
+
+		g.It("Should execute commands over the owned connection", func() {
+			server.Handle("echo", func(args string) string { return args })
+
+			client := attach("eu-1")
+			defer client.Close()
+
+			res, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hello"))
+
+			Expect(client.ExecCommandNoResponse("echo again")).To(BeNil())
+			Eventually(server.Commands).Should(ContainElement("echo again"))
+		})
+
+		g.It("Should reconstruct server command errors", func() {
+			server.SetResponse("ban", "denied")
+
+			client := attach("eu-1")
+			defer client.Close()
+
+			_, err := client.ExecCommand("ban")
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+			Expect(serverErr.Command).To(Equal("ban"))
+			Expect(serverErr.Message).To(Equal("denied"))
+		})
+
+		g.It("Should fail with ErrUnknownClient for unknown clients", func() {
+			client := attach("unknown")
+			defer client.Close()
+
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrUnknownClient)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("no client named")))
+
+			err = client.ExecCommandNoResponse("status")
+			Expect(errors.Is(err, errs.ErrUnknownClient)).To(BeTrue())
+		})
+
+		g.It("Should fail with ErrNotConnected if the owned client is disconnected", func() {
+			sidecar.AddClient("offline", fakeCommander{err: fmt.Errorf("client is closing: %w", errs.ErrNotConnected)})
+
+			client := attach("offline")
+			defer client.Close()
+
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("client is closing")))
+
+			err = client.ExecCommandNoResponse("status")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+		})
+
+		g.It("Should pass the deadline of calls to the owned client", func() {
+			done := make(chan error, 2)
+			sidecar.AddClient("blocking", blockingCommander{done: done})
+
+			client := attach("blocking")
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			_, err := client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Eventually(done).Should(Receive(Equal(context.DeadlineExceeded)))
+
+			ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			err = client.ExecCommandNoResponseContext(ctx, "status")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Eventually(done).Should(Receive(Equal(context.DeadlineExceeded)))
+		})
+
+		g.It("Should stop commands of calls which are cancelled", func() {
+			done := make(chan error, 1)
+			sidecar.AddClient("blocking", blockingCommander{done: done})
+
+			client := attach("blocking")
+			defer client.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				time.Sleep(time.Millisecond * 100)
+				cancel()
+			}()
+
+			_, err := client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Eventually(done).Should(Receive(Equal(context.Canceled)))
+		})
+
+		g.It("Should stream broadcasts to attached processes", func() {
+			client := attach("eu-1")
+			defer client.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			received, listened := listen(ctx, client)
+
+			server.Broadcast(rcontest.BroadcastID, "hello everyone")
+			server.Broadcast(rcontest.BroadcastID, "hello again")
+			Eventually(received).Should(Receive(Equal("hello everyone")))
+			Eventually(received).Should(Receive(Equal("hello again")))
+
+			cancel()
+			Eventually(listened).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		g.It("Should end broadcast streams of removed clients", func() {
+			client := attach("eu-1")
+			defer client.Close()
+
+			_, listened := listen(context.Background(), client)
+
+			sidecar.RemoveClient("eu-1")
+			Eventually(listened).Should(Receive(BeNil()))
+		})
+
+		g.It("Should refuse subscriptions to clients which don't support them", func() {
+			sidecar.AddClient("static", fakeCommander{res: "ok"})
+
+			client := attach("static")
+			defer client.Close()
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+
+			err = client.Listen(context.Background(), func(string) {})
+			Expect(err).To(MatchError(ContainSubstring("does not support subscriptions")))
+		})
+
+		g.It("Should return the listener error once the listener is closed", func() {
+			Expect(listener.Close()).To(BeNil())
+
+			Eventually(served).Should(Receive(Not(BeNil())))
+		})
+
+		g.It("Should return nil from Serve after Stop", func() {
+			// Make sure Serve is running before stopping it.
+			client := attach("eu-1")
+			defer client.Close()
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+
+			sidecar.Stop()
+
+			Eventually(served).Should(Receive(BeNil()))
+		})
+	})
+}
@@ -0,0 +1,5 @@
+// Package sidecarpb contains the protocol buffer messages and gRPC stubs of the sidecar API, generated from
+// sidecar.proto.
+package sidecarpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative sidecar.proto
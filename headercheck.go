@@ -0,0 +1,75 @@
+package rcon
+
+import (
+	"bufio"
+	"errors"
+	"github.com/refractorgscm/rcon/packet"
+)
+
+// BroadcastVerdict is the decision of a BroadcastHeaderChecker.
+type BroadcastVerdict int
+
+const (
+	// BroadcastMaybe defers the decision to the BroadcastChecker, which sees the whole packet.
+	BroadcastMaybe BroadcastVerdict = iota
+
+	// BroadcastNever treats the packet as a response without calling the BroadcastChecker.
+	BroadcastNever
+
+	// BroadcastAlways treats the packet as a broadcast without calling the BroadcastChecker.
+	BroadcastAlways
+
+	// BroadcastDrop discards the packet unread. Its body is never allocated, and it reaches neither the broadcast
+	// handlers nor the TeeHandler, PacketHooks or TraceWire.
+	BroadcastDrop
+)
+
+// BroadcastHeaderChecker is the first stage of broadcast detection. It only sees the header of a packet, before its
+// body is read, so that games sending many broadcasts can classify or drop most packets without allocating and
+// parsing their bodies. Only packets it returns BroadcastMaybe for are passed to the BroadcastChecker.
+type BroadcastHeaderChecker func(h packet.Header) BroadcastVerdict
+
+// isBroadcast reports whether p is a broadcast, consulting the dialect's BroadcastHeaderChecker before the
+// BroadcastChecker.
+func (c *Client) isBroadcast(p packet.Packet) bool {
+	if c.broadcastHeaderChecker != nil {
+		switch c.broadcastHeaderChecker(packet.Header{Size: p.Size(), ID: p.ID(), Type: p.Type()}) {
+		case BroadcastAlways:
+			return true
+		case BroadcastNever:
+			return false
+		}
+	}
+
+	return c.BroadcastChecker(p)
+}
+
+// skipDroppedPackets discards buffered packets the BroadcastHeaderChecker drops until the next packet is one which
+// must be decoded. Malformed headers are left to the decoder, so that they are handled like any other.
+func (c *Client) skipDroppedPackets(reader *bufio.Reader) error {
+	if c.broadcastHeaderChecker == nil {
+		return nil
+	}
+
+	for {
+		h, err := packet.PeekHeader(c.EndianMode, reader)
+		if err != nil {
+			if errors.Is(err, packet.ErrMalformedPacket) {
+				return nil
+			}
+
+			return err
+		}
+
+		// Oversized packets are left to the decoder, which rejects them.
+		if int(h.Size) > c.MaxPacketSize || c.broadcastHeaderChecker(h) != BroadcastDrop {
+			return nil
+		}
+
+		if err := packet.SkipPacket(reader, h); err != nil {
+			return err
+		}
+
+		c.log.Debug("Dropped broadcast ID: ", h.ID, ", Size: ", h.Size)
+	}
+}
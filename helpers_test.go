@@ -0,0 +1,61 @@
+package rcon_test
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer starts a mock server and points config at it. Host, port and password are filled in, and
+// QueueReadTimeout defaults to 200ms to keep timeout tests fast.
+func newTestServer(t *testing.T, config *rcon.Config) *rcontest.Server {
+	server := rcontest.StartServer(t, "password")
+
+	config.Host, config.Port = server.Addr()
+	config.Password = "password"
+	if config.QueueReadTimeout == 0 {
+		config.QueueReadTimeout = time.Millisecond * 200
+	}
+
+	return server
+}
+
+// newTestClient starts a mock server and creates a client for it from config like newTestServer. The client is closed
+// when the test completes.
+func newTestClient(t *testing.T, config *rcon.Config) (*rcontest.Server, *rcon.Client) {
+	server := newTestServer(t, config)
+
+	client := rcon.NewClient(config, nil)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return server, client
+}
+
+// recordingLogger is an rcon.Logger recording every entry as "LEVEL: message", so that tests can check what a client
+// logged.
+type recordingLogger struct {
+	lock    sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(level string, args ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.entries = append(l.entries, level+": "+fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Info(args ...interface{})  { l.record("INFO", args...) }
+func (l *recordingLogger) Error(args ...interface{}) { l.record("ERROR", args...) }
+func (l *recordingLogger) Debug(args ...interface{}) { l.record("DEBUG", args...) }
+
+// Entries returns the entries logged so far.
+func (l *recordingLogger) Entries() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]string(nil), l.entries...)
+}
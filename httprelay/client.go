@@ -0,0 +1,156 @@
+package httprelay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultRequestTimeout is the default timeout of exec requests.
+const DefaultRequestTimeout = time.Second * 10
+
+type Config struct {
+	// BaseURL is the URL the relay is served at, e.g. https://relay.example.com/rcon.
+	BaseURL string
+
+	// Name is the name the target RCON client is registered under on the relay.
+	Name string
+
+	// Token is the relay's bearer token.
+	Token string
+
+	// HTTPClient is the client used for requests. Its timeout must be longer than the relay's PollTimeout, otherwise
+	// polls are cut short.
+	//
+	// Responses compressed by the relay are decompressed transparently, unless the client's transport disables
+	// compression.
+	//
+	// Default: an http.Client without a timeout. Exec requests are bounded by DefaultRequestTimeout instead.
+	HTTPClient *http.Client
+}
+
+// Client executes commands through a Relay. It implements rcon.Commander so it can be used in place of an
+// *rcon.Client.
+type Client struct {
+	*Config
+}
+
+var _ rcon.Commander = (*Client)(nil)
+
+func NewClient(config *Config) *Client {
+	c := &Client{
+		Config: config,
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{}
+	}
+
+	return c
+}
+
+func (c *Client) endpoint(action string) string {
+	return fmt.Sprintf("%s/clients/%s/%s", c.BaseURL, url.PathEscape(c.Name), action)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("relay request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("relay rejected token: %w", errs.ErrAuthentication)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("could not decode relay response: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) exec(command string, noResponse bool) (string, error) {
+	body, err := json.Marshal(ExecRequest{Command: command, NoResponse: noResponse})
+	if err != nil {
+		return "", fmt.Errorf("could not encode exec request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint("exec"), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("could not create exec request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	var res ExecResponse
+	if err := c.do(req, &res); err != nil {
+		return "", err
+	}
+
+	if res.ServerError != nil {
+		return "", res.ServerError
+	}
+
+	if res.Error != "" {
+		return "", fmt.Errorf("relay returned error: %s", res.Error)
+	}
+
+	return res.Response, nil
+}
+
+func (c *Client) ExecCommand(command string) (string, error) {
+	return c.exec(command, false)
+}
+
+func (c *Client) ExecCommandNoResponse(command string) error {
+	_, err := c.exec(command, true)
+	return err
+}
+
+// Listen long-polls the relay for broadcasts and calls handler with each one until ctx is cancelled or a request
+// fails. Only broadcasts published after Listen was called are delivered.
+func (c *Client) Listen(ctx context.Context, handler rcon.BroadcastHandler) error {
+	endpoint := c.endpoint("broadcasts")
+
+	// The first poll only establishes the current position in the backlog.
+	query := ""
+
+	for {
+		req, err := http.NewRequest(http.MethodGet, endpoint+query, nil)
+		if err != nil {
+			return fmt.Errorf("could not create poll request: %w", err)
+		}
+		req = req.WithContext(ctx)
+
+		var res PollResponse
+		if err := c.do(req, &res); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		for _, b := range res.Broadcasts {
+			handler(b.Message)
+		}
+
+		query = "?after=" + strconv.FormatUint(res.Next, 10)
+	}
+}
@@ -0,0 +1,514 @@
+// Package httprelay lets admin tools which cannot open raw TCP connections to game hosts, for example behind corporate
+// proxies or on serverless platforms, execute commands over plain HTTPS.
+//
+// A Relay runs in a process which can reach the game servers and owns the RCON connections. Remote tools use a Client
+// which talks to the relay with ordinary HTTP requests. Broadcasts are delivered by long-polling, which passes through
+// proxies that buffer or terminate streaming responses. Polls on busy channels return broadcasts in batches, and
+// responses are compressed for clients which accept it, keeping web UIs on slow links responsive.
+//
+// The relay does not terminate TLS itself; serve it with http.ListenAndServeTLS or behind a TLS terminating proxy.
+package httprelay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPollTimeout is the default duration a broadcast poll is held open when no broadcasts are available.
+const DefaultPollTimeout = time.Second * 25
+
+// DefaultBacklog is the default number of broadcasts kept per client for pollers to catch up on.
+const DefaultBacklog = 256
+
+// DefaultMaxBatch is the default maximum number of broadcasts returned by a single poll.
+const DefaultMaxBatch = 100
+
+// DefaultCompressMinSize is the default size in bytes from which responses are compressed.
+const DefaultCompressMinSize = 1024
+
+// Encoding is a content coding responses can be compressed with.
+type Encoding struct {
+	// Name is the coding's name in the Accept-Encoding and Content-Encoding headers, such as "gzip".
+	Name string
+
+	// NewWriter returns a writer compressing into w. Closing it must flush the compressed data, but not close w.
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+// Gzip is the gzip content coding at the default compression level.
+var Gzip = Encoding{
+	Name: "gzip",
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+}
+
+// Stats are counters describing the traffic a relay served.
+type Stats struct {
+	// Polls is the number of broadcast polls answered.
+	Polls uint64
+
+	// Broadcasts is the number of broadcasts delivered to pollers.
+	Broadcasts uint64
+
+	// Responses is the number of responses written, of which Compressed were compressed.
+	Responses  uint64
+	Compressed uint64
+
+	// BytesEncoded is the size of all response bodies before compression, and BytesSent their size as sent.
+	BytesEncoded uint64
+	BytesSent    uint64
+}
+
+// ExecRequest is the body of an exec request.
+type ExecRequest struct {
+	Command    string `json:"command"`
+	NoResponse bool   `json:"no_response,omitempty"`
+}
+
+// ExecResponse is the body of an exec response. ServerError is set if the owning client returned an
+// *errs.ServerCommandError so that it can be reconstructed by the Client.
+type ExecResponse struct {
+	Response    string                   `json:"response"`
+	ServerError *errs.ServerCommandError `json:"server_error,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+}
+
+// Broadcast is a broadcast message together with its sequence number.
+type Broadcast struct {
+	Seq     uint64 `json:"seq"`
+	Message string `json:"message"`
+}
+
+// PollResponse is the body of a broadcast poll response. Next is the sequence number to pass as "after" in the next
+// poll.
+type PollResponse struct {
+	Broadcasts []Broadcast `json:"broadcasts"`
+	Next       uint64      `json:"next"`
+}
+
+// Relay serves a set of named RCON clients over HTTP. It implements http.Handler with the following routes:
+//
+//	POST /clients/{name}/exec                  executes an ExecRequest
+//	GET  /clients/{name}/broadcasts?after={n}  long-polls broadcasts with a sequence number greater than n
+//	GET  /clients/{name}/debug                 serves the client's rcon.DebugState, if Debug is enabled
+//
+// A poll without "after" returns immediately with the current sequence number, allowing pollers to start listening
+// without receiving the backlog.
+type Relay struct {
+	// Token is the bearer token clients must present. If empty, requests are not authenticated.
+	Token string
+
+	// PollTimeout is the duration a poll is held open when no broadcasts are available.
+	//
+	// Default: DefaultPollTimeout
+	PollTimeout time.Duration
+
+	// Backlog is the number of broadcasts kept per client.
+	//
+	// Default: DefaultBacklog
+	Backlog int
+
+	// MaxBatch is the maximum number of broadcasts returned by a single poll. Pollers receive the rest with their next
+	// poll.
+	//
+	// Default: DefaultMaxBatch
+	MaxBatch int
+
+	// BatchDelay is how long a waiting poll lingers once a broadcast arrived, so that the broadcasts of busy channels
+	// are delivered together instead of costing a poll each. Polls which find broadcasts waiting return right away.
+	//
+	// Default: 0
+	BatchDelay time.Duration
+
+	// Encodings are the content codings responses may be compressed with, in order of preference. Each response is
+	// compressed with the first coding the request's Accept-Encoding header allows. If empty, responses are not
+	// compressed.
+	//
+	// Default: Gzip
+	Encodings []Encoding
+
+	// CompressMinSize is the size in bytes from which responses are compressed. Smaller responses are sent as is,
+	// since compressing them costs more than it saves.
+	//
+	// Default: DefaultCompressMinSize
+	CompressMinSize int
+
+	// Debug enables the debug route, which serves the DebugDump of clients implementing Debugger, such as
+	// *rcon.Client. The dump redacts the password but exposes internals such as queued commands, so it should only be
+	// enabled on relays requiring a Token.
+	//
+	// Default: false
+	Debug bool
+
+	clientsLock sync.RWMutex
+	clients     map[string]*relayedClient
+
+	polls, broadcasts, responses, compressed, bytesEncoded, bytesSent uint64
+}
+
+// Debugger is implemented by clients which can dump their internals for troubleshooting, such as *rcon.Client.
+type Debugger interface {
+	DebugDump() rcon.DebugState
+}
+
+type relayedClient struct {
+	commander rcon.Commander
+
+	lock       sync.Mutex
+	broadcasts []Broadcast
+	seq        uint64
+	notify     chan struct{}
+}
+
+func NewRelay(token string) *Relay {
+	return &Relay{
+		Token:           token,
+		PollTimeout:     DefaultPollTimeout,
+		Backlog:         DefaultBacklog,
+		MaxBatch:        DefaultMaxBatch,
+		Encodings:       []Encoding{Gzip},
+		CompressMinSize: DefaultCompressMinSize,
+		clients:         map[string]*relayedClient{},
+	}
+}
+
+// AddClient makes client available under name.
+func (r *Relay) AddClient(name string, client rcon.Commander) {
+	r.clientsLock.Lock()
+	defer r.clientsLock.Unlock()
+
+	r.clients[name] = &relayedClient{
+		commander: client,
+		notify:    make(chan struct{}),
+	}
+}
+
+// RemoveClient stops serving the client registered under name.
+func (r *Relay) RemoveClient(name string) {
+	r.clientsLock.Lock()
+	defer r.clientsLock.Unlock()
+
+	delete(r.clients, name)
+}
+
+// BroadcastHandler returns a handler which publishes broadcasts to pollers of the client registered under name. Set it
+// as the BroadcastHandler of the owning *rcon.Client.
+func (r *Relay) BroadcastHandler(name string) rcon.BroadcastHandler {
+	return func(message string) {
+		r.Publish(name, message)
+	}
+}
+
+// Publish makes message available to pollers of the client registered under name.
+func (r *Relay) Publish(name string, message string) {
+	client, err := r.client(name)
+	if err != nil {
+		return
+	}
+
+	backlog := r.Backlog
+	if backlog <= 0 {
+		backlog = DefaultBacklog
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	client.seq++
+	client.broadcasts = append(client.broadcasts, Broadcast{Seq: client.seq, Message: message})
+	if len(client.broadcasts) > backlog {
+		client.broadcasts = client.broadcasts[len(client.broadcasts)-backlog:]
+	}
+
+	// Wake up all waiting pollers.
+	close(client.notify)
+	client.notify = make(chan struct{})
+}
+
+// Stats returns the relay's traffic counters.
+func (r *Relay) Stats() Stats {
+	return Stats{
+		Polls:        atomic.LoadUint64(&r.polls),
+		Broadcasts:   atomic.LoadUint64(&r.broadcasts),
+		Responses:    atomic.LoadUint64(&r.responses),
+		Compressed:   atomic.LoadUint64(&r.compressed),
+		BytesEncoded: atomic.LoadUint64(&r.bytesEncoded),
+		BytesSent:    atomic.LoadUint64(&r.bytesSent),
+	}
+}
+
+func (r *Relay) client(name string) (*relayedClient, error) {
+	r.clientsLock.RLock()
+	defer r.clientsLock.RUnlock()
+
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("no client named %q: %w", name, errs.ErrUnknownClient)
+	}
+
+	return client, nil
+}
+
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(req) {
+		r.writeJSON(w, req, http.StatusUnauthorized, ExecResponse{Error: "unauthorized"})
+		return
+	}
+
+	// Routes are /clients/{name}/{action}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "clients" {
+		r.writeJSON(w, req, http.StatusNotFound, ExecResponse{Error: "not found"})
+		return
+	}
+
+	client, err := r.client(parts[1])
+	if err != nil {
+		r.writeJSON(w, req, http.StatusNotFound, ExecResponse{Error: err.Error()})
+		return
+	}
+
+	switch {
+	case parts[2] == "exec" && req.Method == http.MethodPost:
+		r.serveExec(w, req, client)
+	case parts[2] == "broadcasts" && req.Method == http.MethodGet:
+		r.servePoll(w, req, client)
+	case parts[2] == "debug" && req.Method == http.MethodGet && r.Debug:
+		r.serveDebug(w, req, client)
+	default:
+		r.writeJSON(w, req, http.StatusNotFound, ExecResponse{Error: "not found"})
+	}
+}
+
+func (r *Relay) authorized(req *http.Request) bool {
+	if r.Token == "" {
+		return true
+	}
+
+	presented := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(r.Token)) == 1
+}
+
+func (r *Relay) serveExec(w http.ResponseWriter, req *http.Request, client *relayedClient) {
+	var body ExecRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		r.writeJSON(w, req, http.StatusBadRequest, ExecResponse{Error: "invalid request body"})
+		return
+	}
+
+	if body.NoResponse {
+		if err := client.commander.ExecCommandNoResponse(body.Command); err != nil {
+			r.writeJSON(w, req, http.StatusBadGateway, ExecResponse{Error: err.Error()})
+			return
+		}
+
+		r.writeJSON(w, req, http.StatusOK, ExecResponse{})
+		return
+	}
+
+	res, err := client.commander.ExecCommand(body.Command)
+	if err != nil {
+		var serverErr *errs.ServerCommandError
+		if errors.As(err, &serverErr) {
+			r.writeJSON(w, req, http.StatusOK, ExecResponse{ServerError: serverErr})
+			return
+		}
+
+		r.writeJSON(w, req, http.StatusBadGateway, ExecResponse{Error: err.Error()})
+		return
+	}
+
+	r.writeJSON(w, req, http.StatusOK, ExecResponse{Response: res})
+}
+
+func (r *Relay) serveDebug(w http.ResponseWriter, req *http.Request, client *relayedClient) {
+	debugger, ok := client.commander.(Debugger)
+	if !ok {
+		r.writeJSON(w, req, http.StatusNotFound, ExecResponse{Error: "client does not support debug dumps"})
+		return
+	}
+
+	r.writeJSON(w, req, http.StatusOK, debugger.DebugDump())
+}
+
+func (r *Relay) servePoll(w http.ResponseWriter, req *http.Request, client *relayedClient) {
+	afterParam := req.URL.Query().Get("after")
+	if afterParam == "" {
+		client.lock.Lock()
+		res := PollResponse{Next: client.seq}
+		client.lock.Unlock()
+
+		r.writePoll(w, req, res)
+		return
+	}
+
+	after, err := strconv.ParseUint(afterParam, 10, 64)
+	if err != nil {
+		r.writeJSON(w, req, http.StatusBadRequest, ExecResponse{Error: "invalid after parameter"})
+		return
+	}
+
+	timeout := r.PollTimeout
+	if timeout <= 0 {
+		timeout = DefaultPollTimeout
+	}
+	deadline := time.After(timeout)
+
+	waited := false
+
+	for {
+		res, notify := r.collect(client, after)
+
+		if len(res.Broadcasts) > 0 {
+			if waited && r.BatchDelay > 0 {
+				select {
+				case <-time.After(r.BatchDelay):
+				case <-deadline:
+				case <-req.Context().Done():
+					return
+				}
+
+				res, _ = r.collect(client, after)
+			}
+
+			r.writePoll(w, req, res)
+			return
+		}
+
+		select {
+		case <-notify:
+			waited = true
+		case <-deadline:
+			r.writePoll(w, req, res)
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// collect returns up to MaxBatch broadcasts of client with a sequence number greater than after, and a channel which is
+// closed once another broadcast was published.
+func (r *Relay) collect(client *relayedClient, after uint64) (PollResponse, chan struct{}) {
+	max := r.MaxBatch
+	if max <= 0 {
+		max = DefaultMaxBatch
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	res := PollResponse{Next: client.seq}
+	for _, b := range client.broadcasts {
+		if b.Seq <= after {
+			continue
+		}
+
+		if len(res.Broadcasts) == max {
+			// The poller continues after the last broadcast it received.
+			res.Next = res.Broadcasts[len(res.Broadcasts)-1].Seq
+			break
+		}
+
+		res.Broadcasts = append(res.Broadcasts, b)
+	}
+
+	return res, client.notify
+}
+
+func (r *Relay) writePoll(w http.ResponseWriter, req *http.Request, res PollResponse) {
+	atomic.AddUint64(&r.polls, 1)
+	atomic.AddUint64(&r.broadcasts, uint64(len(res.Broadcasts)))
+
+	r.writeJSON(w, req, http.StatusOK, res)
+}
+
+// encoding returns the first of the relay's Encodings the request accepts, or nil.
+func (r *Relay) encoding(req *http.Request) *Encoding {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+
+		accepted[name] = true
+		for _, param := range params[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+					accepted[name] = false
+				}
+			}
+		}
+	}
+
+	for i, e := range r.Encodings {
+		if ok, listed := accepted[e.Name]; ok || (!listed && accepted["*"]) {
+			return &r.Encodings[i]
+		}
+	}
+
+	return nil
+}
+
+// writeJSON writes v, compressed with an encoding the request accepts if it is at least CompressMinSize bytes long.
+func (r *Relay) writeJSON(w http.ResponseWriter, req *http.Request, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		body, status = []byte(`{"error":"could not encode response"}`), http.StatusInternalServerError
+	}
+	body = append(body, '\n')
+
+	atomic.AddUint64(&r.responses, 1)
+	atomic.AddUint64(&r.bytesEncoded, uint64(len(body)))
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(r.Encodings) > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if e := r.encoding(req); e != nil && len(body) >= r.CompressMinSize {
+		if compressed, err := compress(*e, body); err == nil {
+			w.Header().Set("Content-Encoding", e.Name)
+			body = compressed
+			atomic.AddUint64(&r.compressed, 1)
+		}
+	}
+
+	atomic.AddUint64(&r.bytesSent, uint64(len(body)))
+
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func compress(e Encoding, body []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+
+	writer, err := e.NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,215 @@
+package httprelay_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/httprelay"
+	"github.com/refractorgscm/rcon/rcontest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelay(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Relay", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+		var relay *httprelay.Relay
+		var web *httptest.Server
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "hunter2")
+			host, port := server.Addr()
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "hunter2",
+				QueueReadTimeout: time.Millisecond * 200,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			relay = httprelay.NewRelay("token")
+			relay.AddClient("eu-1", client)
+			web = httptest.NewServer(relay)
+		})
+
+		g.AfterEach(func() {
+			web.Close()
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		// raw doesn't decompress responses, unlike http.DefaultClient.
+		raw := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+		getEncoded := func(path string, acceptEncoding string) (*http.Response, []byte) {
+			req, err := http.NewRequest(http.MethodGet, web.URL+path, nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+
+			res, err := raw.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			return res, body
+		}
+
+		get := func(path string) (*http.Response, []byte) {
+			req, err := http.NewRequest(http.MethodGet, web.URL+path, nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("Authorization", "Bearer token")
+
+			res, err := http.DefaultClient.Do(req)
+			Expect(err).To(BeNil())
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			Expect(err).To(BeNil())
+
+			return res, body
+		}
+
+		publish := func(n int, size int) {
+			for i := 0; i < n; i++ {
+				relay.Publish("eu-1", strings.Repeat(strconv.Itoa(i%10), size))
+			}
+		}
+
+		g.It("Should compress large responses for clients accepting gzip", func() {
+			publish(10, 200)
+
+			res, body := getEncoded("/clients/eu-1/broadcasts?after=0", "deflate, gzip;q=0.5")
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(res.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+			reader, err := gzip.NewReader(bytes.NewReader(body))
+			Expect(err).To(BeNil())
+
+			var poll httprelay.PollResponse
+			Expect(json.NewDecoder(reader).Decode(&poll)).To(BeNil())
+			Expect(poll.Broadcasts).To(HaveLen(10))
+
+			stats := relay.Stats()
+			Expect(stats.Compressed).To(BeEquivalentTo(1))
+			Expect(stats.BytesSent).To(BeNumerically("<", stats.BytesEncoded))
+		})
+
+		g.It("Should not compress small responses or for clients not accepting it", func() {
+			publish(10, 200)
+
+			res, _ := getEncoded("/clients/eu-1/broadcasts?after=0", "gzip;q=0")
+			Expect(res.Header.Get("Content-Encoding")).To(BeEmpty())
+
+			res, _ = getEncoded("/clients/eu-1/broadcasts?after=9", "gzip")
+			Expect(res.Header.Get("Content-Encoding")).To(BeEmpty())
+
+			Expect(relay.Stats().Compressed).To(BeZero())
+		})
+
+		g.It("Should limit the broadcasts returned by a poll", func() {
+			relay.MaxBatch = 3
+			publish(5, 10)
+
+			var poll httprelay.PollResponse
+			_, body := get("/clients/eu-1/broadcasts?after=0")
+			Expect(json.Unmarshal(body, &poll)).To(BeNil())
+			Expect(poll.Broadcasts).To(HaveLen(3))
+			Expect(poll.Next).To(BeEquivalentTo(3))
+
+			_, body = get("/clients/eu-1/broadcasts?after=3")
+			Expect(json.Unmarshal(body, &poll)).To(BeNil())
+			Expect(poll.Broadcasts).To(HaveLen(2))
+			Expect(poll.Next).To(BeEquivalentTo(5))
+		})
+
+		g.It("Should batch broadcasts arriving while a poll waits", func() {
+			relay.BatchDelay = time.Millisecond * 200
+
+			polled := make(chan httprelay.PollResponse, 1)
+			go func() {
+				_, body := get("/clients/eu-1/broadcasts?after=0")
+
+				var poll httprelay.PollResponse
+				_ = json.Unmarshal(body, &poll)
+				polled <- poll
+			}()
+
+			time.Sleep(time.Millisecond * 50)
+			for i := 0; i < 3; i++ {
+				publish(1, 10)
+				time.Sleep(time.Millisecond * 10)
+			}
+
+			var poll httprelay.PollResponse
+			Eventually(polled, time.Second).Should(Receive(&poll))
+			Expect(poll.Broadcasts).To(HaveLen(3))
+			Expect(relay.Stats().Polls).To(BeEquivalentTo(1))
+		})
+
+		g.It("Should deliver compressed broadcasts to relay clients", func() {
+			relayClient := httprelay.NewClient(&httprelay.Config{BaseURL: web.URL, Name: "eu-1", Token: "token"})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			received := make(chan string, 64)
+			go func() {
+				_ = relayClient.Listen(ctx, func(message string) { received <- message })
+			}()
+
+			// Wait for the first poll, which only establishes the position, to complete.
+			Eventually(func() uint64 { return relay.Stats().Polls }).Should(BeEquivalentTo(1))
+
+			message := strings.Repeat("x", 4096)
+			relay.Publish("eu-1", message)
+
+			Eventually(received).Should(Receive(Equal(message)))
+			Expect(relay.Stats().Compressed).To(BeNumerically(">", 0))
+		})
+
+		g.It("Should not serve debug dumps unless enabled", func() {
+			res, _ := get("/clients/eu-1/debug")
+			Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		g.It("Should serve debug dumps without the password", func() {
+			relay.Debug = true
+
+			res, body := get("/clients/eu-1/debug")
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(body)).ToNot(ContainSubstring("hunter2"))
+
+			var state rcon.DebugState
+			Expect(json.Unmarshal(body, &state)).To(BeNil())
+			Expect(state.State).To(Equal(client.Status().String()))
+		})
+
+		g.It("Should require the token for debug dumps", func() {
+			relay.Debug = true
+
+			res, err := http.Get(web.URL + "/clients/eu-1/debug")
+			Expect(err).To(BeNil())
+			_ = res.Body.Close()
+			Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+	})
+}
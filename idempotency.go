@@ -0,0 +1,81 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// idempotentCall tracks an execution of ExecCommandIdempotent. done is closed once response and err are set.
+type idempotentCall struct {
+	done      chan struct{}
+	response  string
+	err       error
+	completed time.Time
+}
+
+type idempotencyKeyKey struct{}
+
+// IdempotencyKeyFromContext returns the idempotency key of a command executed with ExecCommandIdempotent.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return key, ok
+}
+
+// ExecCommandIdempotent executes command at most once per idempotency key within IdempotencyWindow. If a command with
+// the same key already completed, its recorded response is returned without executing command again. If one is still
+// in flight, the call waits for it and returns its result.
+//
+// This protects destructive commands such as bans from being executed twice when callers retry after a reconnect.
+// Failures after which the command may have reached the server, such as read timeouts or a connection lost after it
+// was written, are recorded like responses: executing the command again could execute it twice. Only failures matching
+// errs.ErrNotSent, errs.ErrQueueTimeout or errs.ErrQueueFull are forgotten, so the command can be retried with the
+// same key. For the same reason, Config.Retry only retries the command if it was not sent.
+//
+// The key is attached to ctx, so middleware and the recorded CommandTiming can see it. See IdempotencyKeyFromContext.
+func (c *Client) ExecCommandIdempotent(ctx context.Context, key string, command string) (string, error) {
+	c.idempotencyLock.Lock()
+	c.pruneIdempotencyKeys()
+
+	if call, ok := c.idempotencyKeys[key]; ok {
+		c.idempotencyLock.Unlock()
+
+		c.log.Debug("Idempotency key ", key, " was already used, not executing command again")
+
+		select {
+		case <-call.done:
+			return call.response, call.err
+		case <-ctx.Done():
+			return "", fmt.Errorf("cancelled while waiting for in-flight command: %w", ctx.Err())
+		}
+	}
+
+	call := &idempotentCall{done: make(chan struct{})}
+	c.idempotencyKeys[key] = call
+	c.idempotencyLock.Unlock()
+
+	res, err := c.ExecCommandContext(context.WithValue(ctx, idempotencyKeyKey{}, key), command)
+
+	c.idempotencyLock.Lock()
+	call.response, call.err = res, err
+	call.completed = c.Clock()
+
+	if err != nil && isUnsent(err) {
+		delete(c.idempotencyKeys, key)
+	}
+	c.idempotencyLock.Unlock()
+
+	close(call.done)
+
+	return res, err
+}
+
+// pruneIdempotencyKeys forgets completed calls older than IdempotencyWindow. It must be called with idempotencyLock
+// held.
+func (c *Client) pruneIdempotencyKeys() {
+	for key, call := range c.idempotencyKeys {
+		if !call.completed.IsZero() && c.Clock().Sub(call.completed) > c.IdempotencyWindow {
+			delete(c.idempotencyKeys, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ExecCommandIdempotent", func() {
+		ctx := context.Background()
+
+		g.It("Should not execute a command again after a timeout", func() {
+			server, client := newTestClient(t, &rcon.Config{Retry: rcon.RetryConfig{MaxAttempts: 3}})
+			server.SetDelay(time.Millisecond * 300)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+
+			_, again := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(again).To(Equal(err))
+
+			Consistently(server.Commands, time.Millisecond*400).Should(Equal([]string{"ban Bob"}))
+		})
+
+		g.It("Should not execute a command again after reconnecting", func() {
+			server, client := newTestClient(t, &rcon.Config{Reconnect: rcon.ReconnectConfig{
+				Enabled: true,
+				Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+			}})
+			server.DisconnectOn("ban Bob")
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(err).NotTo(BeNil())
+
+			Eventually(client.Status).Should(Equal(rcon.StateConnected))
+
+			_, again := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(again).To(Equal(err))
+			Expect(server.Commands()).To(Equal([]string{"ban Bob"}))
+		})
+
+		g.It("Should forget the key if the command was not sent", func() {
+			server, client := newTestClient(t, &rcon.Config{PausePolicy: rcon.PauseReject})
+			server.SetResponse("ban Bob", "Banned Bob")
+
+			Expect(client.Connect()).To(BeNil())
+
+			client.Pause()
+			_, err := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(errors.Is(err, errs.ErrPaused)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrNotSent)).To(BeTrue())
+
+			client.Resume()
+			res, err := client.ExecCommandIdempotent(ctx, "ban-bob", "ban Bob")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Banned Bob"))
+
+			Expect(client.Stats().Slowest[0].IdempotencyKey).To(Equal("ban-bob"))
+		})
+	})
+}
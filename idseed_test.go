@@ -0,0 +1,63 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"testing"
+)
+
+func TestIDSeed(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// sentIDs authenticates and executes commands with a client created from config, and returns the IDs of the
+	// packets it sent.
+	sentIDs := func(config *rcon.Config, commands int) []int32 {
+		var lock sync.Mutex
+		var ids []int32
+
+		config.PacketHooks.OnSend = func(p packet.Packet, _ []byte) {
+			lock.Lock()
+			ids = append(ids, p.ID())
+			lock.Unlock()
+		}
+
+		_, client := newTestClient(t, config)
+		Expect(client.Connect()).To(BeNil())
+
+		for i := 0; i < commands; i++ {
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		return append([]int32(nil), ids...)
+	}
+
+	g.Describe("IDSeed", func() {
+		g.It("Should start the packet IDs at 1 by default", func() {
+			Expect(sentIDs(&rcon.Config{}, 2)).To(Equal([]int32{1, 2, 3}))
+		})
+
+		g.It("Should start the packet IDs after the seed", func() {
+			Expect(sentIDs(&rcon.Config{IDSeed: 1000}, 2)).To(Equal([]int32{1001, 1002, 1003}))
+		})
+
+		g.It("Should skip restricted packet IDs", func() {
+			dialect := (&rcon.GameProfile{Name: "seeded", RestrictedPacketIDs: []int32{1001, 1003}}).Dialect()
+
+			Expect(sentIDs(&rcon.Config{IDSeed: 1000, Dialect: dialect}, 2)).To(Equal([]int32{1002, 1004, 1005}))
+		})
+
+		g.It("Should send the same IDs for clients with the same seed", func() {
+			Expect(sentIDs(&rcon.Config{IDSeed: 50}, 3)).To(Equal(sentIDs(&rcon.Config{IDSeed: 50}, 3)))
+		})
+	})
+}
@@ -0,0 +1,141 @@
+package rcon
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CommandWriter is an io.Writer which executes every line written to it as a command. It can be used to pipe scripts
+// into a server:
+//
+//	io.Copy(client.CommandWriter(), os.Stdin)
+type CommandWriter struct {
+	client *Client
+
+	lock sync.Mutex
+	buf  []byte
+}
+
+// CommandWriter returns a writer which executes each newline-terminated line written to it with ExecCommandNoResponse.
+// Trailing carriage returns are removed and empty lines are skipped.
+func (c *Client) CommandWriter() *CommandWriter {
+	return &CommandWriter{client: c}
+}
+
+// Write executes all complete lines in p. An incomplete final line is kept until a later write completes it or Flush is
+// called. If a command fails, the error is returned along with the number of bytes up to and including the failed
+// line. The bytes after it are discarded, so that they can be written again.
+func (w *CommandWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	buffered := len(w.buf)
+	w.buf = append(w.buf, p...)
+
+	consumed := 0
+	for {
+		idx := bytes.IndexByte(w.buf[consumed:], '\n')
+		if idx == -1 {
+			break
+		}
+
+		line := w.buf[consumed : consumed+idx]
+		consumed += idx + 1
+
+		if err := w.exec(string(line)); err != nil {
+			w.buf = nil
+
+			n := consumed - buffered
+			if n < 0 {
+				n = 0
+			}
+
+			return n, err
+		}
+	}
+
+	w.buf = w.buf[consumed:]
+
+	return len(p), nil
+}
+
+// Flush executes the incomplete final line, if any.
+func (w *CommandWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	line := string(w.buf)
+	w.buf = nil
+
+	return w.exec(line)
+}
+
+func (w *CommandWriter) exec(line string) error {
+	command := strings.TrimRight(line, "\r")
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+
+	return w.client.ExecCommandNoResponse(command)
+}
+
+// BroadcastReader is an io.ReadCloser streaming broadcasts as newline-delimited text.
+type BroadcastReader struct {
+	ch     <-chan Broadcast
+	cancel func()
+	buf    []byte
+	closed int32
+}
+
+// BroadcastReader returns a reader producing the message of every broadcast as a line. If channels are given, only
+// broadcasts the BroadcastChannel function classifies into one of them are included. Like any subscription, the
+// reader drops broadcasts if it isn't read fast enough. Close must be called once the reader is no longer needed.
+func (c *Client) BroadcastReader(channels ...string) *BroadcastReader {
+	var filter BroadcastFilter
+	if len(channels) > 0 {
+		filter = c.ChannelFilter(channels...)
+	}
+
+	ch, cancel := c.Subscribe(filter)
+
+	return &BroadcastReader{
+		ch:     ch,
+		cancel: cancel,
+	}
+}
+
+// Read blocks until a broadcast is available. A broadcast longer than p is returned over several reads. Read returns
+// io.EOF once the reader was closed, even if part of a broadcast is left.
+func (r *BroadcastReader) Read(p []byte) (int, error) {
+	if atomic.LoadInt32(&r.closed) == 1 {
+		return 0, io.EOF
+	}
+
+	if len(r.buf) == 0 {
+		// Broadcasts still buffered in the subscription are received after it was cancelled.
+		b, ok := <-r.ch
+		if !ok || atomic.LoadInt32(&r.closed) == 1 {
+			return 0, io.EOF
+		}
+
+		r.buf = []byte(b.Message)
+		if !strings.HasSuffix(b.Message, "\n") {
+			r.buf = append(r.buf, '\n')
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// Close ends the subscription. Pending and later reads return io.EOF.
+func (r *BroadcastReader) Close() error {
+	atomic.StoreInt32(&r.closed, 1)
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,158 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCommandWriter(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("CommandWriter", func() {
+		g.It("Should execute each complete line as a command", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			w := client.CommandWriter()
+
+			n, err := w.Write([]byte("say one\nsay tw"))
+			Expect(err).To(BeNil())
+			Expect(n).To(Equal(14))
+			Eventually(server.Commands).Should(Equal([]string{"say one"}))
+
+			_, err = w.Write([]byte("o\r\n\n  \r\nsay three\nsay fo"))
+			Expect(err).To(BeNil())
+			Eventually(server.Commands).Should(Equal([]string{"say one", "say two", "say three"}))
+
+			Expect(w.Flush()).To(BeNil())
+			Eventually(server.Commands).Should(Equal([]string{"say one", "say two", "say three", "say fo"}))
+
+			Expect(w.Flush()).To(BeNil())
+			Consistently(server.Commands).Should(HaveLen(4))
+		})
+
+		g.It("Should work with io.Copy", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := io.Copy(client.CommandWriter(), strings.NewReader("status\nplayers\n"))
+			Expect(err).To(BeNil())
+			Eventually(server.Commands).Should(Equal([]string{"status", "players"}))
+		})
+
+		g.It("Should report the bytes up to and including the failed line", func() {
+			server, client := newTestClient(t, &rcon.Config{MaxPacketSize: 32})
+			Expect(client.Connect()).To(BeNil())
+
+			w := client.CommandWriter()
+			_, err := w.Write([]byte("say "))
+			Expect(err).To(BeNil())
+
+			long := "say " + strings.Repeat("a", 32)
+			n, err := w.Write([]byte("one\n" + long + "\nsay three\n"))
+			Expect(errors.Is(err, errs.ErrCommandTooLarge)).To(BeTrue())
+			Expect(n).To(Equal(len("one\n" + long + "\n")))
+			Eventually(server.Commands).Should(Equal([]string{"say one"}))
+
+			// The lines after the failed one weren't written, so writing them again executes them once.
+			_, err = w.Write([]byte("say three\n"))
+			Expect(err).To(BeNil())
+			Expect(w.Flush()).To(BeNil())
+			Eventually(server.Commands).Should(Equal([]string{"say one", "say three"}))
+			Consistently(server.Commands).Should(HaveLen(2))
+		})
+	})
+}
+
+func TestBroadcastReader(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("BroadcastReader", func() {
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			client = rcon.NewClient(&rcon.Config{}, nil)
+		})
+
+		g.It("Should return broadcasts as lines across partial reads", func() {
+			r := client.BroadcastReader()
+			defer r.Close()
+
+			client.InjectBroadcast("test", "hello world")
+			client.InjectBroadcast("test", "second\n")
+
+			p := make([]byte, 4)
+			var read []string
+			for i := 0; i < 5; i++ {
+				n, err := r.Read(p)
+				Expect(err).To(BeNil())
+				read = append(read, string(p[:n]))
+			}
+
+			// A read never spans two broadcasts, and messages already ending in a newline don't get another one.
+			Expect(read).To(Equal([]string{"hell", "o wo", "rld\n", "seco", "nd\n"}))
+		})
+
+		g.It("Should only stream broadcasts of the given channels", func() {
+			client.BroadcastChannel = func(p packet.Packet) string {
+				return strings.SplitN(string(p.Body()), ":", 2)[0]
+			}
+
+			r := client.BroadcastReader("chat")
+			defer r.Close()
+
+			client.InjectBroadcast("test", "login: alice")
+			client.InjectBroadcast("test", "chat: hi")
+
+			p := make([]byte, 64)
+			n, err := r.Read(p)
+			Expect(err).To(BeNil())
+			Expect(string(p[:n])).To(Equal("chat: hi\n"))
+		})
+
+		g.It("Should return io.EOF after Close, even with broadcasts left", func() {
+			r := client.BroadcastReader()
+
+			client.InjectBroadcast("test", "hello world")
+			client.InjectBroadcast("test", "unread")
+
+			p := make([]byte, 4)
+			_, err := r.Read(p)
+			Expect(err).To(BeNil())
+
+			Expect(r.Close()).To(BeNil())
+			Expect(r.Close()).To(BeNil())
+
+			n, err := r.Read(p)
+			Expect(n).To(Equal(0))
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		g.It("Should unblock a pending read on Close", func() {
+			r := client.BroadcastReader()
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := r.Read(make([]byte, 16))
+				done <- err
+			}()
+
+			Consistently(done).ShouldNot(Receive())
+			Expect(r.Close()).To(BeNil())
+			Eventually(done).Should(Receive(Equal(io.EOF)))
+		})
+	})
+}
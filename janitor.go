@@ -0,0 +1,38 @@
+package rcon
+
+import (
+	"time"
+)
+
+// startJanitor periodically expires mailboxes which were open for longer than MailboxTTL. A command waiting for a
+// response always removes its mailbox once it gives up, so a mailbox outliving MailboxTTL belongs to a caller which
+// went away without collecting its response.
+func (c *Client) startJanitor(terminate chan uint8) {
+	defer func() {
+		c.wgLock.Lock()
+		c.waitGroup.Done()
+		c.wgLock.Unlock()
+		c.log.Debug("Janitor routine terminated")
+	}()
+
+	ticker := time.NewTicker(c.MailboxTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-terminate:
+			return
+		}
+
+		now := time.Now()
+		expired := c.mailboxes.expire(now.Add(-c.MailboxTTL), now.Add(c.LateResponseGrace))
+		if len(expired) == 0 {
+			continue
+		}
+
+		c.stats.expiredMailboxes(len(expired))
+		globalStats.expiredMailboxes(len(expired))
+		c.log.Info("Expired ", len(expired), " stale mailbox(es) whose response was never collected: ", expired)
+	}
+}
@@ -0,0 +1,49 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMailboxTTL(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("MailboxTTL", func() {
+		g.It("Should fail commands whose mailbox expired and count their response as late", func() {
+			var unhandled int32
+
+			server, client := newTestClient(t, &rcon.Config{
+				QueueReadTimeout:  time.Second * 2,
+				MailboxTTL:        time.Millisecond * 100,
+				LateResponseGrace: time.Second * 2,
+				UnhandledPacketHandler: func(packet.Packet, []byte, string) {
+					atomic.AddInt32(&unhandled, 1)
+				},
+			})
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 400)
+				return "done"
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			start := time.Now()
+			_, err := client.ExecCommand("slow")
+			Expect(errors.Is(err, errs.ErrMailboxClosed)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*400))
+			Expect(client.Stats().ExpiredMailboxes).To(BeEquivalentTo(1))
+
+			Eventually(func() uint64 { return client.Stats().LateResponses }).Should(BeEquivalentTo(1))
+			Expect(atomic.LoadInt32(&unhandled)).To(BeZero())
+		})
+	})
+}
@@ -0,0 +1,129 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultKeepAliveInterval is the keepalive interval used for dialects which require keepalive when no interval was
+// configured.
+const DefaultKeepAliveInterval = time.Second * 30
+
+type KeepAliveConfig struct {
+	// Interval is the time between pings. Zero disables keepalive, unless the dialect requires it, in which case
+	// DefaultKeepAliveInterval is used.
+	Interval time.Duration
+
+	// Command is the command sent as a ping, for example "alive" on Mordhau servers. If empty, an empty response value
+	// packet is sent instead, which Source servers echo back.
+	Command string
+
+	// MaxMissed is the number of consecutive pings which may go unanswered before the connection is considered dead.
+	// A dead connection takes the same path as one closed by the server: the client reconnects if enabled, otherwise
+	// the DisconnectHandler is called with errs.ErrKeepAliveTimeout.
+	//
+	// Default: 2
+	MaxMissed int
+}
+
+func (c *Client) keepAliveEnabled() bool {
+	return c.KeepAlive.Interval > 0 && !c.ConnectionPerCommand
+}
+
+func (c *Client) startKeepAlive(terminate chan uint8) {
+	defer func() {
+		c.wgLock.Lock()
+		c.waitGroup.Done()
+		c.wgLock.Unlock()
+		c.log.Debug("Keepalive routine terminated")
+	}()
+
+	if c.capabilityDegraded(CapabilityKeepAlive) {
+		return
+	}
+
+	ticker := time.NewTicker(c.KeepAlive.Interval)
+	defer ticker.Stop()
+
+	missed := 0
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-terminate:
+			return
+		}
+
+		// Pings would queue up behind the pause gate and be counted as missed.
+		if c.Paused() {
+			continue
+		}
+
+		read := atomic.LoadUint64(&c.bytesRead)
+
+		err := c.ping(terminate)
+		if err == nil {
+			missed = 0
+			c.capabilitySucceeded(CapabilityKeepAlive)
+			continue
+		}
+
+		if errors.Is(err, errs.ErrMailboxClosed) || errors.Is(err, context.Canceled) {
+			continue
+		}
+
+		// The server sent other packets while the ping went unanswered, so the connection is alive and the server
+		// ignores pings.
+		if errors.Is(err, errs.ErrReadTimeout) && atomic.LoadUint64(&c.bytesRead) != read {
+			missed = 0
+			if c.capabilityFailed(CapabilityKeepAlive, "pings went unanswered while other packets were received") {
+				return
+			}
+
+			continue
+		}
+
+		missed++
+		c.log.Debug("Keepalive ping missed (", missed, "/", c.KeepAlive.MaxMissed, "). Error: ", err)
+
+		if missed >= c.KeepAlive.MaxMissed {
+			c.log.Error("Server stopped answering keepalive pings, dropping connection")
+			c.connectionLost(terminate, fmt.Errorf("%d pings missed: %w", missed, errs.ErrKeepAliveTimeout))
+			return
+		}
+	}
+}
+
+// ping sends a keepalive ping and waits for its response. It is cancelled if terminate is closed.
+func (c *Client) ping(terminate chan uint8) error {
+	ctx, cancel := context.WithCancel(WithPriority(context.Background()))
+	defer cancel()
+
+	go func() {
+		select {
+		case <-terminate:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var p packet.Packet
+	if c.KeepAlive.Command == "" {
+		p = c.newClientPacket(packet.TypeCommandRes, "")
+	} else {
+		p = c.newClientPacket(packet.TypeCommand, c.KeepAlive.Command)
+	}
+
+	if err := c.enqueuePacket(ctx, p, 1); err != nil {
+		return err
+	}
+
+	_, err := c.getResponse(ctx, p.ID())
+
+	return err
+}
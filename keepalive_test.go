@@ -0,0 +1,109 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+	"time"
+)
+
+func TestKeepAlive(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Keepalive", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+		var disconnects chan error
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+			disconnects = make(chan error, 4)
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Millisecond * 100,
+				KeepAlive: rcon.KeepAliveConfig{
+					Interval: time.Millisecond * 50,
+				},
+				DisconnectHandler: func(err error, expected bool) {
+					disconnects <- err
+				},
+			}
+		})
+
+		g.It("Should keep the connection open while pings are answered", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			time.Sleep(time.Millisecond * 300)
+			Expect(disconnects).To(BeEmpty())
+
+			// Empty response value pings are echoed, never executed.
+			Expect(server.Commands()).To(BeEmpty())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+		})
+
+		g.It("Should send the configured command as a ping", func() {
+			config.KeepAlive.Command = "alive"
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			Eventually(server.Commands, time.Second).Should(ContainElements("alive", "alive"))
+			Expect(disconnects).To(BeEmpty())
+		})
+
+		g.It("Should drop the connection once MaxMissed pings went unanswered", func() {
+			config.KeepAlive.Command = "alive"
+			config.KeepAlive.MaxMissed = 2
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			server.SetDelay(time.Second)
+
+			var err error
+			Eventually(disconnects, time.Second).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrKeepAliveTimeout)).To(BeTrue())
+		})
+
+		g.It("Should reconnect instead of disconnecting if reconnection is enabled", func() {
+			config.KeepAlive.Command = "alive"
+			config.Reconnect = rcon.ReconnectConfig{
+				Enabled: true,
+				Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+			}
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			server.SetDelay(time.Second)
+			time.Sleep(time.Millisecond * 400)
+			server.SetDelay(0)
+
+			Eventually(func() int {
+				_, ok := server.AuthAttempts()
+				return ok
+			}, time.Second*3).Should(BeNumerically(">=", 2))
+
+			Eventually(func() error {
+				_, err := client.ExecCommand("status")
+				return err
+			}, time.Second*3).Should(BeNil())
+			Expect(disconnects).To(BeEmpty())
+		})
+	})
+}
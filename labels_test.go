@@ -0,0 +1,72 @@
+package rcon_test
+
+import (
+	"bytes"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/events"
+	"github.com/refractorgscm/rcon/metrics"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	labels := map[string]string{"tenant": "acme", "server": "eu-1"}
+
+	g.Describe("Labels", func() {
+		g.It("Should be attached to recorded commands, stats and broadcasts", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Labels:           labels,
+				BroadcastChecker: rcontest.BroadcastChecker,
+			})
+
+			broadcasts, unsubscribe := client.Subscribe(nil)
+			defer unsubscribe()
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+
+			stats := client.Stats()
+			Expect(stats.Labels).To(Equal(labels))
+			Expect(stats.Slowest).To(HaveLen(1))
+			Expect(events.FromCommandTiming(stats.Slowest[0]).Labels).To(Equal(labels))
+			Expect(events.FromStats(stats, stats.Slowest[0].At).Labels).To(Equal(labels))
+
+			server.Broadcast(rcontest.BroadcastID, "hello")
+
+			var b rcon.Broadcast
+			Eventually(broadcasts).Should(Receive(&b))
+			Expect(events.FromBroadcast(b).Labels).To(Equal(labels))
+		})
+
+		g.It("Should be passed to labeled metrics", func() {
+			collector := metrics.NewCollector(map[string]string{"server": "collector"})
+			_, client := newTestClient(t, &rcon.Config{Labels: labels, Metrics: collector})
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+
+			out := &bytes.Buffer{}
+			Expect(metrics.WriteText(out, collector)).To(BeNil())
+			Expect(out.String()).To(ContainSubstring(`rcon_commands_sent_total{server="collector",tenant="acme"} 1`))
+		})
+
+		g.It("Should be copied from the config", func() {
+			config := &rcon.Config{Labels: map[string]string{"tenant": "acme"}}
+			_, client := newTestClient(t, config)
+
+			config.Labels["tenant"] = "changed"
+			Expect(client.Labels()).To(Equal(map[string]string{"tenant": "acme"}))
+		})
+	})
+}
@@ -0,0 +1,181 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"sort"
+	"sync"
+)
+
+// listens tracks the broadcast channels the connection is subscribed to on the server using the dialect's
+// ListenCommand. Each channel is counted once, however many Listen calls share it.
+type listens struct {
+	lock     sync.Mutex
+	channels map[string]int
+
+	// released is closed and replaced whenever a channel is released, waking queued Listen calls.
+	released chan struct{}
+}
+
+// acquire reserves a slot for channel, waiting while max channels are active. It returns true if channel wasn't
+// active yet, in which case the caller must run the listen command.
+func (l *listens) acquire(ctx context.Context, c *Client, channel string, max int) (bool, error) {
+	warned := false
+
+	for {
+		l.lock.Lock()
+		if l.channels == nil {
+			l.channels = map[string]int{}
+			l.released = make(chan struct{})
+		}
+
+		if l.channels[channel] > 0 || max <= 0 || len(l.channels) < max {
+			l.channels[channel]++
+			first := l.channels[channel] == 1
+			l.lock.Unlock()
+
+			return first, nil
+		}
+
+		released := l.released
+		l.lock.Unlock()
+
+		if !warned {
+			c.log.Info("Listening to ", channel, " is queued since the server supports at most ", max,
+				" broadcast channels at once")
+			warned = true
+		}
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return false, fmt.Errorf("cancelled while waiting for a broadcast channel: %w", ctx.Err())
+		}
+	}
+}
+
+// release frees a slot of channel. It returns true if channel is no longer active, in which case the caller should
+// run the stop listen command.
+func (l *listens) release(channel string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.channels[channel]--; l.channels[channel] > 0 {
+		return false
+	}
+
+	delete(l.channels, channel)
+	close(l.released)
+	l.released = make(chan struct{})
+
+	return true
+}
+
+// active returns the active channels in alphabetical order.
+func (l *listens) active() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	channels := make([]string, 0, len(l.channels))
+	for channel := range l.channels {
+		channels = append(channels, channel)
+	}
+
+	sort.Strings(channels)
+
+	return channels
+}
+
+// Listen subscribes the connection to a broadcast channel on the server using the dialect's ListenCommand, and returns
+// a subscription receiving the channel's broadcasts as classified by the BroadcastChannel function. If none is
+// configured, the subscription receives all broadcasts. Listening to a channel more than once only runs the command
+// once.
+//
+// If the dialect declares MaxListens and that many channels are active, Listen waits until one is released or ctx is
+// done. The returned function cancels the subscription and, once no subscription uses the channel anymore, releases it
+// using the dialect's StopListenCommand. Active channels are listened to again after the client reconnected.
+//
+// If the server keeps leaving the listen command unanswered, CapabilityListen is degraded and Listen fails with
+// errs.ErrListenUnsupported.
+func (c *Client) Listen(ctx context.Context, channel string) (<-chan Broadcast, func(), error) {
+	f := c.Features()
+	if f.ListenCommand == "" {
+		return nil, nil, fmt.Errorf("dialect declares no listen command: %w", errs.ErrListenUnsupported)
+	}
+
+	if c.capabilityDegraded(CapabilityListen) {
+		return nil, nil, fmt.Errorf("server does not answer the listen command: %w", errs.ErrListenUnsupported)
+	}
+
+	first, err := c.listens.acquire(ctx, c, channel, f.MaxListens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if first {
+		if err := c.listen(ctx, f.ListenCommand, channel); err != nil {
+			c.listens.release(channel)
+			return nil, nil, fmt.Errorf("could not listen to %s: %w", channel, err)
+		}
+	}
+
+	var filter BroadcastFilter
+	if c.BroadcastChannel != nil {
+		filter = c.ChannelFilter(channel)
+	}
+
+	ch, unsubscribe := c.Subscribe(filter)
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			unsubscribe()
+
+			if !c.listens.release(channel) || f.StopListenCommand == "" {
+				return
+			}
+
+			if _, err := c.ExecCommand(fmt.Sprintf(f.StopListenCommand, channel)); err != nil {
+				c.log.Debug("Could not stop listening to ", channel, ". Error: ", err)
+			}
+		})
+	}
+
+	return ch, cancel, nil
+}
+
+// listen runs the listen command for channel and records whether the server answered it. Only response timeouts count
+// as failures of CapabilityListen, since other errors don't show that the server ignores the command.
+func (c *Client) listen(ctx context.Context, command string, channel string) error {
+	_, err := c.ExecCommandContext(ctx, fmt.Sprintf(command, channel))
+
+	var timeout *errs.ResponseTimeoutError
+	switch {
+	case err == nil:
+		c.capabilitySucceeded(CapabilityListen)
+	case errors.As(err, &timeout):
+		c.capabilityFailed(CapabilityListen, "other commands are answered but the listen command is not")
+	}
+
+	return err
+}
+
+// restoreListens listens to the active channels again on a new connection.
+func (c *Client) restoreListens() {
+	command := c.Features().ListenCommand
+	if command == "" {
+		return
+	}
+
+	for _, channel := range c.listens.active() {
+		if c.capabilityDegraded(CapabilityListen) {
+			return
+		}
+
+		if err := c.listen(context.Background(), command, channel); err != nil {
+			c.log.Error("Could not listen to ", channel, " again after reconnecting. Error: ", err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/presets"
+	"testing"
+	"time"
+)
+
+func TestListen(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	dialect := presets.NewDialect("listen", rcon.Features{
+		Broadcasts:    true,
+		ListenCommand: "listen %s",
+	})
+
+	g.Describe("Listen", func() {
+		g.It("Should degrade when the server ignores the listen command", func() {
+			events := make(chan rcon.CapabilityEvent, 1)
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect:      dialect,
+				DegradeAfter: 2,
+				CapabilityHandler: func(event rcon.CapabilityEvent) {
+					events <- event
+				},
+			})
+			server.Handle("listen", func(string) string {
+				time.Sleep(time.Millisecond * 300)
+				return ""
+			})
+			server.Handle("ping", func(string) string { return "pong" })
+
+			Expect(client.Connect()).To(BeNil())
+
+			for i := 0; i < 2; i++ {
+				_, _, err := client.Listen(context.Background(), "chat")
+
+				var timeout *errs.ResponseTimeoutError
+				Expect(errors.As(err, &timeout)).To(BeTrue())
+			}
+
+			Eventually(events).Should(Receive(WithTransform(func(e rcon.CapabilityEvent) rcon.Capability {
+				return e.Capability
+			}, Equal(rcon.CapabilityListen))))
+			Expect(client.DegradedCapabilities()).To(Equal([]rcon.Capability{rcon.CapabilityListen}))
+
+			_, _, err := client.Listen(context.Background(), "chat")
+			Expect(errors.Is(err, errs.ErrListenUnsupported)).To(BeTrue())
+
+			// The server answers other commands once it is done ignoring the listen commands.
+			Eventually(func() (string, error) {
+				return client.ExecCommand("ping")
+			}, time.Second).Should(Equal("pong"))
+		})
+
+		g.It("Should not degrade when the server rejects the listen command", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect:      dialect,
+				DegradeAfter: 1,
+				ResponseErrorChecker: func(command, response string) bool {
+					return response == "denied"
+				},
+			})
+			server.Handle("listen", func(string) string { return "denied" })
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, _, err := client.Listen(context.Background(), "chat")
+			Expect(err).ToNot(BeNil())
+			Expect(client.DegradedCapabilities()).To(BeEmpty())
+		})
+	})
+}
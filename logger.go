@@ -1,13 +1,73 @@
 package rcon
 
+import (
+	"sort"
+	"strings"
+)
+
 type Logger interface {
 	Info(args ...interface{})
 	Error(args ...interface{})
 	Debug(args ...interface{})
 }
 
+// TraceLogger is implemented by loggers which support a level below Debug. Very verbose output, such as the wire
+// dumps of TraceWire, is logged at Trace level if the Logger implements it.
+type TraceLogger interface {
+	Trace(args ...interface{})
+}
+
+// traceLog logs args at Trace level, falling back to Debug if logger doesn't implement TraceLogger.
+func traceLog(logger Logger, args ...interface{}) {
+	if t, ok := logger.(TraceLogger); ok {
+		t.Trace(args...)
+		return
+	}
+
+	logger.Debug(args...)
+}
+
 type DefaultLogger struct{}
 
 func (l *DefaultLogger) Info(...interface{})  {}
 func (l *DefaultLogger) Error(...interface{}) {}
 func (l *DefaultLogger) Debug(...interface{}) {}
+
+// labeledLogger wraps a Logger and appends the client's labels to every log entry.
+type labeledLogger struct {
+	Logger
+	suffix string
+}
+
+func newLabeledLogger(logger Logger, labels map[string]string) Logger {
+	if len(labels) == 0 {
+		return logger
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + labels[k]
+	}
+
+	return &labeledLogger{
+		Logger: logger,
+		suffix: " [" + strings.Join(pairs, " ") + "]",
+	}
+}
+
+// labeled returns a copy of args with the suffix appended. args is copied since callers passing a slice with spare
+// capacity would otherwise have the suffix written into their backing array.
+func (l *labeledLogger) labeled(args []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(args)+1), args...), l.suffix)
+}
+
+func (l *labeledLogger) Info(args ...interface{})  { l.Logger.Info(l.labeled(args)...) }
+func (l *labeledLogger) Error(args ...interface{}) { l.Logger.Error(l.labeled(args)...) }
+func (l *labeledLogger) Debug(args ...interface{}) { l.Logger.Debug(l.labeled(args)...) }
+func (l *labeledLogger) Trace(args ...interface{}) { traceLog(l.Logger, l.labeled(args)...) }
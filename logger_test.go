@@ -0,0 +1,43 @@
+package rcon
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+// argsLogger records the arguments of every Info call.
+type argsLogger struct {
+	DefaultLogger
+	calls [][]interface{}
+}
+
+func (l *argsLogger) Info(args ...interface{}) { l.calls = append(l.calls, args) }
+
+func TestLabeledLogger(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("labeledLogger", func() {
+		g.It("Should append the labels to every entry", func() {
+			inner := &argsLogger{}
+			newLabeledLogger(inner, map[string]string{"server": "eu-1"}).Info("Connected to ", "eu")
+
+			Expect(inner.calls).To(Equal([][]interface{}{{"Connected to ", "eu", " [server=eu-1]"}}))
+		})
+
+		g.It("Should not write into the backing array of the caller's arguments", func() {
+			inner := &argsLogger{}
+			logger := newLabeledLogger(inner, map[string]string{"server": "eu-1"})
+
+			args := make([]interface{}, 1, 2)
+			args[0] = "first"
+			logger.Info(args...)
+
+			Expect(args[:2][1]).To(BeNil())
+			Expect(inner.calls[0]).To(HaveLen(2))
+		})
+	})
+}
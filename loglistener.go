@@ -0,0 +1,213 @@
+package rcon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BroadcastSourceLog is the source tag of broadcasts received by a LogListener.
+const BroadcastSourceLog = "logaddress"
+
+// logTimeLayout is the layout of the timestamp starting every Source engine log line.
+const logTimeLayout = "01/02/2006 - 15:04:05"
+
+// logMaxDatagram is the largest log datagram the listener accepts.
+const logMaxDatagram = 65535
+
+// LogLine is a parsed Source engine log line.
+type LogLine struct {
+	// Time is the time the line was logged at, on the server's clock.
+	Time time.Time
+
+	// Message is the line without its timestamp, for example `"Player<2><STEAM_1:0:1><CT>" say "hi"`.
+	Message string
+}
+
+// ParseLogLine parses a Source engine log line of the form `L 10/16/2026 - 19:20:08: message`. The timestamp carries
+// no time zone and is interpreted in loc. It returns false if line is not a log line.
+func ParseLogLine(line string, loc *time.Location) (LogLine, bool) {
+	line = strings.TrimRight(line, "\x00\r\n")
+
+	if !strings.HasPrefix(line, "L ") || len(line) < 2+len(logTimeLayout)+1 {
+		return LogLine{}, false
+	}
+
+	stamp := line[2 : 2+len(logTimeLayout)]
+	rest := line[2+len(logTimeLayout):]
+	if !strings.HasPrefix(rest, ":") {
+		return LogLine{}, false
+	}
+
+	t, err := time.ParseInLocation(logTimeLayout, stamp, loc)
+	if err != nil {
+		return LogLine{}, false
+	}
+
+	return LogLine{
+		Time:    t,
+		Message: strings.TrimPrefix(rest[1:], " "),
+	}, true
+}
+
+// LogListener receives the logs Source engine servers send over UDP to the addresses registered with logaddress_add.
+// Many Source games report kills and chat only through their logs, not over RCON. Parsed log lines are delivered to
+// the client's broadcast handler and subscribers with the source BroadcastSourceLog, and with their ServerTime taken
+// from the log line.
+type LogListener struct {
+	// Addr is the local UDP address to listen on, for example ":27500".
+	Addr string
+
+	// PublicAddr is the address the server sends its logs to, as registered with logaddress_add. It must be set if
+	// Addr has no host or binds to all interfaces, such as ":27500" or "0.0.0.0:27500", since the server can't send
+	// logs to a wildcard address. Start fails with errs.ErrNoPublicAddr otherwise.
+	//
+	// Default: the address the listener is bound to, if it is not a wildcard address
+	PublicAddr string
+
+	// Secret is the server's sv_logsecret, which must be set if the server has one. Datagrams which don't carry it are
+	// ignored. Since anyone can send datagrams to the listener, setting a secret is recommended.
+	//
+	// Default: ""
+	Secret string
+
+	// Location is the time zone of the server's log timestamps.
+	//
+	// Default: time.Local
+	Location *time.Location
+
+	// Handler, if set, is called with every parsed log line in addition to it being delivered as a broadcast.
+	Handler func(LogLine)
+
+	client *Client
+
+	lock sync.Mutex
+	conn net.PacketConn
+	done chan struct{}
+}
+
+// NewLogListener creates a LogListener delivering the logs of the server client is connected to, listening on addr.
+func NewLogListener(client *Client, addr string) *LogListener {
+	return &LogListener{
+		Addr:     addr,
+		Location: time.Local,
+		client:   client,
+	}
+}
+
+// Start binds the listener and registers it with the server using logaddress_add.
+func (l *LogListener) Start() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.conn != nil {
+		return errors.New("log listener is already running")
+	}
+
+	conn, err := net.ListenPacket("udp", l.Addr)
+	if err != nil {
+		return fmt.Errorf("could not listen for logs: %w", err)
+	}
+
+	if l.PublicAddr == "" {
+		if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.IsUnspecified() {
+			_ = conn.Close()
+			return fmt.Errorf("listener is bound to wildcard address %s, set PublicAddr: %w", addr,
+				errs.ErrNoPublicAddr)
+		}
+
+		l.PublicAddr = conn.LocalAddr().String()
+	}
+
+	if _, err := l.client.ExecCommand("logaddress_add " + l.PublicAddr); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("could not register log address: %w", err)
+	}
+
+	l.conn = conn
+	l.done = make(chan struct{})
+
+	go l.read(conn, l.done)
+
+	return nil
+}
+
+// Close unregisters the listener using logaddress_del and stops listening.
+func (l *LogListener) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.client.ExecCommand("logaddress_del " + l.PublicAddr)
+
+	_ = l.conn.Close()
+	<-l.done
+	l.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("could not unregister log address: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LogListener) read(conn net.PacketConn, done chan struct{}) {
+	defer close(done)
+
+	buf := make([]byte, logMaxDatagram)
+
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		line, ok := l.parse(buf[:n])
+		if !ok {
+			continue
+		}
+
+		if l.Handler != nil {
+			l.Handler(line)
+		}
+
+		l.client.deliverBroadcast(Broadcast{
+			Source:     BroadcastSourceLog,
+			Message:    line.Message,
+			ServerTime: line.Time,
+		})
+	}
+}
+
+// parse parses a log datagram. Datagrams start with four 0xff bytes followed by 'R' and the log line, or, if the
+// server has a log secret, by 'S', the secret and the log line.
+func (l *LogListener) parse(datagram []byte) (LogLine, bool) {
+	if !bytes.HasPrefix(datagram, []byte{0xff, 0xff, 0xff, 0xff}) || len(datagram) < 5 {
+		return LogLine{}, false
+	}
+
+	kind, rest := datagram[4], datagram[5:]
+
+	switch {
+	case kind == 'R' && l.Secret == "":
+	case kind == 'S' && bytes.HasPrefix(rest, []byte(l.Secret)) && l.Secret != "":
+		rest = rest[len(l.Secret):]
+	default:
+		return LogLine{}, false
+	}
+
+	loc := l.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return ParseLogLine(string(rest), loc)
+}
@@ -0,0 +1,68 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLogListener(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("LogListener", func() {
+		g.It("Should require a public address when bound to all interfaces", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			listener := rcon.NewLogListener(client, ":0")
+
+			err := listener.Start()
+			Expect(errors.Is(err, errs.ErrNoPublicAddr)).To(BeTrue())
+			Expect(server.Commands()).To(BeEmpty())
+
+			listener.PublicAddr = "203.0.113.5:27500"
+			Expect(listener.Start()).To(BeNil())
+			Expect(listener.Close()).To(BeNil())
+			Expect(server.Commands()).To(Equal([]string{
+				"logaddress_add 203.0.113.5:27500",
+				"logaddress_del 203.0.113.5:27500",
+			}))
+		})
+
+		g.It("Should register a specific bound address and deliver log lines", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			Expect(client.Connect()).To(BeNil())
+
+			broadcasts, unsubscribe := client.Subscribe(nil)
+			defer unsubscribe()
+
+			listener := rcon.NewLogListener(client, "127.0.0.1:0")
+			listener.Location = time.UTC
+			Expect(listener.Start()).To(BeNil())
+			defer listener.Close()
+
+			Expect(server.Commands()).To(Equal([]string{"logaddress_add " + listener.PublicAddr}))
+
+			conn, err := net.Dial("udp", listener.PublicAddr)
+			Expect(err).To(BeNil())
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("\xff\xff\xff\xffRL 10/16/2026 - 19:20:08: \"Bob<2><STEAM_1:0:1><CT>\" say \"hi\"\n\x00"))
+			Expect(err).To(BeNil())
+
+			var b rcon.Broadcast
+			Eventually(broadcasts).Should(Receive(&b))
+			Expect(b.Source).To(Equal(rcon.BroadcastSourceLog))
+			Expect(b.Message).To(Equal(`"Bob<2><STEAM_1:0:1><CT>" say "hi"`))
+			Expect(b.ServerTime).To(Equal(time.Date(2026, 10, 16, 19, 20, 8, 0, time.UTC)))
+		})
+	})
+}
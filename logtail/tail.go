@@ -0,0 +1,183 @@
+// Package logtail tails game server log files and turns parsed lines into broadcast events. It covers games whose
+// RCON implementation doesn't push events, but whose logs do.
+//
+// Local files are supported out of the box. Remote files, for example over SFTP, can be tailed by implementing Source;
+// the File type returned by most SFTP clients already satisfies File.
+package logtail
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is the default interval at which a Tailer checks for new lines.
+const DefaultPollInterval = time.Second
+
+// File is a readable, seekable log file.
+type File interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Source opens the log file to tail.
+type Source interface {
+	Open() (File, error)
+}
+
+// LocalFile is a Source for a file on the local filesystem.
+type LocalFile string
+
+func (f LocalFile) Open() (File, error) {
+	return os.Open(string(f))
+}
+
+// Parser extracts a message from a log line. It returns false if the line is not an event of interest.
+type Parser func(line string) (message string, ok bool)
+
+// Event is a message parsed from a log line.
+type Event struct {
+	// Source is the tag of the Tailer which produced the event.
+	Source  string
+	Message string
+	Time    time.Time
+}
+
+type Tailer struct {
+	Source Source
+
+	// Parser extracts events from lines. If nil, every non-empty line is an event.
+	Parser Parser
+
+	// Handler is called with every parsed event.
+	Handler func(Event)
+
+	// Tag identifies this tailer as the source of its events, e.g. "log".
+	Tag string
+
+	// FromStart makes the tailer process the existing content of the file instead of starting at its end.
+	FromStart bool
+
+	// PollInterval is how often the file is checked for new lines.
+	//
+	// Default: DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// BroadcastTo returns a handler which injects events into client as broadcasts, tagged with their source.
+func BroadcastTo(client *rcon.Client) func(Event) {
+	return func(e Event) {
+		client.InjectBroadcast(e.Source, e.Message)
+	}
+}
+
+// Run tails the file until ctx is cancelled. If the file is truncated, for example by log rotation, tailing restarts
+// from its beginning.
+func (t *Tailer) Run(ctx context.Context) error {
+	f, err := t.Source.Open()
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	defer f.Close()
+
+	if !t.FromStart {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			return fmt.Errorf("could not seek to end of log file: %w", err)
+		}
+	}
+
+	interval := t.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	reader := bufio.NewReader(f)
+	partial := ""
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			t.emit(partial + line)
+			partial = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return fmt.Errorf("could not read log file: %w", err)
+		}
+
+		// Keep incomplete lines until the rest has been written.
+		partial += line
+
+		truncated, err := isTruncated(f)
+		if err != nil {
+			return err
+		}
+
+		if truncated {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("could not seek to start of truncated log file: %w", err)
+			}
+			reader.Reset(f)
+			partial = ""
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// isTruncated reports whether f is shorter than the current read position. The read position is left unchanged.
+func isTruncated(f File) (bool, error) {
+	cur, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, fmt.Errorf("could not get log file position: %w", err)
+	}
+
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, fmt.Errorf("could not get log file size: %w", err)
+	}
+
+	if end < cur {
+		return true, nil
+	}
+
+	if _, err := f.Seek(cur, io.SeekStart); err != nil {
+		return false, fmt.Errorf("could not restore log file position: %w", err)
+	}
+
+	return false, nil
+}
+
+func (t *Tailer) emit(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return
+	}
+
+	message := line
+	if t.Parser != nil {
+		var ok bool
+		if message, ok = t.Parser(line); !ok {
+			return
+		}
+	}
+
+	if t.Handler != nil {
+		t.Handler(Event{
+			Source:  t.Tag,
+			Message: message,
+			Time:    time.Now(),
+		})
+	}
+}
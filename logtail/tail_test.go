@@ -0,0 +1,162 @@
+package logtail_test
+
+import (
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/logtail"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailer(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Tailer", func() {
+		var dir string
+		var path string
+		var events chan logtail.Event
+		var tailer *logtail.Tailer
+		var cancel context.CancelFunc
+		var done chan error
+
+		g.BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "logtail")
+			Expect(err).To(BeNil())
+
+			path = filepath.Join(dir, "server.log")
+			Expect(ioutil.WriteFile(path, []byte("old line\n"), 0644)).To(BeNil())
+
+			events = make(chan logtail.Event, 16)
+			tailer = &logtail.Tailer{
+				Source: logtail.LocalFile(path),
+				Handler: func(e logtail.Event) {
+					events <- e
+				},
+				Tag:          "log",
+				PollInterval: time.Millisecond * 10,
+			}
+		})
+
+		g.AfterEach(func() {
+			if cancel != nil {
+				cancel()
+				Eventually(done, time.Second).Should(Receive())
+				cancel = nil
+			}
+			_ = os.RemoveAll(dir)
+		})
+
+		start := func() {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan error, 1)
+			go func() {
+				done <- tailer.Run(ctx)
+			}()
+
+			// Give the tailer time to open the file and seek to its end.
+			time.Sleep(time.Millisecond * 50)
+		}
+
+		appendLog := func(content string) {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			Expect(err).To(BeNil())
+			_, err = f.WriteString(content)
+			Expect(err).To(BeNil())
+			Expect(f.Close()).To(BeNil())
+		}
+
+		next := func() string {
+			var e logtail.Event
+			Eventually(events, time.Second).Should(Receive(&e))
+			return e.Message
+		}
+
+		g.It("Should only emit lines written after it started", func() {
+			start()
+			appendLog("first\nsecond\n")
+
+			Expect(next()).To(Equal("first"))
+			Expect(next()).To(Equal("second"))
+			Consistently(events, time.Millisecond*50).ShouldNot(Receive())
+		})
+
+		g.It("Should emit existing lines with FromStart", func() {
+			tailer.FromStart = true
+			start()
+
+			var e logtail.Event
+			Eventually(events, time.Second).Should(Receive(&e))
+			Expect(e.Message).To(Equal("old line"))
+			Expect(e.Source).To(Equal("log"))
+			Expect(e.Time).NotTo(BeZero())
+		})
+
+		g.It("Should wait for incomplete lines to be finished", func() {
+			start()
+			appendLog("player joi")
+			Consistently(events, time.Millisecond*50).ShouldNot(Receive())
+
+			appendLog("ned\r\n")
+			Expect(next()).To(Equal("player joined"))
+		})
+
+		g.It("Should only emit lines accepted by the parser", func() {
+			tailer.Parser = func(line string) (string, bool) {
+				if !strings.HasPrefix(line, "CHAT: ") {
+					return "", false
+				}
+				return strings.TrimPrefix(line, "CHAT: "), true
+			}
+			start()
+
+			appendLog("noise\nCHAT: hello\n\n")
+			Expect(next()).To(Equal("hello"))
+			Consistently(events, time.Millisecond*50).ShouldNot(Receive())
+		})
+
+		g.It("Should restart from the beginning of a truncated file", func() {
+			start()
+			appendLog("a line long enough to be truncated\n")
+			Expect(next()).To(Equal("a line long enough to be truncated"))
+
+			Expect(ioutil.WriteFile(path, []byte("new\n"), 0644)).To(BeNil())
+			Expect(next()).To(Equal("new"))
+		})
+
+		g.It("Should return the context error once cancelled", func() {
+			start()
+			cancel()
+
+			Eventually(done, time.Second).Should(Receive(Equal(context.Canceled)))
+			cancel = nil
+		})
+
+		g.It("Should fail if the file can't be opened", func() {
+			tailer.Source = logtail.LocalFile(filepath.Join(dir, "missing.log"))
+			Expect(tailer.Run(context.Background())).NotTo(BeNil())
+		})
+
+		g.It("Should inject events into a client as broadcasts", func() {
+			broadcasts := make(chan string, 1)
+			client := rcon.NewClient(&rcon.Config{
+				BroadcastHandler: func(message string) {
+					broadcasts <- message
+				},
+			}, nil)
+
+			logtail.BroadcastTo(client)(logtail.Event{Source: "log", Message: "hello"})
+			Expect(broadcasts).To(Receive(Equal("hello")))
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"regexp"
+	"strconv"
+)
+
+var macroPlaceholder = regexp.MustCompile(`\{(\d+)\}`)
+
+// DefineMacro registers a named sequence of commands. Commands may contain positional placeholders ({0}, {1}, ...)
+// which are substituted with the arguments passed to ExecMacro. Defining a macro with an existing name replaces it.
+func (c *Client) DefineMacro(name string, commands []string) {
+	c.macroLock.Lock()
+	defer c.macroLock.Unlock()
+
+	cmds := make([]string, len(commands))
+	copy(cmds, commands)
+
+	c.macros[name] = cmds
+}
+
+// RemoveMacro removes a previously defined macro. It is a no-op if no macro with the given name exists.
+func (c *Client) RemoveMacro(name string) {
+	c.macroLock.Lock()
+	defer c.macroLock.Unlock()
+
+	delete(c.macros, name)
+}
+
+// ExpandMacro returns the commands of the named macro with all placeholders substituted, without executing them.
+func (c *Client) ExpandMacro(name string, args ...string) ([]string, error) {
+	c.macroLock.RLock()
+	commands, ok := c.macros[name]
+	c.macroLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("macro %q is not defined: %w", name, errs.ErrUnknownMacro)
+	}
+
+	expanded := make([]string, 0, len(commands))
+
+	for _, command := range commands {
+		var argErr error
+
+		out := macroPlaceholder.ReplaceAllStringFunc(command, func(match string) string {
+			idx, err := strconv.Atoi(match[1 : len(match)-1])
+			if err != nil || idx >= len(args) {
+				argErr = fmt.Errorf("macro %q references %s but only %d arguments were given: %w",
+					name, match, len(args), errs.ErrMacroArguments)
+				return match
+			}
+
+			return args[idx]
+		})
+
+		if argErr != nil {
+			return nil, argErr
+		}
+
+		expanded = append(expanded, out)
+	}
+
+	return expanded, nil
+}
+
+// ExecMacro expands the named macro and executes the resulting commands as a batch using ExecCommands, so they are
+// pipelined where the connection allows it. The responses of all commands are returned in order. Every command is
+// executed even if an earlier one fails; the first error is returned along with all responses, the responses of failed
+// commands being empty.
+func (c *Client) ExecMacro(name string, args ...string) ([]string, error) {
+	commands, err := c.ExpandMacro(name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := c.ExecCommands(commands)
+	if err != nil {
+		return responses, fmt.Errorf("macro %q failed: %w", name, err)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,117 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Macros", func() {
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			client = rcon.NewClient(&rcon.Config{}, nil)
+		})
+
+		g.It("Should substitute positional placeholders", func() {
+			client.DefineMacro("kickban", []string{"kick {0} {1}", "ban {0} {1}", "say {0} was banned"})
+
+			commands, err := client.ExpandMacro("kickban", "Griefer", "spawn killing")
+			Expect(err).To(BeNil())
+			Expect(commands).To(Equal([]string{
+				"kick Griefer spawn killing",
+				"ban Griefer spawn killing",
+				"say Griefer was banned",
+			}))
+		})
+
+		g.It("Should allow placeholders to be reordered and repeated", func() {
+			client.DefineMacro("swap", []string{"{1} {0} {1}"})
+
+			commands, err := client.ExpandMacro("swap", "a", "b")
+			Expect(err).To(BeNil())
+			Expect(commands).To(Equal([]string{"b a b"}))
+		})
+
+		g.It("Should return ErrMacroArguments if an argument is missing", func() {
+			client.DefineMacro("kick", []string{"kick {0} {1}"})
+
+			commands, err := client.ExpandMacro("kick", "Griefer")
+			Expect(errors.Is(err, errs.ErrMacroArguments)).To(BeTrue())
+			Expect(commands).To(BeNil())
+		})
+
+		g.It("Should return ErrUnknownMacro for macros which aren't defined", func() {
+			_, err := client.ExpandMacro("missing")
+			Expect(errors.Is(err, errs.ErrUnknownMacro)).To(BeTrue())
+
+			_, err = client.ExecMacro("missing")
+			Expect(errors.Is(err, errs.ErrUnknownMacro)).To(BeTrue())
+		})
+
+		g.It("Should replace and remove macros", func() {
+			client.DefineMacro("restart", []string{"save", "restart"})
+			client.DefineMacro("restart", []string{"restart"})
+
+			commands, err := client.ExpandMacro("restart")
+			Expect(err).To(BeNil())
+			Expect(commands).To(Equal([]string{"restart"}))
+
+			client.RemoveMacro("restart")
+			client.RemoveMacro("restart")
+
+			_, err = client.ExpandMacro("restart")
+			Expect(errors.Is(err, errs.ErrUnknownMacro)).To(BeTrue())
+		})
+
+		g.It("Should execute the expanded commands as a batch", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.Handle("kick", func(args string) string { return "Kicked " + args })
+			server.Handle("ban", func(args string) string { return "Banned " + args })
+			Expect(client.Connect()).To(BeNil())
+
+			client.DefineMacro("kickban", []string{"kick {0}", "ban {0}"})
+
+			responses, err := client.ExecMacro("kickban", "Griefer")
+			Expect(err).To(BeNil())
+			Expect(responses).To(Equal([]string{"Kicked Griefer", "Banned Griefer"}))
+			Expect(server.Commands()).To(Equal([]string{"kick Griefer", "ban Griefer"}))
+		})
+
+		g.It("Should execute every command and return all responses if one fails", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				ResponseErrorChecker: func(_ string, res string) bool { return res == "Player not found" },
+			})
+			server.SetResponse("kick Griefer", "Player not found")
+			server.SetResponse("say done", "done")
+			Expect(client.Connect()).To(BeNil())
+
+			client.DefineMacro("kick", []string{"kick {0}", "say done"})
+
+			responses, err := client.ExecMacro("kick", "Griefer")
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+			Expect(serverErr.Command).To(Equal("kick Griefer"))
+			Expect(responses).To(Equal([]string{"", "done"}))
+		})
+
+		g.It("Should not be affected by later changes to the defined commands", func() {
+			commands := []string{"save"}
+			client.DefineMacro("save", commands)
+			commands[0] = "quit"
+
+			expanded, err := client.ExpandMacro("save")
+			Expect(err).To(BeNil())
+			Expect(expanded).To(Equal([]string{"save"}))
+		})
+	})
+}
@@ -0,0 +1,310 @@
+package rcon
+
+import (
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"time"
+)
+
+// mailboxes holds the response mailboxes of commands which are waiting for a response, keyed by packet ID. A mailbox
+// is a buffered channel the reader routine delivers responses into.
+//
+// All methods are safe for concurrent use. Any number of goroutines may execute commands at the same time while the
+// reader routine delivers responses. Once a mailbox has been removed it is closed, so a goroutine still waiting on it
+// is woken up instead of blocking until its timeout.
+type mailboxes struct {
+	lock  sync.Mutex
+	boxes map[int32]chan packet.Packet
+
+	// abandoned maps the IDs of mailboxes whose command stopped waiting to the time until which a late response is
+	// expected.
+	abandoned map[int32]time.Time
+
+	// opened maps the IDs of open mailboxes to the time they were opened at.
+	opened map[int32]time.Time
+
+	// spilled holds, in order, the packets delivered to growing mailboxes while they were full. refill moves them into
+	// the mailbox as it is consumed.
+	spilled map[int32][]packet.Packet
+
+	// limits maps the IDs of growing mailboxes to the number of packets they may spill.
+	limits map[int32]int
+
+	// overflowed holds the IDs of open mailboxes which were full when a packet was delivered, so that the packet was
+	// dropped.
+	overflowed map[int32]bool
+
+	// watchers are called without the lock held once a response was put into the watched mailbox, with true, or once
+	// the mailbox was closed, with false. They are called at most once.
+	watchers map[int32]func(delivered bool)
+}
+
+func newMailboxes() *mailboxes {
+	return &mailboxes{
+		boxes:      map[int32]chan packet.Packet{},
+		abandoned:  map[int32]time.Time{},
+		opened:     map[int32]time.Time{},
+		spilled:    map[int32][]packet.Packet{},
+		limits:     map[int32]int{},
+		overflowed: map[int32]bool{},
+		watchers:   map[int32]func(bool){},
+	}
+}
+
+// watch registers watcher for the open mailbox of id. If the mailbox already holds a response, watcher is called
+// right away. It returns false if no mailbox is open for id.
+func (m *mailboxes) watch(id int32, watcher func(delivered bool)) bool {
+	m.lock.Lock()
+
+	mailbox, ok := m.boxes[id]
+	if !ok {
+		m.lock.Unlock()
+		return false
+	}
+
+	if len(mailbox) > 0 {
+		m.lock.Unlock()
+		watcher(true)
+		return true
+	}
+
+	m.watchers[id] = watcher
+	m.lock.Unlock()
+
+	return true
+}
+
+// closeBox closes and deletes the mailbox for id and returns its watcher, if any. It must be called with the lock held.
+func (m *mailboxes) closeBox(id int32) func(bool) {
+	mailbox, ok := m.boxes[id]
+	if !ok {
+		return nil
+	}
+
+	close(mailbox)
+	delete(m.boxes, id)
+	delete(m.opened, id)
+	delete(m.spilled, id)
+	delete(m.limits, id)
+	delete(m.overflowed, id)
+
+	watcher := m.watchers[id]
+	delete(m.watchers, id)
+
+	return watcher
+}
+
+// notify calls the given watchers with delivered, skipping nil ones.
+func notify(delivered bool, watchers ...func(bool)) {
+	for _, watcher := range watchers {
+		if watcher != nil {
+			watcher(delivered)
+		}
+	}
+}
+
+// open creates a mailbox for id able to hold size packets and returns it. An existing mailbox for id is closed and
+// replaced.
+func (m *mailboxes) open(id int32, size int) chan packet.Packet {
+	return m.openGrowing(id, size, 0)
+}
+
+// openGrowing opens a mailbox like open which holds up to limit further packets once it is full. They are moved into
+// the mailbox by refill, so its consumer must call refill after every packet it receives.
+func (m *mailboxes) openGrowing(id int32, size int, limit int) chan packet.Packet {
+	m.lock.Lock()
+
+	watcher := m.closeBox(id)
+	delete(m.abandoned, id)
+
+	mailbox := make(chan packet.Packet, size)
+	m.boxes[id] = mailbox
+	m.opened[id] = time.Now()
+	if limit > 0 {
+		m.limits[id] = limit
+	}
+	m.lock.Unlock()
+
+	notify(false, watcher)
+
+	return mailbox
+}
+
+// keep exempts the open mailbox of id from expiry and returns it, or nil if none is open.
+func (m *mailboxes) keep(id int32) chan packet.Packet {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.opened, id)
+
+	return m.boxes[id]
+}
+
+// get returns the mailbox for id, or nil if none is open.
+func (m *mailboxes) get(id int32) chan packet.Packet {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.boxes[id]
+}
+
+// refill moves packets spilled by the growing mailbox of id into it while it has room.
+func (m *mailboxes) refill(id int32) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.refillLocked(id)
+}
+
+// refillLocked is refill with the lock held.
+func (m *mailboxes) refillLocked(id int32) {
+	mailbox, spilled := m.boxes[id], m.spilled[id]
+
+	for len(spilled) > 0 {
+		select {
+		case mailbox <- spilled[0]:
+			spilled = spilled[1:]
+		default:
+			m.spilled[id] = spilled
+			return
+		}
+	}
+
+	delete(m.spilled, id)
+}
+
+// overflow reports whether a packet was dropped because the open mailbox of id was full.
+func (m *mailboxes) overflow(id int32) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.overflowed[id]
+}
+
+// deliveryResult describes the outcome of mailboxes.deliver.
+type deliveryResult int
+
+const (
+	delivered deliveryResult = iota
+	noMailbox
+	mailboxFull
+	lateResponse
+
+	// mailboxOverflow means a mailbox holding multiple packets was full. Unlike mailboxFull, the packet is not a
+	// duplicate but part of a response which has more fragments than the mailbox can hold.
+	mailboxOverflow
+)
+
+// deliver puts p into the mailbox with the matching ID without blocking. Sending happens while the lock is held so that
+// the mailbox cannot be closed mid-send.
+func (m *mailboxes) deliver(p packet.Packet) deliveryResult {
+	m.lock.Lock()
+
+	mailbox, ok := m.boxes[p.ID()]
+	if !ok {
+		defer m.lock.Unlock()
+
+		if until, abandoned := m.abandoned[p.ID()]; abandoned && time.Now().Before(until) {
+			return lateResponse
+		}
+
+		return noMailbox
+	}
+
+	// Once a growing mailbox spilled, later packets are spilled too so that they stay in order.
+	m.refillLocked(p.ID())
+	if len(m.spilled[p.ID()]) == 0 {
+		select {
+		case mailbox <- p:
+			watcher := m.watchers[p.ID()]
+			delete(m.watchers, p.ID())
+			m.lock.Unlock()
+
+			notify(true, watcher)
+
+			return delivered
+		default:
+		}
+	}
+
+	defer m.lock.Unlock()
+
+	if limit, growing := m.limits[p.ID()]; growing && len(m.spilled[p.ID()]) < limit {
+		m.spilled[p.ID()] = append(m.spilled[p.ID()], p)
+		return delivered
+	}
+
+	if cap(mailbox) > 1 {
+		m.overflowed[p.ID()] = true
+		return mailboxOverflow
+	}
+
+	return mailboxFull
+}
+
+// remove closes and deletes the mailbox for id. Removing a mailbox which does not exist is a no-op.
+func (m *mailboxes) remove(id int32) {
+	m.lock.Lock()
+	watcher := m.closeBox(id)
+	m.lock.Unlock()
+
+	notify(false, watcher)
+}
+
+// abandon closes and deletes the mailbox for id like remove, and remembers id until the given time so that a late
+// response can be told apart from an unexpected packet. Expired IDs are forgotten.
+func (m *mailboxes) abandon(id int32, until time.Time) {
+	m.lock.Lock()
+	watcher := m.closeBox(id)
+	defer notify(false, watcher)
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	for abandonedID, expiry := range m.abandoned {
+		if now.After(expiry) {
+			delete(m.abandoned, abandonedID)
+		}
+	}
+
+	m.abandoned[id] = until
+}
+
+// removeIf closes and deletes every mailbox whose ID matches pred and returns the removed IDs.
+func (m *mailboxes) removeIf(pred func(id int32) bool) []int32 {
+	m.lock.Lock()
+
+	var removed []int32
+	var watchers []func(bool)
+	for id := range m.boxes {
+		if pred(id) {
+			watchers = append(watchers, m.closeBox(id))
+			removed = append(removed, id)
+		}
+	}
+	m.lock.Unlock()
+
+	notify(false, watchers...)
+
+	return removed
+}
+
+// expire abandons every mailbox opened before the given time until the time passed as until, and returns the IDs of
+// the expired mailboxes.
+func (m *mailboxes) expire(before time.Time, until time.Time) []int32 {
+	m.lock.Lock()
+
+	var expired []int32
+	var watchers []func(bool)
+	for id, opened := range m.opened {
+		if opened.Before(before) {
+			watchers = append(watchers, m.closeBox(id))
+			m.abandoned[id] = until
+			expired = append(expired, id)
+		}
+	}
+	m.lock.Unlock()
+
+	notify(false, watchers...)
+
+	return expired
+}
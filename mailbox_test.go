@@ -0,0 +1,111 @@
+package rcon
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"testing"
+	"time"
+)
+
+func TestMailboxes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Mailboxes", func() {
+		var m *mailboxes
+
+		g.BeforeEach(func() {
+			m = newMailboxes()
+		})
+
+		response := func(id int32) packet.Packet {
+			return packet.NewPacketWithID(endian.Little, id, packet.TypeCommandRes, "ok")
+		}
+
+		g.It("Should deliver responses to open mailboxes", func() {
+			mailbox := m.open(1, 1)
+
+			Expect(m.deliver(response(1))).To(Equal(delivered))
+			Expect(mailbox).To(Receive())
+			Expect(m.deliver(response(2))).To(Equal(noMailbox))
+		})
+
+		g.It("Should report duplicate responses", func() {
+			m.open(1, 1)
+
+			Expect(m.deliver(response(1))).To(Equal(delivered))
+			Expect(m.deliver(response(1))).To(Equal(mailboxFull))
+			Expect(m.overflow(1)).To(BeFalse())
+		})
+
+		g.It("Should record overflowing multi-packet mailboxes", func() {
+			m.open(1, 2)
+
+			Expect(m.deliver(response(1))).To(Equal(delivered))
+			Expect(m.deliver(response(1))).To(Equal(delivered))
+			Expect(m.deliver(response(1))).To(Equal(mailboxOverflow))
+			Expect(m.overflow(1)).To(BeTrue())
+
+			m.remove(1)
+			Expect(m.overflow(1)).To(BeFalse())
+		})
+
+		g.It("Should grow mailboxes and keep their packets in order", func() {
+			mailbox := m.openGrowing(1, 2, 2)
+
+			for i := 0; i < 4; i++ {
+				p := packet.NewPacketWithID(endian.Little, 1, packet.TypeCommandRes, string(rune('a'+i)))
+				Expect(m.deliver(p)).To(Equal(delivered))
+			}
+
+			Expect(m.deliver(response(1))).To(Equal(mailboxOverflow))
+			Expect(m.overflow(1)).To(BeTrue())
+
+			var bodies []string
+			for i := 0; i < 4; i++ {
+				p := <-mailbox
+				bodies = append(bodies, string(p.Body()[:len(p.Body())-1]))
+				m.refill(1)
+			}
+
+			Expect(bodies).To(Equal([]string{"a", "b", "c", "d"}))
+			Expect(mailbox).ToNot(Receive())
+		})
+
+		g.It("Should expire mailboxes opened before the cutoff", func() {
+			stale := m.open(1, 1)
+			time.Sleep(time.Millisecond * 10)
+			cutoff := time.Now()
+			fresh := m.open(2, 1)
+
+			Expect(m.expire(cutoff, time.Now().Add(time.Minute))).To(Equal([]int32{1}))
+
+			_, ok := <-stale
+			Expect(ok).To(BeFalse())
+
+			// The response to an expired mailbox is late rather than unexpected.
+			Expect(m.deliver(response(1))).To(Equal(lateResponse))
+			Expect(m.deliver(response(2))).To(Equal(delivered))
+			Expect(fresh).To(Receive())
+		})
+
+		g.It("Should treat responses after the late response window as unexpected", func() {
+			m.open(1, 1)
+
+			Expect(m.expire(time.Now().Add(time.Second), time.Now())).To(Equal([]int32{1}))
+			Expect(m.deliver(response(1))).To(Equal(noMailbox))
+		})
+
+		g.It("Should not expire kept mailboxes", func() {
+			m.open(1, 1)
+			Expect(m.keep(1)).ToNot(BeNil())
+
+			Expect(m.expire(time.Now().Add(time.Second), time.Now().Add(time.Minute))).To(BeEmpty())
+			Expect(m.deliver(response(1))).To(Equal(delivered))
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package rcon
+
+import "time"
+
+// Metrics receives measurements from a client. Implementations must be safe for concurrent use, since they are called
+// from the reader and writer routines as well as from every goroutine executing commands. The metrics package provides
+// an implementation which exposes them to Prometheus.
+type Metrics interface {
+	// CommandSent is called for every executed command.
+	CommandSent()
+
+	// ResponseReceived is called when the server answered a command, with the time it took to answer. Responses
+	// identified as errors by the ResponseErrorChecker are included, since the server did answer.
+	ResponseReceived(roundTrip time.Duration)
+
+	// BroadcastReceived is called for every dispatched broadcast.
+	BroadcastReceived()
+
+	// ReadError is called when reading from the connection failed.
+	ReadError()
+
+	// WriteError is called when writing to the connection failed.
+	WriteError()
+
+	// Reconnected is called after the client reconnected.
+	Reconnected()
+}
+
+// LabeledMetrics is implemented by Metrics which can attach labels to their measurements. NewClient passes the
+// configured Labels to SetLabels, unless none are configured.
+type LabeledMetrics interface {
+	Metrics
+
+	// SetLabels attaches the client's labels to the measurements.
+	SetLabels(labels map[string]string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) CommandSent()                   {}
+func (noopMetrics) ResponseReceived(time.Duration) {}
+func (noopMetrics) BroadcastReceived()             {}
+func (noopMetrics) ReadError()                     {}
+func (noopMetrics) WriteError()                    {}
+func (noopMetrics) Reconnected()                   {}
+
+// metrics returns the configured Metrics, or an implementation discarding all measurements.
+func (c *Client) metrics() Metrics {
+	if c.Metrics == nil {
+		return noopMetrics{}
+	}
+
+	return c.Metrics
+}
@@ -0,0 +1,274 @@
+// Package metrics exposes client measurements to Prometheus. A Collector records the measurements of the clients it is
+// configured as Metrics for and serves them in the Prometheus text exposition format, so no Prometheus client library
+// is required:
+//
+//	collector := metrics.NewCollector(map[string]string{"server": "eu-1"})
+//	config.Metrics = collector
+//	http.Handle("/metrics", collector)
+//
+// To serve the collectors of several clients on one endpoint, use Handler.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the round trip latency histogram buckets in seconds used if none are configured.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector records the measurements of one or more clients. It implements rcon.Metrics.
+type Collector struct {
+	labelsLock sync.RWMutex
+	labels     map[string]string
+	buckets    []float64
+
+	commandsSent      uint64
+	responsesReceived uint64
+	broadcasts        uint64
+	readErrors        uint64
+	writeErrors       uint64
+	reconnects        uint64
+
+	histLock     sync.Mutex
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+var _ rcon.LabeledMetrics = (*Collector)(nil)
+
+// NewCollector creates a collector whose metrics carry labels. Use labels to tell the collectors of different clients
+// apart, for example by server name. Label names must match [a-zA-Z_][a-zA-Z0-9_]*; invalid characters are replaced by
+// underscores, and names starting with a digit are prefixed with one.
+func NewCollector(labels map[string]string) *Collector {
+	return NewCollectorWithBuckets(labels, DefaultBuckets)
+}
+
+// NewCollectorWithBuckets creates a collector using buckets, in seconds, for the round trip latency histogram.
+func NewCollectorWithBuckets(labels map[string]string, buckets []float64) *Collector {
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	return &Collector{
+		labels:       sanitizeLabels(labels),
+		buckets:      b,
+		bucketCounts: make([]uint64, len(b)),
+	}
+}
+
+// SetLabels adds the labels of the client the collector is configured for. Labels the collector was created with take
+// precedence. Collectors shared by clients with different labels should be created with their labels instead, since
+// the labels of all clients are merged. Label names are sanitized like those passed to NewCollector.
+func (c *Collector) SetLabels(labels map[string]string) {
+	labels = sanitizeLabels(labels)
+
+	c.labelsLock.Lock()
+	defer c.labelsLock.Unlock()
+
+	for k, v := range labels {
+		if _, ok := c.labels[k]; !ok {
+			c.labels[k] = v
+		}
+	}
+}
+
+// sanitizeLabels returns a copy of labels whose names are valid Prometheus label names. If several names are sanitized
+// to the same one, the name which was valid already is kept, or else the first in sorted order.
+func sanitizeLabels(labels map[string]string) map[string]string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sanitized := make(map[string]string, len(labels))
+	for _, name := range names {
+		valid := sanitizeLabelName(name)
+		if _, ok := sanitized[valid]; ok && valid != name {
+			continue
+		}
+
+		sanitized[valid] = labels[name]
+	}
+
+	return sanitized
+}
+
+// sanitizeLabelName makes name match [a-zA-Z_][a-zA-Z0-9_]* by replacing invalid characters with underscores, and
+// prefixing names which start with a digit or are empty with one.
+func sanitizeLabelName(name string) string {
+	valid := []byte(name)
+	for i, b := range valid {
+		if !(b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9') {
+			valid[i] = '_'
+		}
+	}
+
+	if len(valid) == 0 || valid[0] >= '0' && valid[0] <= '9' {
+		return "_" + string(valid)
+	}
+
+	return string(valid)
+}
+
+// currentLabels returns the collector's labels.
+func (c *Collector) currentLabels() map[string]string {
+	c.labelsLock.RLock()
+	defer c.labelsLock.RUnlock()
+
+	labels := make(map[string]string, len(c.labels))
+	for k, v := range c.labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+func (c *Collector) CommandSent() {
+	atomic.AddUint64(&c.commandsSent, 1)
+}
+
+func (c *Collector) ResponseReceived(roundTrip time.Duration) {
+	atomic.AddUint64(&c.responsesReceived, 1)
+
+	seconds := roundTrip.Seconds()
+
+	c.histLock.Lock()
+	defer c.histLock.Unlock()
+
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			c.bucketCounts[i]++
+		}
+	}
+
+	c.sum += seconds
+	c.count++
+}
+
+func (c *Collector) BroadcastReceived() {
+	atomic.AddUint64(&c.broadcasts, 1)
+}
+
+func (c *Collector) ReadError() {
+	atomic.AddUint64(&c.readErrors, 1)
+}
+
+func (c *Collector) WriteError() {
+	atomic.AddUint64(&c.writeErrors, 1)
+}
+
+func (c *Collector) Reconnected() {
+	atomic.AddUint64(&c.reconnects, 1)
+}
+
+// ServeHTTP serves the collector's metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Handler(c).ServeHTTP(w, r)
+}
+
+// Handler serves the metrics of all collectors in the Prometheus text exposition format.
+func Handler(collectors ...*Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = WriteText(w, collectors...)
+	})
+}
+
+type counter struct {
+	name  string
+	help  string
+	value func(c *Collector) uint64
+}
+
+var counters = []counter{
+	{"rcon_commands_sent_total", "Number of commands sent.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.commandsSent)
+	}},
+	{"rcon_responses_received_total", "Number of command responses received.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.responsesReceived)
+	}},
+	{"rcon_broadcasts_received_total", "Number of broadcasts received.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.broadcasts)
+	}},
+	{"rcon_read_errors_total", "Number of failed connection reads.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.readErrors)
+	}},
+	{"rcon_write_errors_total", "Number of failed connection writes.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.writeErrors)
+	}},
+	{"rcon_reconnects_total", "Number of successful reconnects.", func(c *Collector) uint64 {
+		return atomic.LoadUint64(&c.reconnects)
+	}},
+}
+
+const roundTripName = "rcon_round_trip_seconds"
+
+// WriteText writes the metrics of all collectors to w in the Prometheus text exposition format.
+func WriteText(w io.Writer, collectors ...*Collector) error {
+	out := bufio.NewWriter(w)
+
+	for _, m := range counters {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name)
+
+		for _, c := range collectors {
+			fmt.Fprintf(out, "%s%s %d\n", m.name, formatLabels(c.currentLabels(), "", ""), m.value(c))
+		}
+	}
+
+	fmt.Fprintf(out, "# HELP %s Round trip latency of commands.\n# TYPE %s histogram\n", roundTripName, roundTripName)
+
+	for _, c := range collectors {
+		labels := c.currentLabels()
+
+		c.histLock.Lock()
+		for i, bound := range c.buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(out, "%s_bucket%s %d\n", roundTripName, formatLabels(labels, "le", le), c.bucketCounts[i])
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", roundTripName, formatLabels(labels, "le", "+Inf"), c.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", roundTripName, formatLabels(labels, "", ""),
+			strconv.FormatFloat(c.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count%s %d\n", roundTripName, formatLabels(labels, "", ""), c.count)
+		c.histLock.Unlock()
+	}
+
+	return out.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats labels, plus the extra label if its name is not empty, sorted by name.
+func formatLabels(labels map[string]string, extraName string, extraValue string) string {
+	names := make([]string, 0, len(labels)+1)
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(labels[name])+`"`)
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+labelEscaper.Replace(extraValue)+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
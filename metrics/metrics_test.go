@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("WriteText()", func() {
+		g.It("Should write counters with the collector's labels", func() {
+			c := NewCollector(map[string]string{"server": `eu "1"`})
+			c.CommandSent()
+			c.CommandSent()
+			c.Reconnected()
+
+			out := &bytes.Buffer{}
+			Expect(WriteText(out, c)).To(BeNil())
+
+			Expect(out.String()).To(ContainSubstring("# TYPE rcon_commands_sent_total counter\n"))
+			Expect(out.String()).To(ContainSubstring(`rcon_commands_sent_total{server="eu \"1\""} 2` + "\n"))
+			Expect(out.String()).To(ContainSubstring(`rcon_reconnects_total{server="eu \"1\""} 1` + "\n"))
+		})
+
+		g.It("Should sanitize invalid label names", func() {
+			c := NewCollector(map[string]string{"game-server": "eu-1", "1st": "yes", "": "empty", "zone.name": "west"})
+			c.SetLabels(map[string]string{"team name": "red"})
+
+			out := &bytes.Buffer{}
+			Expect(WriteText(out, c)).To(BeNil())
+
+			Expect(out.String()).To(ContainSubstring(
+				`rcon_commands_sent_total{_="empty",_1st="yes",game_server="eu-1",team_name="red",zone_name="west"} 0` +
+					"\n"))
+		})
+
+		g.It("Should prefer valid label names over sanitized ones", func() {
+			c := NewCollector(map[string]string{"tenant-id": "sanitized", "tenant_id": "valid", "tenant.id": "other"})
+			c.SetLabels(map[string]string{"tenant id": "client"})
+
+			out := &bytes.Buffer{}
+			Expect(WriteText(out, c)).To(BeNil())
+
+			Expect(out.String()).To(ContainSubstring(`rcon_commands_sent_total{tenant_id="valid"} 0` + "\n"))
+		})
+
+		g.It("Should write cumulative histogram buckets", func() {
+			c := NewCollectorWithBuckets(nil, []float64{0.1, 1})
+			c.ResponseReceived(time.Millisecond * 50)
+			c.ResponseReceived(time.Millisecond * 500)
+			c.ResponseReceived(time.Second * 5)
+
+			out := &bytes.Buffer{}
+			Expect(WriteText(out, c)).To(BeNil())
+
+			Expect(out.String()).To(ContainSubstring("rcon_round_trip_seconds_bucket{le=\"0.1\"} 1\n"))
+			Expect(out.String()).To(ContainSubstring("rcon_round_trip_seconds_bucket{le=\"1\"} 2\n"))
+			Expect(out.String()).To(ContainSubstring("rcon_round_trip_seconds_bucket{le=\"+Inf\"} 3\n"))
+			Expect(out.String()).To(ContainSubstring("rcon_round_trip_seconds_count 3\n"))
+			Expect(out.String()).To(ContainSubstring("rcon_responses_received_total 3\n"))
+		})
+	})
+}
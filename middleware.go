@@ -0,0 +1,73 @@
+package rcon
+
+import (
+	"context"
+)
+
+// ExecFunc executes a command and returns its response.
+type ExecFunc func(ctx context.Context, command string) (string, error)
+
+// Middleware wraps the execution of commands, for cross-cutting behaviour like logging, auditing, input sanitization
+// or retries. It returns an ExecFunc which usually calls next, but may also change the command, inspect or replace the
+// response, call next several times, or not call it at all.
+type Middleware func(next ExecFunc) ExecFunc
+
+// Use adds middleware to every command the client executes, including pipelined batches, asynchronous commands,
+// commands executed without a response and streams. Middleware added first is outermost. For ExecCommand,
+// ExecCommandContext and the methods built on them, the innermost ExecFunc retries the command according to
+// Config.Retry; the other paths are not retried. The response passed through the chain is empty for commands executed
+// without a response and for streams, whose output is delivered on the stream's channel instead.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middlewareLock.Lock()
+	defer c.middlewareLock.Unlock()
+
+	c.middleware = append(c.middleware, middleware...)
+
+	exec := ExecFunc(c.execRetrying)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		exec = c.middleware[i](exec)
+	}
+
+	c.execChain = exec
+}
+
+// chain returns the ExecFunc commands are executed with.
+func (c *Client) chain() ExecFunc {
+	c.middlewareLock.RLock()
+	defer c.middlewareLock.RUnlock()
+
+	if c.execChain == nil {
+		return c.execRetrying
+	}
+
+	return c.execChain
+}
+
+// wrap applies the middleware to exec, for command paths which are not executed using execRetrying.
+func (c *Client) wrap(exec ExecFunc) ExecFunc {
+	c.middlewareLock.RLock()
+	defer c.middlewareLock.RUnlock()
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		exec = c.middleware[i](exec)
+	}
+
+	return exec
+}
+
+// hasMiddleware returns true if any middleware was added.
+func (c *Client) hasMiddleware() bool {
+	c.middlewareLock.RLock()
+	defer c.middlewareLock.RUnlock()
+
+	return len(c.middleware) > 0
+}
+
+// execTracked executes command and records it in the command statistics.
+func (c *Client) execTracked(ctx context.Context, command string) (string, error) {
+	done := c.trackCommand(ctx, command, true)
+	res, err := c.execCommand(ctx, command)
+	done(err)
+
+	return res, err
+}
@@ -0,0 +1,121 @@
+package rcon_test
+
+import (
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// upper rewrites commands to upper case and records them.
+	upper := func(seen *[]string, lock *sync.Mutex) rcon.Middleware {
+		return func(next rcon.ExecFunc) rcon.ExecFunc {
+			return func(ctx context.Context, command string) (string, error) {
+				lock.Lock()
+				*seen = append(*seen, command)
+				lock.Unlock()
+
+				return next(ctx, strings.ToUpper(command))
+			}
+		}
+	}
+
+	g.Describe("Middleware", func() {
+		g.It("Should wrap pipelined commands and keep their order", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.Handle("ECHO", func(args string) string { return args })
+
+			var lock sync.Mutex
+			var seen []string
+			client.Use(upper(&seen, &lock))
+
+			Expect(client.Connect()).To(BeNil())
+
+			responses, err := client.ExecCommands([]string{"echo a", "echo b", "echo c"})
+			Expect(err).To(BeNil())
+			Expect(responses).To(Equal([]string{"A", "B", "C"}))
+			Expect(seen).To(ConsistOf("echo a", "echo b", "echo c"))
+			Expect(server.Commands()).To(Equal([]string{"ECHO A", "ECHO B", "ECHO C"}))
+		})
+
+		g.It("Should wrap asynchronous commands", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.Handle("PING", func(string) string { return "pong" })
+
+			var lock sync.Mutex
+			var seen []string
+			client.Use(upper(&seen, &lock))
+
+			Expect(client.Connect()).To(BeNil())
+
+			pending, err := client.ExecCommandAsync("ping")
+			Expect(err).To(BeNil())
+
+			res, err := pending.Result()
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("pong"))
+
+			lock.Lock()
+			Expect(seen).To(Equal([]string{"ping"}))
+			lock.Unlock()
+		})
+
+		g.It("Should wrap commands executed without a response", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+
+			var lock sync.Mutex
+			var seen []string
+			client.Use(upper(&seen, &lock))
+
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.ExecCommandNoResponse("say hi")).To(BeNil())
+
+			Eventually(server.Commands).Should(ContainElement("SAY HI"))
+			Expect(seen).To(Equal([]string{"say hi"}))
+		})
+
+		g.It("Should wrap streams", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.Handle("TAIL", func(string) string { return "line" })
+
+			var lock sync.Mutex
+			var seen []string
+			client.Use(upper(&seen, &lock))
+
+			Expect(client.Connect()).To(BeNil())
+
+			out, cancel, err := client.Stream("tail")
+			Expect(err).To(BeNil())
+			defer cancel()
+
+			Eventually(out, time.Second).Should(Receive(Equal("line")))
+			Expect(seen).To(Equal([]string{"tail"}))
+		})
+
+		g.It("Should not start streams the middleware rejects", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+
+			client.Use(func(next rcon.ExecFunc) rcon.ExecFunc {
+				return func(ctx context.Context, command string) (string, error) {
+					return "", nil
+				}
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, _, err := client.Stream("tail")
+			Expect(err).ToNot(BeNil())
+			Consistently(server.Commands, time.Millisecond*100).Should(BeEmpty())
+		})
+	})
+}
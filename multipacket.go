@@ -0,0 +1,261 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"net"
+	"time"
+)
+
+// multiPacketMailboxSize is the number of fragments the mailbox of a multi-packet response buffers. Fragments are
+// consumed as they arrive; if the assembling command falls behind, the mailbox grows by up to multiPacketMaxSpilled
+// fragments, beyond which the response fails with errs.ErrResponseTruncated.
+const multiPacketMailboxSize = 256
+
+// multiPacketMaxSpilled is the number of fragments a multi-packet mailbox grows by at most, which bounds the memory a
+// response can hold to about 64 MiB of 4096 byte fragments.
+const multiPacketMaxSpilled = 16384
+
+// DefaultFragmentSize is the fragment size of Source servers, which split responses larger than 4096 bytes.
+const DefaultFragmentSize = 4096
+
+// DefaultSentinelGrace is the default time the client waits for late fragments with SentinelUnordered.
+const DefaultSentinelGrace = time.Millisecond * 100
+
+// SentinelBehavior describes how a server answers the empty sentinel packet which is sent after every command to find
+// the end of multi-packet responses. It differs between builds of the same game.
+type SentinelBehavior int
+
+const (
+	// SentinelEcho servers echo the sentinel after the last fragment of the response. This is how older SRCDS builds
+	// behave.
+	SentinelEcho SentinelBehavior = iota
+
+	// SentinelEchoWithTrailer servers echo the sentinel after the last fragment, then send a second packet with the
+	// sentinel's ID whose body is 0x00 0x01 0x00 0x00. The trailer is consumed so that it isn't treated as unexpected.
+	SentinelEchoWithTrailer
+
+	// SentinelUnordered servers may echo the sentinel before the last fragment was sent, as newer SRCDS builds with
+	// larger send buffers do. After the echo, the response is complete once a fragment shorter than the fragment size
+	// arrived, or once no further fragment arrived within the sentinel grace period.
+	SentinelUnordered
+)
+
+// execMultiPacket sends p followed by an empty sentinel packet and assembles all response fragments received before
+// the sentinel's echo into a single packet. Since the server answers packets in order, the sentinel's echo marks the
+// end of the response to p. Servers which don't strictly answer in order are handled according to the dialect's
+// SentinelBehavior.
+func (c *Client) execMultiPacket(ctx context.Context, p packet.Packet) (res packet.Packet, err error) {
+	fragmentSize, behavior, grace := c.fragmentation()
+
+	sentinel := c.newClientPacket(packet.TypeCommandRes, "")
+
+	// The mailbox is opened here rather than by enqueuePacket since it grows when the command falls behind.
+	c.mailboxes.openGrowing(p.ID(), multiPacketMailboxSize, multiPacketMaxSpilled)
+	if err := c.enqueuePacket(ctx, p, 0); err != nil {
+		return nil, fmt.Errorf("could not enqueue command packet: %w", err)
+	}
+	defer c.removeMailbox(p.ID())
+
+	sentinelMailboxSize := 1
+	if behavior == SentinelEchoWithTrailer {
+		sentinelMailboxSize = 2
+	}
+
+	// The sentinel belongs to the command, which already counted against the rate limit.
+	if err := c.enqueuePacket(WithPriority(ctx), sentinel, sentinelMailboxSize); err != nil {
+		return nil, fmt.Errorf("could not enqueue sentinel packet: %w", err)
+	}
+	defer c.removeMailbox(sentinel.ID())
+
+	// Fragments and the sentinel's echo may still arrive after giving up.
+	defer func() {
+		if err != nil {
+			c.abandonMailbox(p.ID())
+			c.abandonMailbox(sentinel.ID())
+		}
+	}()
+
+	fragments := c.mailboxes.get(p.ID())
+	done := c.mailboxes.get(sentinel.ID())
+	if fragments == nil || done == nil {
+		return nil, fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+	}
+
+	start := time.Now()
+	timeout := time.After(c.readTimeout())
+	body := &bytes.Buffer{}
+	lastFragment := -1
+
+	appendFragment := func(f packet.Packet) {
+		b := f.Body()
+		body.Write(b[:len(b)-1])
+		lastFragment = len(b) - 1
+
+		c.mailboxes.refill(p.ID())
+	}
+
+	// wait returns an error if timeout passes or ctx is done before ch yields a value.
+	wait := func(ch <-chan packet.Packet) error {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+			}
+
+			return nil
+		case <-timeout:
+			return &errs.ResponseTimeoutError{PacketID: p.ID(), Timeout: c.readTimeout()}
+		case <-ctx.Done():
+			return fmt.Errorf("mailbox read operation cancelled: %w", ctx.Err())
+		}
+	}
+
+	for {
+		select {
+		case f, ok := <-fragments:
+			if !ok {
+				return nil, fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+			}
+
+			appendFragment(f)
+		case _, ok := <-done:
+			if !ok {
+				return nil, fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+			}
+
+			if behavior == SentinelEchoWithTrailer {
+				if err := wait(done); err != nil {
+					return nil, err
+				}
+			}
+
+			// Fragments are delivered in order, so everything preceding the sentinel is already in the mailbox.
+		drain:
+			for {
+				select {
+				case f, ok := <-fragments:
+					if !ok {
+						break drain
+					}
+
+					appendFragment(f)
+				default:
+					break drain
+				}
+			}
+
+			if behavior == SentinelUnordered {
+			late:
+				for lastFragment < 0 || lastFragment >= fragmentSize {
+					select {
+					case f, ok := <-fragments:
+						if !ok {
+							return nil, fmt.Errorf("mailbox was closed before a response arrived: %w", errs.ErrMailboxClosed)
+						}
+
+						appendFragment(f)
+					case <-time.After(grace):
+						break late
+					case <-ctx.Done():
+						return nil, fmt.Errorf("mailbox read operation cancelled: %w", ctx.Err())
+					}
+				}
+			}
+
+			if c.mailboxes.overflow(p.ID()) {
+				return nil, fmt.Errorf("more than %d fragments of the response to packet %d were buffered: %w",
+					multiPacketMailboxSize+multiPacketMaxSpilled, p.ID(), errs.ErrResponseTruncated)
+			}
+
+			c.latencies.add(time.Since(start))
+			c.capabilitySucceeded(CapabilityMultiPacket)
+
+			return c.assembled(p, body), nil
+		case <-timeout:
+			if lastFragment >= 0 {
+				c.capabilityFailed(CapabilityMultiPacket, "the command was answered but the sentinel was not echoed")
+			}
+
+			return nil, &errs.ResponseTimeoutError{PacketID: p.ID(), Timeout: c.readTimeout()}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("mailbox read operation cancelled: %w", ctx.Err())
+		}
+	}
+}
+
+// readMultiPacket is the per-command connection equivalent of execMultiPacket. It must be called with the connection
+// owned by the caller after p was sent.
+func (c *Client) readMultiPacket(p packet.Packet) (packet.Packet, error) {
+	fragmentSize, behavior, grace := c.fragmentation()
+
+	sentinel := c.newClientPacket(packet.TypeCommandRes, "")
+
+	if err := c.sendPacket(sentinel); err != nil {
+		return nil, fmt.Errorf("could not send sentinel packet: %w", err)
+	}
+
+	body := &bytes.Buffer{}
+	lastFragment := -1
+	echoed := false
+
+	for {
+		var f packet.Packet
+		var err error
+
+		if echoed && behavior == SentinelUnordered {
+			f, err = c.readPacketDeadline(time.Now().Add(grace))
+			if isTimeout(err) {
+				c.capabilitySucceeded(CapabilityMultiPacket)
+				return c.assembled(p, body), nil
+			}
+		} else {
+			f, err = c.readPacketTimeout()
+		}
+
+		if err != nil {
+			if isTimeout(err) && lastFragment >= 0 && !echoed {
+				c.capabilityFailed(CapabilityMultiPacket, "the command was answered but the sentinel was not echoed")
+			}
+
+			return nil, fmt.Errorf("could not get command response: %w", err)
+		}
+
+		switch f.ID() {
+		case sentinel.ID():
+			if echoed || behavior == SentinelEcho {
+				c.capabilitySucceeded(CapabilityMultiPacket)
+				return c.assembled(p, body), nil
+			}
+
+			echoed = true
+		case p.ID():
+			b := f.Body()
+			body.Write(b[:len(b)-1])
+			lastFragment = len(b) - 1
+		default:
+			continue
+		}
+
+		if echoed && behavior == SentinelUnordered && lastFragment >= 0 && lastFragment < fragmentSize {
+			c.capabilitySucceeded(CapabilityMultiPacket)
+			return c.assembled(p, body), nil
+		}
+	}
+}
+
+// isTimeout returns true if err was caused by a connection deadline passing.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// assembled builds the response packet to p from the concatenated fragment bodies. Newlines are only trimmed from the
+// assembled body, since trimming each fragment would remove newlines which happen to fall on fragment boundaries.
+func (c *Client) assembled(p packet.Packet, body *bytes.Buffer) packet.Packet {
+	return packet.NewPacketWithID(c.EndianMode, p.ID(), packet.TypeCommandRes, string(bytes.Trim(body.Bytes(), "\n")))
+}
@@ -0,0 +1,47 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiPacket(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Multi-packet responses", func() {
+		g.It("Should assemble responses split across packets", func() {
+			server, client := newTestClient(t, &rcon.Config{MultiPacketResponses: true})
+			response := strings.Repeat("a", rcontest.MaxResponseBody) + strings.Repeat("b", 10)
+			server.SetResponse("cvarlist", response)
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("cvarlist")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(response))
+		})
+
+		g.It("Should assemble responses with more fragments than the mailbox buffers", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				MultiPacketResponses: true,
+				QueueReadTimeout:     time.Second * 5,
+			})
+			response := strings.Repeat("x", rcontest.MaxResponseBody*300)
+			server.SetResponse("cvarlist", response)
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("cvarlist")
+			Expect(err).To(BeNil())
+			Expect(res).To(HaveLen(len(response)))
+		})
+	})
+}
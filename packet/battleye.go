@@ -0,0 +1,102 @@
+package packet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"hash/crc32"
+)
+
+// BattlEye RCON packet types.
+const (
+	BattlEyeTypeLogin   = byte(0x00)
+	BattlEyeTypeCommand = byte(0x01)
+	BattlEyeTypeMessage = byte(0x02)
+)
+
+// ErrChecksum is returned when the CRC32 checksum of a BattlEye packet does not match its contents.
+var ErrChecksum = fmt.Errorf("checksum mismatch")
+
+// battlEyeHeaderBytes is the length of "BE", the 4 byte checksum and the 0xFF separator.
+const battlEyeHeaderBytes = 2 + 4 + 1
+
+// BattlEyePacket is a packet of the BattlEye RCON protocol used by Arma and DayZ. Unlike Source RCON, BattlEye runs
+// over UDP and every datagram carries exactly one packet.
+//
+// Login packets carry the password (or the login result) as Payload and have no sequence number. Command and message
+// packets carry a sequence number in Seq.
+type BattlEyePacket struct {
+	Type    byte
+	Seq     byte
+	Payload []byte
+}
+
+// Build encodes p: "BE", the CRC32 checksum of everything following it, 0xFF, type, sequence number and payload.
+func (p *BattlEyePacket) Build() []byte {
+	body := bytes.NewBuffer([]byte{0xFF, p.Type})
+	if p.Type != BattlEyeTypeLogin {
+		body.WriteByte(p.Seq)
+	}
+	body.Write(p.Payload)
+
+	out := make([]byte, 0, battlEyeHeaderBytes+body.Len())
+	out = append(out, 'B', 'E')
+
+	checksum := make([]byte, 4)
+	binary.LittleEndian.PutUint32(checksum, crc32.ChecksumIEEE(body.Bytes()))
+	out = append(out, checksum...)
+
+	return append(out, body.Bytes()...)
+}
+
+// DecodeBattlEyePacket decodes a single BattlEye datagram. ErrMalformedPacket is returned if the header is invalid
+// and ErrChecksum if the checksum does not match.
+func DecodeBattlEyePacket(data []byte) (*BattlEyePacket, error) {
+	if len(data) < battlEyeHeaderBytes+1 || data[0] != 'B' || data[1] != 'E' || data[6] != 0xFF {
+		return nil, &errs.MalformedPacketError{Reason: "invalid battleye header"}
+	}
+
+	if binary.LittleEndian.Uint32(data[2:6]) != crc32.ChecksumIEEE(data[6:]) {
+		return nil, ErrChecksum
+	}
+
+	p := &BattlEyePacket{
+		Type: data[7],
+	}
+
+	rest := data[8:]
+	if p.Type != BattlEyeTypeLogin {
+		if len(rest) < 1 {
+			return nil, &errs.MalformedPacketError{Reason: "battleye packet is missing its sequence number"}
+		}
+
+		p.Seq = rest[0]
+		rest = rest[1:]
+	}
+
+	p.Payload = append([]byte{}, rest...)
+
+	return p, nil
+}
+
+// BattlEyeMultipart describes one part of a command response which the server split across multiple packets.
+type BattlEyeMultipart struct {
+	Total int
+	Index int
+	Data  []byte
+}
+
+// Multipart returns the multipart header of a command response packet, or false if the packet is not part of a
+// multipart response.
+func (p *BattlEyePacket) Multipart() (BattlEyeMultipart, bool) {
+	if p.Type != BattlEyeTypeCommand || len(p.Payload) < 3 || p.Payload[0] != 0x00 {
+		return BattlEyeMultipart{}, false
+	}
+
+	return BattlEyeMultipart{
+		Total: int(p.Payload[1]),
+		Index: int(p.Payload[2]),
+		Data:  p.Payload[3:],
+	}, true
+}
@@ -1,16 +1,19 @@
 package packet
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"github.com/pkg/errors"
 	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
 	"io"
 	"math"
+	"sync"
 )
 
 var nextClientPacketID int32 = 0
+var nextClientPacketIDLock sync.Mutex
 
 type ClientPacket struct {
 	mode  endian.Mode
@@ -49,13 +52,16 @@ func getNextID(restrictedIDs []int32) int32 {
 }
 
 func NewClientPacket(mode endian.Mode, pType PacketType, body string, restrictedIDs []int32) Packet {
+	nextClientPacketIDLock.Lock()
 	nextClientPacketID = getNextID(restrictedIDs)
+	id := nextClientPacketID
+	nextClientPacketIDLock.Unlock()
 
 	p := &ClientPacket{
 		mode:  mode,
 		pType: pType,
 		body:  []byte(body),
-		id:    nextClientPacketID,
+		id:    id,
 	}
 
 	if len(body) == 0 {
@@ -65,6 +71,17 @@ func NewClientPacket(mode endian.Mode, pType PacketType, body string, restricted
 	return p
 }
 
+// NewPacketWithID creates a packet with an explicit ID instead of drawing one from the client ID generator. It is
+// intended for server-side code such as mocks and demo servers which need to answer with the ID of a request.
+func NewPacketWithID(mode endian.Mode, id int32, pType PacketType, body string) Packet {
+	return &ClientPacket{
+		mode:  mode,
+		pType: pType,
+		body:  []byte(body),
+		id:    id,
+	}
+}
+
 const int32Bytes = 4
 const endPadBytes = 1
 
@@ -81,7 +98,11 @@ func (p *ClientPacket) Type() PacketType {
 }
 
 func (p *ClientPacket) Body() []byte {
-	return append(p.body, byte('\x00'))
+	// Copy before appending so that the terminator is never written into the backing array of p.body.
+	body := make([]byte, len(p.body), len(p.body)+1)
+	copy(body, p.body)
+
+	return append(body, byte('\x00'))
 }
 
 func (p *ClientPacket) Build() ([]byte, error) {
@@ -90,62 +111,150 @@ func (p *ClientPacket) Build() ([]byte, error) {
 	order := p.mode
 
 	if err := binary.Write(buffer, order, p.Size()); err != nil {
-		return nil, errors.Wrap(err, "could not write packet size")
+		return nil, fmt.Errorf("could not write packet size: %w", err)
 	}
 
 	if err := binary.Write(buffer, order, p.ID()); err != nil {
-		return nil, errors.Wrap(err, "could not write packet size")
+		return nil, fmt.Errorf("could not write packet size: %w", err)
 	}
 
 	if err := binary.Write(buffer, order, p.Type()); err != nil {
-		return nil, errors.Wrap(err, "could not write packet size")
+		return nil, fmt.Errorf("could not write packet size: %w", err)
 	}
 
 	if err := binary.Write(buffer, order, p.Body()); err != nil {
-		return nil, errors.Wrap(err, "could not write packet size")
+		return nil, fmt.Errorf("could not write packet size: %w", err)
 	}
 
 	if err := binary.Write(buffer, order, byte('\x00')); err != nil {
-		return nil, errors.Wrap(err, "could not write packet size")
+		return nil, fmt.Errorf("could not write packet size: %w", err)
 	}
 
 	return buffer.Bytes(), nil
 }
 
-var malformedPacketErr = fmt.Errorf("malformed packet")
+// ErrMalformedPacket is matched by the errors returned when a decoded packet header cannot possibly describe a valid
+// packet. It usually means the stream has lost framing and needs to be resynchronized. The errors are
+// *errs.MalformedPacketError values carrying the reason.
+var ErrMalformedPacket = errs.ErrMalformedPacket
+
+// ErrProtocolViolation is returned by DecodeClientPacketStrict for packets which were decoded, but do not follow the
+// protocol exactly.
+var ErrProtocolViolation = fmt.Errorf("protocol violation")
+
+// minPacketSize is the smallest size a packet can declare: id + type + body null terminator + end padding.
+const minPacketSize = int32Bytes + int32Bytes + 1 + endPadBytes
+
+// DefaultMaxPacketSize is the largest size a decoded packet may declare by default. Larger sizes are rejected with
+// ErrMalformedPacket before the body is read, so a corrupted or malicious size field can't make the decoder wait for,
+// or allocate, an unbounded body.
+const DefaultMaxPacketSize = 1 << 20
+
+// maxPlausibleSize is the largest size Resync will accept when scanning for the next header. It is deliberately
+// generous since it only exists to reject obvious garbage. ResyncMax accepts a different limit.
+const maxPlausibleSize = DefaultMaxPacketSize
+
+const headerBytes = int32Bytes * 3
+
+// DefaultBodyPreallocation is the number of body bytes DecodeClientPacket allocates up front. Bodies declaring a larger
+// size are read in stages as bytes arrive.
+const DefaultBodyPreallocation = 4096
 
 func DecodeClientPacket(mode endian.Mode, reader io.Reader) (*ClientPacket, error) {
+	return DecodeClientPacketStaged(mode, reader, DefaultBodyPreallocation)
+}
+
+// DecodeClientPacketStaged decodes a packet like DecodeClientPacket, but allocates at most prealloc body bytes up
+// front. If the packet declares a larger size, the body buffer grows as bytes actually arrive. This bounds the memory
+// a server declaring an inflated size can make the client allocate before sending any data. Packets declaring a size
+// above DefaultMaxPacketSize are rejected with ErrMalformedPacket; use DecodeClientPacketMax for a different limit.
+func DecodeClientPacketStaged(mode endian.Mode, reader io.Reader, prealloc int) (*ClientPacket, error) {
+	return DecodeClientPacketMax(mode, reader, prealloc, DefaultMaxPacketSize)
+}
+
+// DecodeClientPacketMax decodes a packet like DecodeClientPacketStaged, but rejects packets declaring a size above max
+// instead of DefaultMaxPacketSize. A max of zero or less means DefaultMaxPacketSize.
+func DecodeClientPacketMax(mode endian.Mode, reader io.Reader, prealloc int, max int) (*ClientPacket, error) {
+	if max <= 0 {
+		max = DefaultMaxPacketSize
+	}
+
+	p, _, err := decodeClientPacket(mode, reader, prealloc, max)
+	return p, err
+}
+
+// DecodeClientPacketStrict decodes a packet like DecodeClientPacketStaged, but returns an error wrapping
+// ErrProtocolViolation for packets which do not follow the protocol exactly instead of tolerating them: packets of an
+// unknown type and packets whose body is not followed by exactly the null terminator and end padding. The whole packet
+// is consumed and returned alongside the violation, so the stream stays in sync. For a different size limit, use a
+// SourceCodec with Strict and MaxPacketSize set.
+func DecodeClientPacketStrict(mode endian.Mode, reader io.Reader, prealloc int) (*ClientPacket, error) {
+	return checkStrict(decodeClientPacket(mode, reader, prealloc, DefaultMaxPacketSize))
+}
+
+// checkStrict returns an error wrapping ErrProtocolViolation if the decoded packet p, whose body was read as raw, does
+// not follow the protocol exactly.
+func checkStrict(p *ClientPacket, raw []byte, err error) (*ClientPacket, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.pType {
+	case TypeAuth, TypeCommand, TypeCommandRes:
+	default:
+		return p, fmt.Errorf("packet %d has unknown type %d: %w", p.id, p.pType, ErrProtocolViolation)
+	}
+
+	if len(raw) < 2 || raw[len(raw)-2] != '\x00' || raw[len(raw)-1] != '\x00' {
+		return p, fmt.Errorf("packet %d is not terminated by two null bytes: %w", p.id, ErrProtocolViolation)
+	}
+
+	return p, nil
+}
+
+// decodeClientPacket decodes a packet and also returns its body as read, including terminators. Packets declaring a
+// size above max are rejected.
+func decodeClientPacket(mode endian.Mode, reader io.Reader, prealloc int, max int) (*ClientPacket, []byte, error) {
 	var size int32
 	var id int32
 	var pType int32
 
 	// Read size
 	if err := binary.Read(reader, mode, &size); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if size < minPacketSize {
+		return nil, nil, &errs.MalformedPacketError{Reason: fmt.Sprintf("packet size %d is below the minimum", size)}
+	}
+
+	if int(size) > max {
+		return nil, nil, &errs.MalformedPacketError{
+			Reason: fmt.Sprintf("packet size %d exceeds the maximum of %d", size, max),
+		}
 	}
 
 	// Read ID
 	if err := binary.Read(reader, mode, &id); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Read type
 	if err := binary.Read(reader, mode, &pType); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Read body
 	bodyLen := size - 4 - 4 // size - id bytes - type bytes
-	body := make([]byte, bodyLen)
 
-	_, err := io.ReadFull(reader, body)
+	raw, err := readBody(reader, int(bodyLen), prealloc)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Trim unneeded bytes from body
-	body = bytes.Trim(body, "\x00")
-	body = bytes.Trim(body, "\n")
+	// Strip the body's null terminator and the end padding. Only trailing nulls are removed so that decoding is the
+	// exact inverse of Build.
+	body := bytes.TrimRight(raw, "\x00")
 
 	// Construct and return client packet
 	return &ClientPacket{
@@ -153,5 +262,116 @@ func DecodeClientPacket(mode endian.Mode, reader io.Reader) (*ClientPacket, erro
 		pType: PacketType(pType),
 		body:  body,
 		id:    id,
-	}, nil
+	}, raw, nil
+}
+
+// readBody reads exactly n bytes from reader. If n exceeds prealloc, only prealloc bytes are allocated up front and
+// the buffer grows as data is read.
+func readBody(reader io.Reader, n int, prealloc int) ([]byte, error) {
+	if n <= prealloc {
+		body := make([]byte, n)
+
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return nil, err
+		}
+
+		return body, nil
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, prealloc))
+
+	read, err := io.CopyN(buf, reader, int64(n))
+	if err != nil {
+		if err == io.EOF && read > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Header is the fixed-size part of a packet which precedes its body.
+type Header struct {
+	// Size is the size the packet declares: the number of bytes following the size field.
+	Size int32
+	ID   int32
+	Type PacketType
+}
+
+// PeekHeader returns the header of the next packet in reader without consuming any bytes. Headers declaring a size
+// below the minimum are returned along with an error wrapping ErrMalformedPacket.
+func PeekHeader(mode endian.Mode, reader *bufio.Reader) (Header, error) {
+	b, err := reader.Peek(headerBytes)
+	if err != nil {
+		return Header{}, err
+	}
+
+	h := Header{
+		Size: int32(mode.Uint32(b[0:4])),
+		ID:   int32(mode.Uint32(b[4:8])),
+		Type: PacketType(int32(mode.Uint32(b[8:12]))),
+	}
+
+	if h.Size < minPacketSize {
+		return h, &errs.MalformedPacketError{Reason: fmt.Sprintf("packet size %d is below the minimum", h.Size)}
+	}
+
+	return h, nil
+}
+
+// SkipPacket discards the next packet in reader, whose header h was returned by PeekHeader, without allocating its
+// body.
+func SkipPacket(reader *bufio.Reader, h Header) error {
+	_, err := reader.Discard(int32Bytes + int(h.Size))
+	return err
+}
+
+// plausibleHeader reports whether header could be the start of a valid packet declaring a size of at most max.
+func plausibleHeader(mode endian.Mode, header []byte, max int) bool {
+	size := int32(mode.Uint32(header[0:4]))
+	if size < minPacketSize || int(size) > max {
+		return false
+	}
+
+	switch PacketType(int32(mode.Uint32(header[8:12]))) {
+	case TypeAuth, TypeCommand, TypeCommandRes:
+		return true
+	}
+
+	return false
+}
+
+// Resync discards bytes from reader until the buffered data starts with a plausible packet header. It returns the
+// number of bytes which were discarded. Resync is used to recover after framing has been lost, for example after
+// garbage was injected into the stream or a partial packet was read.
+func Resync(mode endian.Mode, reader *bufio.Reader) (int, error) {
+	return ResyncMax(mode, reader, maxPlausibleSize)
+}
+
+// ResyncMax resynchronizes like Resync, but only accepts headers declaring a size of at most max, such as the maximum
+// packet size the decoder enforces. A max of zero or less means DefaultMaxPacketSize.
+func ResyncMax(mode endian.Mode, reader *bufio.Reader, max int) (int, error) {
+	if max <= 0 {
+		max = maxPlausibleSize
+	}
+
+	discarded := 0
+
+	for {
+		header, err := reader.Peek(headerBytes)
+		if err != nil {
+			return discarded, err
+		}
+
+		if plausibleHeader(mode, header, max) {
+			return discarded, nil
+		}
+
+		if _, err := reader.Discard(1); err != nil {
+			return discarded, err
+		}
+		discarded++
+	}
 }
@@ -0,0 +1,74 @@
+package packet
+
+import (
+	"github.com/refractorgscm/rcon/endian"
+	"io"
+)
+
+// Codec frames packets on the wire. Implementing it lets games with their own framing, such as extra header fields,
+// checksums or length prefixes, be supported without reimplementing the client.
+type Codec interface {
+	// Encode returns the wire representation of p.
+	Encode(p Packet) ([]byte, error)
+
+	// Decode reads the next packet from r. Errors wrapping ErrMalformedPacket mean the stream lost framing.
+	Decode(r io.Reader) (Packet, error)
+}
+
+// SourceCodec is the Codec of the Source RCON protocol.
+type SourceCodec struct {
+	Mode endian.Mode
+
+	// BodyPreallocation is the number of body bytes allocated up front. See DecodeClientPacketStaged.
+	//
+	// Default: DefaultBodyPreallocation
+	BodyPreallocation int
+
+	// MaxPacketSize is the largest size a decoded packet may declare. Packets declaring a larger size are rejected
+	// with ErrMalformedPacket.
+	//
+	// Default: DefaultMaxPacketSize
+	MaxPacketSize int
+
+	// Strict makes Decode report protocol violations like DecodeClientPacketStrict. The packet is returned alongside
+	// the violation.
+	Strict bool
+}
+
+// NewSourceCodec creates a SourceCodec for packets in the byte order mode.
+func NewSourceCodec(mode endian.Mode) *SourceCodec {
+	return &SourceCodec{
+		Mode:              mode,
+		BodyPreallocation: DefaultBodyPreallocation,
+		MaxPacketSize:     DefaultMaxPacketSize,
+	}
+}
+
+func (c *SourceCodec) Encode(p Packet) ([]byte, error) {
+	body := p.Body()
+
+	return NewPacketWithID(c.Mode, p.ID(), p.Type(), string(body[:len(body)-1])).Build()
+}
+
+func (c *SourceCodec) Decode(r io.Reader) (Packet, error) {
+	prealloc := c.BodyPreallocation
+	if prealloc <= 0 {
+		prealloc = DefaultBodyPreallocation
+	}
+
+	max := c.MaxPacketSize
+	if max <= 0 {
+		max = DefaultMaxPacketSize
+	}
+
+	p, raw, err := decodeClientPacket(c.Mode, r, prealloc, max)
+	if c.Strict {
+		p, err = checkStrict(p, raw, err)
+	}
+
+	if p == nil {
+		return nil, err
+	}
+
+	return p, err
+}
@@ -0,0 +1,113 @@
+package packet
+
+import (
+	"math"
+	"sync"
+)
+
+// IDGenerator hands out packet IDs which are never restricted. It is safe for concurrent use, and the restricted set
+// can be swapped at any time.
+type IDGenerator struct {
+	lock       sync.Mutex
+	last       int32
+	restricted map[int32]struct{}
+
+	// restrictedIDs is a copy of the slice the restricted set was created from.
+	restrictedIDs []int32
+}
+
+func NewIDGenerator(restrictedIDs []int32) *IDGenerator {
+	return NewSeededIDGenerator(0, restrictedIDs)
+}
+
+// NewSeededIDGenerator creates a generator whose first ID is the first unrestricted ID after seed. Generators created
+// with the same seed and restricted IDs hand out the same sequence of IDs. Seeds outside of [0, math.MaxInt32) are
+// treated as 0.
+func NewSeededIDGenerator(seed int32, restrictedIDs []int32) *IDGenerator {
+	if seed < 0 || seed == math.MaxInt32 {
+		seed = 0
+	}
+
+	g := &IDGenerator{last: seed}
+	g.SetRestricted(restrictedIDs)
+
+	return g
+}
+
+// idCount is the number of IDs handed out before the counter wraps around.
+const idCount = math.MaxInt32 - 1
+
+// following returns the ID after id, wrapping around to 1 before overflowing.
+func following(id int32) int32 {
+	if id+1 == math.MaxInt32 {
+		return 1
+	}
+
+	return id + 1
+}
+
+func (g *IDGenerator) advance() {
+	g.last = following(g.last)
+}
+
+func (g *IDGenerator) isRestricted(id int32) bool {
+	_, ok := g.restricted[id]
+	return ok
+}
+
+// Next returns the next unrestricted packet ID. IDs wrap around to 1 before overflowing. Next panics if every ID is
+// restricted.
+func (g *IDGenerator) Next() int32 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	// Increment the counter until it is no longer a restricted ID, giving up after a full cycle
+	for i := 0; i < idCount; i++ {
+		g.advance()
+
+		if !g.isRestricted(g.last) {
+			return g.last
+		}
+	}
+
+	panic("packet: every packet ID is restricted")
+}
+
+// SetRestricted atomically replaces the set of restricted IDs. If IDs following the most recently issued one became
+// restricted, the counter is moved past them so that they are skipped without being considered again.
+func (g *IDGenerator) SetRestricted(restrictedIDs []int32) {
+	restricted := make(map[int32]struct{}, len(restrictedIDs))
+	for _, id := range restrictedIDs {
+		restricted[id] = struct{}{}
+	}
+
+	ids := make([]int32, len(restrictedIDs))
+	copy(ids, restrictedIDs)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.restricted = restricted
+	g.restrictedIDs = ids
+
+	for i := 0; i < idCount && g.isRestricted(following(g.last)); i++ {
+		g.advance()
+	}
+}
+
+// Restricted returns the currently restricted IDs as passed to SetRestricted. The slice is shared and must not be
+// modified.
+func (g *IDGenerator) Restricted() []int32 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.restrictedIDs
+}
+
+// IsRestricted reports whether id is currently restricted.
+func (g *IDGenerator) IsRestricted(id int32) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.isRestricted(id)
+}
@@ -1,11 +1,16 @@
 package packet
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"github.com/franela/goblin"
 	. "github.com/onsi/gomega"
 	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"io"
 	"math"
+	"sync"
 	"testing"
 )
 
@@ -108,6 +113,371 @@ func Test(t *testing.T) {
 					Expect(err).To(BeNil())
 					Expect(decoded).To(Equal(packet))
 				})
+
+				g.It("Should return ErrMalformedPacket for an implausible size", func() {
+					raw := []byte{'\x02', '\x00', '\x00', '\x00', '\x01', '\x00', '\x00', '\x00', '\x02', '\x00',
+						'\x00', '\x00'}
+
+					_, err := DecodeClientPacket(packet.mode, bytes.NewReader(raw))
+					Expect(errors.Is(err, ErrMalformedPacket)).To(BeTrue())
+
+					var malformed *errs.MalformedPacketError
+					Expect(errors.As(err, &malformed)).To(BeTrue())
+					Expect(malformed.Reason).To(Equal("packet size 2 is below the minimum"))
+				})
+
+				g.It("Should return ErrMalformedPacket for a size above the maximum", func() {
+					raw, err := NewPacketWithID(packet.mode, 1, TypeCommandRes, "too long").Build()
+					Expect(err).To(BeNil())
+
+					codec := NewSourceCodec(packet.mode)
+					codec.MaxPacketSize = 10
+					_, err = codec.Decode(bytes.NewReader(raw))
+					Expect(errors.Is(err, ErrMalformedPacket)).To(BeTrue())
+
+					codec.MaxPacketSize = 18
+					decoded, err := codec.Decode(bytes.NewReader(raw))
+					Expect(err).To(BeNil())
+					Expect(string(decoded.Body())).To(Equal("too long\x00"))
+				})
+			})
+
+			g.Describe("DecodeClientPacketStaged()", func() {
+				g.It("Should decode bodies larger than the preallocation", func() {
+					decoded, err := DecodeClientPacketStaged(packet.mode, bytes.NewReader(rawPacket), 4)
+
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+
+				g.It("Should return ErrUnexpectedEOF if an inflated size is never filled", func() {
+					raw := append([]byte{}, rawPacket...)
+					raw[0] = '\xff'
+
+					_, err := DecodeClientPacketStaged(packet.mode, bytes.NewReader(raw), 4)
+					Expect(err).To(Equal(io.ErrUnexpectedEOF))
+				})
+			})
+
+			g.Describe("DecodeClientPacketMax()", func() {
+				g.It("Should decode packets declaring exactly the maximum size", func() {
+					decoded, err := DecodeClientPacketMax(packet.mode, bytes.NewReader(rawPacket), 4, int(packet.Size()))
+
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+
+				g.It("Should reject packets declaring one byte more than the maximum", func() {
+					_, err := DecodeClientPacketMax(packet.mode, bytes.NewReader(rawPacket), 4, int(packet.Size())-1)
+
+					Expect(errors.Is(err, ErrMalformedPacket)).To(BeTrue())
+				})
+			})
+
+			g.Describe("DecodeClientPacketStrict()", func() {
+				g.It("Should decode a well formed packet", func() {
+					decoded, err := DecodeClientPacketStrict(packet.mode, bytes.NewReader(rawPacket), 4)
+
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+
+				g.It("Should return ErrProtocolViolation for a missing terminator", func() {
+					raw := append([]byte{}, rawPacket...)
+					raw[len(raw)-2] = '!'
+
+					decoded, err := DecodeClientPacketStrict(packet.mode, bytes.NewReader(raw), 4)
+					Expect(errors.Is(err, ErrProtocolViolation)).To(BeTrue())
+					Expect(decoded.ID()).To(Equal(packet.id))
+				})
+
+				g.It("Should return ErrProtocolViolation for an unknown type", func() {
+					raw := append([]byte{}, rawPacket...)
+					raw[8] = '\x07'
+
+					_, err := DecodeClientPacketStrict(packet.mode, bytes.NewReader(raw), 4)
+					Expect(errors.Is(err, ErrProtocolViolation)).To(BeTrue())
+				})
+			})
+
+			g.Describe("PeekHeader()", func() {
+				g.It("Should return the header without consuming it, and SkipPacket should skip the packet", func() {
+					reader := bufio.NewReader(bytes.NewReader(append(append([]byte{}, rawPacket...), rawPacket...)))
+
+					h, err := PeekHeader(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(h).To(Equal(Header{Size: packet.Size(), ID: 1, Type: TypeCommand}))
+
+					Expect(SkipPacket(reader, h)).To(BeNil())
+
+					decoded, err := DecodeClientPacket(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+			})
+
+			g.Describe("Resync()", func() {
+				g.It("Should skip injected garbage and decode the next packet", func() {
+					garbage := []byte{'\xde', '\xad', '\xbe', '\xef', '\xff', '\x00', '\x13'}
+					reader := bufio.NewReader(bytes.NewReader(append(garbage, rawPacket...)))
+
+					discarded, err := Resync(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(discarded).To(Equal(len(garbage)))
+
+					decoded, err := DecodeClientPacket(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+
+				g.It("Should not discard anything when already aligned", func() {
+					reader := bufio.NewReader(bytes.NewReader(rawPacket))
+
+					discarded, err := Resync(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(discarded).To(Equal(0))
+				})
+
+				g.It("Should recover after a malformed header was consumed", func() {
+					stream := []byte{'\xff', '\xff', '\xff', '\xff', '\x00', '\x00', '\x00', '\x00', '\x00', '\x00',
+						'\x00', '\x00', '\x42'}
+					stream = append(stream, rawPacket...)
+					reader := bufio.NewReader(bytes.NewReader(stream))
+
+					_, err := DecodeClientPacket(packet.mode, reader)
+					Expect(errors.Is(err, ErrMalformedPacket)).To(BeTrue())
+
+					_, err = Resync(packet.mode, reader)
+					Expect(err).To(BeNil())
+
+					decoded, err := DecodeClientPacket(packet.mode, reader)
+					Expect(err).To(BeNil())
+					Expect(decoded).To(Equal(packet))
+				})
+
+				g.It("Should only accept headers within the maximum size", func() {
+					reader := bufio.NewReader(bytes.NewReader(rawPacket))
+
+					discarded, err := ResyncMax(packet.mode, reader, int(packet.Size()))
+					Expect(err).To(BeNil())
+					Expect(discarded).To(Equal(0))
+
+					_, err = ResyncMax(packet.mode, reader, int(packet.Size())-1)
+					Expect(err).ToNot(BeNil())
+				})
+
+				g.It("Should return an error if no plausible header is found", func() {
+					reader := bufio.NewReader(bytes.NewReader([]byte{'\xde', '\xad', '\xbe', '\xef'}))
+
+					_, err := Resync(packet.mode, reader)
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("IDGenerator", func() {
+			g.It("Should skip restricted packet IDs", func() {
+				gen := NewIDGenerator([]int32{2, 3})
+
+				Expect(gen.Next()).To(Equal(int32(1)))
+				Expect(gen.Next()).To(Equal(int32(4)))
+			})
+
+			g.It("Should reset the counter if the next value would overflow", func() {
+				gen := NewIDGenerator(nil)
+				gen.last = math.MaxInt32 - 1
+
+				Expect(gen.Next()).To(Equal(int32(1)))
+			})
+
+			g.It("Should skip IDs which became restricted at runtime", func() {
+				gen := NewIDGenerator(nil)
+				Expect(gen.Next()).To(Equal(int32(1)))
+
+				gen.SetRestricted([]int32{2, 3, 4})
+				Expect(gen.IsRestricted(3)).To(BeTrue())
+				Expect(gen.Next()).To(Equal(int32(5)))
+			})
+
+			g.It("Should skip IDs which became restricted at runtime across the wrap around", func() {
+				gen := NewIDGenerator(nil)
+				gen.last = math.MaxInt32 - 1
+
+				gen.SetRestricted([]int32{1, 2})
+				Expect(gen.last).To(Equal(int32(2)))
+				Expect(gen.Next()).To(Equal(int32(3)))
+			})
+
+			g.It("Should stop restricting IDs which were removed from the set", func() {
+				gen := NewIDGenerator([]int32{3})
+				gen.SetRestricted(nil)
+				Expect(gen.IsRestricted(3)).To(BeFalse())
+
+				gen.Next()
+				gen.Next()
+				Expect(gen.Next()).To(Equal(int32(3)))
+			})
+
+			g.It("Should hand out the same sequence for the same seed", func() {
+				a := NewSeededIDGenerator(100, []int32{102})
+				b := NewSeededIDGenerator(100, []int32{102})
+
+				for _, want := range []int32{101, 103, 104} {
+					Expect(a.Next()).To(Equal(want))
+					Expect(b.Next()).To(Equal(want))
+				}
+			})
+
+			g.It("Should never hand out the same ID twice concurrently", func() {
+				gen := NewIDGenerator([]int32{10, 20, 30})
+				ids := make(chan int32, 1000)
+
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for j := 0; j < 100; j++ {
+							ids <- gen.Next()
+						}
+					}()
+				}
+				wg.Wait()
+				close(ids)
+
+				seen := map[int32]bool{}
+				for id := range ids {
+					Expect(seen).ToNot(HaveKey(id))
+					Expect(gen.IsRestricted(id)).To(BeFalse())
+					seen[id] = true
+				}
+			})
+		})
+
+		g.Describe("BattlEyePacket", func() {
+			g.It("Should round trip a command packet", func() {
+				p := &BattlEyePacket{Type: BattlEyeTypeCommand, Seq: 7, Payload: []byte("players")}
+
+				decoded, err := DecodeBattlEyePacket(p.Build())
+				Expect(err).To(BeNil())
+				Expect(decoded).To(Equal(p))
+			})
+
+			g.It("Should encode login packets without a sequence number", func() {
+				raw := (&BattlEyePacket{Type: BattlEyeTypeLogin, Payload: []byte("pw")}).Build()
+
+				Expect(raw[:2]).To(Equal([]byte("BE")))
+				Expect(raw[6:]).To(Equal([]byte{'\xff', '\x00', 'p', 'w'}))
+			})
+
+			g.It("Should return ErrChecksum if the checksum does not match", func() {
+				raw := (&BattlEyePacket{Type: BattlEyeTypeCommand, Seq: 1, Payload: []byte("a")}).Build()
+				raw[len(raw)-1] = 'b'
+
+				_, err := DecodeBattlEyePacket(raw)
+				Expect(err).To(Equal(ErrChecksum))
+			})
+
+			g.It("Should return ErrMalformedPacket for an invalid header", func() {
+				_, err := DecodeBattlEyePacket([]byte("XX\x00\x00\x00\x00\xff\x01\x00"))
+				Expect(errors.Is(err, ErrMalformedPacket)).To(BeTrue())
+			})
+
+			g.It("Should parse multipart headers", func() {
+				p := &BattlEyePacket{Type: BattlEyeTypeCommand, Seq: 3, Payload: []byte{'\x00', '\x02', '\x01', 'h', 'i'}}
+
+				part, ok := p.Multipart()
+				Expect(ok).To(BeTrue())
+				Expect(part).To(Equal(BattlEyeMultipart{Total: 2, Index: 1, Data: []byte("hi")}))
+			})
+		})
+
+		g.Describe("Test vectors", func() {
+			var vectors []TestVector
+
+			g.Before(func() {
+				var err error
+				vectors, err = TestVectors()
+				Expect(err).To(BeNil())
+			})
+
+			g.It("Should only convert vectors to packets of their own protocol", func() {
+				for _, v := range vectors {
+					_, sourceErr := v.Packet()
+					_, battlEyeErr := v.BattlEyePacket()
+
+					Expect(sourceErr == nil).ToNot(Equal(battlEyeErr == nil), v.Dialect+"/"+v.Name)
+					Expect(battlEyeErr == nil).To(Equal(v.IsBattlEye()), v.Dialect+"/"+v.Name)
+				}
+			})
+
+			g.It("Should embed the vectors file", func() {
+				fromFile, err := LoadTestVectorsFile(TestVectorsPath)
+				Expect(err).To(BeNil())
+				Expect(vectors).To(Equal(fromFile))
+			})
+
+			g.It("Should load at least one vector per endian mode", func() {
+				seen := map[string]bool{}
+				for _, v := range vectors {
+					seen[v.Endian] = true
+				}
+
+				Expect(seen).To(HaveKey("little"))
+				Expect(seen).To(HaveKey("big"))
+			})
+
+			g.It("Should load vectors for every dialect", func() {
+				seen := map[string]bool{}
+				for _, v := range vectors {
+					seen[v.Dialect] = true
+				}
+
+				Expect(seen).To(HaveKey("source"))
+				Expect(seen).To(HaveKey("mordhau"))
+				Expect(seen).To(HaveKey("minecraft"))
+				Expect(seen).To(HaveKey("battleye"))
+			})
+
+			g.It("Should build the golden bytes for every vector", func() {
+				for _, v := range vectors {
+					if v.IsBattlEye() {
+						p, err := v.BattlEyePacket()
+						Expect(err).To(BeNil())
+						Expect(p.Build()).To(Equal(v.Raw), v.Dialect+"/"+v.Name)
+						continue
+					}
+
+					p, err := v.Packet()
+					Expect(err).To(BeNil())
+
+					got, err := p.Build()
+					Expect(err).To(BeNil())
+					Expect(got).To(Equal(v.Raw), v.Dialect+"/"+v.Name+"/"+v.Endian)
+				}
+			})
+
+			g.It("Should decode the golden bytes for every vector", func() {
+				for _, v := range vectors {
+					if v.IsBattlEye() {
+						decoded, err := DecodeBattlEyePacket(v.Raw)
+						Expect(err).To(BeNil(), v.Dialect+"/"+v.Name)
+
+						expected, err := v.BattlEyePacket()
+						Expect(err).To(BeNil())
+						Expect(decoded).To(Equal(expected), v.Dialect+"/"+v.Name)
+						continue
+					}
+
+					mode, err := v.Mode()
+					Expect(err).To(BeNil())
+
+					decoded, err := DecodeClientPacket(mode, bytes.NewReader(v.Raw))
+					Expect(err).To(BeNil())
+					Expect(decoded.ID()).To(Equal(v.ID))
+					Expect(decoded.Type()).To(Equal(v.Type))
+					Expect(string(decoded.body)).To(Equal(v.Body))
+				}
 			})
 		})
 	})
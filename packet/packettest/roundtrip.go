@@ -0,0 +1,126 @@
+// Package packettest provides reusable property tests for packet codecs. Dialect implementations can run the same
+// round-trip checks as the Source packet implementation by supplying their own Codec.
+package packettest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing/quick"
+)
+
+// MaxBodyLen is the maximum length of randomly generated bodies.
+const MaxBodyLen = 4096
+
+// Codec is a pair of functions which encode and decode packets for a dialect.
+type Codec struct {
+	Encode func(mode endian.Mode, id int32, pType packet.PacketType, body string) ([]byte, error)
+	Decode func(mode endian.Mode, r io.Reader) (packet.Packet, error)
+}
+
+// SourceCodec is the Codec of the Source RCON packet implementation.
+var SourceCodec = FromCodec(func(mode endian.Mode) packet.Codec {
+	return packet.NewSourceCodec(mode)
+})
+
+// FromCodec returns a Codec using the packet.Codec newCodec creates for each byte order.
+func FromCodec(newCodec func(mode endian.Mode) packet.Codec) Codec {
+	return Codec{
+		Encode: func(mode endian.Mode, id int32, pType packet.PacketType, body string) ([]byte, error) {
+			return newCodec(mode).Encode(packet.NewPacketWithID(mode, id, pType, body))
+		},
+		Decode: func(mode endian.Mode, r io.Reader) (packet.Packet, error) {
+			return newCodec(mode).Decode(r)
+		},
+	}
+}
+
+// Sample is a randomly generated packet. Bodies never contain null bytes since the body is a null terminated string on
+// the wire, but may contain any other byte including newlines.
+type Sample struct {
+	ID   int32
+	Type packet.PacketType
+	Body string
+}
+
+var sampleTypes = []packet.PacketType{packet.TypeAuth, packet.TypeAuthRes, packet.TypeCommand, packet.TypeCommandRes}
+
+// Generate implements quick.Generator.
+func (Sample) Generate(r *rand.Rand, size int) reflect.Value {
+	bodyLen := r.Intn(size + 1)
+	if bodyLen > MaxBodyLen {
+		bodyLen = MaxBodyLen
+	}
+
+	body := make([]byte, bodyLen)
+	for i := range body {
+		body[i] = byte(r.Intn(255) + 1)
+	}
+
+	return reflect.ValueOf(Sample{
+		ID:   r.Int31(),
+		Type: sampleTypes[r.Intn(len(sampleTypes))],
+		Body: string(body),
+	})
+}
+
+// RoundTrip encodes s with codec and decodes the result, returning an error describing the first property which does
+// not hold.
+func RoundTrip(codec Codec, mode endian.Mode, s Sample) error {
+	raw, err := codec.Encode(mode, s.ID, s.Type, s.Body)
+	if err != nil {
+		return fmt.Errorf("encode failed: %w", err)
+	}
+
+	reader := bytes.NewReader(raw)
+
+	decoded, err := codec.Decode(mode, reader)
+	if err != nil {
+		return fmt.Errorf("decode failed: %w", err)
+	}
+
+	if reader.Len() != 0 {
+		return fmt.Errorf("decode left %d trailing bytes", reader.Len())
+	}
+
+	if decoded.ID() != s.ID {
+		return fmt.Errorf("id mismatch: got %d, want %d", decoded.ID(), s.ID)
+	}
+
+	if decoded.Type() != s.Type {
+		return fmt.Errorf("type mismatch: got %d, want %d", decoded.Type(), s.Type)
+	}
+
+	wantBody := append([]byte(s.Body), '\x00')
+	if !bytes.Equal(decoded.Body(), wantBody) {
+		return fmt.Errorf("body mismatch: got %q, want %q", decoded.Body(), wantBody)
+	}
+
+	if int(decoded.Size()) != len(raw)-4 {
+		return fmt.Errorf("size mismatch: got %d, encoded %d bytes after the size field", decoded.Size(), len(raw)-4)
+	}
+
+	return nil
+}
+
+// CheckRoundTrip runs RoundTrip against randomly generated samples in both little and big endian mode.
+func CheckRoundTrip(codec Codec, config *quick.Config) error {
+	for _, mode := range []endian.Mode{endian.Little, endian.Big} {
+		var failure error
+
+		property := func(s Sample) bool {
+			failure = RoundTrip(codec, mode, s)
+			return failure == nil
+		}
+
+		if err := quick.Check(property, config); err != nil {
+			return fmt.Errorf("%s: %v: %w", mode, err, failure)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package packet_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/packet/packettest"
+	"testing"
+	"testing/quick"
+)
+
+func TestRoundTrip(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Round trip", func() {
+		g.It("Should decode every built packet to an equal packet", func() {
+			Expect(packettest.CheckRoundTrip(packettest.SourceCodec, &quick.Config{MaxCount: 500})).To(BeNil())
+		})
+
+		g.It("Should preserve leading and trailing newlines", func() {
+			s := packettest.Sample{ID: 7, Type: packet.TypeCommandRes, Body: "\nline\n"}
+
+			Expect(packettest.RoundTrip(packettest.SourceCodec, endian.Little, s)).To(BeNil())
+		})
+
+		g.It("Should round trip an empty body", func() {
+			s := packettest.Sample{ID: 1, Type: packet.TypeCommand, Body: ""}
+
+			Expect(packettest.RoundTrip(packettest.SourceCodec, endian.Big, s)).To(BeNil())
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package packet
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/refractorgscm/rcon/endian"
+	"io"
+	"os"
+)
+
+// TestVectorsPath is the path, relative to this package's directory, of the golden wire-format vectors. The file is
+// plain JSON so that implementations in other languages can verify byte-level compatibility against the same data.
+// It is embedded in the package, so Go code should use TestVectors instead of reading it from disk.
+const TestVectorsPath = "testdata/vectors.json"
+
+// battlEyeVectorDialect is the dialect of test vectors describing BattlEye packets.
+const battlEyeVectorDialect = "battleye"
+
+//go:embed testdata/vectors.json
+var testVectorsJSON []byte
+
+// TestVectorsJSON returns the embedded golden vectors file, for tools exporting it to implementations in other
+// languages.
+func TestVectorsJSON() []byte {
+	return append([]byte(nil), testVectorsJSON...)
+}
+
+// TestVectors decodes the embedded golden vectors. Unlike LoadTestVectorsFile it works from any working directory,
+// including in tests of other packages and modules.
+func TestVectors() ([]TestVector, error) {
+	return LoadTestVectors(bytes.NewReader(testVectorsJSON))
+}
+
+// TestVector is a single golden wire-format sample. Raw holds the exact bytes which should be produced when building
+// a packet with the given ID, Type and Body using the vector's byte order.
+//
+// Vectors of the "battleye" dialect describe a BattlEyePacket instead: Type is its BattlEye packet type, ID its
+// sequence number and Body its payload. Their byte order is always little endian.
+type TestVector struct {
+	Name    string     `json:"name"`
+	Dialect string     `json:"dialect"`
+	Endian  string     `json:"endian"`
+	ID      int32      `json:"id"`
+	Type    PacketType `json:"type"`
+	Body    string     `json:"body"`
+	Raw     []byte     `json:"-"`
+	RawHex  string     `json:"raw"`
+}
+
+// Mode returns the byte order the vector was encoded with.
+func (v TestVector) Mode() (endian.Mode, error) {
+	switch v.Endian {
+	case "little":
+		return endian.Little, nil
+	case "big":
+		return endian.Big, nil
+	}
+
+	return nil, fmt.Errorf("unknown endian mode %q", v.Endian)
+}
+
+// IsBattlEye reports whether the vector describes a BattlEyePacket rather than a Source packet.
+func (v TestVector) IsBattlEye() bool {
+	return v.Dialect == battlEyeVectorDialect
+}
+
+// Packet returns a ClientPacket populated with the vector's fields. It fails for BattlEye vectors; use BattlEyePacket
+// for those.
+func (v TestVector) Packet() (*ClientPacket, error) {
+	if v.IsBattlEye() {
+		return nil, fmt.Errorf("test vector %s is a battleye packet", v.Name)
+	}
+
+	mode, err := v.Mode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientPacket{
+		mode:  mode,
+		pType: v.Type,
+		body:  []byte(v.Body),
+		id:    v.ID,
+	}, nil
+}
+
+// BattlEyePacket returns a BattlEyePacket populated with the vector's fields. It fails for vectors of other dialects.
+func (v TestVector) BattlEyePacket() (*BattlEyePacket, error) {
+	if !v.IsBattlEye() {
+		return nil, fmt.Errorf("test vector %s is not a battleye packet", v.Name)
+	}
+
+	return &BattlEyePacket{
+		Type:    byte(v.Type),
+		Seq:     byte(v.ID),
+		Payload: []byte(v.Body),
+	}, nil
+}
+
+// LoadTestVectors decodes golden vectors from r.
+func LoadTestVectors(r io.Reader) ([]TestVector, error) {
+	var vectors []TestVector
+
+	if err := json.NewDecoder(r).Decode(&vectors); err != nil {
+		return nil, fmt.Errorf("could not decode test vectors: %w", err)
+	}
+
+	for i := range vectors {
+		raw, err := hex.DecodeString(vectors[i].RawHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw bytes in test vector %d (%s): %w", i, vectors[i].Name, err)
+		}
+
+		vectors[i].Raw = raw
+	}
+
+	return vectors, nil
+}
+
+// LoadTestVectorsFile opens the file at path and decodes golden vectors from it.
+func LoadTestVectorsFile(path string) ([]TestVector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open test vectors file: %w", err)
+	}
+	defer f.Close()
+
+	return LoadTestVectors(f)
+}
@@ -0,0 +1,111 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+	"testing"
+)
+
+// overhead is the number of bytes the size field counts besides the body.
+const overhead = 10
+
+func TestMaxPacketSize(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("MaxPacketSize", func() {
+		const max = 64
+		var disconnects chan error
+
+		config := func() *rcon.Config {
+			ch := make(chan error, 1)
+			disconnects = ch
+
+			return &rcon.Config{
+				MaxPacketSize:     max,
+				ResyncStrategy:    rcon.ResyncDisconnect,
+				DisconnectHandler: func(err error, _ bool) { ch <- err },
+			}
+		}
+
+		g.It("Should send commands at the limit and reject larger ones", func() {
+			server, client := newTestClient(t, config())
+			atLimit := strings.Repeat("a", max-overhead)
+			server.SetResponse(atLimit, "ok")
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand(atLimit)
+			Expect(err).To(BeNil())
+
+			_, err = client.ExecCommand(atLimit + "a")
+			Expect(errors.Is(err, errs.ErrCommandTooLarge)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrNotSent)).To(BeTrue())
+			Expect(server.Commands()).To(Equal([]string{atLimit}))
+		})
+
+		g.It("Should accept responses at the limit and reject larger ones", func() {
+			server, client := newTestClient(t, config())
+			server.SetResponse("small", strings.Repeat("r", max-overhead))
+			server.SetResponse("large", strings.Repeat("r", max-overhead+1))
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("small")
+			Expect(err).To(BeNil())
+			Expect(res).To(HaveLen(max - overhead))
+
+			_, err = client.ExecCommand("large")
+			Expect(err).NotTo(BeNil())
+
+			Eventually(disconnects).Should(Receive(WithTransform(func(err error) bool {
+				return errors.Is(err, errs.ErrDesync)
+			}, BeTrue())))
+		})
+
+		g.It("Should apply the limit to a configured Source codec", func() {
+			c := config()
+			c.Codec = packet.NewSourceCodec(endian.Little)
+
+			server, client := newTestClient(t, c)
+			server.SetResponse("large", strings.Repeat("r", max-overhead+1))
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("large")
+			Expect(err).NotTo(BeNil())
+			Eventually(disconnects).Should(Receive())
+		})
+
+		g.It("Should split command lists which don't fit", func() {
+			c := config()
+			c.SplitCommands = true
+
+			server, client := newTestClient(t, c)
+			server.Handle("say", func(args string) string { return "said " + args })
+
+			Expect(client.Connect()).To(BeNil())
+
+			first := "say " + strings.Repeat("a", 30)
+			second := "say " + strings.Repeat("b", 30)
+			quoted := `say "` + strings.Repeat("c;", 10) + `"`
+
+			res, err := client.ExecCommand(first + ";" + second + ";" + quoted)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("said " + strings.Repeat("a", 30) + "\nsaid " + strings.Repeat("b", 30) +
+				"\nsaid " + quoted[4:]))
+			Expect(server.Commands()).To(Equal([]string{first, second, quoted}))
+
+			_, err = client.ExecCommand(strings.Repeat("x", max))
+			Expect(errors.Is(err, errs.ErrCommandTooLarge)).To(BeTrue())
+		})
+	})
+}
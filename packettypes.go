@@ -0,0 +1,89 @@
+package rcon
+
+import (
+	"github.com/refractorgscm/rcon/packet"
+	"strconv"
+)
+
+// PacketHandler handles server packets of a custom type, for example game-specific telemetry. It is called by the
+// reader routine, so it should return quickly.
+type PacketHandler func(p packet.Packet)
+
+// UnhandledPacketHandler is called with packets the client could not route: packets of unknown types for which no
+// PacketHandler is registered, and responses for which no mailbox is open. raw is the packet encoded as it was
+// received. reason describes why the packet was not handled.
+type UnhandledPacketHandler func(p packet.Packet, raw []byte, reason string)
+
+// HandlePacketType registers handler for server packets of type t, replacing the handler configured in PacketHandlers
+// or by the dialect. A nil handler removes the registration.
+func (c *Client) HandlePacketType(t packet.PacketType, handler PacketHandler) {
+	c.packetHandlersLock.Lock()
+	defer c.packetHandlersLock.Unlock()
+
+	if handler == nil {
+		delete(c.packetHandlers, t)
+		return
+	}
+
+	c.packetHandlers[t] = handler
+}
+
+// initPacketHandlers fills the registry from the dialect's features and PacketHandlers. Handlers configured in
+// PacketHandlers take precedence.
+func (c *Client) initPacketHandlers() {
+	c.packetHandlers = map[packet.PacketType]PacketHandler{}
+
+	if c.Dialect != nil {
+		for t, h := range c.Dialect.Features().PacketHandlers {
+			c.packetHandlers[t] = h
+		}
+	}
+
+	for t, h := range c.PacketHandlers {
+		c.packetHandlers[t] = h
+	}
+}
+
+func (c *Client) packetHandler(t packet.PacketType) PacketHandler {
+	c.packetHandlersLock.RLock()
+	defer c.packetHandlersLock.RUnlock()
+
+	return c.packetHandlers[t]
+}
+
+// isKnownType reports whether servers send packets of type t as part of the Source RCON protocol.
+func isKnownType(t packet.PacketType) bool {
+	return t == packet.TypeCommandRes || t == packet.TypeAuthRes
+}
+
+// handleCustomPacket routes a packet of an unknown type to its registered handler or, if there is none, to the
+// UnhandledPacketHandler. It returns false for packets of known types, which must be routed as usual.
+func (c *Client) handleCustomPacket(p packet.Packet) bool {
+	if isKnownType(p.Type()) {
+		return false
+	}
+
+	if handler := c.packetHandler(p.Type()); handler != nil {
+		handler(p)
+		return true
+	}
+
+	c.log.Debug("Packet ", p.ID(), " has unknown type ", p.Type())
+	c.unhandledPacket(p, "has unknown type "+strconv.Itoa(int(p.Type())))
+
+	return true
+}
+
+// unhandledPacket reports p to the UnhandledPacketHandler.
+func (c *Client) unhandledPacket(p packet.Packet, reason string) {
+	if c.UnhandledPacketHandler == nil {
+		return
+	}
+
+	raw, err := c.codec().Encode(p)
+	if err != nil {
+		c.log.Debug("Could not encode unhandled packet ", p.ID(), ". Error: ", err)
+	}
+
+	c.UnhandledPacketHandler(p, raw, reason)
+}
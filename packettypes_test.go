@@ -0,0 +1,187 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// telemetryType is a custom packet type servers in these tests send.
+const telemetryType = packet.PacketType(5)
+
+type telemetryDialect struct {
+	handler rcon.PacketHandler
+}
+
+func (telemetryDialect) Name() string { return "telemetry" }
+
+func (d telemetryDialect) Features() rcon.Features {
+	return rcon.Features{
+		Broadcasts:       true,
+		BroadcastChecker: rcontest.BroadcastChecker,
+		PacketHandlers:   map[packet.PacketType]rcon.PacketHandler{telemetryType: d.handler},
+	}
+}
+
+// packetRecorder is a PacketHandler recording the bodies of the packets it handled.
+type packetRecorder struct {
+	lock   sync.Mutex
+	ids    []int32
+	bodies []string
+}
+
+func (r *packetRecorder) handle(p packet.Packet) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.ids = append(r.ids, p.ID())
+	r.bodies = append(r.bodies, strings.TrimRight(string(p.Body()), "\x00"))
+}
+
+func (r *packetRecorder) Bodies() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]string(nil), r.bodies...)
+}
+
+func (r *packetRecorder) IDs() []int32 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]int32(nil), r.ids...)
+}
+
+func TestPacketTypes(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Custom packet types", func() {
+		var recorder *packetRecorder
+
+		g.BeforeEach(func() {
+			recorder = &packetRecorder{}
+		})
+
+		g.It("Should route packets of a registered type to their handler", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			client.HandlePacketType(telemetryType, recorder.handle)
+
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(7, telemetryType, "fps=60")
+			Eventually(recorder.Bodies).Should(Equal([]string{"fps=60"}))
+			Expect(recorder.IDs()).To(Equal([]int32{7}))
+		})
+
+		g.It("Should not route packets of a registered type to broadcasts", func() {
+			server, client := newTestClient(t, &rcon.Config{Dialect: telemetryDialect{handler: recorder.handle}})
+
+			broadcasts, unsubscribe := client.Subscribe(nil)
+			defer unsubscribe()
+
+			Expect(client.Connect()).To(BeNil())
+
+			// The ID marks the packet as a broadcast, but its type takes precedence.
+			server.Send(rcontest.BroadcastID, telemetryType, "fps=60")
+			Eventually(recorder.Bodies).Should(Equal([]string{"fps=60"}))
+
+			server.Broadcast(rcontest.BroadcastID, "chat")
+			Eventually(broadcasts).Should(Receive(WithTransform(func(b rcon.Broadcast) string {
+				return b.Message
+			}, Equal("chat"))))
+			Consistently(broadcasts).ShouldNot(Receive())
+		})
+
+		g.It("Should not route packets of a registered type to mailboxes", func() {
+			var lock sync.Mutex
+			var slowID int32
+
+			server, client := newTestClient(t, &rcon.Config{
+				QueueReadTimeout: time.Second,
+				PacketHooks: rcon.PacketHooks{
+					OnSend: func(p packet.Packet, _ []byte) {
+						if strings.HasPrefix(string(p.Body()), "slow") {
+							lock.Lock()
+							slowID = p.ID()
+							lock.Unlock()
+						}
+					},
+				},
+			})
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 200)
+				return "done"
+			})
+			client.HandlePacketType(telemetryType, recorder.handle)
+
+			Expect(client.Connect()).To(BeNil())
+
+			results := make(chan string, 1)
+			go func() {
+				res, _ := client.ExecCommand("slow")
+				results <- res
+			}()
+			Eventually(server.Commands).Should(ContainElement("slow"))
+
+			lock.Lock()
+			id := slowID
+			lock.Unlock()
+
+			// A packet of the custom type carrying the ID of the command in flight must not be taken as its response.
+			server.Send(id, telemetryType, "fps=60")
+			Eventually(recorder.IDs).Should(Equal([]int32{id}))
+
+			Eventually(results).Should(Receive(Equal("done")))
+		})
+
+		g.It("Should let handlers configured in PacketHandlers take precedence over the dialect", func() {
+			dialectRecorder := &packetRecorder{}
+
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect:        telemetryDialect{handler: dialectRecorder.handle},
+				PacketHandlers: map[packet.PacketType]rcon.PacketHandler{telemetryType: recorder.handle},
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(7, telemetryType, "fps=60")
+			Eventually(recorder.Bodies).Should(Equal([]string{"fps=60"}))
+			Expect(dialectRecorder.Bodies()).To(BeEmpty())
+		})
+
+		g.It("Should report packets of a type without handler as unhandled", func() {
+			var lock sync.Mutex
+			var reasons []string
+
+			server, client := newTestClient(t, &rcon.Config{
+				UnhandledPacketHandler: func(p packet.Packet, raw []byte, reason string) {
+					lock.Lock()
+					defer lock.Unlock()
+
+					reasons = append(reasons, reason)
+				},
+			})
+			client.HandlePacketType(telemetryType, recorder.handle)
+			client.HandlePacketType(telemetryType, nil)
+
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(7, telemetryType, "fps=60")
+			Eventually(func() []string {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return append([]string(nil), reasons...)
+			}).Should(Equal([]string{"has unknown type 5"}))
+			Expect(recorder.Bodies()).To(BeEmpty())
+		})
+	})
+}
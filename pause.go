@@ -0,0 +1,83 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+)
+
+// PausePolicy determines what happens to commands executed while the client is paused.
+type PausePolicy uint8
+
+const (
+	// PauseBuffer makes commands wait until the client is resumed or their context is cancelled. This is the default.
+	PauseBuffer PausePolicy = iota
+
+	// PauseReject makes commands fail immediately with errs.ErrPaused.
+	PauseReject
+)
+
+// Pause stops the client from sending commands without dropping the connection. Broadcasts and responses to commands
+// which were already sent are still received. This is useful while a server is changing maps, where commands are known
+// to be ignored or to crash the server. Calling Pause on a paused client is a no-op.
+func (c *Client) Pause() {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+
+	if c.resumed != nil {
+		return
+	}
+
+	c.resumed = make(chan struct{})
+	c.log.Debug("Command queue paused")
+}
+
+// Resume lets a paused client send commands again. Buffered commands are sent in no particular order. Calling Resume
+// on a client which isn't paused is a no-op.
+func (c *Client) Resume() {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+
+	if c.resumed == nil {
+		return
+	}
+
+	close(c.resumed)
+	c.resumed = nil
+	c.log.Debug("Command queue resumed")
+}
+
+// Paused reports whether the client is currently paused.
+func (c *Client) Paused() bool {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+
+	return c.resumed != nil
+}
+
+// pauseChan returns a channel which is closed once the client is resumed, or nil if the client is not paused.
+func (c *Client) pauseChan() chan struct{} {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+
+	return c.resumed
+}
+
+// waitIfPaused blocks while the client is paused according to the configured PausePolicy.
+func (c *Client) waitIfPaused(ctx context.Context) error {
+	resumed := c.pauseChan()
+	if resumed == nil {
+		return nil
+	}
+
+	if c.PausePolicy == PauseReject {
+		return fmt.Errorf("client is paused: %w", errs.ErrPaused)
+	}
+
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cancelled while paused: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,128 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Pause()", func() {
+		var server *demo.Server
+		var client *rcon.Client
+		var broadcasts chan string
+		var executed int32
+
+		connect := func(policy rcon.PausePolicy) {
+			host, port, err := server.Start()
+			Expect(err).To(BeNil())
+
+			ch := broadcasts
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second,
+				PausePolicy:      policy,
+				BroadcastChecker: func(p packet.Packet) bool {
+					return p.ID() == demo.ChatBroadcastID
+				},
+				BroadcastHandler: func(msg string) { ch <- msg },
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		}
+
+		g.BeforeEach(func() {
+			atomic.StoreInt32(&executed, 0)
+			broadcasts = make(chan string, 1)
+
+			server = demo.NewServer("password")
+			server.Handle("status", func(string) string {
+				atomic.AddInt32(&executed, 1)
+				return "running"
+			})
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		executions := func() int32 {
+			return atomic.LoadInt32(&executed)
+		}
+
+		g.It("Should hold commands back until resumed", func() {
+			connect(rcon.PauseBuffer)
+
+			client.Pause()
+			client.Pause()
+			Expect(client.Paused()).To(BeTrue())
+
+			results := make(chan string, 1)
+			go func() {
+				res, _ := client.ExecCommand("status")
+				results <- res
+			}()
+
+			Consistently(executions, time.Millisecond*100).Should(BeZero())
+
+			client.Resume()
+			Expect(client.Paused()).To(BeFalse())
+			Eventually(results).Should(Receive(Equal("running")))
+		})
+
+		g.It("Should reject commands with PauseReject", func() {
+			connect(rcon.PauseReject)
+
+			client.Pause()
+
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrPaused)).To(BeTrue())
+			Expect(executions()).To(BeZero())
+
+			client.Resume()
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("running"))
+		})
+
+		g.It("Should give up waiting when the context is cancelled", func() {
+			connect(rcon.PauseBuffer)
+
+			client.Pause()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+
+			_, err := client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			client.Resume()
+			Consistently(executions, time.Millisecond*50).Should(BeZero())
+		})
+
+		g.It("Should keep receiving broadcasts while paused", func() {
+			connect(rcon.PauseBuffer)
+
+			client.Pause()
+			server.Broadcast(demo.ChatBroadcastID, "changing map")
+
+			Eventually(broadcasts).Should(Receive(Equal("changing map")))
+		})
+	})
+}
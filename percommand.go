@@ -0,0 +1,53 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+)
+
+// execPerCommand sends p over a freshly dialed and authenticated connection and reads its response. It is used when
+// ConnectionPerCommand is enabled. Commands are serialized since each one owns the connection while it runs.
+func (c *Client) execPerCommand(p packet.Packet) (packet.Packet, error) {
+	c.perCommandLock.Lock()
+	defer c.perCommandLock.Unlock()
+
+	if !c.perCommandConnected {
+		return nil, errs.ErrNotConnected
+	}
+
+	if err := c.dial(); err != nil {
+		return nil, fmt.Errorf("could not open per-command connection: %w", err)
+	}
+	defer c.closeConn()
+
+	if err := c.sendPacket(p); err != nil {
+		return nil, fmt.Errorf("could not send command packet: %w", err)
+	}
+
+	if c.multiPacket() {
+		return c.readMultiPacket(p)
+	}
+
+	res, err := c.readPacketTimeout()
+	if err != nil {
+		return nil, fmt.Errorf("could not get command response: %w", err)
+	}
+
+	return res, nil
+}
+
+func (c *Client) closePerCommand() error {
+	c.perCommandLock.Lock()
+	connected := c.perCommandConnected
+	c.perCommandConnected = false
+	c.perCommandLock.Unlock()
+
+	if !connected {
+		return errs.ErrNotConnected
+	}
+
+	c.notifyDisconnect(nil)
+
+	return nil
+}
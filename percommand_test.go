@@ -0,0 +1,169 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/errs"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// connCounter forwards TCP connections to a server and counts the connections opened and still open.
+type connCounter struct {
+	listener net.Listener
+	opened   int32
+	open     int32
+}
+
+func startConnCounter(t *testing.T, host string, port uint16) *connCounter {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &connCounter{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+			if err != nil {
+				_ = conn.Close()
+				continue
+			}
+
+			atomic.AddInt32(&c.opened, 1)
+			atomic.AddInt32(&c.open, 1)
+
+			go func() {
+				var wg sync.WaitGroup
+				wg.Add(2)
+
+				pipe := func(dst, src net.Conn) {
+					defer wg.Done()
+					_, _ = io.Copy(dst, src)
+					_ = dst.Close()
+					_ = src.Close()
+				}
+				go pipe(upstream, conn)
+				go pipe(conn, upstream)
+
+				wg.Wait()
+				atomic.AddInt32(&c.open, -1)
+			}()
+		}
+	}()
+
+	return c
+}
+
+func (c *connCounter) addr() (string, uint16) {
+	addr := c.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), uint16(addr.Port)
+}
+
+func (c *connCounter) openedConns() int32 {
+	return atomic.LoadInt32(&c.opened)
+}
+
+func (c *connCounter) openConns() int32 {
+	return atomic.LoadInt32(&c.open)
+}
+
+func TestConnectionPerCommand(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Connection per command", func() {
+		var server *demo.Server
+		var counter *connCounter
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			server = demo.NewServer("password")
+			serverHost, serverPort, err := server.Start()
+			Expect(err).To(BeNil())
+
+			counter = startConnCounter(t, serverHost, serverPort)
+			host, port := counter.addr()
+
+			config = &rcon.Config{
+				Host:                 host,
+				Port:                 port,
+				Password:             "password",
+				ConnectionPerCommand: true,
+				ConnTimeout:          time.Second,
+				QueueReadTimeout:     time.Second,
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = server.Close()
+		})
+
+		g.It("Should verify the credentials when connecting without keeping the connection", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			Expect(counter.openedConns()).To(BeEquivalentTo(1))
+			Eventually(counter.openConns).Should(BeEquivalentTo(0))
+		})
+
+		g.It("Should open a fresh connection for every command", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			for _, message := range []string{"one", "two", "three"} {
+				res, err := client.ExecCommand("echo " + message)
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(message))
+
+				Eventually(counter.openConns).Should(BeEquivalentTo(0))
+			}
+
+			Expect(client.ExecCommandNoResponse("echo four")).To(BeNil())
+			Eventually(counter.openConns).Should(BeEquivalentTo(0))
+
+			Expect(counter.openedConns()).To(BeEquivalentTo(5))
+		})
+
+		g.It("Should return ErrAuthentication for a wrong password", func() {
+			config.Password = "wrong"
+			client := rcon.NewClient(config, nil)
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should refuse commands once closed", func() {
+			closed := make(chan bool, 1)
+			config.DisconnectHandler = func(err error, expected bool) { closed <- expected }
+
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Close()).To(BeNil())
+			Eventually(closed).Should(Receive(BeTrue()))
+
+			_, err := client.ExecCommand("echo late")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+			Expect(counter.openedConns()).To(BeEquivalentTo(1))
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+)
+
+// ExecCommands executes commands and returns their responses in order. See ExecCommandsContext.
+func (c *Client) ExecCommands(commands []string) ([]string, error) {
+	return c.ExecCommandsContext(context.Background(), commands)
+}
+
+// ExecCommandsContext executes commands and returns their responses in order. On connections which are kept open
+// between commands and answer in single packets, all commands are written before any response is awaited, so a batch
+// costs about one round trip instead of one per command. Responses are matched to their commands by packet ID. In all
+// other modes the commands are executed one after another.
+//
+// Every command is executed even if an earlier one fails. If any command failed, the first error is returned along
+// with all responses; the responses of failed commands are empty. Each command passes through the middleware added
+// with Use; the commands are still written in order.
+func (c *Client) ExecCommandsContext(ctx context.Context, commands []string) ([]string, error) {
+	if c.Transport != nil || c.Protocol == ProtocolBattlEye || c.ConnectionPerCommand || c.MultiPacketResponses {
+		return c.execSerial(ctx, commands)
+	}
+
+	responses := make([]string, len(commands))
+	var firstErr error
+
+	fail := func(i int, err error) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("command %d (%q) failed: %w", i, commands[i], err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return responses, fmt.Errorf("commands cancelled: %w", err)
+	}
+
+	if err := c.checkClosing(); err != nil {
+		return responses, err
+	}
+
+	if err := c.waitIfPaused(ctx); err != nil {
+		return responses, err
+	}
+
+	// sent[i] is closed once command i was written, or failed before it could be, so the commands are written in order
+	// even though each passes through the middleware in a goroutine of its own.
+	sent := make([]chan struct{}, len(commands))
+	for i := range sent {
+		sent[i] = make(chan struct{})
+	}
+
+	failures := make([]error, len(commands))
+
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		i := i
+
+		var once sync.Once
+		markSent := func() {
+			once.Do(func() {
+				close(sent[i])
+			})
+		}
+
+		exec := c.wrap(func(ctx context.Context, command string) (string, error) {
+			if i > 0 {
+				<-sent[i-1]
+			}
+
+			done := c.trackCommand(ctx, command, true)
+			res, err := c.execPipelined(ctx, command, markSent)
+			done(err)
+
+			return res, err
+		})
+
+		wg.Add(1)
+		go func(command string) {
+			defer wg.Done()
+			defer markSent()
+
+			responses[i], failures[i] = exec(ctx, command)
+		}(command)
+	}
+
+	wg.Wait()
+
+	for i, err := range failures {
+		if err != nil {
+			responses[i] = ""
+			fail(i, err)
+		}
+	}
+
+	return responses, firstErr
+}
+
+// execPipelined writes command and waits for its response. sent is called once the command was written or failed
+// before it could be.
+func (c *Client) execPipelined(ctx context.Context, command string, sent func()) (string, error) {
+	if err := c.checkCommandSize(command); err != nil {
+		sent()
+		return "", err
+	}
+
+	p := c.newClientPacket(packet.TypeCommand, command)
+
+	c.log.Debug("Pipelining command: ", command)
+
+	err := c.enqueuePacket(ctx, p, 1)
+	sent()
+	if err != nil {
+		return "", fmt.Errorf("could not enqueue command packet: %w", err)
+	}
+
+	res, err := c.getResponse(ctx, p.ID())
+	if err != nil {
+		return "", fmt.Errorf("could not get command response: %w", err)
+	}
+
+	// Trim off null terminator
+	body := res.Body()
+	body = body[:len(body)-1]
+
+	return c.checkResponse(command, string(body))
+}
+
+// execSerial executes commands one after another with the semantics of ExecCommandsContext.
+func (c *Client) execSerial(ctx context.Context, commands []string) ([]string, error) {
+	responses := make([]string, len(commands))
+	var firstErr error
+
+	for i, command := range commands {
+		res, err := c.ExecCommandContext(ctx, command)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("command %d (%q) failed: %w", i, command, err)
+			}
+
+			continue
+		}
+
+		responses[i] = res
+	}
+
+	return responses, firstErr
+}
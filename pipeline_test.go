@@ -0,0 +1,125 @@
+package rcon_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecCommands(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	commands := make([]string, 20)
+	expected := make([]string, 20)
+	for i := range commands {
+		commands[i] = fmt.Sprintf("echo %d", i)
+		expected[i] = fmt.Sprint(i)
+	}
+
+	g.Describe("ExecCommands()", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.Handle("echo", func(args string) string { return args })
+			host, port := server.Addr()
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second * 2,
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		connect := func() {
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+		}
+
+		g.It("Should return the responses in order", func() {
+			connect()
+
+			res, err := client.ExecCommands(commands)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(expected))
+			Expect(server.Commands()).To(Equal(commands))
+		})
+
+		g.It("Should write all commands before awaiting a response", func() {
+			var sent int64
+			config.TeeOutbound = true
+			config.TeeHandler = func(p packet.Packet, direction rcon.TeeDirection) {
+				if direction == rcon.TeeOutbound && p.Type() == packet.TypeCommand {
+					atomic.AddInt64(&sent, 1)
+				}
+			}
+
+			release := make(chan struct{})
+			server.Handle("hold", func(string) string {
+				<-release
+				return "held"
+			})
+			connect()
+
+			done := make(chan []string, 1)
+			go func() {
+				res, _ := client.ExecCommands(append([]string{"hold"}, commands...))
+				done <- res
+			}()
+
+			// The server doesn't answer anything until the first command is released.
+			Eventually(func() int64 { return atomic.LoadInt64(&sent) }).Should(BeEquivalentTo(len(commands) + 1))
+			Expect(done).ToNot(Receive())
+
+			close(release)
+
+			var res []string
+			Eventually(done).Should(Receive(&res))
+			Expect(res).To(Equal(append([]string{"held"}, expected...)))
+		})
+
+		g.It("Should execute every command even if one fails", func() {
+			config.ResponseErrorChecker = func(command, response string) bool {
+				return response == "denied"
+			}
+			server.SetResponse("ban", "denied")
+			connect()
+
+			res, err := client.ExecCommands([]string{"echo a", "ban", "echo b"})
+			Expect(err).To(MatchError(ContainSubstring(`command 1 ("ban") failed`)))
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+			Expect(res).To(Equal([]string{"a", "", "b"}))
+		})
+
+		g.It("Should execute the commands one after another with multi-packet responses", func() {
+			config.MultiPacketResponses = true
+			connect()
+
+			res, err := client.ExecCommands(commands)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(expected))
+			Expect(server.Commands()).To(ContainElements(commands))
+		})
+	})
+}
@@ -0,0 +1,369 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool manages clients for many servers, keyed by host:port (see ConfigKey). Clients are connected lazily on first use, and clients
+// whose connection died are recycled: they are discarded and a new client is connected the next time the server is
+// used. A Pool is safe for concurrent use.
+type Pool struct {
+	// Logger is passed to every client created by the pool.
+	Logger Logger
+
+	// HealthCheckInterval is the time between health checks of connected clients. Zero disables health checks.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckCommand is executed on every connected client during a health check. A client whose health check
+	// fails is recycled. If empty, health checks only recycle clients which are no longer ready.
+	HealthCheckCommand string
+
+	// BroadcastHandler, if set, is called with the broadcasts received by every client in the pool and the key of the
+	// server which received them. It is called from the clients' reader routines, so it must be safe for concurrent
+	// use.
+	BroadcastHandler func(key string, message string)
+
+	// DedupWindow is the time within which identical broadcasts received by several clients of the same cluster are
+	// passed to BroadcastHandler only once. A message repeated on the server, and so received more than once by every
+	// client, is passed on as often as it was repeated. Servers are added to a cluster with AddClustered. Zero disables
+	// deduplication.
+	DedupWindow time.Duration
+
+	lock    sync.Mutex
+	entries map[string]*poolEntry
+	stop    chan struct{}
+
+	dedup *broadcastDedup
+}
+
+type poolEntry struct {
+	key     string
+	cluster string
+	config  *Config
+
+	lock   sync.Mutex
+	client *Client
+
+	// dead is set by the DisconnectHandler of client. Every client gets its own flag, so that a late disconnect of a
+	// recycled client can't mark its successor as dead.
+	dead *int32
+}
+
+// NewPool creates an empty pool.
+func NewPool() *Pool {
+	return &Pool{
+		entries: map[string]*poolEntry{},
+		dedup:   newBroadcastDedup(),
+	}
+}
+
+// PoolKey returns the key of the server at host and port.
+func PoolKey(host string, port uint16) string {
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// ConfigKey returns the key of the server described by config. It is the PoolKey of Host and Port or, for clustered
+// servers with Hosts set, every endpoint in failover order separated by commas, so that clusters sharing a primary
+// endpoint don't collide.
+func ConfigKey(config *Config) string {
+	if len(config.Hosts) == 0 {
+		return PoolKey(config.Host, config.Port)
+	}
+
+	return strings.Join(configEndpoints(config), ",")
+}
+
+// Add registers the server described by config and returns its key. The client is not connected until the server is
+// first used. Adding a server which is already registered replaces its config and closes its current client.
+//
+// The pool connects clients using a copy of config, whose DisconnectHandler is wrapped so that the pool learns about
+// dead connections.
+func (p *Pool) Add(config *Config) string {
+	return p.AddClustered(config, "")
+}
+
+// AddClustered registers the server described by config like Add, as a member of cluster. Clients of the same
+// cluster are connected to the same physical server, for example through sibling connections or relays, so identical
+// broadcasts they receive within DedupWindow are passed to BroadcastHandler only once. An empty cluster puts the
+// server in a cluster of its own.
+func (p *Pool) AddClustered(config *Config, cluster string) string {
+	key := ConfigKey(config)
+	if cluster == "" {
+		cluster = key
+	}
+
+	p.lock.Lock()
+	old := p.entries[key]
+	p.entries[key] = &poolEntry{key: key, cluster: cluster, config: config}
+	p.lock.Unlock()
+
+	if old != nil {
+		old.recycle()
+	}
+
+	return key
+}
+
+// Remove closes the client of the server identified by key and removes it from the pool.
+func (p *Pool) Remove(key string) error {
+	p.lock.Lock()
+	e, ok := p.entries[key]
+	delete(p.entries, key)
+	p.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no server with key %q: %w", key, errs.ErrUnknownClient)
+	}
+
+	e.recycle()
+
+	return nil
+}
+
+// Client returns the connected client of the server identified by key, connecting it if necessary.
+func (p *Pool) Client(key string) (*Client, error) {
+	e, err := p.entry(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.get(p)
+}
+
+// Exec executes command on the server identified by key.
+func (p *Pool) Exec(key string, command string) (string, error) {
+	return p.ExecContext(context.Background(), key, command)
+}
+
+// ExecContext executes command on the server identified by key. If the server's connection turns out to be closed
+// before the command was sent, the client is recycled and the command is retried once on a new connection.
+func (p *Pool) ExecContext(ctx context.Context, key string, command string) (string, error) {
+	e, err := p.entry(key)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; ; attempt++ {
+		client, err := e.get(p)
+		if err != nil {
+			return "", err
+		}
+
+		res, err := client.ExecCommandContext(ctx, command)
+		if errors.Is(err, errs.ErrNotConnected) && attempt == 0 {
+			e.recycleClient(client)
+			continue
+		}
+
+		return res, err
+	}
+}
+
+// Keys returns the keys of all servers in the pool.
+func (p *Pool) Keys() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	keys := make([]string, 0, len(p.entries))
+	for key := range p.entries {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// StartHealthChecks checks the clients of all servers every HealthCheckInterval until Close is called.
+func (p *Pool) StartHealthChecks() {
+	if p.HealthCheckInterval <= 0 {
+		return
+	}
+
+	p.lock.Lock()
+	if p.stop != nil {
+		p.lock.Unlock()
+		return
+	}
+	p.stop = make(chan struct{})
+	stop := p.stop
+	p.lock.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(p.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.healthCheck()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops health checks and closes all clients. The servers stay registered, so using the pool again reconnects
+// them.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+
+	entries := make([]*poolEntry, 0, len(p.entries))
+	for _, e := range p.entries {
+		entries = append(entries, e)
+	}
+	p.lock.Unlock()
+
+	for _, e := range entries {
+		e.recycle()
+	}
+
+	return nil
+}
+
+func (p *Pool) entry(key string) (*poolEntry, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	e, ok := p.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("no server with key %q: %w", key, errs.ErrUnknownClient)
+	}
+
+	return e, nil
+}
+
+// healthCheck recycles the clients which are dead or fail the health check command. Servers whose client isn't
+// connected are skipped, since they are connected lazily.
+func (p *Pool) healthCheck() {
+	p.lock.Lock()
+	entries := make(map[string]*poolEntry, len(p.entries))
+	for key, e := range p.entries {
+		entries[key] = e
+	}
+	p.lock.Unlock()
+
+	for key, e := range entries {
+		e.lock.Lock()
+		client, dead := e.client, e.dead
+		e.lock.Unlock()
+
+		if client == nil {
+			continue
+		}
+
+		healthy := atomic.LoadInt32(dead) == 0 && client.Ready()
+
+		if healthy && p.HealthCheckCommand != "" {
+			if _, err := client.ExecCommand(p.HealthCheckCommand); err != nil {
+				if !answered(err) {
+					healthy = false
+				}
+			}
+		}
+
+		if !healthy {
+			if p.Logger != nil {
+				p.Logger.Info("Recycling unhealthy client for ", key)
+			}
+
+			e.recycleClient(client)
+		}
+	}
+}
+
+// dispatchBroadcast passes a broadcast received by the client of e to the BroadcastHandler, unless an identical one
+// was received by another client in e's cluster within DedupWindow.
+func (p *Pool) dispatchBroadcast(e *poolEntry, message string) {
+	if p.BroadcastHandler == nil {
+		return
+	}
+
+	if p.DedupWindow > 0 && p.dedup.seen(e.cluster, e.key, message, p.DedupWindow) {
+		return
+	}
+
+	p.BroadcastHandler(e.key, message)
+}
+
+// get returns the entry's client, connecting a new one if there is none or the current one is dead.
+func (e *poolEntry) get(p *Pool) (*Client, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.client != nil && atomic.LoadInt32(e.dead) == 0 {
+		return e.client, nil
+	}
+
+	if e.client != nil {
+		_ = e.client.Close()
+		e.client = nil
+	}
+
+	dead := new(int32)
+
+	config := *e.config
+	handler := config.DisconnectHandler
+	config.DisconnectHandler = func(err error, expected bool) {
+		atomic.StoreInt32(dead, 1)
+
+		if handler != nil {
+			handler(err, expected)
+		}
+	}
+
+	broadcastHandler := config.BroadcastHandler
+	config.BroadcastHandler = func(message string) {
+		if broadcastHandler != nil {
+			broadcastHandler(message)
+		}
+
+		p.dispatchBroadcast(e, message)
+	}
+
+	client := NewClient(&config, p.Logger)
+	if err := client.Connect(); err != nil {
+		// A failed self-test leaves the client connected.
+		_ = client.Close()
+		return nil, err
+	}
+
+	e.client = client
+	e.dead = dead
+
+	return client, nil
+}
+
+// recycleClient closes client if it is still the entry's client, so that the next use connects a new one.
+func (e *poolEntry) recycleClient(client *Client) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.client != client {
+		return
+	}
+
+	_ = client.Close()
+	e.client = nil
+}
+
+// recycle closes the entry's client.
+func (e *poolEntry) recycle() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.client != nil {
+		_ = e.client.Close()
+		e.client = nil
+	}
+}
@@ -0,0 +1,194 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Pool", func() {
+		var pool *rcon.Pool
+
+		g.BeforeEach(func() {
+			pool = rcon.NewPool()
+		})
+
+		g.AfterEach(func() {
+			_ = pool.Close()
+		})
+
+		g.It("Should connect lazily and execute commands", func() {
+			config := &rcon.Config{}
+			server := newTestServer(t, config)
+			server.SetResponse("status", "ok")
+
+			key := pool.Add(config)
+			Expect(key).To(Equal(rcon.PoolKey(config.Host, config.Port)))
+			Expect(server.AuthAttempts()).To(Equal(0))
+
+			res, err := pool.Exec(key, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+
+		g.It("Should recycle dead connections", func() {
+			config := &rcon.Config{}
+			server := newTestServer(t, config)
+			server.SetResponse("status", "ok")
+
+			key := pool.Add(config)
+			_, err := pool.Exec(key, "status")
+			Expect(err).To(BeNil())
+
+			server.DisconnectAll()
+
+			Eventually(func() error {
+				_, err := pool.Exec(key, "status")
+				return err
+			}).Should(BeNil())
+
+			attempts, _ := server.AuthAttempts()
+			Expect(attempts).To(Equal(2))
+		})
+
+		g.It("Should reject unknown keys", func() {
+			_, err := pool.Exec("127.0.0.1:1", "status")
+			Expect(errors.Is(err, errs.ErrUnknownClient)).To(BeTrue())
+
+			config := &rcon.Config{}
+			newTestServer(t, config)
+
+			key := pool.Add(config)
+			Expect(pool.Remove(key)).To(BeNil())
+			Expect(errors.Is(pool.Remove(key), errs.ErrUnknownClient)).To(BeTrue())
+		})
+
+		g.It("Should key clustered servers by all of their endpoints", func() {
+			primary := &rcon.Config{Hosts: []string{"10.0.0.1", "10.0.0.2:27016"}, Port: 27015}
+			other := &rcon.Config{Hosts: []string{"10.0.0.1", "10.0.0.3"}, Port: 27015}
+
+			Expect(rcon.ConfigKey(primary)).To(Equal("10.0.0.1:27015,10.0.0.2:27016"))
+			Expect(pool.Add(primary)).NotTo(Equal(pool.Add(other)))
+			Expect(pool.Keys()).To(HaveLen(2))
+		})
+	})
+
+	g.Describe("Pool health checks", func() {
+		var pool *rcon.Pool
+
+		g.BeforeEach(func() {
+			pool = rcon.NewPool()
+			pool.HealthCheckInterval = time.Millisecond * 50
+		})
+
+		g.AfterEach(func() {
+			_ = pool.Close()
+		})
+
+		g.It("Should recycle clients whose health check command fails", func() {
+			config := &rcon.Config{QueueReadTimeout: time.Millisecond * 100}
+			server := newTestServer(t, config)
+
+			var hang int32
+			server.Handle("ping", func(string) string {
+				if atomic.LoadInt32(&hang) == 1 {
+					time.Sleep(time.Millisecond * 300)
+				}
+				return "pong"
+			})
+
+			pool.HealthCheckCommand = "ping"
+			key := pool.Add(config)
+
+			client, err := pool.Client(key)
+			Expect(err).To(BeNil())
+
+			pool.StartHealthChecks()
+			Consistently(client.Status, time.Millisecond*150).Should(Equal(rcon.StateConnected))
+
+			atomic.StoreInt32(&hang, 1)
+			Eventually(client.Status).Should(Equal(rcon.StateClosed))
+
+			atomic.StoreInt32(&hang, 0)
+			recycled, err := pool.Client(key)
+			Expect(err).To(BeNil())
+			Expect(recycled).ToNot(BeIdenticalTo(client))
+			Expect(recycled.Status()).To(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should keep clients whose health check command is answered with an error", func() {
+			config := &rcon.Config{
+				ResponseErrorChecker: func(command, response string) bool {
+					return response == "Unknown command: ping"
+				},
+			}
+			newTestServer(t, config)
+
+			pool.HealthCheckCommand = "ping"
+			key := pool.Add(config)
+
+			client, err := pool.Client(key)
+			Expect(err).To(BeNil())
+
+			pool.StartHealthChecks()
+			Consistently(client.Status, time.Millisecond*200).Should(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should recycle clients which are not ready", func() {
+			config := &rcon.Config{Reconnect: rcon.ReconnectConfig{
+				Enabled: true,
+				Backoff: rcon.ConstantBackoff(time.Hour),
+			}}
+			server := newTestServer(t, config)
+
+			key := pool.Add(config)
+
+			client, err := pool.Client(key)
+			Expect(err).To(BeNil())
+
+			// The client waits an hour before reconnecting, so it is only closed if the health check recycles it.
+			server.DisconnectAll()
+			Eventually(client.Status).Should(Equal(rcon.StateReconnecting))
+
+			pool.StartHealthChecks()
+			Eventually(client.Status).Should(Equal(rcon.StateClosed))
+		})
+
+		g.It("Should stop health checks when the pool is closed", func() {
+			config := &rcon.Config{}
+			server := newTestServer(t, config)
+
+			var pings int32
+			server.Handle("ping", func(string) string {
+				atomic.AddInt32(&pings, 1)
+				return "pong"
+			})
+
+			pool.HealthCheckCommand = "ping"
+			key := pool.Add(config)
+
+			_, err := pool.Client(key)
+			Expect(err).To(BeNil())
+
+			pool.StartHealthChecks()
+			Eventually(func() int32 { return atomic.LoadInt32(&pings) }).Should(BeNumerically(">=", 2))
+
+			Expect(pool.Close()).To(BeNil())
+			stopped := atomic.LoadInt32(&pings)
+
+			Consistently(func() int32 { return atomic.LoadInt32(&pings) }, time.Millisecond*200).Should(Equal(stopped))
+			Expect(server.Commands()).To(HaveLen(int(stopped)))
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExecAllOptions configure Pool.ExecAllWithOptions.
+type ExecAllOptions struct {
+	// Concurrency is the maximum number of servers the command is executed on at the same time. Zero executes it on
+	// all servers at once.
+	Concurrency int
+
+	// Quorum is the number of successful responses after which the command is cancelled on the servers which have not
+	// answered yet. Zero waits for all servers.
+	Quorum int
+}
+
+// PoolResult is the outcome of a command executed on one server of a pool.
+type PoolResult struct {
+	Key      string
+	Response string
+	Err      error
+}
+
+// ExecAll executes command on every server in the pool concurrently and returns the results sorted by key. If ctx
+// has a deadline, no server is waited on for longer than it.
+func (p *Pool) ExecAll(ctx context.Context, command string) []PoolResult {
+	results, _ := p.ExecAllWithOptions(ctx, command, ExecAllOptions{})
+	return results
+}
+
+// ExecAllWithOptions executes command on every server in the pool and returns the results sorted by key.
+//
+// Every server gets a context derived from ctx. If ctx has a deadline and Concurrency limits how many servers are
+// running at once, the remaining time is split fairly: each server gets the remaining time divided by the number of
+// rounds still needed to reach all servers, so that slow servers early on can't use up the time of those after them.
+//
+// If Quorum is set, the servers still running are cancelled once Quorum servers answered successfully, and servers
+// which have not started are skipped; their results carry context.Canceled. errs.ErrQuorumNotReached is returned if
+// fewer than Quorum servers answered successfully.
+func (p *Pool) ExecAllWithOptions(ctx context.Context, command string, options ExecAllOptions) ([]PoolResult, error) {
+	keys := p.Keys()
+	sort.Strings(keys)
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 || concurrency > len(keys) {
+		concurrency = len(keys)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]PoolResult, len(keys))
+	slots := make(chan struct{}, concurrency)
+
+	var lock sync.Mutex
+	started := 0
+	succeeded := 0
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		results[i].Key = key
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = fmt.Errorf("command cancelled: %w", ctx.Err())
+			continue
+		}
+
+		lock.Lock()
+		serverCtx, serverCancel := fairContext(ctx, len(keys)-started, concurrency)
+		started++
+		lock.Unlock()
+
+		wg.Add(1)
+		go func(i int, key string) {
+			defer func() {
+				serverCancel()
+				<-slots
+				wg.Done()
+			}()
+
+			res, err := p.ExecContext(serverCtx, key, command)
+			results[i].Response, results[i].Err = res, err
+
+			if err != nil || options.Quorum <= 0 {
+				return
+			}
+
+			lock.Lock()
+			succeeded++
+			if succeeded == options.Quorum {
+				cancel()
+			}
+			lock.Unlock()
+		}(i, key)
+	}
+
+	wg.Wait()
+
+	if options.Quorum > 0 && succeeded < options.Quorum {
+		return results, fmt.Errorf("%d of %d servers answered: %w", succeeded, options.Quorum, errs.ErrQuorumNotReached)
+	}
+
+	return results, nil
+}
+
+// fairContext derives the context of a server from ctx. If ctx has a deadline, the server gets an equal share of the
+// remaining time among the rounds needed to execute the command on the remaining servers, concurrency at a time.
+func fairContext(ctx context.Context, remaining int, concurrency int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	rounds := (remaining + concurrency - 1) / concurrency
+	share := time.Until(deadline) / time.Duration(rounds)
+
+	return context.WithTimeout(ctx, share)
+}
@@ -0,0 +1,120 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestExecAll(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ExecAll()", func() {
+		var pool *rcon.Pool
+		var keys []string
+		var servers map[string]*rcontest.Server
+
+		g.BeforeEach(func() {
+			pool = rcon.NewPool()
+			keys = nil
+			servers = map[string]*rcontest.Server{}
+
+			for i := 0; i < 3; i++ {
+				server := rcontest.StartServer(t, "password")
+				host, port := server.Addr()
+
+				config := &rcon.Config{
+					Host:             host,
+					Port:             port,
+					Password:         "password",
+					QueueReadTimeout: time.Second * 2,
+					ResponseErrorChecker: func(command, response string) bool {
+						return response == "denied"
+					},
+				}
+
+				key := pool.Add(config)
+				server.Handle("whoami", func(string) string { return key })
+				servers[key] = server
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+		})
+
+		g.AfterEach(func() {
+			_ = pool.Close()
+		})
+
+		g.It("Should return the results of all servers sorted by key", func() {
+			results := pool.ExecAll(context.Background(), "whoami")
+
+			Expect(results).To(HaveLen(3))
+			for i, result := range results {
+				Expect(result).To(Equal(rcon.PoolResult{Key: keys[i], Response: keys[i]}))
+			}
+		})
+
+		g.It("Should not wait on any server for longer than the deadline", func() {
+			servers[keys[1]].SetDelay(time.Millisecond * 500)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+
+			start := time.Now()
+			results := pool.ExecAll(ctx, "whoami")
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*300))
+
+			Expect(results[0].Err).To(BeNil())
+			Expect(errors.Is(results[1].Err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(results[2].Err).To(BeNil())
+		})
+
+		g.It("Should split the remaining time between rounds", func() {
+			servers[keys[0]].SetDelay(time.Millisecond * 500)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+			defer cancel()
+
+			// With one server at a time, the first gets a third of the time, so the others still get to run.
+			results, err := pool.ExecAllWithOptions(ctx, "whoami", rcon.ExecAllOptions{Concurrency: 1})
+			Expect(err).To(BeNil())
+
+			Expect(errors.Is(results[0].Err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(results[1]).To(Equal(rcon.PoolResult{Key: keys[1], Response: keys[1]}))
+			Expect(results[2]).To(Equal(rcon.PoolResult{Key: keys[2], Response: keys[2]}))
+		})
+
+		g.It("Should cancel the remaining servers once the quorum answered", func() {
+			servers[keys[2]].SetDelay(time.Millisecond * 500)
+
+			start := time.Now()
+			results, err := pool.ExecAllWithOptions(context.Background(), "whoami", rcon.ExecAllOptions{Quorum: 2})
+			Expect(err).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*300))
+
+			Expect(results[0].Err).To(BeNil())
+			Expect(results[1].Err).To(BeNil())
+			Expect(errors.Is(results[2].Err, context.Canceled)).To(BeTrue())
+		})
+
+		g.It("Should fail if the quorum isn't reached", func() {
+			servers[keys[0]].SetResponse("whoami", "denied")
+			servers[keys[1]].SetResponse("whoami", "denied")
+
+			results, err := pool.ExecAllWithOptions(context.Background(), "whoami", rcon.ExecAllOptions{Quorum: 2})
+			Expect(errors.Is(err, errs.ErrQuorumNotReached)).To(BeTrue())
+			Expect(results[2].Err).To(BeNil())
+		})
+	})
+}
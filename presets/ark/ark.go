@@ -0,0 +1,94 @@
+// Package ark configures clients for ARK: Survival Evolved servers. ARK doesn't push broadcasts, so chat has to be
+// polled using getchat; ChatPoller does that and delivers the messages as broadcasts.
+package ark
+
+import (
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NoResponse is the response ARK servers send to commands which have no output.
+const NoResponse = "Server received, But no response!!"
+
+// ErrUnknownFormat is returned by the parsers for responses which do not match the expected format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Dialect describes ARK servers, which don't push broadcasts.
+var Dialect = presets.NewDialect("ark", rcon.Features{})
+
+// Game configures a client for ARK servers.
+func Game(config *rcon.Config) {
+	config.Dialect = Dialect
+}
+
+func init() {
+	rcon.RegisterGame("ark", Game)
+}
+
+// Player is an entry of the listplayers response. ID is the player's Steam or Epic ID.
+type Player struct {
+	Index int
+	Name  string
+	ID    string
+}
+
+var listPlayersPattern = regexp.MustCompile(`^(\d+)\. (.+), (\S+)$`)
+
+// isEmpty reports whether response is ARK's answer to a command without output.
+func isEmpty(response string) bool {
+	response = strings.TrimSpace(response)
+	return response == "" || strings.HasPrefix(response, NoResponse)
+}
+
+// ParseListPlayers parses the response to listplayers.
+func ParseListPlayers(response string) ([]Player, error) {
+	if isEmpty(response) || strings.Contains(response, "No Players Connected") {
+		return nil, nil
+	}
+
+	var players []Player
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		m := listPlayersPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected listplayers line %q: %w", line, ErrUnknownFormat)
+		}
+
+		index, _ := strconv.Atoi(m[1])
+		players = append(players, Player{Index: index, Name: m[2], ID: m[3]})
+	}
+
+	return players, nil
+}
+
+// ListPlayers executes listplayers and parses the response.
+func ListPlayers(client *rcon.Client) ([]Player, error) {
+	res, err := client.ExecCommand("listplayers")
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseListPlayers(res)
+}
+
+// ServerChat sends message to the chat of all players.
+func ServerChat(client *rcon.Client, message string) error {
+	_, err := client.ExecCommand("serverchat " + message)
+	return err
+}
+
+// ServerChatTo sends message to the chat of the player with the given Steam or Epic ID.
+func ServerChatTo(client *rcon.Client, playerID string, message string) error {
+	_, err := client.ExecCommand("serverchatto \"" + playerID + "\" " + message)
+	return err
+}
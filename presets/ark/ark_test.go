@@ -0,0 +1,50 @@
+package ark_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/ark"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"testing"
+)
+
+func TestParseListPlayers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseListPlayers()", func() {
+		g.It("Should parse players with Steam and Epic IDs", func() {
+			data, err := fixtures.Read("listplayers.txt")
+			Expect(err).To(BeNil())
+
+			players, err := ark.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]ark.Player{
+				{Index: 0, Name: "Survivor One", ID: "76561198000000001"},
+				{Index: 1, Name: "Smith, John", ID: "0002a10186d9414496bf20d22d3860ba"},
+			}))
+		})
+
+		g.It("Should return no players for an empty server", func() {
+			for _, name := range []string{"listplayers_none.txt", "noresponse.txt"} {
+				data, err := fixtures.Read(name)
+				Expect(err).To(BeNil())
+
+				players, err := ark.ParseListPlayers(data)
+
+				Expect(err).To(BeNil())
+				Expect(players).To(BeEmpty())
+			}
+		})
+
+		g.It("Should return ErrUnknownFormat for other lines", func() {
+			_, err := ark.ParseListPlayers("0. Survivor One, 76561198000000001\nUnknown command")
+
+			Expect(errors.Is(err, ark.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package ark
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"strings"
+	"time"
+)
+
+// DefaultChatPollInterval is the default interval at which a ChatPoller executes getchat.
+const DefaultChatPollInterval = time.Second * 2
+
+// BroadcastSource is the source tag of broadcasts delivered by a ChatPoller.
+const BroadcastSource = "getchat"
+
+// ChatPoller polls chat using getchat and injects every message into the client as a broadcast, so that ARK chat
+// reaches the BroadcastHandler and subscriptions like the broadcasts of games which push them. getchat returns the
+// messages received since the previous call, so only one poller should run per server.
+type ChatPoller struct {
+	Client *rcon.Client
+
+	// Interval is the time between polls.
+	//
+	// Default: DefaultChatPollInterval
+	Interval time.Duration
+
+	// ErrorHandler, if set, is called with errors executing getchat. Polling continues after errors.
+	ErrorHandler func(err error)
+}
+
+// Run polls chat until ctx is cancelled.
+func (p *ChatPoller) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultChatPollInterval
+	}
+
+	for {
+		if err := p.Poll(ctx); err != nil && p.ErrorHandler != nil {
+			p.ErrorHandler(err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Poll executes getchat once and injects the messages it returned.
+func (p *ChatPoller) Poll(ctx context.Context) error {
+	res, err := p.Client.ExecCommandContext(ctx, "getchat")
+	if err != nil {
+		return fmt.Errorf("could not get chat: %w", err)
+	}
+
+	if isEmpty(res) {
+		return nil
+	}
+
+	for _, line := range strings.Split(res, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			p.Client.InjectBroadcast(BroadcastSource, line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package presets
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"strings"
+	"time"
+)
+
+// DefaultSaveTimeout is the default time Save will wait for a completion broadcast.
+const DefaultSaveTimeout = time.Second * 30
+
+// SaveSpec describes how to trigger a world save on a game server and how to recognise that it completed.
+type SaveSpec struct {
+	// Command is the command which triggers the save.
+	Command string
+
+	// ResponseConfirms reports whether the command response confirms the save completed. If nil, the response is not
+	// checked.
+	ResponseConfirms func(response string) bool
+
+	// BroadcastConfirms reports whether a broadcast message confirms the save completed. If set, Save waits for a
+	// matching broadcast after the command was executed.
+	BroadcastConfirms func(message string) bool
+
+	// Timeout is how long Save waits for a confirming broadcast.
+	//
+	// Default: DefaultSaveTimeout
+	Timeout time.Duration
+}
+
+// Save triggers a save as described by spec and returns once it was confirmed. errs.ErrSaveNotConfirmed is returned
+// if the response or broadcast did not confirm the save. Broadcasts are received through a subscription, so concurrent
+// saves and the client's BroadcastHandler are not affected. If ctx is cancelled first, ctx.Err() is returned.
+func Save(ctx context.Context, client *rcon.Client, spec SaveSpec) error {
+	var broadcasts <-chan rcon.Broadcast
+
+	if spec.BroadcastConfirms != nil {
+		// Subscribe before executing the command so that a broadcast arriving with the response isn't missed.
+		var unsubscribe func()
+		broadcasts, unsubscribe = client.Subscribe(nil)
+		defer unsubscribe()
+	}
+
+	res, err := client.ExecCommandContext(ctx, spec.Command)
+	if err != nil {
+		return fmt.Errorf("could not execute save command: %w", err)
+	}
+
+	if spec.ResponseConfirms != nil && !spec.ResponseConfirms(res) {
+		return fmt.Errorf("unexpected save response: %s: %w", res, errs.ErrSaveNotConfirmed)
+	}
+
+	if broadcasts == nil {
+		return nil
+	}
+
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSaveTimeout
+	}
+
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case b, ok := <-broadcasts:
+			if !ok {
+				return fmt.Errorf("broadcast subscription closed: %w", errs.ErrSaveNotConfirmed)
+			}
+
+			if spec.BroadcastConfirms(b.Message) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("timed out waiting for save broadcast: %w", errs.ErrSaveNotConfirmed)
+		}
+	}
+}
+
+func responseContains(substr string) func(string) bool {
+	substr = strings.ToLower(substr)
+
+	return func(response string) bool {
+		return strings.Contains(strings.ToLower(response), substr)
+	}
+}
+
+// MinecraftSave is a SaveSpec which flushes the world to disk on a Minecraft server.
+var MinecraftSave = SaveSpec{
+	Command:          "save-all flush",
+	ResponseConfirms: responseContains("saved the game"),
+}
+
+// FactorioSave is a SaveSpec which saves the map on a Factorio server.
+var FactorioSave = SaveSpec{
+	Command: "/server-save",
+}
+
+// MinecraftBackup disables automatic saving, flushes the world to disk and then calls backup. Automatic saving is
+// re-enabled once backup returns, regardless of whether it succeeded, so that the files on disk are consistent while
+// they are being copied. If ctx is cancelled, the backup is aborted, but automatic saving is still re-enabled.
+func MinecraftBackup(ctx context.Context, client *rcon.Client, backup func() error) error {
+	res, err := client.ExecCommandContext(ctx, "save-off")
+	if err != nil {
+		return fmt.Errorf("could not disable automatic saving: %w", err)
+	}
+
+	// "Automatic saving is now disabled", or "Saving is already turned off" if it was disabled before.
+	if !responseContains("disabled")(res) && !responseContains("already")(res) {
+		return fmt.Errorf("unexpected save-off response: %s: %w", res, errs.ErrSaveNotConfirmed)
+	}
+
+	// Not bound to ctx: automatic saving must come back on even if the backup was cancelled.
+	defer func() {
+		_, _ = client.ExecCommand("save-on")
+	}()
+
+	if err := Save(ctx, client, MinecraftSave); err != nil {
+		return err
+	}
+
+	if err := backup(); err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	return nil
+}
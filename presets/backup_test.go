@@ -0,0 +1,181 @@
+package presets_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSave(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	spec := presets.SaveSpec{
+		Command: "save",
+		BroadcastConfirms: func(message string) bool {
+			return strings.HasPrefix(message, "Saved")
+		},
+		Timeout: time.Millisecond * 300,
+	}
+
+	g.Describe("Save()", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+		var broadcasts chan string
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			broadcasts = make(chan string, 16)
+			ch := broadcasts
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Millisecond * 200,
+				BroadcastChecker: rcontest.BroadcastChecker,
+				BroadcastHandler: func(msg string) { ch <- msg },
+			}, nil)
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should confirm concurrent saves without replacing the broadcast handler", func() {
+			server.Handle("save", func(string) string {
+				go func() {
+					time.Sleep(time.Millisecond * 20)
+					server.Broadcast(rcontest.BroadcastID, "Saved the game")
+				}()
+				return ""
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			var wg sync.WaitGroup
+			results := make(chan error, 4)
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					results <- presets.Save(context.Background(), client, spec)
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			for err := range results {
+				Expect(err).To(BeNil())
+			}
+
+			server.Broadcast(rcontest.BroadcastID, "after the save")
+			Eventually(broadcasts).Should(Receive(Equal("after the save")))
+		})
+
+		g.It("Should fail if no confirming broadcast arrives", func() {
+			server.Handle("save", func(string) string {
+				go server.Broadcast(rcontest.BroadcastID, "Saving failed")
+				return ""
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			err := presets.Save(context.Background(), client, spec)
+			Expect(errors.Is(err, errs.ErrSaveNotConfirmed)).To(BeTrue())
+		})
+	})
+}
+
+func TestMinecraftBackup(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("MinecraftBackup()", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			server.SetResponse("save-off", "Automatic saving is now disabled")
+			server.SetResponse("save-all flush", "Saved the game")
+			server.SetResponse("save-on", "Automatic saving is now enabled")
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Millisecond * 200,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should flush the world before the backup and re-enable saving after it", func() {
+			var before []string
+			err := presets.MinecraftBackup(context.Background(), client, func() error {
+				before = server.Commands()
+				return nil
+			})
+
+			Expect(err).To(BeNil())
+			Expect(before).To(Equal([]string{"save-off", "save-all flush"}))
+			Expect(server.Commands()).To(Equal([]string{"save-off", "save-all flush", "save-on"}))
+		})
+
+		g.It("Should re-enable saving if the backup fails", func() {
+			failed := errors.New("disk full")
+			err := presets.MinecraftBackup(context.Background(), client, func() error {
+				return failed
+			})
+
+			Expect(errors.Is(err, failed)).To(BeTrue())
+			Expect(server.Commands()).To(Equal([]string{"save-off", "save-all flush", "save-on"}))
+		})
+
+		g.It("Should re-enable saving without a backup if the save is not confirmed", func() {
+			server.SetResponse("save-all flush", "An error occurred")
+
+			called := false
+			err := presets.MinecraftBackup(context.Background(), client, func() error {
+				called = true
+				return nil
+			})
+
+			Expect(errors.Is(err, errs.ErrSaveNotConfirmed)).To(BeTrue())
+			Expect(called).To(BeFalse())
+			Expect(server.Commands()).To(Equal([]string{"save-off", "save-all flush", "save-on"}))
+		})
+
+		g.It("Should not touch saving if it could not be disabled", func() {
+			server.SetResponse("save-off", "Unknown command: save-off")
+
+			err := presets.MinecraftBackup(context.Background(), client, func() error { return nil })
+
+			Expect(errors.Is(err, errs.ErrSaveNotConfirmed)).To(BeTrue())
+			Expect(server.Commands()).To(Equal([]string{"save-off"}))
+		})
+	})
+}
@@ -0,0 +1,22 @@
+package presets
+
+import "github.com/refractorgscm/rcon/packet"
+
+// mordhauChannels maps Mordhau broadcast packet IDs to the channel names used by the server's listen command.
+var mordhauChannels = map[int32]string{
+	54321: "matchstate",
+	54324: "scorefeed",
+	54325: "chat",
+	54326: "login",
+	54330: "punishment",
+}
+
+// MordhauBroadcastChannel classifies Mordhau broadcasts by the packet ID they are sent on, using the channel names of
+// the listen command. Broadcasts on other IDs are classified as "unknown".
+func MordhauBroadcastChannel(p packet.Packet) string {
+	if channel, ok := mordhauChannels[p.ID()]; ok {
+		return channel
+	}
+
+	return "unknown"
+}
@@ -1,6 +1,9 @@
 package presets
 
-import "github.com/refractorgscm/rcon/packet"
+import (
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+)
 
 func MordhauBroadcastChecker(p packet.Packet) bool {
 	for _, v := range MordhauRestrictedPacketIDs {
@@ -11,3 +14,15 @@ func MordhauBroadcastChecker(p packet.Packet) bool {
 
 	return false
 }
+
+// MordhauBroadcastHeaderChecker classifies Mordhau packets by their ID alone, so that broadcast bodies don't need to
+// be inspected.
+func MordhauBroadcastHeaderChecker(h packet.Header) rcon.BroadcastVerdict {
+	for _, v := range MordhauRestrictedPacketIDs {
+		if v == h.ID {
+			return rcon.BroadcastAlways
+		}
+	}
+
+	return rcon.BroadcastNever
+}
@@ -0,0 +1,26 @@
+package presets
+
+// MordhauCommands are the RCON commands known to be supported by Mordhau servers.
+var MordhauCommands = []string{
+	"addadmin", "adminlist", "alive", "ban", "banlist", "changelevel", "info", "kick", "listen", "mute", "mutelist",
+	"playerlist", "removeadmin", "say", "stoplisten", "unban", "unmute",
+}
+
+// SourceCommands are common RCON commands supported by Source engine servers.
+var SourceCommands = []string{
+	"banid", "changelevel", "cvarlist", "echo", "exec", "kick", "kickid", "listid", "maps", "removeid", "say",
+	"status", "users", "writeid",
+}
+
+// MinecraftCommands are common RCON commands supported by Minecraft servers.
+var MinecraftCommands = []string{
+	"ban", "ban-ip", "banlist", "deop", "difficulty", "gamemode", "give", "kick", "list", "op", "pardon", "pardon-ip",
+	"save-all", "save-off", "save-on", "say", "stop", "time", "tp", "weather", "whitelist",
+}
+
+// KnownCommands maps the names games are registered under to the commands known to be supported by them.
+var KnownCommands = map[string][]string{
+	"mordhau":   MordhauCommands,
+	"source":    SourceCommands,
+	"minecraft": MinecraftCommands,
+}
@@ -0,0 +1,96 @@
+// Package conan configures clients for Conan Exiles servers and parses their ListPlayers responses.
+package conan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownFormat is returned by ParseListPlayers for responses which do not match the expected format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Dialect describes Conan Exiles servers. Funcom's RCON implementation answers commands only; it doesn't push
+// broadcasts and reserves no packet IDs, so none are restricted and no packet is treated as a broadcast.
+var Dialect = presets.NewDialect("conan", rcon.Features{})
+
+// Game configures a client for Conan Exiles servers.
+func Game(config *rcon.Config) {
+	config.Dialect = Dialect
+}
+
+func init() {
+	rcon.RegisterGame("conan", Game)
+}
+
+// Player is a row of the ListPlayers response. Columns the server did not print are left empty.
+type Player struct {
+	Index        int
+	CharName     string
+	PlayerName   string
+	UserID       string
+	PlatformID   string
+	PlatformName string
+}
+
+// ParseListPlayers parses the table printed by ListPlayers. Columns are looked up by their header, so servers which
+// print fewer columns or print them in a different order are supported.
+func ParseListPlayers(response string) ([]Player, error) {
+	var columns map[string]int
+	var players []Player
+
+	for _, line := range strings.Split(response, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		cells := strings.Split(line, "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+
+		if columns == nil {
+			columns = map[string]int{}
+			for i, name := range cells {
+				columns[strings.ToLower(name)] = i
+			}
+
+			if _, ok := columns["idx"]; !ok {
+				return nil, fmt.Errorf("unexpected ListPlayers header %q: %w", line, ErrUnknownFormat)
+			}
+
+			continue
+		}
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(cells) {
+				return cells[i]
+			}
+
+			return ""
+		}
+
+		index, err := strconv.Atoi(cell("idx"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid player index in %q: %w", line, ErrUnknownFormat)
+		}
+
+		players = append(players, Player{
+			Index:        index,
+			CharName:     cell("char name"),
+			PlayerName:   cell("player name"),
+			UserID:       cell("user id"),
+			PlatformID:   cell("platform id"),
+			PlatformName: cell("platform name"),
+		})
+	}
+
+	if columns == nil {
+		return nil, fmt.Errorf("empty ListPlayers response: %w", ErrUnknownFormat)
+	}
+
+	return players, nil
+}
@@ -0,0 +1,74 @@
+package conan_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/conan"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"testing"
+)
+
+func TestParseListPlayers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseListPlayers()", func() {
+		g.It("Should parse the player table", func() {
+			data, err := fixtures.Read("listplayers.txt")
+			Expect(err).To(BeNil())
+
+			players, err := conan.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]conan.Player{
+				{
+					Index:        0,
+					CharName:     "Conan",
+					PlayerName:   "Player One",
+					UserID:       "7EF1A2B3C4D5E6F7",
+					PlatformID:   "76561198000000001",
+					PlatformName: "Steam",
+				},
+				{
+					Index:        1,
+					CharName:     "Valeria",
+					PlayerName:   "Player Two",
+					UserID:       "8AF1A2B3C4D5E6F8",
+					PlatformID:   "76561198000000002",
+					PlatformName: "Steam",
+				},
+			}))
+		})
+
+		g.It("Should leave columns the server didn't print empty", func() {
+			data, err := fixtures.Read("listplayers_legacy.txt")
+			Expect(err).To(BeNil())
+
+			players, err := conan.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]conan.Player{{Index: 0, CharName: "Conan", PlayerName: "Player One"}}))
+		})
+
+		g.It("Should return no players for an empty server", func() {
+			data, err := fixtures.Read("listplayers_empty.txt")
+			Expect(err).To(BeNil())
+
+			players, err := conan.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(BeEmpty())
+		})
+
+		g.It("Should return ErrUnknownFormat for other responses", func() {
+			_, err := conan.ParseListPlayers("Couldn't find the command: listplayer")
+			Expect(errors.Is(err, conan.ErrUnknownFormat)).To(BeTrue())
+
+			_, err = conan.ParseListPlayers("")
+			Expect(errors.Is(err, conan.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+}
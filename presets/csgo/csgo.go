@@ -0,0 +1,86 @@
+// Package csgo configures clients for Counter-Strike: Global Offensive and Counter-Strike 2 servers, parses their
+// status output and reads and writes cvars.
+package csgo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnknownFormat is returned by the parsers for responses which do not match the expected format.
+	ErrUnknownFormat = errors.New("unknown format")
+
+	// ErrUnknownCvar is returned by GetCvar if the server does not know the cvar.
+	ErrUnknownCvar = errors.New("unknown cvar")
+)
+
+// Dialect describes CS:GO and CS2 servers. CS:GO answers every command, including the empty sentinel packet used to
+// find the end of multi-packet responses, with SERVERDATA_RESPONSE_VALUE packets, and sends an empty one for commands
+// without output. The dialect assembles multi-packet responses, so these empty packets end a response instead of
+// being left over as unexpected packets. The empty packet sent ahead of the auth response is skipped by the client.
+var Dialect = presets.SourceDialect
+
+// Game configures a client for CS:GO and CS2 servers.
+func Game(config *rcon.Config) {
+	config.Dialect = Dialect
+}
+
+func init() {
+	rcon.RegisterGame("csgo", Game)
+	rcon.RegisterGame("cs2", Game)
+}
+
+var (
+	quotedCvarPattern = regexp.MustCompile(`^"([^"]+)" = "([^"]*)"`)
+	plainCvarPattern  = regexp.MustCompile(`^(\S+) = (.*)$`)
+)
+
+// ParseCvar parses the response to a command consisting only of a cvar's name, e.g. "mp_roundtime". Both the CS:GO
+// format, `"mp_roundtime" = "5" ( def. "5" )`, and the CS2 format, `mp_roundtime = 5`, are supported.
+func ParseCvar(response string) (string, string, error) {
+	response = strings.TrimSpace(response)
+
+	if m := quotedCvarPattern.FindStringSubmatch(response); m != nil {
+		return m[1], m[2], nil
+	}
+
+	if m := plainCvarPattern.FindStringSubmatch(strings.SplitN(response, "\n", 2)[0]); m != nil {
+		return m[1], strings.TrimSpace(m[2]), nil
+	}
+
+	return "", "", fmt.Errorf("unexpected cvar response %q: %w", response, ErrUnknownFormat)
+}
+
+// GetCvar returns the value of the cvar name, e.g. "mp_roundtime" or "sv_cheats".
+func GetCvar(client *rcon.Client, name string) (string, error) {
+	res, err := client.ExecCommand(name)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(res) == "" || strings.HasPrefix(res, "Unknown command") {
+		return "", fmt.Errorf("cvar %q: %w", name, ErrUnknownCvar)
+	}
+
+	cvar, value, err := ParseCvar(res)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(cvar, name) {
+		return "", fmt.Errorf("response describes cvar %q instead of %q: %w", cvar, name, ErrUnknownFormat)
+	}
+
+	return value, nil
+}
+
+// SetCvar sets the cvar name to value.
+func SetCvar(client *rcon.Client, name string, value string) error {
+	_, err := client.ExecCommand(name + " \"" + strings.Replace(value, "\"", "", -1) + "\"")
+	return err
+}
@@ -0,0 +1,115 @@
+package csgo_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/csgo"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"testing"
+)
+
+func TestParsers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseStatus()", func() {
+		g.It("Should parse the CS:GO format", func() {
+			data, err := fixtures.Read("status_csgo.txt")
+			Expect(err).To(BeNil())
+
+			status, err := csgo.ParseStatus(data)
+
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(csgo.Status{
+				Hostname: "Counter-Strike: Global Offensive",
+				Map:      "de_dust2",
+				Players:  "2 humans, 1 bots (16/0 max) (not hibernating)",
+				Connected: []csgo.Player{
+					{
+						UserID:    2,
+						Name:      "Player One",
+						SteamID64: "76561197960290418",
+						Connected: "05:12",
+						Ping:      42,
+						State:     "active",
+						Address:   "203.0.113.5:27005",
+					},
+					{UserID: 3, Name: "Bot Bob", Bot: true, State: "active"},
+					{
+						UserID:    4,
+						Name:      "Player Two",
+						SteamID64: "76561197960401509",
+						Connected: "1:02:03",
+						Ping:      87,
+						Loss:      1,
+						State:     "active",
+						Address:   "198.51.100.7:27005",
+					},
+				},
+			}))
+		})
+
+		g.It("Should parse the CS2 format without pending connections", func() {
+			data, err := fixtures.Read("status_cs2.txt")
+			Expect(err).To(BeNil())
+
+			status, err := csgo.ParseStatus(data)
+
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(csgo.Status{
+				Hostname: "Counter-Strike 2",
+				Map:      "de_inferno",
+				Players:  "1 humans, 1 bots (20 max) (not hibernating) (unreserved)",
+				Connected: []csgo.Player{
+					{
+						UserID:    2,
+						Name:      "Player One",
+						Connected: "04:32",
+						Ping:      35,
+						State:     "active",
+						Address:   "203.0.113.5:27005",
+					},
+					{UserID: 3, Name: "Bot Alice", Bot: true, Connected: "BOT", State: "active", Address: "BOT"},
+				},
+			}))
+		})
+
+		g.It("Should return ErrUnknownFormat for other output", func() {
+			_, err := csgo.ParseStatus("Unknown command \"stats\"")
+
+			Expect(errors.Is(err, csgo.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParseCvar()", func() {
+		g.It("Should parse both cvar formats", func() {
+			for _, name := range []string{"cvar_csgo.txt", "cvar_cs2.txt"} {
+				data, err := fixtures.Read(name)
+				Expect(err).To(BeNil())
+
+				cvar, value, err := csgo.ParseCvar(data)
+
+				Expect(err).To(BeNil())
+				Expect(cvar).To(Equal("mp_roundtime"))
+				Expect(value).To(Equal("5"))
+			}
+		})
+
+		g.It("Should return ErrUnknownFormat for other responses", func() {
+			_, _, err := csgo.ParseCvar("Unknown command \"mp_roundtim\"")
+
+			Expect(errors.Is(err, csgo.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("SteamID64()", func() {
+		g.It("Should convert both SteamID formats", func() {
+			Expect(csgo.SteamID64("STEAM_1:0:12345")).To(Equal("76561197960290418"))
+			Expect(csgo.SteamID64("[U:1:24690]")).To(Equal("76561197960290418"))
+			Expect(csgo.SteamID64("BOT")).To(Equal(""))
+		})
+	})
+}
@@ -0,0 +1,160 @@
+package csgo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// steamID64Base is the SteamID64 of the first individual account in the public universe.
+const steamID64Base = 76561197960265728
+
+// Status is the output of status.
+type Status struct {
+	Hostname string
+	Map      string
+
+	// Players is the players line as printed, e.g. "2 humans, 0 bots (16/0 max) (not hibernating)".
+	Players string
+
+	Connected []Player
+}
+
+// Player is an entry of the player table in the status output. SteamID64 is empty for bots and on CS2 servers, which
+// don't print SteamIDs.
+type Player struct {
+	UserID    int
+	Name      string
+	SteamID64 string
+	Bot       bool
+	Connected string
+	Ping      int
+	Loss      int
+	State     string
+	Address   string
+}
+
+var (
+	// # userid name uniqueid connected ping loss state rate adr
+	csgoPlayerPattern = regexp.MustCompile(
+		`^#\s*(\d+)\s+(?:\d+\s+)?"(.*)"\s+(\S+)\s+(\S+)\s+(\d+)\s+(\d+)\s+(\S+)\s+\d+\s*(\S*)$`)
+	csgoBotPattern = regexp.MustCompile(`^#\s*(\d+)\s+"(.*)"\s+BOT\s+(\S+)`)
+
+	// id time ping loss state rate adr name
+	cs2PlayerPattern = regexp.MustCompile(`^(\d+)\s+(\S+)\s+(\d+)\s+(\d+)\s+(\S+)\s+(\d+)\s*(\S+)\s+'(.*)'$`)
+
+	steam2Pattern = regexp.MustCompile(`^STEAM_\d:([01]):(\d+)$`)
+	steam3Pattern = regexp.MustCompile(`^\[U:1:(\d+)\]$`)
+)
+
+// ParseStatus parses the output of status. Both the CS:GO and the CS2 formats are supported.
+func ParseStatus(output string) (Status, error) {
+	var s Status
+	header := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if p, ok := parsePlayer(line); ok {
+			s.Connected = append(s.Connected, p)
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		value := strings.TrimSpace(line[i+1:])
+
+		switch strings.TrimSpace(line[:i]) {
+		case "hostname":
+			s.Hostname = value
+		case "map":
+			s.Map = value
+		case "players":
+			s.Players = value
+		default:
+			continue
+		}
+
+		header = true
+	}
+
+	if !header && len(s.Connected) == 0 {
+		return Status{}, fmt.Errorf("unexpected status output %q: %w", output, ErrUnknownFormat)
+	}
+
+	return s, nil
+}
+
+// parsePlayer parses a row of the player table.
+func parsePlayer(line string) (Player, bool) {
+	if m := csgoBotPattern.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		return Player{UserID: id, Name: m[2], Bot: true, State: m[3]}, true
+	}
+
+	if m := csgoPlayerPattern.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		ping, _ := strconv.Atoi(m[5])
+		loss, _ := strconv.Atoi(m[6])
+
+		return Player{
+			UserID:    id,
+			Name:      m[2],
+			SteamID64: SteamID64(m[3]),
+			Connected: m[4],
+			Ping:      ping,
+			Loss:      loss,
+			State:     m[7],
+			Address:   m[8],
+		}, true
+	}
+
+	// CS2 lists pending connections with the user ID 65535 and no channel; they aren't players yet.
+	if m := cs2PlayerPattern.FindStringSubmatch(line); m != nil && m[2] != "[NoChan]" {
+		id, _ := strconv.Atoi(m[1])
+		ping, _ := strconv.Atoi(m[3])
+		loss, _ := strconv.Atoi(m[4])
+
+		return Player{
+			UserID:    id,
+			Name:      m[8],
+			Bot:       m[7] == "BOT",
+			Connected: m[2],
+			Ping:      ping,
+			Loss:      loss,
+			State:     m[5],
+			Address:   m[7],
+		}, true
+	}
+
+	return Player{}, false
+}
+
+// SteamID64 converts a SteamID in the STEAM_X:Y:Z or [U:1:Z] format to a SteamID64. It returns an empty string for
+// IDs in neither format, such as BOT.
+func SteamID64(id string) string {
+	if m := steam2Pattern.FindStringSubmatch(id); m != nil {
+		y, _ := strconv.ParseUint(m[1], 10, 64)
+		z, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			return ""
+		}
+
+		return strconv.FormatUint(steamID64Base+z*2+y, 10)
+	}
+
+	if m := steam3Pattern.FindStringSubmatch(id); m != nil {
+		z, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return ""
+		}
+
+		return strconv.FormatUint(steamID64Base+z, 10)
+	}
+
+	return ""
+}
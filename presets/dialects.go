@@ -0,0 +1,60 @@
+package presets
+
+import "github.com/refractorgscm/rcon"
+
+type dialect struct {
+	name     string
+	features rcon.Features
+}
+
+func (d *dialect) Name() string {
+	return d.name
+}
+
+func (d *dialect) Features() rcon.Features {
+	return d.features
+}
+
+// NewDialect creates a dialect with the given name and features. It can be used to describe games which don't have a
+// preset.
+func NewDialect(name string, features rcon.Features) rcon.Dialect {
+	return &dialect{
+		name:     name,
+		features: features,
+	}
+}
+
+// WithTimeCommand returns a dialect with the features of d which reads the server's clock by executing command and
+// parsing the response with parse, for servers running a plugin which prints their clock. It enables Client.SyncClock.
+func WithTimeCommand(d rcon.Dialect, command string, parse rcon.TimeParser) rcon.Dialect {
+	features := d.Features()
+	features.TimeCommand = command
+	features.TimeParser = parse
+
+	return NewDialect(d.Name(), features)
+}
+
+// SourceDialect describes Source engine servers (CS:GO, TF2, Garry's Mod and others). It assembles multi-packet
+// responses correctly on both older SRCDS builds, which echo the sentinel after the last fragment, and newer ones,
+// which may echo it early. Use NewSourceDialect to match a specific build exactly.
+var SourceDialect = SourceProfile.Dialect()
+
+// NewSourceDialect creates a Source dialect for servers splitting responses into fragments of fragmentSize bytes and
+// answering sentinel packets as described by sentinel.
+func NewSourceDialect(fragmentSize int, sentinel rcon.SentinelBehavior) rcon.Dialect {
+	return NewDialect("source", rcon.Features{
+		MultiPacket:  true,
+		MaxBodySize:  4096,
+		FragmentSize: fragmentSize,
+		Sentinel:     sentinel,
+	})
+}
+
+// MordhauDialect describes Mordhau servers, which support broadcasts on reserved packet IDs. See
+// MordhauRestrictedPacketIDs and MordhauBroadcastChecker.
+var MordhauDialect = MordhauProfile.Dialect()
+
+// MinecraftDialect describes Minecraft servers. Minecraft limits incoming command packets to 1446 bytes and splits
+// responses into fragments of 4096 bytes. It answers the sentinel packet with an "Unknown request" response carrying
+// the sentinel's ID after the last fragment, which marks the end of the response like an echo.
+var MinecraftDialect = MinecraftProfile.Dialect()
@@ -0,0 +1,59 @@
+package presets
+
+import "github.com/refractorgscm/rcon"
+
+// MordhauProfile declares the protocol quirks of Mordhau servers: broadcasts on reserved packet IDs and the "alive"
+// keepalive command. It also sets the Mordhau broadcast channels and response error checker.
+var MordhauProfile = &rcon.GameProfile{
+	Name:                   "mordhau",
+	RestrictedPacketIDs:    MordhauRestrictedPacketIDs,
+	BroadcastChecker:       MordhauBroadcastChecker,
+	BroadcastHeaderChecker: MordhauBroadcastHeaderChecker,
+	ListenCommand:          "listen %s",
+	StopListenCommand:      "stoplisten %s",
+	KeepAliveCommand:       MordhauKeepAlive.Command,
+	KeepAliveInterval:      MordhauKeepAlive.Interval,
+	Configure: func(config *rcon.Config) {
+		config.BroadcastChannel = MordhauBroadcastChannel
+		config.ResponseErrorChecker = MordhauResponseErrorChecker
+	},
+}
+
+// SourceProfile declares the protocol quirks of Source engine servers. See SourceDialect.
+var SourceProfile = &rcon.GameProfile{
+	Name:         "source",
+	MaxBodySize:  4096,
+	MultiPacket:  true,
+	FragmentSize: rcon.DefaultFragmentSize,
+	Sentinel:     rcon.SentinelUnordered,
+}
+
+// MinecraftProfile declares the protocol quirks of Minecraft servers. See MinecraftDialect.
+var MinecraftProfile = &rcon.GameProfile{
+	Name:         "minecraft",
+	MaxBodySize:  1446,
+	MultiPacket:  true,
+	FragmentSize: 4096,
+	Sentinel:     rcon.SentinelEcho,
+}
+
+// MordhauGame configures a client for Mordhau servers. It applies MordhauProfile.
+func MordhauGame(config *rcon.Config) {
+	MordhauProfile.Apply(config)
+}
+
+// SourceGame configures a client for Source engine servers. It applies SourceProfile.
+func SourceGame(config *rcon.Config) {
+	SourceProfile.Apply(config)
+}
+
+// MinecraftGame configures a client for Minecraft servers. It applies MinecraftProfile.
+func MinecraftGame(config *rcon.Config) {
+	MinecraftProfile.Apply(config)
+}
+
+func init() {
+	rcon.RegisterProfile(MordhauProfile)
+	rcon.RegisterProfile(SourceProfile)
+	rcon.RegisterProfile(MinecraftProfile)
+}
@@ -0,0 +1,17 @@
+// Package fixtures loads the recorded server output the preset packages are tested against.
+package fixtures
+
+import (
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Read returns the server output recorded in testdata/name of the package under test.
+func Read(name string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
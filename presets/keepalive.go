@@ -0,0 +1,13 @@
+package presets
+
+import (
+	"github.com/refractorgscm/rcon"
+	"time"
+)
+
+// MordhauKeepAlive pings Mordhau servers with the "alive" command, which Mordhau provides to keep RCON sessions from
+// being closed for inactivity.
+var MordhauKeepAlive = rcon.KeepAliveConfig{
+	Interval: time.Second * 30,
+	Command:  "alive",
+}
@@ -21,3 +21,9 @@ func (dl *DebugLogger) Debug(args ...interface{}) {
 	newArgs = append(newArgs, args...)
 	log.Print(newArgs...)
 }
+
+func (dl *DebugLogger) Trace(args ...interface{}) {
+	newArgs := []interface{}{"[TRACE] "}
+	newArgs = append(newArgs, args...)
+	log.Print(newArgs...)
+}
@@ -0,0 +1,41 @@
+// Package minecraft configures clients for Minecraft servers and parses the output of their commands.
+package minecraft
+
+import (
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"regexp"
+)
+
+// Dialect describes Minecraft servers. It is presets.MinecraftDialect.
+var Dialect = presets.MinecraftDialect
+
+type Options struct {
+	// StripColors removes § formatting codes from every response.
+	StripColors bool
+}
+
+// Game configures a client for Minecraft servers with formatting codes stripped from responses.
+func Game(config *rcon.Config) {
+	GameWithOptions(Options{StripColors: true})(config)
+}
+
+// GameWithOptions returns a preset configuring a client for Minecraft servers according to options.
+func GameWithOptions(options Options) rcon.GamePreset {
+	return func(config *rcon.Config) {
+		presets.MinecraftGame(config)
+
+		if options.StripColors {
+			config.ResponseFilter = func(command, response string) string {
+				return StripColorCodes(response)
+			}
+		}
+	}
+}
+
+var colorCodePattern = regexp.MustCompile(`§.?`)
+
+// StripColorCodes removes § formatting codes, such as §c for red or §l for bold, from s.
+func StripColorCodes(s string) string {
+	return colorCodePattern.ReplaceAllString(s, "")
+}
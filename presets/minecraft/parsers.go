@@ -0,0 +1,120 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownFormat is returned by the parsers for output which does not match the expected format.
+	ErrUnknownFormat = errors.New("unknown format")
+
+	// ErrAlreadyBanned is returned by ParseBan if the player was already banned.
+	ErrAlreadyBanned = errors.New("player is already banned")
+
+	// ErrUnknownPlayer is returned by ParseBan if the player does not exist.
+	ErrUnknownPlayer = errors.New("player does not exist")
+)
+
+// Player is an entry of the list command's output. UUID is only set for the output of "list uuids".
+type Player struct {
+	Name string
+	UUID string
+}
+
+// PlayerList is the output of the list command.
+type PlayerList struct {
+	Online  int
+	Max     int
+	Players []Player
+}
+
+// Ban is an entry of the banlist command's output.
+type Ban struct {
+	Target string
+	Source string
+	Reason string
+}
+
+var (
+	listPattern       = regexp.MustCompile(`(?s)^There are (\d+)(?: of a max of |/)(\d+) players online:(.*)$`)
+	listPlayerPattern = regexp.MustCompile(`^(.*) \(([0-9a-fA-F-]{36})\)$`)
+	banPattern        = regexp.MustCompile(`^Banned (.+?): (.*)$`)
+	banListPattern    = regexp.MustCompile(`^(.+?) was banned by (.+?): (.*)$`)
+)
+
+// ParseList parses the output of "list" and "list uuids". Both the current format and the "There are n/max players
+// online" format of older versions are supported.
+func ParseList(output string) (PlayerList, error) {
+	m := listPattern.FindStringSubmatch(strings.TrimSpace(StripColorCodes(output)))
+	if m == nil {
+		return PlayerList{}, fmt.Errorf("unexpected list output %q: %w", output, ErrUnknownFormat)
+	}
+
+	online, _ := strconv.Atoi(m[1])
+	max, _ := strconv.Atoi(m[2])
+	list := PlayerList{Online: online, Max: max}
+
+	for _, name := range strings.Split(m[3], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if pm := listPlayerPattern.FindStringSubmatch(name); pm != nil {
+			list.Players = append(list.Players, Player{Name: pm[1], UUID: pm[2]})
+		} else {
+			list.Players = append(list.Players, Player{Name: name})
+		}
+	}
+
+	return list, nil
+}
+
+// ParseBan parses the output of "ban" and returns the banned player and the reason. ErrAlreadyBanned and
+// ErrUnknownPlayer are returned if the server refused the ban.
+func ParseBan(output string) (string, string, error) {
+	output = strings.TrimSpace(StripColorCodes(output))
+
+	switch {
+	case strings.HasPrefix(output, "Nothing changed"):
+		return "", "", ErrAlreadyBanned
+	case strings.HasPrefix(output, "That player does not exist"):
+		return "", "", ErrUnknownPlayer
+	}
+
+	m := banPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", "", fmt.Errorf("unexpected ban output %q: %w", output, ErrUnknownFormat)
+	}
+
+	return m[1], m[2], nil
+}
+
+// ParseBanList parses the output of "banlist".
+func ParseBanList(output string) ([]Ban, error) {
+	output = strings.TrimSpace(StripColorCodes(output))
+	if strings.HasPrefix(output, "There are no bans") {
+		return nil, nil
+	}
+
+	lines := strings.Split(output, "\n")
+	if !strings.HasPrefix(lines[0], "There are") {
+		return nil, fmt.Errorf("unexpected banlist output %q: %w", output, ErrUnknownFormat)
+	}
+
+	var bans []Ban
+	for _, line := range lines[1:] {
+		m := banListPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+
+		bans = append(bans, Ban{Target: m[1], Source: m[2], Reason: m[3]})
+	}
+
+	return bans, nil
+}
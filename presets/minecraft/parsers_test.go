@@ -0,0 +1,118 @@
+package minecraft_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"github.com/refractorgscm/rcon/presets/minecraft"
+	"testing"
+)
+
+func TestParsers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseList()", func() {
+		g.It("Should parse the output of list", func() {
+			data, err := fixtures.Read("list.txt")
+			Expect(err).To(BeNil())
+
+			list, err := minecraft.ParseList(data)
+
+			Expect(err).To(BeNil())
+			Expect(list).To(Equal(minecraft.PlayerList{
+				Online:  2,
+				Max:     20,
+				Players: []minecraft.Player{{Name: "Steve"}, {Name: "Alex"}},
+			}))
+		})
+
+		g.It("Should parse the output of list uuids", func() {
+			data, err := fixtures.Read("list_uuids.txt")
+			Expect(err).To(BeNil())
+
+			list, err := minecraft.ParseList(data)
+
+			Expect(err).To(BeNil())
+			Expect(list.Players).To(Equal([]minecraft.Player{
+				{Name: "Steve", UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+			}))
+		})
+
+		g.It("Should parse the colored output of older versions", func() {
+			data, err := fixtures.Read("list_legacy.txt")
+			Expect(err).To(BeNil())
+
+			list, err := minecraft.ParseList(data)
+
+			Expect(err).To(BeNil())
+			Expect(list).To(Equal(minecraft.PlayerList{
+				Online:  1,
+				Max:     10,
+				Players: []minecraft.Player{{Name: "Notch"}},
+			}))
+		})
+
+		g.It("Should parse an empty server", func() {
+			data, err := fixtures.Read("list_empty.txt")
+			Expect(err).To(BeNil())
+
+			list, err := minecraft.ParseList(data)
+
+			Expect(err).To(BeNil())
+			Expect(list).To(Equal(minecraft.PlayerList{Max: 20}))
+		})
+
+		g.It("Should return ErrUnknownFormat for other output", func() {
+			_, err := minecraft.ParseList("Unknown or incomplete command")
+
+			Expect(errors.Is(err, minecraft.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParseBan()", func() {
+		g.It("Should return the banned player and the reason", func() {
+			data, err := fixtures.Read("ban.txt")
+			Expect(err).To(BeNil())
+
+			player, reason, err := minecraft.ParseBan(data)
+
+			Expect(err).To(BeNil())
+			Expect(player).To(Equal("Griefer"))
+			Expect(reason).To(Equal("Destroying spawn"))
+		})
+
+		g.It("Should return the errors of refused bans", func() {
+			_, _, err := minecraft.ParseBan("Nothing changed. The player is already banned")
+			Expect(errors.Is(err, minecraft.ErrAlreadyBanned)).To(BeTrue())
+
+			_, _, err = minecraft.ParseBan("That player does not exist")
+			Expect(errors.Is(err, minecraft.ErrUnknownPlayer)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParseBanList()", func() {
+		g.It("Should parse the output of banlist", func() {
+			data, err := fixtures.Read("banlist.txt")
+			Expect(err).To(BeNil())
+
+			bans, err := minecraft.ParseBanList(data)
+
+			Expect(err).To(BeNil())
+			Expect(bans).To(Equal([]minecraft.Ban{
+				{Target: "Griefer", Source: "Server", Reason: "Destroying spawn"},
+				{Target: "Spammer", Source: "Steve", Reason: "Chat spam"},
+			}))
+		})
+
+		g.It("Should return no bans if there are none", func() {
+			bans, err := minecraft.ParseBanList("There are no bans")
+
+			Expect(err).To(BeNil())
+			Expect(bans).To(BeEmpty())
+		})
+	})
+}
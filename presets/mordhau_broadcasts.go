@@ -0,0 +1,163 @@
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownBroadcast is returned by ParseMordhauBroadcast for broadcasts which do not match any known format.
+var ErrUnknownBroadcast = errors.New("unknown broadcast format")
+
+// MordhauTimeLayout is the layout of timestamps in Mordhau broadcasts, e.g. 2021.05.12-22.14.33.
+const MordhauTimeLayout = "2006.01.02-15.04.05"
+
+// ChatMessage is a Mordhau chat broadcast. Channel is empty if the broadcast did not name one.
+type ChatMessage struct {
+	PlayerID string
+	Name     string
+	Channel  string
+	Message  string
+}
+
+// LoginEvent is a Mordhau login broadcast. LoggedIn is false if the player logged out.
+type LoginEvent struct {
+	Time     time.Time
+	PlayerID string
+	Name     string
+	LoggedIn bool
+}
+
+// PunishmentEvent is a Mordhau punishment broadcast. Action is the lowercase punishment, e.g. "banned" or "muted".
+// Duration is in minutes; zero means permanent.
+type PunishmentEvent struct {
+	AdminName string
+	AdminID   string
+	Action    string
+	PlayerID  string
+	Duration  int
+	Reason    string
+}
+
+// MatchStateEvent is a Mordhau match state broadcast, e.g. "In progress" or "Leaving map".
+type MatchStateEvent struct {
+	State string
+}
+
+// ScorefeedEvent is a Mordhau scorefeed broadcast describing a kill.
+type ScorefeedEvent struct {
+	Time       time.Time
+	KillerID   string
+	KillerName string
+	VictimID   string
+	VictimName string
+}
+
+var (
+	mordhauChatPattern       = regexp.MustCompile(`^Chat: ([^,]+), (.*?), (?:\(([^)]+)\) )?(.*)$`)
+	mordhauLoginPattern      = regexp.MustCompile(`^Login: ([0-9.\-]+): (.*) \(([^)]+)\) logged (in|out)$`)
+	mordhauPunishmentPattern = regexp.MustCompile(
+		`^Punishment: Admin (.*) \(([^)]+)\) (\w+) player (\S+)(?: \(Duration: (\d+), Reason: (.*)\))?$`)
+	mordhauMatchStatePattern = regexp.MustCompile(`^MatchState: (.*)$`)
+	mordhauScorefeedPattern  = regexp.MustCompile(`^Scorefeed: ([0-9.\-]+): (.*) \(([^)]+)\) killed (.*) \(([^)]+)\)$`)
+)
+
+// ParseMordhauBroadcast parses a Mordhau broadcast into a ChatMessage, LoginEvent, PunishmentEvent, MatchStateEvent or
+// ScorefeedEvent. ErrUnknownBroadcast is returned if the message matches none of them.
+func ParseMordhauBroadcast(message string) (interface{}, error) {
+	message = strings.TrimSpace(message)
+
+	if m := mordhauChatPattern.FindStringSubmatch(message); m != nil {
+		return ChatMessage{PlayerID: m[1], Name: m[2], Channel: m[3], Message: m[4]}, nil
+	}
+
+	if m := mordhauLoginPattern.FindStringSubmatch(message); m != nil {
+		t, err := time.Parse(MordhauTimeLayout, m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid login timestamp: %w", err)
+		}
+
+		return LoginEvent{Time: t, Name: m[2], PlayerID: m[3], LoggedIn: m[4] == "in"}, nil
+	}
+
+	if m := mordhauPunishmentPattern.FindStringSubmatch(message); m != nil {
+		e := PunishmentEvent{AdminName: m[1], AdminID: m[2], Action: strings.ToLower(m[3]), PlayerID: m[4], Reason: m[6]}
+
+		if m[5] != "" {
+			duration, err := strconv.Atoi(m[5])
+			if err != nil {
+				return nil, fmt.Errorf("invalid punishment duration: %w", err)
+			}
+
+			e.Duration = duration
+		}
+
+		return e, nil
+	}
+
+	if m := mordhauScorefeedPattern.FindStringSubmatch(message); m != nil {
+		t, err := time.Parse(MordhauTimeLayout, m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid scorefeed timestamp: %w", err)
+		}
+
+		return ScorefeedEvent{Time: t, KillerName: m[2], KillerID: m[3], VictimName: m[4], VictimID: m[5]}, nil
+	}
+
+	if m := mordhauMatchStatePattern.FindStringSubmatch(message); m != nil {
+		return MatchStateEvent{State: m[1]}, nil
+	}
+
+	return nil, ErrUnknownBroadcast
+}
+
+// MordhauBroadcastDispatcher parses Mordhau broadcasts and routes them to per-type handlers. Handlers which are nil are
+// skipped. Its Handle method can be used as a client's BroadcastHandler.
+type MordhauBroadcastDispatcher struct {
+	OnChat       func(ChatMessage)
+	OnLogin      func(LoginEvent)
+	OnPunishment func(PunishmentEvent)
+	OnMatchState func(MatchStateEvent)
+	OnScorefeed  func(ScorefeedEvent)
+
+	// OnUnparsed is called with broadcasts which could not be parsed and the reason.
+	OnUnparsed func(message string, err error)
+}
+
+// Handle parses message and calls the matching handler.
+func (d *MordhauBroadcastDispatcher) Handle(message string) {
+	event, err := ParseMordhauBroadcast(message)
+	if err != nil {
+		if d.OnUnparsed != nil {
+			d.OnUnparsed(message, err)
+		}
+
+		return
+	}
+
+	switch e := event.(type) {
+	case ChatMessage:
+		if d.OnChat != nil {
+			d.OnChat(e)
+		}
+	case LoginEvent:
+		if d.OnLogin != nil {
+			d.OnLogin(e)
+		}
+	case PunishmentEvent:
+		if d.OnPunishment != nil {
+			d.OnPunishment(e)
+		}
+	case MatchStateEvent:
+		if d.OnMatchState != nil {
+			d.OnMatchState(e)
+		}
+	case ScorefeedEvent:
+		if d.OnScorefeed != nil {
+			d.OnScorefeed(e)
+		}
+	}
+}
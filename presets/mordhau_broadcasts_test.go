@@ -0,0 +1,144 @@
+package presets_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMordhauBroadcasts(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// recorded returns the broadcasts in testdata/mordhau_broadcasts.txt, which holds one per line as received from a
+	// Mordhau server.
+	recorded := func() []string {
+		data, err := fixtures.Read("mordhau_broadcasts.txt")
+		Expect(err).To(BeNil())
+
+		return strings.Split(strings.TrimSpace(data), "\n")
+	}
+
+	at := func(hour, min, sec int) time.Time {
+		return time.Date(2021, 5, 12, hour, min, sec, 0, time.UTC)
+	}
+
+	expected := []interface{}{
+		presets.ChatMessage{PlayerID: "76561198000000001", Name: "Alpha One", Channel: "ALL", Message: "gg everyone"},
+		presets.ChatMessage{PlayerID: "76561198000000002", Name: "Bravo", Message: "hello, is anyone on?"},
+		presets.LoginEvent{Time: at(22, 14, 33), PlayerID: "76561198000000001", Name: "Alpha One", LoggedIn: true},
+		presets.LoginEvent{Time: at(23, 1, 7), PlayerID: "76561198000000002", Name: "Bravo", LoggedIn: false},
+		presets.PunishmentEvent{
+			AdminName: "Charlie",
+			AdminID:   "76561198000000003",
+			Action:    "banned",
+			PlayerID:  "76561198000000002",
+			Duration:  60,
+			Reason:    "teamkilling",
+		},
+		presets.PunishmentEvent{
+			AdminName: "Charlie",
+			AdminID:   "76561198000000003",
+			Action:    "kicked",
+			PlayerID:  "76561198000000004",
+		},
+		presets.MatchStateEvent{State: "In progress"},
+		presets.MatchStateEvent{State: "Leaving map"},
+		presets.ScorefeedEvent{
+			Time:       at(22, 20, 45),
+			KillerID:   "76561198000000001",
+			KillerName: "Alpha One",
+			VictimID:   "76561198000000002",
+			VictimName: "Bravo",
+		},
+		presets.ScorefeedEvent{
+			Time:       at(22, 21, 2),
+			KillerID:   "76561198000000002",
+			KillerName: "Bravo",
+			VictimID:   "76561198000000001",
+			VictimName: "Alpha One",
+		},
+	}
+
+	g.Describe("ParseMordhauBroadcast()", func() {
+		g.It("Should parse every known broadcast", func() {
+			broadcasts := recorded()
+			Expect(broadcasts).To(HaveLen(len(expected) + 1))
+
+			for i, want := range expected {
+				event, err := presets.ParseMordhauBroadcast(broadcasts[i])
+				Expect(err).To(BeNil(), broadcasts[i])
+				Expect(event).To(Equal(want), broadcasts[i])
+			}
+		})
+
+		g.It("Should ignore surrounding whitespace", func() {
+			event, err := presets.ParseMordhauBroadcast("  MatchState: In progress\r\n")
+			Expect(err).To(BeNil())
+			Expect(event).To(Equal(presets.MatchStateEvent{State: "In progress"}))
+		})
+
+		g.It("Should return ErrUnknownBroadcast for unknown formats", func() {
+			broadcasts := recorded()
+			event, err := presets.ParseMordhauBroadcast(broadcasts[len(broadcasts)-1])
+			Expect(event).To(BeNil())
+			Expect(errors.Is(err, presets.ErrUnknownBroadcast)).To(BeTrue())
+		})
+
+		g.It("Should reject invalid timestamps", func() {
+			_, err := presets.ParseMordhauBroadcast("Login: 2021.13.45-99.00.00: Bravo (76561198000000002) logged in")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Error()).To(HavePrefix("invalid login timestamp"))
+		})
+	})
+
+	g.Describe("MordhauBroadcastDispatcher", func() {
+		g.It("Should route every broadcast to the handler of its type", func() {
+			var received []interface{}
+			var unparsed []string
+
+			d := &presets.MordhauBroadcastDispatcher{
+				OnChat:       func(e presets.ChatMessage) { received = append(received, e) },
+				OnLogin:      func(e presets.LoginEvent) { received = append(received, e) },
+				OnPunishment: func(e presets.PunishmentEvent) { received = append(received, e) },
+				OnMatchState: func(e presets.MatchStateEvent) { received = append(received, e) },
+				OnScorefeed:  func(e presets.ScorefeedEvent) { received = append(received, e) },
+				OnUnparsed: func(message string, err error) {
+					Expect(errors.Is(err, presets.ErrUnknownBroadcast)).To(BeTrue())
+					unparsed = append(unparsed, message)
+				},
+			}
+
+			for _, b := range recorded() {
+				d.Handle(b)
+			}
+
+			Expect(received).To(Equal(expected))
+			Expect(unparsed).To(Equal([]string{"Keepalive: 76561198000000001"}))
+		})
+
+		g.It("Should skip broadcasts without a handler", func() {
+			var chats []presets.ChatMessage
+
+			d := &presets.MordhauBroadcastDispatcher{
+				OnChat: func(e presets.ChatMessage) { chats = append(chats, e) },
+			}
+
+			for _, b := range recorded() {
+				d.Handle(b)
+			}
+
+			Expect(chats).To(Equal([]presets.ChatMessage{
+				expected[0].(presets.ChatMessage),
+				expected[1].(presets.ChatMessage),
+			}))
+		})
+	})
+}
@@ -0,0 +1,13 @@
+package presets
+
+import "strings"
+
+// RedactArguments is a command redactor which keeps only the command name, replacing its arguments with "[redacted]".
+func RedactArguments(command string) string {
+	i := strings.IndexAny(command, " \t")
+	if i < 0 {
+		return command
+	}
+
+	return command[:i] + " [redacted]"
+}
@@ -0,0 +1,30 @@
+package presets
+
+import "strings"
+
+// ResponseMatchErrorChecker returns a response error checker which treats a response as an error if it starts with any
+// of the provided messages. Matching is case-insensitive and ignores surrounding whitespace.
+func ResponseMatchErrorChecker(messages ...string) func(command, response string) bool {
+	lowered := make([]string, len(messages))
+	for i, m := range messages {
+		lowered[i] = strings.ToLower(m)
+	}
+
+	return func(command, response string) bool {
+		response = strings.ToLower(strings.TrimSpace(response))
+
+		for _, m := range lowered {
+			if strings.HasPrefix(response, m) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MordhauResponseErrorChecker detects the plain text error responses sent by Mordhau servers.
+var MordhauResponseErrorChecker = ResponseMatchErrorChecker(
+	"Player not found",
+	"Invalid command",
+)
@@ -0,0 +1,87 @@
+package presets_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+)
+
+func TestResponseErrorCheckers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ResponseMatchErrorChecker()", func() {
+		checker := presets.ResponseMatchErrorChecker("Player not found", "Invalid command")
+
+		tests := []struct {
+			name     string
+			response string
+			error    bool
+		}{
+			{name: "an exact match", response: "Player not found", error: true},
+			{name: "a prefix match", response: "Player not found: bob", error: true},
+			{name: "any of the messages", response: "Invalid command", error: true},
+			{name: "a match in another case", response: "PLAYER NOT FOUND", error: true},
+			{name: "a match with surrounding whitespace", response: "  \tPlayer not found\r\n", error: true},
+			{name: "a normal response", response: "Kicked bob", error: false},
+			{name: "a message which isn't at the start", response: "bob: Player not found", error: false},
+			{name: "a truncated message", response: "Player not", error: false},
+			{name: "an empty response", response: "", error: false},
+		}
+
+		for _, test := range tests {
+			test := test
+
+			verb := "Should match "
+			if !test.error {
+				verb = "Should not match "
+			}
+
+			g.It(verb+test.name, func() {
+				Expect(checker("kick bob", test.response)).To(Equal(test.error), test.response)
+			})
+		}
+
+		g.It("Should never match without messages", func() {
+			Expect(presets.ResponseMatchErrorChecker()("kick bob", "Player not found")).To(BeFalse())
+		})
+	})
+
+	g.Describe("MordhauResponseErrorChecker", func() {
+		g.It("Should make ExecCommand return the server's error message", func() {
+			server := rcontest.StartServer(t, "password")
+			server.SetResponse("kick bob", "Player not found")
+			server.SetResponse("kick alice", "Kicked alice")
+			host, port := server.Addr()
+
+			client := rcon.NewClient(&rcon.Config{
+				Host:                 host,
+				Port:                 port,
+				Password:             "password",
+				ResponseErrorChecker: presets.MordhauResponseErrorChecker,
+			}, nil)
+			defer client.Close()
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("kick bob")
+			Expect(res).To(BeEmpty())
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+			Expect(serverErr.Command).To(Equal("kick bob"))
+			Expect(serverErr.Message).To(Equal("Player not found"))
+
+			res, err = client.ExecCommand("kick alice")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Kicked alice"))
+		})
+	})
+}
@@ -0,0 +1,130 @@
+// Package rustlegacy configures clients for Rust servers which still expose Source RCON instead of WebRCON, and parses
+// their status and playerlist responses. Servers using WebRCON are supported by the webrcon package.
+package rustlegacy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownFormat is returned by the parsers for responses which do not match the expected format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Dialect describes Rust servers exposing Source RCON.
+var Dialect = presets.NewDialect("rustlegacy", rcon.Features{})
+
+// Game configures a client for Rust servers exposing Source RCON.
+func Game(config *rcon.Config) {
+	config.Dialect = Dialect
+}
+
+func init() {
+	rcon.RegisterGame("rustlegacy", Game)
+}
+
+// Status is the response to status.
+type Status struct {
+	Hostname   string
+	Version    string
+	Map        string
+	Players    int
+	MaxPlayers int
+	Connected  []StatusPlayer
+}
+
+// StatusPlayer is an entry of the player table in the status response. Connected is as printed by the server, e.g.
+// "120.5" or "1234s".
+type StatusPlayer struct {
+	SteamID   string
+	Name      string
+	Ping      int
+	Connected string
+	Address   string
+}
+
+var (
+	statusPlayersPattern = regexp.MustCompile(`^(\d+) \((\d+) max\)`)
+	statusRowPattern     = regexp.MustCompile(`^(\d+)\s+"(.*)"\s+(\d+)\s+(\S+)\s+(\S+)`)
+)
+
+// ParseStatus parses the response to status.
+func ParseStatus(response string) (Status, error) {
+	var s Status
+	header := false
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+
+		if m := statusRowPattern.FindStringSubmatch(line); m != nil {
+			ping, _ := strconv.Atoi(m[3])
+			s.Connected = append(s.Connected, StatusPlayer{
+				SteamID:   m[1],
+				Name:      m[2],
+				Ping:      ping,
+				Connected: m[4],
+				Address:   m[5],
+			})
+
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+
+		value := strings.TrimSpace(line[i+1:])
+
+		switch strings.TrimSpace(line[:i]) {
+		case "hostname":
+			s.Hostname = value
+		case "version":
+			s.Version = value
+		case "map":
+			s.Map = value
+		case "players":
+			if m := statusPlayersPattern.FindStringSubmatch(value); m != nil {
+				s.Players, _ = strconv.Atoi(m[1])
+				s.MaxPlayers, _ = strconv.Atoi(m[2])
+			}
+		default:
+			continue
+		}
+
+		header = true
+	}
+
+	if !header {
+		return Status{}, fmt.Errorf("unexpected status response %q: %w", response, ErrUnknownFormat)
+	}
+
+	return s, nil
+}
+
+// Player is an entry of the playerlist response.
+type Player struct {
+	SteamID          string  `json:"SteamID"`
+	OwnerSteamID     string  `json:"OwnerSteamID"`
+	DisplayName      string  `json:"DisplayName"`
+	Ping             int     `json:"Ping"`
+	Address          string  `json:"Address"`
+	ConnectedSeconds int     `json:"ConnectedSeconds"`
+	ViolationLevel   float64 `json:"VoiationLevel"` // sic, the server misspells the field
+	Health           float64 `json:"Health"`
+}
+
+// ParsePlayerList parses the response to playerlist, which the server prints as a JSON array.
+func ParsePlayerList(response string) ([]Player, error) {
+	var players []Player
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &players); err != nil {
+		return nil, fmt.Errorf("unexpected playerlist response: %v: %w", err, ErrUnknownFormat)
+	}
+
+	return players, nil
+}
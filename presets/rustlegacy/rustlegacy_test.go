@@ -0,0 +1,83 @@
+package rustlegacy_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"github.com/refractorgscm/rcon/presets/rustlegacy"
+	"testing"
+)
+
+func TestParsers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseStatus()", func() {
+		g.It("Should parse the header and the player table", func() {
+			data, err := fixtures.Read("status.txt")
+			Expect(err).To(BeNil())
+
+			status, err := rustlegacy.ParseStatus(data)
+
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(rustlegacy.Status{
+				Hostname:   "My Rust Server",
+				Version:    "2306 secure (secure mode enabled, connected to Steam3)",
+				Map:        "Procedural Map",
+				Players:    2,
+				MaxPlayers: 100,
+				Connected: []rustlegacy.StatusPlayer{
+					{
+						SteamID:   "76561198000000001",
+						Name:      "Survivor",
+						Ping:      42,
+						Connected: "120.5",
+						Address:   "203.0.113.5:28015",
+					},
+					{
+						SteamID:   "76561198000000002",
+						Name:      "Other One",
+						Ping:      87,
+						Connected: "3600.2",
+						Address:   "198.51.100.7:28015",
+					},
+				},
+			}))
+		})
+
+		g.It("Should return ErrUnknownFormat for other responses", func() {
+			_, err := rustlegacy.ParseStatus("Command 'status' not found")
+
+			Expect(errors.Is(err, rustlegacy.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParsePlayerList()", func() {
+		g.It("Should parse the JSON player list", func() {
+			data, err := fixtures.Read("playerlist.json")
+			Expect(err).To(BeNil())
+
+			players, err := rustlegacy.ParsePlayerList(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]rustlegacy.Player{{
+				SteamID:          "76561198000000001",
+				OwnerSteamID:     "0",
+				DisplayName:      "Survivor",
+				Ping:             42,
+				Address:          "203.0.113.5",
+				ConnectedSeconds: 120,
+				Health:           87.5,
+			}}))
+		})
+
+		g.It("Should return ErrUnknownFormat for responses which aren't JSON", func() {
+			_, err := rustlegacy.ParsePlayerList("Command 'playerlist' not found")
+
+			Expect(errors.Is(err, rustlegacy.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+}
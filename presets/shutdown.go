@@ -0,0 +1,114 @@
+package presets
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"sort"
+	"time"
+)
+
+// WarningStep is a single in-game warning sent during a graceful shutdown. Message is broadcast to players, then the
+// shutdown waits for Wait before moving on to the next step.
+type WarningStep struct {
+	Message string
+	Wait    time.Duration
+}
+
+// ShutdownSpec describes the game-specific commands used by GracefulShutdown.
+type ShutdownSpec struct {
+	// BroadcastFormat is a format string with a single %s verb used to broadcast a message to all players.
+	BroadcastFormat string
+
+	// KickAllFormat is a format string with a single %s verb used to kick all players with a reason. If empty, players
+	// are not kicked before saving.
+	KickAllFormat string
+
+	// KickMessage is the reason shown to players when they are kicked.
+	KickMessage string
+
+	// Save is used to save the world before stopping. If nil, no save is performed.
+	Save *SaveSpec
+
+	// StopCommand is the command which stops the server.
+	StopCommand string
+}
+
+// MinecraftShutdown is a ShutdownSpec for vanilla Minecraft servers.
+var MinecraftShutdown = ShutdownSpec{
+	BroadcastFormat: "say %s",
+	KickAllFormat:   "kick @a %s",
+	KickMessage:     "The server is shutting down. Please reconnect shortly.",
+	Save:            &MinecraftSave,
+	StopCommand:     "stop",
+}
+
+// Countdown builds warning steps announcing a shutdown at each of the given remaining durations. format must contain
+// a single %s verb which is replaced with the remaining time. The last step waits out the remaining time.
+func Countdown(format string, remaining ...time.Duration) []WarningStep {
+	sorted := make([]time.Duration, len(remaining))
+	copy(sorted, remaining)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	steps := make([]WarningStep, len(sorted))
+	for i, r := range sorted {
+		next := time.Duration(0)
+		if i+1 < len(sorted) {
+			next = sorted[i+1]
+		}
+
+		steps[i] = WarningStep{
+			Message: fmt.Sprintf(format, r),
+			Wait:    r - next,
+		}
+	}
+
+	return steps
+}
+
+// GracefulShutdown broadcasts the warning steps in order, kicks all players, saves the world and then issues the stop
+// command. If ctx is cancelled before the stop command was sent, the shutdown is aborted and an error wrapping ctx.Err()
+// is returned, including while a warning, kick or save command is in flight.
+func GracefulShutdown(ctx context.Context, client *rcon.Client, warnings []WarningStep, spec ShutdownSpec) error {
+	for _, step := range warnings {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := client.ExecCommandContext(ctx, fmt.Sprintf(spec.BroadcastFormat, step.Message)); err != nil {
+			return fmt.Errorf("could not broadcast shutdown warning: %w", err)
+		}
+
+		select {
+		case <-time.After(step.Wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if spec.KickAllFormat != "" {
+		if _, err := client.ExecCommandContext(ctx, fmt.Sprintf(spec.KickAllFormat, spec.KickMessage)); err != nil {
+			return fmt.Errorf("could not kick players: %w", err)
+		}
+	}
+
+	if spec.Save != nil {
+		if err := Save(ctx, client, *spec.Save); err != nil {
+			return fmt.Errorf("could not save before shutdown: %w", err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := client.ExecCommandNoResponse(spec.StopCommand); err != nil {
+		return fmt.Errorf("could not send stop command: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package presets_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountdown(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Countdown()", func() {
+		g.It("Should announce the remaining times in descending order", func() {
+			steps := presets.Countdown("Shutdown in %s", time.Second*10, time.Minute, time.Second*30)
+
+			Expect(steps).To(Equal([]presets.WarningStep{
+				{Message: "Shutdown in 1m0s", Wait: time.Second * 30},
+				{Message: "Shutdown in 30s", Wait: time.Second * 20},
+				{Message: "Shutdown in 10s", Wait: time.Second * 10},
+			}))
+		})
+	})
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	spec := presets.ShutdownSpec{
+		BroadcastFormat: "say %s",
+		KickAllFormat:   "kickall %s",
+		KickMessage:     "bye",
+		Save: &presets.SaveSpec{
+			Command: "save",
+			BroadcastConfirms: func(message string) bool {
+				return strings.HasPrefix(message, "Saved")
+			},
+			Timeout: time.Second * 5,
+		},
+		StopCommand: "stop",
+	}
+
+	g.Describe("GracefulShutdown()", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second,
+				BroadcastChecker: rcontest.BroadcastChecker,
+			}, nil)
+			Expect(client.Connect()).To(BeNil())
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should warn, kick, save and stop in order", func() {
+			server.Handle("save", func(string) string {
+				go func() {
+					time.Sleep(time.Millisecond * 20)
+					server.Broadcast(rcontest.BroadcastID, "Saved the game")
+				}()
+				return ""
+			})
+
+			warnings := presets.Countdown("in %s", time.Millisecond*20, time.Millisecond*10)
+			Expect(presets.GracefulShutdown(context.Background(), client, warnings, spec)).To(BeNil())
+
+			Eventually(server.Commands).Should(Equal([]string{
+				"say in 20ms",
+				"say in 10ms",
+				"kickall bye",
+				"save",
+				"stop",
+			}))
+		})
+
+		g.It("Should abort if cancelled during the countdown", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- presets.GracefulShutdown(ctx, client, presets.Countdown("in %s", time.Minute), spec)
+			}()
+
+			Eventually(server.Commands).Should(Equal([]string{"say in 1m0s"}))
+			cancel()
+
+			var err error
+			Eventually(done).Should(Receive(&err))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Consistently(server.Commands, time.Millisecond*100).Should(Equal([]string{"say in 1m0s"}))
+		})
+
+		g.It("Should abort if cancelled while waiting for the save", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- presets.GracefulShutdown(ctx, client, nil, spec)
+			}()
+
+			Eventually(server.Commands).Should(ContainElement("save"))
+			cancel()
+
+			var err error
+			Eventually(done, time.Millisecond*500).Should(Receive(&err))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Consistently(server.Commands, time.Millisecond*100).ShouldNot(ContainElement("stop"))
+		})
+
+		g.It("Should abort if cancelled while a command is in flight", func() {
+			server.SetDelay(time.Millisecond * 500)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+
+			start := time.Now()
+			err := presets.GracefulShutdown(ctx, client, presets.Countdown("in %s", time.Second), spec)
+
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*400))
+		})
+	})
+}
@@ -0,0 +1,173 @@
+package squad
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownFormat is returned by the parsers for responses and broadcasts which do not match the expected format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Player is an entry of the ListPlayers response. SteamID and EOSID are empty if the server did not report them.
+// TeamID and SquadID are zero for players which are not in a team or squad.
+type Player struct {
+	ID       int
+	SteamID  string
+	EOSID    string
+	Name     string
+	TeamID   int
+	SquadID  int
+	IsLeader bool
+	Role     string
+}
+
+// DisconnectedPlayer is an entry of the recently disconnected players in the ListPlayers response.
+type DisconnectedPlayer struct {
+	ID              int
+	SteamID         string
+	EOSID           string
+	Name            string
+	SinceDisconnect string
+}
+
+// CurrentMap is the response to ShowCurrentMap. Factions is empty on servers which don't report them.
+type CurrentMap struct {
+	Level    string
+	Layer    string
+	Factions string
+}
+
+// ChatMessage is a Squad chat broadcast. Channel is the chat the message was sent in, e.g. "ChatAll" or "ChatTeam".
+type ChatMessage struct {
+	Channel string
+	SteamID string
+	EOSID   string
+	Name    string
+	Message string
+}
+
+var (
+	currentMapPattern = regexp.MustCompile(`^Current level is (.*), layer is (.*?)(?:, factions (.*))?$`)
+	chatPattern       = regexp.MustCompile(`^\[(Chat\w+)\] \[(?:SteamID:(\d+)|Online IDs:([^\]]*))\] (.*?) : (.*)$`)
+	onlineIDPattern   = regexp.MustCompile(`(EOS|steam): ?(\w+)`)
+)
+
+// ParseListPlayers parses the response to ListPlayers into the active and the recently disconnected players. Both the
+// current format, which lists Online IDs, and the older one listing SteamIDs are supported.
+func ParseListPlayers(response string) ([]Player, []DisconnectedPlayer, error) {
+	var players []Player
+	var disconnected []DisconnectedPlayer
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "ID:") {
+			continue
+		}
+
+		fields := parseFields(line)
+
+		id, err := strconv.Atoi(fields["ID"])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid player ID in %q: %w", line, ErrUnknownFormat)
+		}
+
+		steamID, eosID := playerIDs(fields)
+
+		if since, ok := fields["Since Disconnect"]; ok {
+			disconnected = append(disconnected, DisconnectedPlayer{
+				ID:              id,
+				SteamID:         steamID,
+				EOSID:           eosID,
+				Name:            fields["Name"],
+				SinceDisconnect: since,
+			})
+
+			continue
+		}
+
+		// Team and squad IDs are N/A for players without one, which is reported as zero.
+		teamID, _ := strconv.Atoi(fields["Team ID"])
+		squadID, _ := strconv.Atoi(fields["Squad ID"])
+
+		players = append(players, Player{
+			ID:       id,
+			SteamID:  steamID,
+			EOSID:    eosID,
+			Name:     fields["Name"],
+			TeamID:   teamID,
+			SquadID:  squadID,
+			IsLeader: strings.EqualFold(fields["Is Leader"], "True"),
+			Role:     fields["Role"],
+		})
+	}
+
+	return players, disconnected, nil
+}
+
+// parseFields splits a ListPlayers line of the form "Key: Value | Key: Value" into its fields.
+func parseFields(line string) map[string]string {
+	fields := map[string]string{}
+
+	for _, part := range strings.Split(line, " | ") {
+		i := strings.Index(part, ":")
+		if i < 0 {
+			continue
+		}
+
+		fields[strings.TrimSpace(part[:i])] = strings.TrimSpace(part[i+1:])
+	}
+
+	return fields
+}
+
+// playerIDs returns the Steam and EOS IDs of a ListPlayers entry.
+func playerIDs(fields map[string]string) (string, string) {
+	if steamID, ok := fields["SteamID"]; ok {
+		return steamID, ""
+	}
+
+	return parseOnlineIDs(fields["Online IDs"])
+}
+
+// parseOnlineIDs parses an Online IDs list such as "EOS: 0002a1 steam: 76561198000000000".
+func parseOnlineIDs(ids string) (string, string) {
+	var steamID, eosID string
+
+	for _, m := range onlineIDPattern.FindAllStringSubmatch(ids, -1) {
+		if m[1] == "steam" {
+			steamID = m[2]
+		} else {
+			eosID = m[2]
+		}
+	}
+
+	return steamID, eosID
+}
+
+// ParseCurrentMap parses the response to ShowCurrentMap.
+func ParseCurrentMap(response string) (CurrentMap, error) {
+	m := currentMapPattern.FindStringSubmatch(strings.TrimSpace(response))
+	if m == nil {
+		return CurrentMap{}, fmt.Errorf("unexpected current map response %q: %w", response, ErrUnknownFormat)
+	}
+
+	return CurrentMap{Level: m[1], Layer: m[2], Factions: m[3]}, nil
+}
+
+// ParseChatMessage parses a chat broadcast.
+func ParseChatMessage(message string) (ChatMessage, error) {
+	m := chatPattern.FindStringSubmatch(strings.TrimSpace(message))
+	if m == nil {
+		return ChatMessage{}, fmt.Errorf("unexpected chat message %q: %w", message, ErrUnknownFormat)
+	}
+
+	c := ChatMessage{Channel: m[1], SteamID: m[2], Name: m[4], Message: m[5]}
+	if m[3] != "" {
+		c.SteamID, c.EOSID = parseOnlineIDs(m[3])
+	}
+
+	return c, nil
+}
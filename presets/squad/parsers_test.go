@@ -0,0 +1,155 @@
+package squad_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets/internal/fixtures"
+	"github.com/refractorgscm/rcon/presets/squad"
+	"strings"
+	"testing"
+)
+
+func TestParsers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("ParseListPlayers()", func() {
+		g.It("Should parse players listed with Online IDs", func() {
+			data, err := fixtures.Read("listplayers.txt")
+			Expect(err).To(BeNil())
+
+			players, disconnected, err := squad.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]squad.Player{
+				{
+					ID:       0,
+					SteamID:  "76561198000000001",
+					EOSID:    "0002a10186d9414496bf20d22d3860ba",
+					Name:     "Alpha One",
+					TeamID:   1,
+					SquadID:  1,
+					IsLeader: true,
+					Role:     "USA_SL_01",
+				},
+				{
+					ID:      3,
+					SteamID: "76561198000000002",
+					EOSID:   "0002b20186d9414496bf20d22d3860bb",
+					Name:    "Bravo",
+					TeamID:  2,
+					Role:    "RGF_Rifleman_01",
+				},
+			}))
+			Expect(disconnected).To(Equal([]squad.DisconnectedPlayer{{
+				ID:              7,
+				SteamID:         "76561198000000003",
+				EOSID:           "0002c30186d9414496bf20d22d3860bc",
+				Name:            "Charlie",
+				SinceDisconnect: "02m.30s",
+			}}))
+		})
+
+		g.It("Should parse players listed with SteamIDs", func() {
+			data, err := fixtures.Read("listplayers_steamid.txt")
+			Expect(err).To(BeNil())
+
+			players, disconnected, err := squad.ParseListPlayers(data)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]squad.Player{{
+				ID:      12,
+				SteamID: "76561198000000012",
+				Name:    "Delta",
+				TeamID:  1,
+				SquadID: 2,
+				Role:    "GB_Medic_01",
+			}}))
+			Expect(disconnected).To(Equal([]squad.DisconnectedPlayer{{
+				ID:              9,
+				SteamID:         "76561198000000009",
+				Name:            "Echo",
+				SinceDisconnect: "00m.12s",
+			}}))
+		})
+
+		g.It("Should return ErrUnknownFormat for invalid player IDs", func() {
+			_, _, err := squad.ParseListPlayers("ID: x | SteamID: 76561198000000012 | Name: Delta")
+
+			Expect(errors.Is(err, squad.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParseCurrentMap()", func() {
+		g.It("Should parse the level, layer and factions", func() {
+			data, err := fixtures.Read("currentmap.txt")
+			Expect(err).To(BeNil())
+
+			current, err := squad.ParseCurrentMap(data)
+
+			Expect(err).To(BeNil())
+			Expect(current).To(Equal(squad.CurrentMap{
+				Level:    "Gorodok",
+				Layer:    "Gorodok RAAS v1",
+				Factions: "USA RGF",
+			}))
+		})
+
+		g.It("Should parse responses without factions", func() {
+			data, err := fixtures.Read("currentmap_postscriptum.txt")
+			Expect(err).To(BeNil())
+
+			current, err := squad.ParseCurrentMap(data)
+
+			Expect(err).To(BeNil())
+			Expect(current).To(Equal(squad.CurrentMap{Level: "Arnhem", Layer: "Arnhem Offensive"}))
+		})
+
+		g.It("Should return ErrUnknownFormat for other responses", func() {
+			_, err := squad.ParseCurrentMap("Unknown command")
+
+			Expect(errors.Is(err, squad.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+
+	g.Describe("ParseChatMessage()", func() {
+		g.It("Should parse chat messages of both ID formats", func() {
+			var messages []squad.ChatMessage
+
+			data, err := fixtures.Read("chat.txt")
+			Expect(err).To(BeNil())
+
+			for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+				message, err := squad.ParseChatMessage(line)
+				Expect(err).To(BeNil())
+
+				messages = append(messages, message)
+			}
+
+			Expect(messages).To(Equal([]squad.ChatMessage{
+				{
+					Channel: "ChatAll",
+					SteamID: "76561198000000001",
+					EOSID:   "0002a10186d9414496bf20d22d3860ba",
+					Name:    "Alpha One",
+					Message: "gg",
+				},
+				{
+					Channel: "ChatTeam",
+					SteamID: "76561198000000012",
+					Name:    "Delta",
+					Message: "need a medic at B",
+				},
+			}))
+		})
+
+		g.It("Should return ErrUnknownFormat for other broadcasts", func() {
+			_, err := squad.ParseChatMessage("[ChatAdmin] Server restarting")
+
+			Expect(errors.Is(err, squad.ErrUnknownFormat)).To(BeTrue())
+		})
+	})
+}
@@ -0,0 +1,33 @@
+// Package squad configures clients for Squad and Post Scriptum servers and parses their responses and broadcasts.
+package squad
+
+import (
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/presets"
+)
+
+// TypeChatValue is the packet type Squad uses for chat broadcasts. Chat packets are told apart from command responses
+// by their type rather than by reserved packet IDs, so no packet IDs need to be restricted.
+const TypeChatValue = packet.PacketType(1)
+
+// BroadcastChecker treats Squad chat packets as broadcasts.
+func BroadcastChecker(p packet.Packet) bool {
+	return p.Type() == TypeChatValue
+}
+
+// Dialect describes Squad and Post Scriptum servers.
+var Dialect = presets.NewDialect("squad", rcon.Features{
+	Broadcasts:       true,
+	BroadcastChecker: BroadcastChecker,
+})
+
+// Game configures a client for Squad and Post Scriptum servers.
+func Game(config *rcon.Config) {
+	config.Dialect = Dialect
+}
+
+func init() {
+	rcon.RegisterGame("squad", Game)
+	rcon.RegisterGame("postscriptum", Game)
+}
@@ -0,0 +1,41 @@
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayoutParser returns a time parser which parses the trimmed response using the given time.Parse layout. It can
+// be used with Client.EstimateClockOffset for games whose time command prints a formatted date. Times without a zone
+// are interpreted in loc, which must be the zone of the server's clock; a wrong zone skews the offset by the difference
+// between the zones. If loc is nil, every response fails to parse.
+//
+// None of the supported games print the server's wall clock over RCON, so no game preset declares a time command. Add
+// the command of a server plugin to a preset's dialect with WithTimeCommand.
+func TimeLayoutParser(layout string, loc *time.Location) func(response string) (time.Time, error) {
+	return func(response string) (time.Time, error) {
+		if loc == nil {
+			return time.Time{}, errors.New("could not parse time response: no server time zone")
+		}
+
+		t, err := time.ParseInLocation(layout, strings.TrimSpace(response), loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not parse time response: %w", err)
+		}
+
+		return t, nil
+	}
+}
+
+// UnixTimeParser parses a response consisting of a unix timestamp in seconds.
+func UnixTimeParser(response string) (time.Time, error) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(response), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse unix timestamp response: %w", err)
+	}
+
+	return time.Unix(secs, 0), nil
+}
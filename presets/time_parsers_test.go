@@ -0,0 +1,57 @@
+package presets_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/presets"
+	"testing"
+	"time"
+)
+
+func TestTimeParsers(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	g.Describe("TimeLayoutParser()", func() {
+		g.It("Should interpret times without a zone in the server's zone", func() {
+			parse := presets.TimeLayoutParser("2006-01-02 15:04:05", berlin)
+
+			parsed, err := parse(" 2026-10-16 14:00:00\n")
+			Expect(err).To(BeNil())
+			Expect(parsed.Equal(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		g.It("Should keep the zone of times which have one", func() {
+			parse := presets.TimeLayoutParser(time.RFC3339, berlin)
+
+			parsed, err := parse("2026-10-16T14:00:00Z")
+			Expect(err).To(BeNil())
+			Expect(parsed.Equal(time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		g.It("Should fail on responses not matching the layout", func() {
+			_, err := presets.TimeLayoutParser(time.RFC3339, berlin)("Unknown command: time")
+			Expect(err).To(MatchError(ContainSubstring("could not parse time response")))
+		})
+
+		g.It("Should fail without a zone", func() {
+			_, err := presets.TimeLayoutParser(time.RFC3339, nil)("2026-10-16T14:00:00Z")
+			Expect(err).To(MatchError(ContainSubstring("no server time zone")))
+		})
+	})
+
+	g.Describe("UnixTimeParser()", func() {
+		g.It("Should parse unix timestamps in seconds", func() {
+			parsed, err := presets.UnixTimeParser("1792152000\n")
+			Expect(err).To(BeNil())
+			Expect(parsed.Equal(time.Unix(1792152000, 0))).To(BeTrue())
+
+			_, err = presets.UnixTimeParser("soon")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+}
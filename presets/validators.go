@@ -0,0 +1,28 @@
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"strings"
+)
+
+// NonEmptyResponse rejects responses which are empty or consist only of whitespace.
+var NonEmptyResponse rcon.ResponseValidator = func(response string) error {
+	if strings.TrimSpace(response) == "" {
+		return errors.New("response is empty")
+	}
+
+	return nil
+}
+
+// ResponseContains returns a validator which rejects responses not containing substr.
+func ResponseContains(substr string) rcon.ResponseValidator {
+	return func(response string) error {
+		if !strings.Contains(response, substr) {
+			return fmt.Errorf("response does not contain %q", substr)
+		}
+
+		return nil
+	}
+}
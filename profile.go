@@ -0,0 +1,156 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"sync"
+	"time"
+)
+
+// GameProfile declares all protocol quirks of a game in one place. Applying a profile configures the client's dialect
+// and keepalive, so choosing a game is a single step instead of setting several config fields.
+type GameProfile struct {
+	// Name is the name the profile is registered under, such as "mordhau". It is also the name of its dialect.
+	Name string
+
+	// EndianMode is the byte order of the game's packets.
+	//
+	// Default: endian.Little
+	EndianMode endian.Mode
+
+	// RestrictedPacketIDs are the packet IDs the server reserves for special messages such as broadcasts.
+	RestrictedPacketIDs []int32
+
+	// BroadcastChecker reports whether a packet is a broadcast. If set, the game is assumed to send broadcasts.
+	BroadcastChecker BroadcastMessageChecker
+
+	// BroadcastHeaderChecker classifies packets by their header before their body is read. See
+	// Features.BroadcastHeaderChecker.
+	BroadcastHeaderChecker BroadcastHeaderChecker
+
+	// KeepAliveCommand is the command used to ping the server. See KeepAliveConfig.Command.
+	KeepAliveCommand string
+
+	// KeepAliveInterval, if set, enables keepalive with this interval unless the config sets its own.
+	KeepAliveInterval time.Duration
+
+	// MaxBodySize is the largest command body the server accepts. Zero means no limit. See Features.MaxBodySize; the
+	// size of whole packets is limited by Config.MaxPacketSize.
+	MaxBodySize int
+
+	// MultiPacket is true if the server splits large responses across multiple packets. FragmentSize, Sentinel and
+	// SentinelGrace describe how. See Features.
+	MultiPacket   bool
+	FragmentSize  int
+	Sentinel      SentinelBehavior
+	SentinelGrace time.Duration
+
+	// BroadcastTime reads the server's timestamp from broadcast messages. See Features.BroadcastTime.
+	BroadcastTime BroadcastTimeParser
+
+	// ListenCommand, StopListenCommand and MaxListens describe how to subscribe to broadcast channels. See Features.
+	ListenCommand     string
+	StopListenCommand string
+	MaxListens        int
+
+	// BodyTransform wraps packet bodies on the wire. See Features.BodyTransform.
+	BodyTransform BodyTransform
+
+	// TimeCommand and TimeParser read the server's wall clock. See Features.TimeCommand.
+	TimeCommand string
+	TimeParser  TimeParser
+
+	// Configure, if set, is applied after the profile's protocol settings. It can set anything which isn't a protocol
+	// quirk, such as a ResponseErrorChecker or BroadcastChannel function.
+	Configure GamePreset
+}
+
+// profileDialect is the dialect described by a GameProfile.
+type profileDialect struct {
+	profile *GameProfile
+}
+
+func (d profileDialect) Name() string {
+	return d.profile.Name
+}
+
+func (d profileDialect) Features() Features {
+	p := d.profile
+
+	return Features{
+		Broadcasts:             p.BroadcastChecker != nil,
+		MultiPacket:            p.MultiPacket,
+		FragmentSize:           p.FragmentSize,
+		Sentinel:               p.Sentinel,
+		SentinelGrace:          p.SentinelGrace,
+		MaxBodySize:            p.MaxBodySize,
+		EndianMode:             p.EndianMode,
+		RestrictedPacketIDs:    p.RestrictedPacketIDs,
+		BroadcastChecker:       p.BroadcastChecker,
+		BroadcastHeaderChecker: p.BroadcastHeaderChecker,
+		BroadcastTime:          p.BroadcastTime,
+		ListenCommand:          p.ListenCommand,
+		StopListenCommand:      p.StopListenCommand,
+		MaxListens:             p.MaxListens,
+		BodyTransform:          p.BodyTransform,
+		TimeCommand:            p.TimeCommand,
+		TimeParser:             p.TimeParser,
+	}
+}
+
+// Dialect returns the dialect described by the profile.
+func (p *GameProfile) Dialect() Dialect {
+	return profileDialect{profile: p}
+}
+
+// Apply configures config for the profile's game. Keepalive settings already present in config are kept.
+func (p *GameProfile) Apply(config *Config) {
+	config.Dialect = p.Dialect()
+
+	if config.KeepAlive.Command == "" {
+		config.KeepAlive.Command = p.KeepAliveCommand
+	}
+
+	if config.KeepAlive.Interval == 0 {
+		config.KeepAlive.Interval = p.KeepAliveInterval
+	}
+
+	if p.Configure != nil {
+		p.Configure(config)
+	}
+}
+
+var profilesLock sync.RWMutex
+var profiles = map[string]*GameProfile{}
+
+// RegisterProfile makes profile available under its name to NewClientForGame and, as a game preset, to Dial.
+func RegisterProfile(profile *GameProfile) {
+	profilesLock.Lock()
+	profiles[profile.Name] = profile
+	profilesLock.Unlock()
+
+	RegisterGame(profile.Name, profile.Apply)
+}
+
+// Profile returns the profile registered under name.
+func Profile(name string) (*GameProfile, bool) {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+
+	profile, ok := profiles[name]
+	return profile, ok
+}
+
+// NewClientForGame applies the profile or game preset registered under name to config and creates a client with the
+// default logger. It returns errs.ErrUnknownGame if nothing is registered under name.
+func NewClientForGame(name string, config *Config) (*Client, error) {
+	preset, ok := gamePreset(name)
+	if !ok {
+		return nil, fmt.Errorf("game %q: %w", name, errs.ErrUnknownGame)
+	}
+
+	preset(config)
+
+	return NewClient(config, nil), nil
+}
@@ -0,0 +1,172 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGameProfile(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	profile := &rcon.GameProfile{
+		Name:                "profiletest",
+		EndianMode:          endian.Big,
+		RestrictedPacketIDs: []int32{7},
+		BroadcastChecker: func(p packet.Packet) bool {
+			return p.ID() == 7
+		},
+		KeepAliveCommand:  "alive",
+		KeepAliveInterval: time.Second * 10,
+		MaxBodySize:       16,
+		MultiPacket:       true,
+		FragmentSize:      1024,
+		Sentinel:          rcon.SentinelUnordered,
+		SentinelGrace:     time.Millisecond * 50,
+		ListenCommand:     "listen %s",
+		MaxListens:        2,
+		TimeCommand:       "date",
+		TimeParser: func(string) (time.Time, error) {
+			return time.Unix(0, 0), nil
+		},
+	}
+
+	rcon.RegisterProfile(profile)
+
+	g.Describe("GameProfile.Dialect()", func() {
+		g.It("Should map the profile onto the dialect's features", func() {
+			dialect := profile.Dialect()
+			Expect(dialect.Name()).To(Equal("profiletest"))
+
+			f := dialect.Features()
+			Expect(f.Broadcasts).To(BeTrue())
+			Expect(f.MultiPacket).To(BeTrue())
+			Expect(f.FragmentSize).To(Equal(1024))
+			Expect(f.Sentinel).To(Equal(rcon.SentinelUnordered))
+			Expect(f.SentinelGrace).To(Equal(time.Millisecond * 50))
+			Expect(f.MaxBodySize).To(Equal(16))
+			Expect(f.EndianMode).To(Equal(endian.Big))
+			Expect(f.RestrictedPacketIDs).To(Equal([]int32{7}))
+			Expect(f.ListenCommand).To(Equal("listen %s"))
+			Expect(f.MaxListens).To(Equal(2))
+			Expect(f.TimeCommand).To(Equal("date"))
+			Expect(f.TimeParser).ToNot(BeNil())
+		})
+
+		g.It("Should not declare broadcasts without a broadcast checker", func() {
+			quiet := &rcon.GameProfile{Name: "quiet"}
+			Expect(quiet.Dialect().Features().Broadcasts).To(BeFalse())
+		})
+	})
+
+	g.Describe("GameProfile.Apply()", func() {
+		g.It("Should set the dialect and keepalive", func() {
+			config := &rcon.Config{}
+			profile.Apply(config)
+
+			Expect(config.Dialect).ToNot(BeNil())
+			Expect(config.Dialect.Name()).To(Equal("profiletest"))
+			Expect(config.KeepAlive.Command).To(Equal("alive"))
+			Expect(config.KeepAlive.Interval).To(Equal(time.Second * 10))
+		})
+
+		g.It("Should keep keepalive settings from the config", func() {
+			config := &rcon.Config{KeepAlive: rcon.KeepAliveConfig{Command: "ping", Interval: time.Minute}}
+			profile.Apply(config)
+
+			Expect(config.KeepAlive.Command).To(Equal("ping"))
+			Expect(config.KeepAlive.Interval).To(Equal(time.Minute))
+		})
+
+		g.It("Should run Configure after the protocol settings", func() {
+			var dialect rcon.Dialect
+
+			configured := &rcon.GameProfile{
+				Name: "configured",
+				Configure: func(config *rcon.Config) {
+					dialect = config.Dialect
+					config.KeepAlive.Command = "status"
+				},
+				KeepAliveCommand: "alive",
+			}
+
+			config := &rcon.Config{}
+			configured.Apply(config)
+
+			Expect(dialect).ToNot(BeNil())
+			Expect(dialect.Name()).To(Equal("configured"))
+			Expect(config.KeepAlive.Command).To(Equal("status"))
+		})
+	})
+
+	g.Describe("RegisterProfile()", func() {
+		g.It("Should make the profile available by name", func() {
+			registered, ok := rcon.Profile("profiletest")
+			Expect(ok).To(BeTrue())
+			Expect(registered).To(BeIdenticalTo(profile))
+
+			_, ok = rcon.Profile("no-such-profile")
+			Expect(ok).To(BeFalse())
+		})
+
+		g.It("Should register the profile as a game preset for Dial", func() {
+			config, err := rcon.ParseURL("rcon://:pw@host:1?game=profiletest")
+			Expect(err).To(BeNil())
+			Expect(config.Dialect).ToNot(BeNil())
+			Expect(config.Dialect.Name()).To(Equal("profiletest"))
+			Expect(config.KeepAlive.Command).To(Equal("alive"))
+		})
+	})
+
+	g.Describe("NewClientForGame()", func() {
+		g.It("Should return ErrUnknownGame for unregistered games", func() {
+			client, err := rcon.NewClientForGame("no-such-game", &rcon.Config{})
+			Expect(client).To(BeNil())
+			Expect(errors.Is(err, errs.ErrUnknownGame)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("no-such-game"))
+		})
+
+		g.It("Should create a client with the profile's features", func() {
+			client, err := rcon.NewClientForGame("profiletest", &rcon.Config{})
+			Expect(err).To(BeNil())
+
+			f := client.Features()
+			Expect(f.MaxBodySize).To(Equal(16))
+			Expect(f.RestrictedPacketIDs).To(Equal([]int32{7}))
+			Expect(client.MultiPacketResponses).To(BeTrue())
+			Expect(client.EndianMode).To(Equal(endian.Big))
+		})
+
+		g.It("Should reject commands longer than the profile's MaxBodySize", func() {
+			config := &rcon.Config{}
+			server := newTestServer(t, config)
+
+			rcon.RegisterProfile(&rcon.GameProfile{Name: "limitedprofile", MaxBodySize: 16})
+
+			client, err := rcon.NewClientForGame("limitedprofile", config)
+			Expect(err).To(BeNil())
+			defer client.Close()
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err = client.ExecCommand(strings.Repeat("a", 17))
+			Expect(errors.Is(err, errs.ErrCommandTooLarge)).To(BeTrue())
+
+			server.Handle("echo", func(args string) string { return args })
+
+			res, err := client.ExecCommand("echo 0123456789")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("0123456789"))
+		})
+	})
+}
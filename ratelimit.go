@@ -0,0 +1,88 @@
+package rcon
+
+import (
+	"context"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"time"
+)
+
+type RateLimitConfig struct {
+	// CommandsPerSecond caps the rate commands are written to the server at. Commands exceeding it wait in the write
+	// queue; the wait counts towards their read timeout. Zero means no limit. Only Source RCON connections are
+	// limited.
+	CommandsPerSecond float64
+
+	// Burst is the number of commands which may be written at once after a quiet period.
+	//
+	// Default: 1
+	Burst int
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context marking commands executed with it as priority commands, which bypass the rate limit.
+// Keepalive pings are always priority commands.
+func WithPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// isPriority returns true if ctx was created with WithPriority.
+func isPriority(ctx context.Context) bool {
+	priority, _ := ctx.Value(priorityKey{}).(bool)
+	return priority
+}
+
+// queuedPacket is a packet waiting in the write queue.
+type queuedPacket struct {
+	packet   packet.Packet
+	priority bool
+}
+
+// commandLimiter is a token bucket refilled at rate commands per second, holding at most burst commands. It is shared
+// by all connections of a client so that reconnecting doesn't reset it.
+type commandLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newCommandLimiter(config RateLimitConfig) *commandLimiter {
+	if config.CommandsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := float64(config.Burst)
+	return &commandLimiter{rate: config.CommandsPerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take withdraws a command from the limiter and returns how long the caller must wait before writing it.
+func (l *commandLimiter) take() time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// rateLimit returns how long the writer must wait before writing q.
+func (c *Client) rateLimit(q queuedPacket) time.Duration {
+	if c.limiter == nil || q.priority {
+		return 0
+	}
+
+	return c.limiter.take()
+}
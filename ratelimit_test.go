@@ -0,0 +1,92 @@
+package rcon_test
+
+import (
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Rate limiting", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			config = &rcon.Config{
+				Host:     host,
+				Port:     port,
+				Password: "password",
+			}
+		})
+
+		// run executes n commands with ctx one after another and returns how long they took.
+		run := func(client *rcon.Client, ctx context.Context, n int) time.Duration {
+			start := time.Now()
+			for i := 0; i < n; i++ {
+				res, err := client.ExecCommandContext(ctx, "status")
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal("ok"))
+			}
+
+			return time.Since(start)
+		}
+
+		g.It("Should not limit commands by default", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			Expect(run(client, context.Background(), 20)).To(BeNumerically("<", time.Millisecond*200))
+		})
+
+		g.It("Should write Burst commands at once and space out the rest", func() {
+			config.RateLimit = rcon.RateLimitConfig{CommandsPerSecond: 20, Burst: 2}
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			Expect(run(client, context.Background(), 2)).To(BeNumerically("<", time.Millisecond*40))
+
+			// The bucket is empty, so each further command waits for a token refilled every 50ms.
+			Expect(run(client, context.Background(), 4)).To(BeNumerically(">=", time.Millisecond*150))
+			Expect(server.Commands()).To(HaveLen(6))
+		})
+
+		g.It("Should let priority commands bypass the limit", func() {
+			config.RateLimit = rcon.RateLimitConfig{CommandsPerSecond: 2}
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			run(client, context.Background(), 1)
+			Expect(run(client, rcon.WithPriority(context.Background()), 5)).To(BeNumerically("<", time.Millisecond*200))
+			Expect(run(client, context.Background(), 1)).To(BeNumerically(">=", time.Millisecond*300))
+		})
+
+		g.It("Should not count multi-packet sentinels as commands", func() {
+			config.MultiPacketResponses = true
+			config.RateLimit = rcon.RateLimitConfig{CommandsPerSecond: 5}
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			// The second command waits 200ms for its token. It would wait 400ms if the first sentinel took one too.
+			elapsed := run(client, context.Background(), 2)
+			Expect(elapsed).To(BeNumerically(">=", time.Millisecond*150))
+			Expect(elapsed).To(BeNumerically("<", time.Millisecond*350))
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package rcontest
+
+import (
+	"strings"
+)
+
+// AdminUser is the user clients authenticating with the server's Password are logged in as.
+const AdminUser = "admin"
+
+// Authorizer decides whether user may execute command. Commands it rejects are answered with DeniedResponse instead
+// of being executed.
+type Authorizer interface {
+	Authorize(user string, command string) bool
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface.
+type AuthorizerFunc func(user string, command string) bool
+
+func (f AuthorizerFunc) Authorize(user string, command string) bool {
+	return f(user, command)
+}
+
+// DeniedResponse is the response to commands rejected by the Authorizer. %s is replaced with the command name.
+const DeniedResponse = "Permission denied: %s"
+
+// CommandPolicy restricts the commands a user may execute by name. Names are matched case insensitively.
+type CommandPolicy struct {
+	// Allow lists the commands the user may execute. If empty, all commands not listed in Deny are allowed.
+	Allow []string `json:"allow"`
+
+	// Deny lists the commands the user may not execute. It takes precedence over Allow.
+	Deny []string `json:"deny"`
+}
+
+func (p CommandPolicy) allows(name string) bool {
+	for _, denied := range p.Deny {
+		if strings.EqualFold(denied, name) {
+			return false
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return true
+	}
+
+	for _, allowed := range p.Allow {
+		if strings.EqualFold(allowed, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CommandPolicies is an Authorizer applying a CommandPolicy per user. Users without a policy may execute every
+// command.
+type CommandPolicies map[string]CommandPolicy
+
+func (p CommandPolicies) Authorize(user string, command string) bool {
+	policy, ok := p[user]
+	if !ok {
+		return true
+	}
+
+	return policy.allows(commandName(command))
+}
+
+// AddUser makes the server accept password, authenticating clients using it as user. Source RCON only transmits a
+// password, so every user needs a distinct one.
+func (s *Server) AddUser(user string, password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.users[password] = user
+}
+
+// SetAuthorizer makes the server check every command against a. A nil Authorizer allows every command.
+func (s *Server) SetAuthorizer(a Authorizer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.authorizer = a
+}
+
+// authenticate returns the user password belongs to. It must be called with the lock held.
+func (s *Server) authenticate(password string) (string, bool) {
+	if s.failAuth {
+		return "", false
+	}
+
+	if password == s.Password {
+		return AdminUser, true
+	}
+
+	user, ok := s.users[password]
+	return user, ok
+}
+
+// commandName returns the name of command, its first word.
+func commandName(command string) string {
+	if idx := strings.IndexByte(command, ' '); idx != -1 {
+		return command[:idx]
+	}
+
+	return command
+}
@@ -0,0 +1,505 @@
+// Package rcontest provides a mock RCON server for tests. It speaks the Source RCON protocol over a loopback TCP
+// listener, records received commands, answers with scripted responses and can simulate misbehaving servers: failed
+// authentication, slow responses and abrupt disconnects. Broadcasts can be injected at any time.
+package rcontest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/packet"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MaxResponseBody is the maximum body size of a single response packet. Larger responses are split across multiple
+// packets like Source servers do.
+const MaxResponseBody = 4096
+
+// BroadcastID is a packet ID tests can use for injected broadcasts. Pair it with BroadcastChecker.
+const BroadcastID = -2
+
+// BroadcastChecker treats packets with BroadcastID as broadcasts.
+func BroadcastChecker(p packet.Packet) bool {
+	return p.ID() == BroadcastID
+}
+
+// CommandHandler produces the response to a command. args contains everything after the command name.
+type CommandHandler func(args string) string
+
+// Server is a mock RCON server.
+type Server struct {
+	Password   string
+	EndianMode endian.Mode
+
+	listener  net.Listener
+	connsLock sync.Mutex
+	conns     map[net.Conn]*sync.Mutex
+
+	lock            sync.RWMutex
+	handlers        map[string]CommandHandler
+	responses       map[string]string
+	commands        []string
+	failAuth        bool
+	delay           time.Duration
+	sentinelTrailer bool
+	ignoreSentinels bool
+	disconnectOn    map[string]bool
+	garbageOn       map[string][]byte
+	authAttempts    int
+	successfulAuths int
+	users           map[string]string
+	authorizer      Authorizer
+	bodyTransform   rcon.BodyTransform
+	packetCodec     packet.Codec
+}
+
+func NewServer(password string) *Server {
+	return &Server{
+		Password:     password,
+		EndianMode:   endian.Little,
+		conns:        map[net.Conn]*sync.Mutex{},
+		handlers:     map[string]CommandHandler{},
+		responses:    map[string]string{},
+		disconnectOn: map[string]bool{},
+		garbageOn:    map[string][]byte{},
+		users:        map[string]string{},
+	}
+}
+
+// StartServer creates and starts a server which is closed when the test completes.
+func StartServer(t testing.TB, password string) *Server {
+	s := NewServer(password)
+
+	if _, _, err := s.Start(); err != nil {
+		t.Fatalf("could not start rcontest server: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+// Handle registers handler for the command name, replacing any existing handler. Command names are matched case
+// insensitively.
+func (s *Server) Handle(name string, handler CommandHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.handlers[strings.ToLower(name)] = handler
+}
+
+// SetResponse scripts the response to an exact command. Scripted responses take precedence over handlers.
+func (s *Server) SetResponse(command, response string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.responses[command] = response
+}
+
+// SetFailAuth makes the server reject every authentication attempt.
+func (s *Server) SetFailAuth(fail bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.failAuth = fail
+}
+
+// SetBodyTransform makes the server wrap packet bodies like a dialect's rcon.Features.BodyTransform. The server
+// decodes requests with Decode and encodes responses with Encode, so it expects the same transform as the client.
+func (s *Server) SetBodyTransform(transform rcon.BodyTransform) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.bodyTransform = transform
+}
+
+// SetCodec makes the server frame packets with codec instead of the Source codec, like rcon.Config.Codec.
+func (s *Server) SetCodec(codec packet.Codec) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.packetCodec = codec
+}
+
+// SetDelay makes the server wait for d before answering each command.
+func (s *Server) SetDelay(d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.delay = d
+}
+
+// SetSentinelTrailer makes the server follow every echoed sentinel with a second packet whose body is
+// 0x00 0x01 0x00 0x00, like SRCDS does.
+func (s *Server) SetSentinelTrailer(trailer bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sentinelTrailer = trailer
+}
+
+// SetIgnoreSentinels makes the server drop empty response value packets without echoing them, like servers which
+// don't support multi-packet responses.
+func (s *Server) SetIgnoreSentinels(ignore bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.ignoreSentinels = ignore
+}
+
+// DisconnectOn makes the server abruptly close the connection, without answering, when it receives command.
+func (s *Server) DisconnectOn(command string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.disconnectOn[command] = true
+}
+
+// GarbageOn makes the server write garbage to the connection, unframed, before answering command. It simulates a
+// stream which lost framing, for testing the client's ResyncStrategy.
+func (s *Server) GarbageOn(command string, garbage []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.garbageOn[command] = garbage
+}
+
+// Commands returns every command received so far, in order.
+func (s *Server) Commands() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	out := make([]string, len(s.commands))
+	copy(out, s.commands)
+
+	return out
+}
+
+// AuthAttempts returns the number of authentication attempts and how many of them succeeded.
+func (s *Server) AuthAttempts() (int, int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.authAttempts, s.successfulAuths
+}
+
+// Start begins listening on a random loopback port and returns the address clients should connect to.
+func (s *Server) Start() (string, uint16, error) {
+	return s.Listen("127.0.0.1:0")
+}
+
+// Listen begins listening on addr, such as ":27015", and returns the address clients should connect to.
+func (s *Server) Listen(addr string) (string, uint16, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not start listener: %w", err)
+	}
+	s.listener = listener
+
+	go s.acceptLoop()
+
+	host, port := s.Addr()
+
+	return host, port, nil
+}
+
+// Addr returns the host and port the server is listening on.
+func (s *Server) Addr() (string, uint16) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+
+	return addr.IP.String(), uint16(addr.Port)
+}
+
+// Transport returns an rcon.Transport which connects clients to the server through in-memory pipes instead of TCP.
+// The server does not need to be started to be used this way.
+func (s *Server) Transport() rcon.Transport {
+	return rcon.TransportFunc(func(ctx context.Context, address string) (rcon.Conn, error) {
+		client, server := net.Pipe()
+		go s.handleConn(server)
+
+		return client, nil
+	})
+}
+
+// DisconnectAll abruptly drops every client connection while the server keeps accepting new ones.
+func (s *Server) DisconnectAll() {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// Close stops the server and disconnects all clients.
+func (s *Server) Close() error {
+	s.DisconnectAll()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Close()
+}
+
+// Broadcast sends an unsolicited message with the given packet ID to every authenticated client.
+func (s *Server) Broadcast(id int32, message string) {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	for conn, lock := range s.conns {
+		_ = s.write(conn, lock, packet.NewPacketWithID(s.EndianMode, id, packet.TypeCommandRes, message))
+	}
+}
+
+// Send sends an unsolicited packet of type t to every authenticated client. It simulates servers with custom packet
+// types, such as game-specific telemetry.
+func (s *Server) Send(id int32, t packet.PacketType, body string) {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	for conn, lock := range s.conns {
+		_ = s.write(conn, lock, packet.NewPacketWithID(s.EndianMode, id, t, body))
+	}
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer func() {
+		s.connsLock.Lock()
+		delete(s.conns, conn)
+		s.connsLock.Unlock()
+		_ = conn.Close()
+	}()
+
+	lock := &sync.Mutex{}
+	reader := bufio.NewReader(conn)
+	authenticated := false
+	user := ""
+
+	for {
+		p, err := s.read(reader)
+		if err != nil {
+			return
+		}
+
+		body := string(p.Body())
+		body = body[:len(body)-1]
+
+		if !authenticated {
+			if p.Type() != packet.TypeAuth {
+				return
+			}
+
+			s.lock.Lock()
+			s.authAttempts++
+			id := p.ID()
+			if authUser, ok := s.authenticate(body); ok {
+				user = authUser
+				s.successfulAuths++
+			} else {
+				id = packet.AuthFailedID
+			}
+			s.lock.Unlock()
+
+			// Register the connection before answering so that broadcasts sent right after the client authenticated
+			// reach it.
+			if id != packet.AuthFailedID {
+				authenticated = true
+				s.connsLock.Lock()
+				s.conns[conn] = lock
+				s.connsLock.Unlock()
+			}
+
+			if err := s.write(conn, lock, packet.NewPacketWithID(s.EndianMode, id, packet.TypeAuthRes, "")); err != nil {
+				return
+			}
+
+			if id == packet.AuthFailedID {
+				return
+			}
+
+			continue
+		}
+
+		// Like Source servers, echo empty response value packets. Clients use them as a sentinel to detect the end of
+		// multi-packet responses.
+		if p.Type() == packet.TypeCommandRes && body == "" {
+			s.lock.RLock()
+			ignore := s.ignoreSentinels
+			s.lock.RUnlock()
+
+			if ignore {
+				continue
+			}
+
+			if err := s.write(conn, lock, packet.NewPacketWithID(s.EndianMode, p.ID(), packet.TypeCommandRes, "")); err != nil {
+				return
+			}
+
+			s.lock.RLock()
+			trailer := s.sentinelTrailer
+			s.lock.RUnlock()
+
+			if trailer {
+				p := packet.NewPacketWithID(s.EndianMode, p.ID(), packet.TypeCommandRes, "\x00\x01\x00\x00")
+				if err := s.write(conn, lock, p); err != nil {
+					return
+				}
+			}
+
+			continue
+		}
+
+		s.lock.Lock()
+		s.commands = append(s.commands, body)
+		delay := s.delay
+		disconnect := s.disconnectOn[body]
+		garbage := s.garbageOn[body]
+		s.lock.Unlock()
+
+		if disconnect {
+			return
+		}
+
+		if len(garbage) > 0 {
+			lock.Lock()
+			_, err := conn.Write(garbage)
+			lock.Unlock()
+
+			if err != nil {
+				return
+			}
+		}
+
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+
+		// Split large responses across multiple packets.
+		res := s.execAs(user, body)
+		for {
+			chunk := res
+			if len(chunk) > MaxResponseBody {
+				chunk = chunk[:MaxResponseBody]
+			}
+			res = res[len(chunk):]
+
+			if err := s.write(conn, lock, packet.NewPacketWithID(s.EndianMode, p.ID(), packet.TypeCommandRes, chunk)); err != nil {
+				return
+			}
+
+			if len(res) == 0 {
+				break
+			}
+		}
+	}
+}
+
+// execAs executes command on behalf of user, if the Authorizer allows it.
+func (s *Server) execAs(user string, command string) string {
+	s.lock.RLock()
+	authorizer := s.authorizer
+	s.lock.RUnlock()
+
+	if authorizer != nil && !authorizer.Authorize(user, command) {
+		return fmt.Sprintf(DeniedResponse, commandName(command))
+	}
+
+	return s.exec(command)
+}
+
+func (s *Server) exec(command string) string {
+	name, args := commandName(command), ""
+	if len(name) < len(command) {
+		args = command[len(name)+1:]
+	}
+
+	s.lock.RLock()
+	res, scripted := s.responses[command]
+	handler, ok := s.handlers[strings.ToLower(name)]
+	s.lock.RUnlock()
+
+	if scripted {
+		return res
+	}
+
+	if !ok {
+		return fmt.Sprintf("Unknown command: %s", name)
+	}
+
+	return handler(args)
+}
+
+// framing returns the codec and body transform packets are exchanged with.
+func (s *Server) framing() (packet.Codec, rcon.BodyTransform) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.packetCodec != nil {
+		return s.packetCodec, s.bodyTransform
+	}
+
+	return packet.NewSourceCodec(s.EndianMode), s.bodyTransform
+}
+
+func (s *Server) read(reader *bufio.Reader) (packet.Packet, error) {
+	codec, transform := s.framing()
+
+	p, err := codec.Decode(reader)
+	if err != nil || transform.Decode == nil {
+		return p, err
+	}
+
+	body := p.Body()
+	decoded, err := transform.Decode(body[:len(body)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return packet.NewPacketWithID(s.EndianMode, p.ID(), p.Type(), string(decoded)), nil
+}
+
+func (s *Server) write(conn net.Conn, lock *sync.Mutex, p packet.Packet) error {
+	codec, transform := s.framing()
+
+	if transform.Encode != nil {
+		body := p.Body()
+		encoded, err := transform.Encode(body[:len(body)-1])
+		if err != nil {
+			return err
+		}
+
+		p = packet.NewPacketWithID(s.EndianMode, p.ID(), p.Type(), string(encoded))
+	}
+
+	out, err := codec.Encode(p)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, err = conn.Write(out)
+
+	return err
+}
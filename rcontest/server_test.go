@@ -0,0 +1,172 @@
+package rcontest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/presets"
+	"github.com/refractorgscm/rcon/rcontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Server", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+		var broadcasts chan string
+		var disconnects chan error
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			broadcasts = make(chan string, 1)
+			disconnects = make(chan error, 1)
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:              host,
+				Port:              port,
+				Password:          "password",
+				QueueReadTimeout:  time.Millisecond * 200,
+				BroadcastChecker:  rcontest.BroadcastChecker,
+				BroadcastHandler:  func(msg string) { broadcasts <- msg },
+				DisconnectHandler: func(err error, _ bool) { disconnects <- err },
+			}, nil)
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should answer with scripted responses and record commands", func() {
+			server.SetResponse("status", "all good")
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("all good"))
+			Expect(server.Commands()).To(Equal([]string{"status"}))
+		})
+
+		g.It("Should answer with handlers", func() {
+			server.Handle("echo", func(args string) string { return args })
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hello"))
+		})
+
+		g.It("Should simulate sentinel trailers", func() {
+			server.SetSentinelTrailer(true)
+			server.SetResponse("cvarlist", strings.Repeat("x", rcontest.MaxResponseBody*2))
+
+			client.Dialect = presets.NewDialect("source", rcon.Features{
+				MultiPacket: true,
+				Sentinel:    rcon.SentinelEchoWithTrailer,
+			})
+			client.MultiPacketResponses = true
+
+			// In strict mode an unconsumed trailer is reported as an unexpected packet.
+			violations := make(chan error, 4)
+			client.Strict = true
+			client.ProtocolViolationHandler = func(err error) { violations <- err }
+
+			Expect(client.Connect()).To(BeNil())
+
+			for i := 0; i < 2; i++ {
+				res, err := client.ExecCommand("cvarlist")
+				Expect(err).To(BeNil())
+				Expect(res).To(HaveLen(rcontest.MaxResponseBody * 2))
+			}
+
+			Consistently(violations, time.Millisecond*100).ShouldNot(Receive())
+		})
+
+		g.It("Should limit listened broadcast channels", func() {
+			server.Handle("listen", func(string) string { return "" })
+			server.Handle("stoplisten", func(string) string { return "" })
+			client.Dialect = presets.NewDialect("listen", rcon.Features{
+				Broadcasts:        true,
+				ListenCommand:     "listen %s",
+				StopListenCommand: "stoplisten %s",
+				MaxListens:        1,
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, stopChat, err := client.Listen(context.Background(), "chat")
+			Expect(err).To(BeNil())
+
+			// Listening to the same channel again doesn't take another slot.
+			_, stopChatAgain, err := client.Listen(context.Background(), "chat")
+			Expect(err).To(BeNil())
+
+			listened := make(chan error, 1)
+			go func() {
+				_, _, err := client.Listen(context.Background(), "login")
+				listened <- err
+			}()
+
+			stopChat()
+			Consistently(listened, time.Millisecond*100).ShouldNot(Receive())
+
+			stopChatAgain()
+			Eventually(listened).Should(Receive(BeNil()))
+			Expect(server.Commands()).To(Equal([]string{"listen chat", "stoplisten chat", "listen login"}))
+		})
+
+		g.It("Should simulate authentication failure", func() {
+			server.SetFailAuth(true)
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should simulate slow responses", func() {
+			server.SetDelay(time.Millisecond * 500)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+
+			var timeout *errs.ResponseTimeoutError
+			Expect(errors.As(err, &timeout)).To(BeTrue())
+			Expect(timeout.Timeout).To(Equal(time.Millisecond * 200))
+		})
+
+		g.It("Should inject broadcasts", func() {
+			Expect(client.Connect()).To(BeNil())
+
+			// Make sure the connection was registered before broadcasting.
+			_, _ = client.ExecCommand("status")
+			server.Broadcast(rcontest.BroadcastID, "hello everyone")
+
+			Eventually(broadcasts).Should(Receive(Equal("hello everyone")))
+		})
+
+		g.It("Should simulate abrupt disconnects", func() {
+			server.DisconnectOn("crash")
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, _ = client.ExecCommand("crash")
+
+			Eventually(disconnects).Should(Receive())
+		})
+	})
+}
@@ -0,0 +1,184 @@
+package rcon
+
+import (
+	"errors"
+	"github.com/refractorgscm/rcon/errs"
+	"time"
+)
+
+// BackoffStrategy returns how long to wait before the given reconnection attempt. Attempts start at 1.
+type BackoffStrategy func(attempt int) time.Duration
+
+// ConstantBackoff waits the same duration before every attempt.
+func ConstantBackoff(d time.Duration) BackoffStrategy {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff doubles the wait before every attempt, starting at base and never exceeding max.
+func ExponentialBackoff(base, max time.Duration) BackoffStrategy {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+
+		if d > max {
+			d = max
+		}
+
+		return d
+	}
+}
+
+type ReconnectConfig struct {
+	// Enabled turns on automatic reconnection when the server closes the connection.
+	Enabled bool
+
+	// MaxAttempts is the number of reconnection attempts before giving up and calling the DisconnectHandler. A value
+	// of zero or less retries forever. Regardless of MaxAttempts, the client gives up as soon as the server rejects
+	// the password or bans the client, since further attempts can't succeed and may get the client's address banned.
+	MaxAttempts int
+
+	// Backoff determines the wait before each attempt.
+	//
+	// Default: ExponentialBackoff(1s, 30s)
+	Backoff BackoffStrategy
+
+	// Canary is executed on every new connection before the client is considered reconnected.
+	Canary CanaryConfig
+
+	// OnReconnect is called after the client reconnected and re-authenticated. It should restore any server-side
+	// session state, for example by re-running "listen chat" to resubscribe to broadcasts. Errors returned by
+	// OnReconnect are logged; the connection is kept.
+	OnReconnect func(c *Client) error
+}
+
+// connectionLost is called by the routines of the connection identified by terminate when it was lost unexpectedly. If
+// reconnection is enabled, a reconnect routine is started. Otherwise, the client disconnects and the DisconnectHandler
+// is called.
+func (c *Client) connectionLost(terminate chan uint8, err error) {
+	if !c.Reconnect.Enabled || c.draining() {
+		c.disconnect(terminate, err)
+		return
+	}
+
+	// The session is ended while holding reconnectLock so that a concurrent Close either ends the session first or
+	// sees the reconnect and cancels it.
+	c.reconnectLock.Lock()
+	if !c.endSession(terminate) {
+		c.reconnectLock.Unlock()
+		return
+	}
+	c.reconnecting = true
+	c.setReady(false)
+	c.transition(StateReconnecting, StateConnected)
+	c.stopReconnect = make(chan struct{})
+	stop := c.stopReconnect
+	c.reconnectLock.Unlock()
+
+	// Hold the wait group for the duration of the reconnect so that waiters don't return between connections.
+	c.wgLock.Lock()
+	c.waitGroup.Add(1)
+	c.wgLock.Unlock()
+
+	c.closeConn()
+
+	go c.reconnect(err, stop)
+}
+
+func (c *Client) reconnect(cause error, stop chan struct{}) {
+	defer func() {
+		c.wgLock.Lock()
+		c.waitGroup.Done()
+		c.wgLock.Unlock()
+	}()
+
+	lastErr := cause
+
+	for attempt := 1; c.Reconnect.MaxAttempts <= 0 || attempt <= c.Reconnect.MaxAttempts; attempt++ {
+		wait := c.Reconnect.Backoff(attempt)
+		c.log.Info("Reconnecting in ", wait, " (attempt ", attempt, ")")
+
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			c.log.Debug("Reconnect cancelled")
+			c.notifyDisconnect(nil)
+			return
+		}
+
+		if err := c.dial(); err != nil {
+			c.log.Error("Reconnect attempt ", attempt, " failed. Error: ", err)
+			lastErr = err
+
+			if errors.Is(err, errs.ErrAuthentication) || errors.Is(err, errs.ErrBanned) {
+				break
+			}
+
+			continue
+		}
+
+		if err := c.runCanary(stop); err != nil {
+			c.log.Error("Reconnect attempt ", attempt, " failed. Error: ", err)
+			c.closeConn()
+			lastErr = err
+			continue
+		}
+
+		// Close may have been called while dialing.
+		c.reconnectLock.Lock()
+		if !c.reconnecting {
+			c.reconnectLock.Unlock()
+			c.closeConn()
+			c.notifyDisconnect(nil)
+			return
+		}
+		c.reconnecting = false
+		c.reconnectLock.Unlock()
+
+		c.startRoutines()
+		c.markConnected()
+		c.log.Info("Reconnected after ", attempt, " attempt(s)")
+		c.metrics().Reconnected()
+
+		// runSelfTest logs failures itself. Unless it closed the client, the connection is kept and still needs its
+		// session state restored.
+		if err := c.runSelfTest(); err != nil && c.SelfTestDisconnect {
+			return
+		}
+
+		c.restoreListens()
+
+		if c.Reconnect.OnReconnect != nil {
+			if err := c.Reconnect.OnReconnect(c); err != nil {
+				c.log.Error("OnReconnect hook failed. Error: ", err)
+			}
+		}
+
+		return
+	}
+
+	c.reconnectLock.Lock()
+	c.reconnecting = false
+	c.reconnectLock.Unlock()
+
+	c.log.Error("Giving up reconnecting. Error: ", lastErr)
+	c.notifyDisconnect(lastErr)
+}
+
+// cancelReconnect stops an in-progress reconnect. It returns false if the client was not reconnecting.
+func (c *Client) cancelReconnect() bool {
+	c.reconnectLock.Lock()
+	defer c.reconnectLock.Unlock()
+
+	if !c.reconnecting {
+		return false
+	}
+
+	c.reconnecting = false
+	close(c.stopReconnect)
+
+	return true
+}
@@ -0,0 +1,86 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+	"time"
+)
+
+func TestReconnect(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Reconnect", func() {
+		var disconnects chan error
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			disconnects = make(chan error, 1)
+			config = &rcon.Config{
+				Reconnect: rcon.ReconnectConfig{
+					Enabled: true,
+					Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+				},
+				DisconnectHandler: func(err error, _ bool) { disconnects <- err },
+			}
+		})
+
+		g.It("Should reconnect and run the OnReconnect hook", func() {
+			reconnected := make(chan struct{}, 1)
+			config.Reconnect.OnReconnect = func(c *rcon.Client) error {
+				_, err := c.ExecCommand("listen chat")
+				reconnected <- struct{}{}
+				return err
+			}
+
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+
+			Expect(client.Connect()).To(BeNil())
+			server.DisconnectAll()
+
+			Eventually(reconnected).Should(Receive())
+			Expect(disconnects).NotTo(Receive())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+			Expect(server.Commands()).To(Equal([]string{"listen chat", "status"}))
+		})
+
+		g.It("Should give up after MaxAttempts", func() {
+			config.Reconnect.MaxAttempts = 2
+
+			server, client := newTestClient(t, config)
+			Expect(client.Connect()).To(BeNil())
+			Expect(server.Close()).To(BeNil())
+
+			Eventually(disconnects).Should(Receive(Not(BeNil())))
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+		})
+
+		g.It("Should stop reconnecting when authentication fails", func() {
+			server, client := newTestClient(t, config)
+			Expect(client.Connect()).To(BeNil())
+
+			server.SetFailAuth(true)
+			server.DisconnectAll()
+
+			var err error
+			Eventually(disconnects).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+
+			Consistently(func() int {
+				attempts, _ := server.AuthAttempts()
+				return attempts
+			}, time.Millisecond*100).Should(Equal(2))
+		})
+	})
+}
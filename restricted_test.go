@@ -0,0 +1,111 @@
+package rcon_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+	"testing"
+)
+
+func TestSetRestrictedPacketIDs(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("SetRestrictedPacketIDs", func() {
+		// every returns every nth ID up to max, starting at offset.
+		every := func(n int32, offset int32, max int32) []int32 {
+			var ids []int32
+			for id := offset; id <= max; id += n {
+				ids = append(ids, id)
+			}
+			return ids
+		}
+
+		g.It("Should be safe to swap while commands are executing", func() {
+			var sentLock sync.Mutex
+			var sent []int32
+
+			server, client := newTestClient(t, &rcon.Config{
+				PacketHooks: rcon.PacketHooks{
+					OnSend: func(p packet.Packet, _ []byte) {
+						sentLock.Lock()
+						sent = append(sent, p.ID())
+						sentLock.Unlock()
+					},
+				},
+			})
+			server.Handle("echo", func(args string) string { return args })
+
+			Expect(client.Connect()).To(BeNil())
+
+			const workers, commands = 8, 25
+			failures := make(chan error, workers*commands)
+
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+
+					for i := 0; i < commands; i++ {
+						want := fmt.Sprintf("%d-%d", w, i)
+
+						res, err := client.ExecCommand("echo " + want)
+						if err != nil && !errors.Is(err, errs.ErrMailboxClosed) {
+							failures <- err
+						} else if err == nil && res != want {
+							failures <- fmt.Errorf("got response %q to command %q", res, want)
+						}
+					}
+				}(w)
+			}
+
+			// Keep swapping the restricted IDs for as long as the commands are executing.
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			for round := int32(0); ; round++ {
+				select {
+				case <-done:
+				default:
+					client.SetRestrictedPacketIDs(every(3, round%3+1, 2000))
+					continue
+				}
+
+				break
+			}
+
+			close(failures)
+			Expect(failures).NotTo(Receive())
+
+			client.SetRestrictedPacketIDs(every(2, 1, 100000))
+
+			sentLock.Lock()
+			sent = nil
+			sentLock.Unlock()
+
+			for i := 0; i < 20; i++ {
+				_, err := client.ExecCommand("echo after")
+				Expect(err).To(BeNil())
+			}
+
+			sentLock.Lock()
+			defer sentLock.Unlock()
+
+			Expect(sent).To(HaveLen(20))
+			for _, id := range sent {
+				Expect(id%2).To(BeEquivalentTo(0), "packet %d was sent with a restricted ID", id)
+			}
+		})
+	})
+}
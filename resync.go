@@ -0,0 +1,57 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+)
+
+// ResyncStrategy determines what the client does when it detects that the packet stream has lost framing, for example
+// because of a bad size field or a partial packet left over after a reconnect race.
+type ResyncStrategy uint8
+
+const (
+	// ResyncNone logs the malformed packet and carries on reading. This is the default.
+	ResyncNone ResyncStrategy = iota
+
+	// ResyncScan discards incoming bytes until a plausible packet header is found and resumes reading from there.
+	ResyncScan
+
+	// ResyncDisconnect drops the connection so that a clean stream can be established. If automatic reconnection is
+	// enabled the client reconnects, otherwise the DisconnectHandler is called with errs.ErrDesync.
+	ResyncDisconnect
+)
+
+// resync recovers from a malformed packet according to the configured strategy. It returns true if the connection
+// was dropped, in which case the calling reader routine must return.
+func (c *Client) resync(terminate chan uint8, cause error) bool {
+	switch c.ResyncStrategy {
+	case ResyncScan:
+		if !c.sourceFraming() {
+			c.log.Debug("Malformed packet received, not scanning since the codec doesn't use Source framing. Error: ",
+				cause)
+			return false
+		}
+
+		_, reader := c.connection()
+		if reader == nil {
+			return false
+		}
+
+		discarded, err := packet.ResyncMax(c.EndianMode, reader, c.MaxPacketSize)
+		if err != nil {
+			c.log.Debug("Resync scan failed. Error: ", err)
+			return false
+		}
+
+		c.log.Debug("Resynchronized packet stream, discarded ", discarded, " bytes")
+	case ResyncDisconnect:
+		c.log.Error("Packet stream desynchronized, disconnecting. Error: ", cause)
+		c.connectionLost(terminate, fmt.Errorf("%s: %w", cause.Error(), errs.ErrDesync))
+		return true
+	default:
+		c.log.Debug("Malformed packet received. Error: ", cause)
+	}
+
+	return false
+}
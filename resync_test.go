@@ -0,0 +1,97 @@
+package rcon_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"strings"
+	"testing"
+)
+
+func TestResync(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Resync", func() {
+		// The garbage is not a multiple of the size field's length, so reading on after it misparses every following
+		// packet. The response is large enough that no header straddling the garbage and the response looks plausible.
+		garbage := bytes.Repeat([]byte{0xff}, 6)
+		response := strings.Repeat("x", 300)
+
+		var disconnects chan error
+
+		config := func(strategy rcon.ResyncStrategy) *rcon.Config {
+			ch := make(chan error, 1)
+			disconnects = ch
+
+			return &rcon.Config{
+				MaxPacketSize:     4096,
+				ResyncStrategy:    strategy,
+				DisconnectHandler: func(err error, _ bool) { ch <- err },
+			}
+		}
+
+		g.It("Should scan past garbage and keep pending commands", func() {
+			server, client := newTestClient(t, config(rcon.ResyncScan))
+			server.SetResponse("status", response)
+			server.SetResponse("players", "none")
+			server.GarbageOn("status", garbage)
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(response))
+
+			res, err = client.ExecCommand("players")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("none"))
+			Expect(disconnects).NotTo(Receive())
+		})
+
+		g.It("Should disconnect on garbage", func() {
+			server, client := newTestClient(t, config(rcon.ResyncDisconnect))
+			server.SetResponse("status", response)
+			server.GarbageOn("status", garbage)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).NotTo(BeNil())
+
+			Eventually(disconnects).Should(Receive(WithTransform(func(err error) bool {
+				return errors.Is(err, errs.ErrDesync)
+			}, BeTrue())))
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+		})
+
+		g.It("Should reconnect on garbage if reconnection is enabled", func() {
+			c := config(rcon.ResyncDisconnect)
+			c.Reconnect = rcon.ReconnectConfig{Enabled: true, Backoff: rcon.ConstantBackoff(0)}
+
+			server, client := newTestClient(t, c)
+			server.SetResponse("status", response)
+			server.SetResponse("players", "none")
+			server.GarbageOn("status", garbage)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).NotTo(BeNil())
+
+			Eventually(func() error {
+				_, err := client.ExecCommand("players")
+				return err
+			}).Should(BeNil())
+			Expect(disconnects).NotTo(Receive())
+
+			attempts, _ := server.AuthAttempts()
+			Expect(attempts).To(Equal(2))
+		})
+	})
+}
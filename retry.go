@@ -0,0 +1,84 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"github.com/refractorgscm/rcon/errs"
+	"time"
+)
+
+// RetryConfig configures automatic retries of failed commands.
+type RetryConfig struct {
+	// MaxAttempts is the number of times a command is executed at most, including the first attempt. A value of one or
+	// less disables retries.
+	MaxAttempts int
+
+	// Retryable reports whether a command which failed with err should be executed again.
+	//
+	// Default: IsTransient
+	Retryable func(err error) bool
+
+	// Backoff determines the wait before each retry. Retries start at 1.
+	//
+	// Default: ExponentialBackoff(100ms, 2s)
+	Backoff BackoffStrategy
+
+	// Idempotent, if set, reports whether command may be executed more than once. Commands for which it returns false
+	// are only retried if they certainly didn't reach the server, because they could not be queued for writing. A
+	// command which timed out waiting for its response may have been executed, so it is not retried. Commands
+	// executed with ExecCommandIdempotent are treated as not idempotent regardless.
+	Idempotent func(command string) bool
+}
+
+// IsTransient reports whether err is a failure which may not recur if the command is executed again: a read timeout,
+// or a write queue which was full or timed out.
+func IsTransient(err error) bool {
+	return errors.Is(err, errs.ErrReadTimeout) || isUnsent(err)
+}
+
+// isUnsent reports whether err means a command was never written to the connection.
+func isUnsent(err error) bool {
+	var partial *errs.PartialCommandError
+	if errors.As(err, &partial) && partial.Executed > 0 {
+		return false
+	}
+
+	return errors.Is(err, errs.ErrNotSent) || errors.Is(err, errs.ErrQueueTimeout) || errors.Is(err, errs.ErrQueueFull)
+}
+
+// execRetrying executes command, retrying it according to the Retry config.
+func (c *Client) execRetrying(ctx context.Context, command string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := c.execTracked(ctx, command)
+		if err == nil || !c.shouldRetry(ctx, command, attempt, err) {
+			return res, err
+		}
+
+		wait := c.Retry.Backoff(attempt)
+		c.log.Debug("Retrying command in ", wait, " after attempt ", attempt, " failed. Error: ", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", &errs.RetryCancelledError{Attempts: attempt, LastErr: err, Err: ctx.Err()}
+		}
+	}
+}
+
+func (c *Client) shouldRetry(ctx context.Context, command string, attempt int, err error) bool {
+	if attempt >= c.Retry.MaxAttempts || ctx.Err() != nil || !c.Retry.Retryable(err) {
+		return false
+	}
+
+	if _, ok := IdempotencyKeyFromContext(ctx); ok {
+		return isUnsent(err)
+	}
+
+	if c.Retry.Idempotent != nil && !c.Retry.Idempotent(command) {
+		return isUnsent(err)
+	}
+
+	return true
+}
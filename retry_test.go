@@ -0,0 +1,81 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Retry", func() {
+		g.It("Should retry timed out commands", func() {
+			server, client := newTestClient(t, &rcon.Config{Retry: rcon.RetryConfig{MaxAttempts: 2}})
+
+			calls := 0
+			server.Handle("slow", func(string) string {
+				if calls++; calls == 1 {
+					time.Sleep(time.Millisecond * 300)
+				}
+				return "done"
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("slow")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("done"))
+			Expect(client.Stats().Commands).To(BeEquivalentTo(2))
+		})
+
+		g.It("Should not retry non-idempotent commands which may have been executed", func() {
+			server, client := newTestClient(t, &rcon.Config{Retry: rcon.RetryConfig{
+				MaxAttempts: 3,
+				Idempotent:  func(command string) bool { return command != "ban Bob" },
+			}})
+			server.SetDelay(time.Millisecond * 300)
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("ban Bob")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(client.Stats().Commands).To(BeEquivalentTo(1))
+		})
+
+		g.It("Should wrap the context error when cancelled while waiting to retry", func() {
+			server, client := newTestClient(t, &rcon.Config{Retry: rcon.RetryConfig{
+				MaxAttempts: 3,
+				Backoff:     rcon.ConstantBackoff(time.Hour),
+			}})
+			server.SetDelay(time.Millisecond * 300)
+
+			Expect(client.Connect()).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+			defer cancel()
+
+			_, err := client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+
+			var cancelled *errs.RetryCancelledError
+			Expect(errors.As(err, &cancelled)).To(BeTrue())
+			Expect(cancelled.Attempts).To(Equal(1))
+
+			ctx, cancel = context.WithCancel(context.Background())
+			time.AfterFunc(time.Millisecond*300, cancel)
+
+			_, err = client.ExecCommandContext(ctx, "status")
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
+}
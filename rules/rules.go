@@ -0,0 +1,221 @@
+// Package rules turns broadcasts and command responses into events using rules loaded at runtime, for example from a
+// config file, so that supporting a new game's text formats doesn't require recompiling the application.
+//
+// The built-in engine matches regular expressions. Rules are written in JSON:
+//
+//	[
+//	  {"name": "chat", "pattern": "^Chat: (?P<id>[^,]+), (?P<name>.*?), (?P<message>.*)$", "channel": "chat",
+//	   "template": "${name}: ${message}"}
+//	]
+//
+// Other engines, such as an expression language or an embedded Lua interpreter, can be plugged in by implementing
+// Engine.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Event is a message matched by a rule.
+type Event struct {
+	// Rule is the name of the rule which matched.
+	Rule string
+
+	// Channel is the channel the message is classified into.
+	Channel string
+
+	// Message is the message, rewritten by the rule's template if it has one.
+	Message string
+
+	// Fields are the values of the named groups of the rule's pattern.
+	Fields map[string]string
+}
+
+// Engine evaluates rules against messages.
+type Engine interface {
+	// Match returns the event for message, or false if no rule matched.
+	Match(message string) (Event, bool)
+}
+
+// Rule is a rule of the built-in engine.
+type Rule struct {
+	// Name identifies the rule in events.
+	Name string `json:"name"`
+
+	// Pattern is the regular expression messages are matched against. Its named groups become the event's fields.
+	Pattern string `json:"pattern"`
+
+	// Channel is the channel matching messages are classified into.
+	Channel string `json:"channel"`
+
+	// Template, if set, rewrites matching messages. ${name} is replaced by the value of the named group name.
+	Template string `json:"template"`
+}
+
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// RuleSet is the built-in engine. Rules are evaluated in order; the first matching rule wins.
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// Compile compiles rules into a RuleSet.
+func Compile(rules []Rule) (*RuleSet, error) {
+	s := &RuleSet{}
+
+	for i, r := range rules {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of rule %d (%s): %w", i, r.Name, err)
+		}
+
+		s.rules = append(s.rules, compiledRule{Rule: r, pattern: pattern})
+	}
+
+	return s, nil
+}
+
+// Load reads JSON encoded rules from r and compiles them.
+func Load(r io.Reader) (*RuleSet, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("could not decode rules: %w", err)
+	}
+
+	return Compile(rules)
+}
+
+// LoadFile reads JSON encoded rules from the file at path and compiles them.
+func LoadFile(path string) (*RuleSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open rules file: %w", err)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+func (s *RuleSet) Match(message string) (Event, bool) {
+	for _, r := range s.rules {
+		m := r.pattern.FindStringSubmatchIndex(message)
+		if m == nil {
+			continue
+		}
+
+		e := Event{Rule: r.Name, Channel: r.Channel, Message: message, Fields: map[string]string{}}
+
+		for i, name := range r.pattern.SubexpNames() {
+			if name != "" && m[2*i] >= 0 {
+				e.Fields[name] = message[m[2*i]:m[2*i+1]]
+			}
+		}
+
+		if r.Template != "" {
+			e.Message = string(r.pattern.ExpandString(nil, r.Template, message, m))
+		}
+
+		return e, true
+	}
+
+	return Event{}, false
+}
+
+// Reloadable is an Engine whose underlying engine can be replaced at runtime, for example when its config file
+// changed. It is safe for concurrent use.
+type Reloadable struct {
+	lock   sync.RWMutex
+	engine Engine
+}
+
+// NewReloadable creates a Reloadable using engine.
+func NewReloadable(engine Engine) *Reloadable {
+	return &Reloadable{engine: engine}
+}
+
+// Set replaces the underlying engine.
+func (r *Reloadable) Set(engine Engine) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.engine = engine
+}
+
+// ReloadFile loads the rules in the file at path and replaces the underlying engine with them. If the file can't be
+// loaded, the current engine is kept.
+func (r *Reloadable) ReloadFile(path string) error {
+	s, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	r.Set(s)
+
+	return nil
+}
+
+func (r *Reloadable) Match(message string) (Event, bool) {
+	r.lock.RLock()
+	engine := r.engine
+	r.lock.RUnlock()
+
+	if engine == nil {
+		return Event{}, false
+	}
+
+	return engine.Match(message)
+}
+
+// BroadcastChannel returns a rcon.BroadcastChannelFunc classifying broadcasts into the channels of the rules they
+// match.
+func BroadcastChannel(engine Engine) rcon.BroadcastChannelFunc {
+	return func(p packet.Packet) string {
+		body := p.Body()
+		if e, ok := engine.Match(strings.TrimSpace(string(body[:len(body)-1]))); ok {
+			return e.Channel
+		}
+
+		return ""
+	}
+}
+
+// BroadcastHandler returns a rcon.BroadcastHandler calling handler with the event of every broadcast which matches a
+// rule. Broadcasts matching no rule are passed to unmatched, if it is not nil.
+func BroadcastHandler(engine Engine, handler func(Event), unmatched func(message string)) rcon.BroadcastHandler {
+	return func(message string) {
+		if e, ok := engine.Match(strings.TrimSpace(message)); ok {
+			handler(e)
+		} else if unmatched != nil {
+			unmatched(message)
+		}
+	}
+}
+
+// Events matches every line of a command response and returns the events of the lines which matched a rule.
+func Events(engine Engine, response string) []Event {
+	var events []Event
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if e, ok := engine.Match(line); ok {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
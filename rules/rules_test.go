@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("RuleSet", func() {
+		g.It("Should extract fields and apply the first matching rule's template", func() {
+			s, err := Load(strings.NewReader(`[
+				{"name": "chat", "pattern": "^Chat: (?P<name>[^,]+), (?P<message>.*)$", "channel": "chat",
+				 "template": "${name}: ${message}"},
+				{"name": "any", "pattern": ".*", "channel": "other"}
+			]`))
+			Expect(err).To(BeNil())
+
+			e, ok := s.Match("Chat: Alice, hello")
+			Expect(ok).To(BeTrue())
+			Expect(e.Rule).To(Equal("chat"))
+			Expect(e.Channel).To(Equal("chat"))
+			Expect(e.Message).To(Equal("Alice: hello"))
+			Expect(e.Fields).To(Equal(map[string]string{"name": "Alice", "message": "hello"}))
+
+			e, ok = s.Match("Login: Bob")
+			Expect(ok).To(BeTrue())
+			Expect(e.Channel).To(Equal("other"))
+			Expect(e.Message).To(Equal("Login: Bob"))
+		})
+
+		g.It("Should reject invalid patterns", func() {
+			_, err := Compile([]Rule{{Name: "broken", Pattern: "("}})
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	g.Describe("Events()", func() {
+		g.It("Should return the events of matching lines", func() {
+			s, err := Compile([]Rule{{Name: "player", Pattern: `^(?P<id>\d+)\. (?P<name>.+)$`}})
+			Expect(err).To(BeNil())
+
+			events := Events(NewReloadable(s), "0. Alice\n\nnot a player\n1. Bob\n")
+			Expect(events).To(HaveLen(2))
+			Expect(events[1].Fields["name"]).To(Equal("Bob"))
+		})
+	})
+}
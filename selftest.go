@@ -0,0 +1,48 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"sync/atomic"
+)
+
+// SelfTestFailureHandler is called when a self-test command fails.
+type SelfTestFailureHandler func(command string, err error)
+
+// Ready reports whether the client is connected and has passed its self-test.
+func (c *Client) Ready() bool {
+	return atomic.LoadInt32(&c.ready) == 1
+}
+
+func (c *Client) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+
+	atomic.StoreInt32(&c.ready, v)
+}
+
+// runSelfTest executes the configured self-test commands. The client is marked as ready if all of them succeed. On
+// the first failure the SelfTestFailureHandler is called and, if SelfTestDisconnect is set, the client is closed.
+func (c *Client) runSelfTest() error {
+	for _, command := range c.SelfTest {
+		if _, err := c.ExecCommand(command); err != nil {
+			c.log.Error("Self-test command ", command, " failed. Error: ", err)
+
+			if c.SelfTestFailureHandler != nil {
+				c.SelfTestFailureHandler(command, err)
+			}
+
+			if c.SelfTestDisconnect {
+				_ = c.Close()
+			}
+
+			return fmt.Errorf("command %q failed: %v: %w", command, err, errs.ErrSelfTestFailed)
+		}
+	}
+
+	c.setReady(true)
+
+	return nil
+}
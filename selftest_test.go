@@ -0,0 +1,125 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfTest(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("SelfTest", func() {
+		var failures chan string
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			failures = make(chan string, 4)
+			config = &rcon.Config{
+				SelfTest: []string{"status", "version"},
+				SelfTestFailureHandler: func(command string, _ error) {
+					failures <- command
+				},
+				ResponseErrorChecker: func(_, response string) bool {
+					return strings.HasPrefix(response, "Unknown command")
+				},
+			}
+		})
+
+		g.It("Should mark the client as ready once every command succeeded", func() {
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+			server.SetResponse("version", "1.0")
+
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Ready()).To(BeTrue())
+			Expect(server.Commands()).To(Equal([]string{"status", "version"}))
+			Expect(failures).NotTo(Receive())
+		})
+
+		g.It("Should stay connected but not ready if a command fails", func() {
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrSelfTestFailed)).To(BeTrue())
+			Expect(failures).To(Receive(Equal("version")))
+			Expect(client.Ready()).To(BeFalse())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+
+		g.It("Should close the client if a command fails with SelfTestDisconnect", func() {
+			config.SelfTestDisconnect = true
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrSelfTestFailed)).To(BeTrue())
+			Expect(failures).To(Receive(Equal("version")))
+			Expect(client.Ready()).To(BeFalse())
+			Expect(client.Status()).To(Equal(rcon.StateClosed))
+		})
+
+		g.It("Should still run the OnReconnect hook if the self-test fails after reconnecting", func() {
+			reconnected := make(chan struct{}, 1)
+			config.Reconnect = rcon.ReconnectConfig{
+				Enabled: true,
+				Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+				OnReconnect: func(*rcon.Client) error {
+					reconnected <- struct{}{}
+					return nil
+				},
+			}
+
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+			server.SetResponse("version", "1.0")
+			Expect(client.Connect()).To(BeNil())
+
+			server.SetResponse("version", "Unknown command: version")
+			server.DisconnectAll()
+
+			Eventually(failures).Should(Receive(Equal("version")))
+			Eventually(reconnected).Should(Receive())
+			Expect(client.Ready()).To(BeFalse())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should not run the OnReconnect hook once the self-test closed the client", func() {
+			config.SelfTestDisconnect = true
+			reconnected := make(chan struct{}, 1)
+			config.Reconnect = rcon.ReconnectConfig{
+				Enabled: true,
+				Backoff: rcon.ConstantBackoff(time.Millisecond * 10),
+				OnReconnect: func(*rcon.Client) error {
+					reconnected <- struct{}{}
+					return nil
+				},
+			}
+
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+			server.SetResponse("version", "1.0")
+			Expect(client.Connect()).To(BeNil())
+
+			server.SetResponse("version", "Unknown command: version")
+			server.DisconnectAll()
+
+			Eventually(failures).Should(Receive(Equal("version")))
+			Eventually(client.Status).Should(Equal(rcon.StateClosed))
+			Consistently(reconnected, time.Millisecond*100).ShouldNot(Receive())
+		})
+	})
+}
@@ -0,0 +1,151 @@
+// Package shutdown reports why a long-running program built on the client stopped, using exit codes and a final JSON
+// status line, so that orchestrators can tell configuration mistakes, rejected passwords, requested shutdowns and lost
+// connections apart without parsing log output:
+//
+//	client := rcon.NewClient(config, nil)
+//	if err := client.Connect(); err != nil {
+//		shutdown.Exit(shutdown.Classify(err), err)
+//	}
+//
+//	// Once the program is done, or the client's connection ended:
+//	shutdown.ExitClient(client)
+package shutdown
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"io"
+	"os"
+	"time"
+)
+
+// Reason is why a program stopped.
+type Reason int
+
+const (
+	// ReasonNone means the program completed normally.
+	ReasonNone Reason = iota
+
+	// ReasonConfig means the program's configuration was invalid. Restarting won't help until it is fixed.
+	ReasonConfig
+
+	// ReasonAuth means the server rejected the RCON password.
+	ReasonAuth
+
+	// ReasonSignal means the program was asked to stop, for example by SIGTERM.
+	ReasonSignal
+
+	// ReasonIO means the connection to the server failed and could not be recovered.
+	ReasonIO
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return "none"
+	case ReasonConfig:
+		return "config"
+	case ReasonAuth:
+		return "auth"
+	case ReasonSignal:
+		return "signal"
+	case ReasonIO:
+		return "io"
+	}
+
+	return "unknown"
+}
+
+// ExitCode returns the exit code for r. 2 matches the exit code of the flag package for invalid usage.
+func (r Reason) ExitCode() int {
+	switch r {
+	case ReasonNone:
+		return 0
+	case ReasonConfig:
+		return 2
+	case ReasonAuth:
+		return 3
+	case ReasonSignal:
+		return 4
+	}
+
+	return 1
+}
+
+// configError marks an error as a configuration error for Classify.
+type configError struct {
+	error
+}
+
+func (e configError) Cause() error {
+	return e.error
+}
+
+func (e configError) Unwrap() error {
+	return e.error
+}
+
+// ConfigError marks err as a configuration error, which Classify reports as ReasonConfig.
+func ConfigError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return configError{err}
+}
+
+// Classify returns the reason matching err. Authentication failures and bans are reported as ReasonAuth, errors marked
+// with ConfigError as ReasonConfig and all other errors as ReasonIO.
+func Classify(err error) Reason {
+	var config configError
+
+	switch {
+	case err == nil:
+		return ReasonNone
+	case errors.As(err, &config):
+		return ReasonConfig
+	case errors.Is(err, errs.ErrAuthentication), errors.Is(err, errs.ErrBanned):
+		return ReasonAuth
+	}
+
+	return ReasonIO
+}
+
+// Status is the final status line written by Report.
+type Status struct {
+	Reason string    `json:"reason"`
+	Code   int       `json:"code"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Report writes the status line for reason and err to w and returns the exit code for reason.
+func Report(w io.Writer, reason Reason, err error) int {
+	status := Status{
+		Reason: reason.String(),
+		Code:   reason.ExitCode(),
+		Time:   time.Now().UTC(),
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	_ = json.NewEncoder(w).Encode(status)
+
+	return status.Code
+}
+
+// Exit reports reason and err on stderr and exits with the matching exit code.
+func Exit(reason Reason, err error) {
+	os.Exit(Report(os.Stderr, reason, err))
+}
+
+// ExitClient exits with the reason the connection of client ended, as returned by its Err method. A client which was
+// closed using Close exits with ReasonNone.
+func ExitClient(client *rcon.Client) {
+	err := client.Err()
+	Exit(Classify(err), err)
+}
@@ -0,0 +1,125 @@
+package shutdown_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"github.com/refractorgscm/rcon/shutdown"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Classify", func() {
+		g.It("Should classify nil as ReasonNone", func() {
+			Expect(shutdown.Classify(nil)).To(Equal(shutdown.ReasonNone))
+		})
+
+		g.It("Should classify wrapped authentication failures as ReasonAuth", func() {
+			err := fmt.Errorf("could not connect: %w", errs.ErrAuthentication)
+			Expect(shutdown.Classify(err)).To(Equal(shutdown.ReasonAuth))
+		})
+
+		g.It("Should classify errors marked with ConfigError as ReasonConfig", func() {
+			Expect(shutdown.Classify(shutdown.ConfigError(errors.New("invalid port")))).To(Equal(shutdown.ReasonConfig))
+			Expect(shutdown.Classify(fmt.Errorf("parse: %w", shutdown.ConfigError(errors.New("invalid port"))))).
+				To(Equal(shutdown.ReasonConfig))
+			Expect(shutdown.ConfigError(nil)).To(BeNil())
+		})
+
+		g.It("Should classify all other errors as ReasonIO", func() {
+			Expect(shutdown.Classify(errors.New("connection reset"))).To(Equal(shutdown.ReasonIO))
+			Expect(shutdown.Classify(errs.ErrNotConnected)).To(Equal(shutdown.ReasonIO))
+		})
+	})
+
+	g.Describe("Reasons", func() {
+		g.It("Should map reasons to distinct exit codes", func() {
+			Expect(shutdown.ReasonNone.ExitCode()).To(Equal(0))
+			Expect(shutdown.ReasonIO.ExitCode()).To(Equal(1))
+			Expect(shutdown.ReasonConfig.ExitCode()).To(Equal(2))
+			Expect(shutdown.ReasonAuth.ExitCode()).To(Equal(3))
+			Expect(shutdown.ReasonSignal.ExitCode()).To(Equal(4))
+		})
+
+		g.It("Should report a JSON status line", func() {
+			buf := &bytes.Buffer{}
+			code := shutdown.Report(buf, shutdown.ReasonAuth, errs.ErrAuthentication)
+			Expect(code).To(Equal(3))
+
+			var status shutdown.Status
+			Expect(json.Unmarshal(buf.Bytes(), &status)).To(BeNil())
+			Expect(status.Reason).To(Equal("auth"))
+			Expect(status.Code).To(Equal(3))
+			Expect(status.Error).To(Equal("authentication failed"))
+			Expect(status.Time).NotTo(BeZero())
+		})
+
+		g.It("Should omit the error of a normal exit", func() {
+			buf := &bytes.Buffer{}
+			Expect(shutdown.Report(buf, shutdown.ReasonNone, nil)).To(Equal(0))
+			Expect(buf.String()).NotTo(ContainSubstring("error"))
+		})
+	})
+
+	g.Describe("Client errors", func() {
+		var server *rcontest.Server
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			host, port := server.Addr()
+
+			config = &rcon.Config{
+				Host:     host,
+				Port:     port,
+				Password: "password",
+			}
+		})
+
+		g.It("Should classify a rejected password as ReasonAuth", func() {
+			config.Password = "wrong"
+			client := rcon.NewClient(config, nil)
+
+			err := client.Connect()
+			Expect(shutdown.Classify(err)).To(Equal(shutdown.ReasonAuth))
+		})
+
+		g.It("Should keep the error which ended the connection in Err", func() {
+			disconnected := make(chan struct{}, 1)
+			config.DisconnectHandler = func(error, bool) {
+				disconnected <- struct{}{}
+			}
+
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Err()).To(BeNil())
+
+			server.DisconnectAll()
+			Eventually(disconnected, time.Second).Should(Receive())
+
+			Expect(client.Err()).NotTo(BeNil())
+			Expect(shutdown.Classify(client.Err())).To(Equal(shutdown.ReasonIO))
+		})
+
+		g.It("Should report a closed client as ReasonNone", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Close()).To(BeNil())
+
+			Expect(client.Err()).To(BeNil())
+			Expect(shutdown.Classify(client.Err())).To(Equal(shutdown.ReasonNone))
+		})
+	})
+}
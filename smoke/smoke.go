@@ -0,0 +1,194 @@
+// Package smoke runs an end-to-end smoke test against an RCON server: it connects, authenticates, executes an echo
+// command and optionally listens for broadcasts, then reports a structured result. It can be used as a deploy-time
+// health gate, and doubles as an example of how the library is used.
+package smoke
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/shutdown"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCommand is the default command executed by Run.
+const DefaultCommand = "echo smoke"
+
+// ErrUnexpectedResponse is returned if the response to the smoke test command did not contain the expected text.
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+// ErrNoBroadcast is returned if RequireBroadcast is set and no broadcast was received while listening.
+var ErrNoBroadcast = errors.New("no broadcast received")
+
+// Config configures a smoke test.
+type Config struct {
+	// Client is the configuration of the client under test. A copy is used, so the configuration can be reused. If it
+	// has a BroadcastHandler, it is still called.
+	Client *rcon.Config
+
+	// Logger is passed to the client under test.
+	//
+	// Default: nil (no logging)
+	Logger rcon.Logger
+
+	// Command is executed once the client is connected.
+	//
+	// Default: DefaultCommand
+	Command string
+
+	// Expect, if set, must be contained in the response to Command for the test to pass.
+	//
+	// Default: "" (any response passes)
+	Expect string
+
+	// ListenCommand, if set, is executed before listening for broadcasts, for example "listen chat" on Mordhau.
+	//
+	// Default: ""
+	ListenCommand string
+
+	// BroadcastWait is how long to listen for broadcasts after Command was executed.
+	//
+	// Default: 0 (don't listen)
+	BroadcastWait time.Duration
+
+	// RequireBroadcast makes the test fail if no broadcast was received within BroadcastWait.
+	//
+	// Default: false
+	RequireBroadcast bool
+}
+
+// Stage is a step of the smoke test.
+type Stage string
+
+const (
+	StageConnect    Stage = "connect"
+	StageAuth       Stage = "auth"
+	StageCommand    Stage = "command"
+	StageBroadcasts Stage = "broadcasts"
+	StageDone       Stage = "done"
+)
+
+// Result is the outcome of a smoke test.
+type Result struct {
+	// OK is true if every stage passed.
+	OK bool `json:"ok"`
+
+	// Stage is the stage the test failed in, or StageDone if it passed.
+	Stage Stage `json:"stage"`
+
+	// Err is the error which failed the test.
+	Err error `json:"-"`
+
+	// Error is the message of Err, for JSON reports.
+	Error string `json:"error,omitempty"`
+
+	ConnectTime time.Duration `json:"connectTime"`
+	CommandTime time.Duration `json:"commandTime"`
+	Response    string        `json:"response"`
+	Broadcasts  []string      `json:"broadcasts,omitempty"`
+}
+
+// WriteJSON writes r to w as a single line of JSON.
+func (r *Result) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+func (r *Result) fail(stage Stage, err error) *Result {
+	r.Stage = stage
+	r.Err = err
+	r.Error = err.Error()
+
+	return r
+}
+
+// Run runs a smoke test as configured by config. Failures are reported in the result rather than returned.
+func Run(config Config) *Result {
+	result := &Result{}
+
+	if config.Client == nil {
+		return result.fail(StageConnect, shutdown.ConfigError(errors.New("no client configuration")))
+	}
+
+	if config.Command == "" {
+		config.Command = DefaultCommand
+	}
+
+	// Broadcasts are collected separately and only copied into the result once the test ended, since the handler may
+	// still be called while the client is closing.
+	var broadcastsLock sync.Mutex
+	var broadcasts []string
+	done := false
+
+	defer func() {
+		broadcastsLock.Lock()
+		result.Broadcasts = broadcasts
+		done = true
+		broadcastsLock.Unlock()
+	}()
+
+	clientConfig := *config.Client
+	handler := clientConfig.BroadcastHandler
+	clientConfig.BroadcastHandler = func(message string) {
+		broadcastsLock.Lock()
+		if !done {
+			broadcasts = append(broadcasts, message)
+		}
+		broadcastsLock.Unlock()
+
+		if handler != nil {
+			handler(message)
+		}
+	}
+
+	client := rcon.NewClient(&clientConfig, config.Logger)
+
+	start := time.Now()
+	if err := client.Connect(); err != nil {
+		if shutdown.Classify(err) == shutdown.ReasonAuth {
+			return result.fail(StageAuth, err)
+		}
+
+		return result.fail(StageConnect, err)
+	}
+	defer client.Close()
+	result.ConnectTime = time.Since(start)
+
+	start = time.Now()
+	res, err := client.ExecCommand(config.Command)
+	result.CommandTime = time.Since(start)
+	result.Response = res
+	if err != nil {
+		return result.fail(StageCommand, err)
+	}
+
+	if !strings.Contains(res, config.Expect) {
+		return result.fail(StageCommand, fmt.Errorf("expected %q in %q: %w", config.Expect, res, ErrUnexpectedResponse))
+	}
+
+	if config.BroadcastWait > 0 {
+		if config.ListenCommand != "" {
+			if _, err := client.ExecCommand(config.ListenCommand); err != nil {
+				return result.fail(StageBroadcasts, err)
+			}
+		}
+
+		time.Sleep(config.BroadcastWait)
+
+		broadcastsLock.Lock()
+		received := len(broadcasts)
+		broadcastsLock.Unlock()
+
+		if config.RequireBroadcast && received == 0 {
+			return result.fail(StageBroadcasts, fmt.Errorf("listened for %s: %w", config.BroadcastWait, ErrNoBroadcast))
+		}
+	}
+
+	result.OK = true
+	result.Stage = StageDone
+
+	return result
+}
@@ -0,0 +1,51 @@
+package smoke
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Run()", func() {
+		g.It("Should pass against a server answering the command", func() {
+			s := rcontest.StartServer(t, "password")
+			s.SetResponse(DefaultCommand, "smoke")
+			host, port := s.Addr()
+
+			result := Run(Config{
+				Client:        &rcon.Config{Host: host, Port: port, Password: "password"},
+				Expect:        "smoke",
+				BroadcastWait: 10 * time.Millisecond,
+			})
+			Expect(result.Err).To(BeNil())
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Stage).To(Equal(StageDone))
+			Expect(result.Response).To(Equal("smoke"))
+		})
+
+		g.It("Should report the stage a test failed in", func() {
+			s := rcontest.StartServer(t, "password")
+			host, port := s.Addr()
+
+			result := Run(Config{Client: &rcon.Config{Host: host, Port: port, Password: "wrong"}})
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Stage).To(Equal(StageAuth))
+
+			result = Run(Config{
+				Client:           &rcon.Config{Host: host, Port: port, Password: "password"},
+				BroadcastWait:    10 * time.Millisecond,
+				RequireBroadcast: true,
+			})
+			Expect(result.Stage).To(Equal(StageBroadcasts))
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package rcon
+
+import (
+	"context"
+	"github.com/refractorgscm/rcon/errs"
+	"strings"
+)
+
+// splitCommand splits command at the ";" separators outside of double quotes into as few parts as possible which each
+// pass checkCommandSize. It returns false if SplitCommands is disabled or command can't be split into such parts.
+func (c *Client) splitCommand(command string) ([]string, bool) {
+	if !c.SplitCommands {
+		return nil, false
+	}
+
+	var segments []string
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(command); i++ {
+		switch command[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				segments = append(segments, command[start:i])
+				start = i + 1
+			}
+		}
+	}
+	segments = append(segments, command[start:])
+
+	if len(segments) < 2 {
+		return nil, false
+	}
+
+	var parts []string
+	current := ""
+
+	for i, segment := range segments {
+		if i > 0 {
+			if joined := current + ";" + segment; c.checkCommandSize(joined) == nil {
+				current = joined
+				continue
+			}
+
+			parts = append(parts, current)
+		}
+
+		if c.checkCommandSize(segment) != nil {
+			return nil, false
+		}
+
+		current = segment
+	}
+
+	return append(parts, current), true
+}
+
+// execSplit executes the parts command was split into by splitCommand one after another and joins their responses
+// with newlines.
+func (c *Client) execSplit(ctx context.Context, command string, parts []string) (string, error) {
+	c.log.Debug("Splitting oversized command into ", len(parts), " parts")
+
+	responses := make([]string, 0, len(parts))
+
+	for i, part := range parts {
+		res, err := c.execCommand(ctx, part)
+		if err != nil {
+			return "", &errs.PartialCommandError{Command: command, Parts: len(parts), Executed: i, Err: err}
+		}
+
+		responses = append(responses, res)
+	}
+
+	return strings.Join(responses, "\n"), nil
+}
@@ -0,0 +1,153 @@
+package rcon
+
+import (
+	"sync/atomic"
+)
+
+// State is the connection state of a client.
+type State int32
+
+const (
+	// StateDisconnected clients have not connected yet, or lost their connection and are not reconnecting.
+	StateDisconnected State = iota
+
+	// StateConnected clients are connected and authenticated.
+	StateConnected
+
+	// StateClosing clients are being closed. New commands are rejected.
+	StateClosing
+
+	// StateClosed clients were closed using Close. They can be connected again.
+	StateClosed
+
+	// StateConnecting clients are opening their connection.
+	StateConnecting
+
+	// StateAuthenticating clients are connected and waiting for the server to accept their password.
+	StateAuthenticating
+
+	// StateReconnecting clients lost their connection and are trying to reconnect.
+	StateReconnecting
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnected:
+		return "connected"
+	case StateClosing:
+		return "closing"
+	case StateClosed:
+		return "closed"
+	case StateConnecting:
+		return "connecting"
+	case StateAuthenticating:
+		return "authenticating"
+	case StateReconnecting:
+		return "reconnecting"
+	}
+
+	return "unknown"
+}
+
+// Err returns the error which ended the client's last connection, after reconnecting failed if enabled. It is the
+// error passed to the DisconnectHandler. Err returns nil while the client is connected or reconnecting, if it never
+// connected and after it was closed using Close.
+func (c *Client) Err() error {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
+
+	return c.err
+}
+
+// StatusChangeHandler is called with the previous and the new state whenever the state of a client changes. It is
+// called synchronously by the goroutine which changed the state, so it should return quickly.
+type StatusChangeHandler func(old, new State)
+
+// Status returns the connection state of the client.
+func (c *Client) Status() State {
+	return State(atomic.LoadInt32(&c.state))
+}
+
+func (c *Client) setState(s State) {
+	old := State(atomic.SwapInt32(&c.state, int32(s)))
+	c.stateChanged(old, s)
+}
+
+// transition moves the client from one of the states in from to to. It returns false if the client was in none of
+// them.
+func (c *Client) transition(to State, from ...State) bool {
+	for {
+		s := c.Status()
+
+		valid := false
+		for _, f := range from {
+			if s == f {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return false
+		}
+
+		if atomic.CompareAndSwapInt32(&c.state, int32(s), int32(to)) {
+			c.stateChanged(s, to)
+			return true
+		}
+	}
+}
+
+func (c *Client) stateChanged(old, new State) {
+	if old == new {
+		return
+	}
+
+	c.log.Debug("Status changed from ", old, " to ", new)
+
+	if c.StatusChangeHandler != nil {
+		c.StatusChangeHandler(old, new)
+	}
+}
+
+// markConnected moves the client to StateConnected and arms the DisconnectHandler for the new connection.
+func (c *Client) markConnected() {
+	c.errLock.Lock()
+	c.err = nil
+	c.errLock.Unlock()
+
+	atomic.StoreInt32(&c.disconnectNotified, 0)
+	c.setState(StateConnected)
+}
+
+// beginClose moves the client to StateClosing. It returns false if the client is already closing or closed.
+func (c *Client) beginClose() bool {
+	return c.transition(StateClosing, StateDisconnected, StateConnected, StateConnecting, StateAuthenticating,
+		StateReconnecting)
+}
+
+// notifyDisconnect calls the DisconnectHandler. It is called at most once per connection, however many paths detect
+// the disconnect. While the client is closing, every disconnect is reported as expected.
+func (c *Client) notifyDisconnect(err error) {
+	c.setReady(false)
+
+	if !atomic.CompareAndSwapInt32(&c.disconnectNotified, 0, 1) {
+		return
+	}
+
+	if c.Status() == StateClosing {
+		err = nil
+	} else {
+		c.transition(StateDisconnected, StateConnected, StateReconnecting)
+	}
+
+	c.errLock.Lock()
+	c.err = err
+	c.errLock.Unlock()
+
+	if c.DisconnectHandler != nil {
+		c.DisconnectHandler(err, err == nil)
+	}
+}
@@ -0,0 +1,140 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestState(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("State", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+		var disconnects chan error
+
+		var lock sync.Mutex
+		var states []rcon.State
+
+		recorded := func() []rcon.State {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return append([]rcon.State(nil), states...)
+		}
+
+		g.BeforeEach(func() {
+			lock.Lock()
+			states = nil
+			lock.Unlock()
+
+			server = rcontest.StartServer(t, "password")
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			disconnects = make(chan error, 4)
+			ch := disconnects
+
+			client = rcon.NewClient(&rcon.Config{
+				Host:              host,
+				Port:              port,
+				Password:          "password",
+				QueueReadTimeout:  time.Millisecond * 200,
+				DisconnectHandler: func(err error, _ bool) { ch <- err },
+				StatusChangeHandler: func(_, new rcon.State) {
+					lock.Lock()
+					states = append(states, new)
+					lock.Unlock()
+				},
+			}, nil)
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		g.It("Should move through the connection states", func() {
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+
+			Expect(client.Close()).To(BeNil())
+			Expect(client.Status()).To(Equal(rcon.StateClosed))
+
+			Expect(recorded()).To(Equal([]rcon.State{
+				rcon.StateConnecting,
+				rcon.StateAuthenticating,
+				rcon.StateConnected,
+				rcon.StateClosing,
+				rcon.StateClosed,
+			}))
+		})
+
+		g.It("Should be safe to close more than once", func() {
+			Expect(client.Connect()).To(BeNil())
+
+			var wg sync.WaitGroup
+			results := make(chan error, 4)
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					results <- client.Close()
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			succeeded := 0
+			for err := range results {
+				if err == nil {
+					succeeded++
+				} else {
+					Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+				}
+			}
+			Expect(succeeded).To(Equal(1))
+
+			Expect(errors.Is(client.Close(), errs.ErrNotConnected)).To(BeTrue())
+			Eventually(disconnects).Should(Receive(BeNil()))
+			Consistently(disconnects, time.Millisecond*100).ShouldNot(Receive())
+		})
+
+		g.It("Should report a lost connection once", func() {
+			Expect(client.Connect()).To(BeNil())
+
+			server.DisconnectAll()
+
+			Eventually(disconnects).Should(Receive(Not(BeNil())))
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+
+			// Closing a client whose connection was already lost doesn't report the disconnect again.
+			_ = client.Close()
+			Consistently(disconnects, time.Millisecond*100).ShouldNot(Receive())
+		})
+
+		g.It("Should connect again after being closed", func() {
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Close()).To(BeNil())
+
+			Expect(client.Connect()).To(BeNil())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+	})
+}
@@ -0,0 +1,222 @@
+package rcon
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentCommandsSize is the number of recently completed commands Stats picks the slowest commands from.
+const recentCommandsSize = 128
+
+// DefaultSlowCommandCount is the default number of slowest recent commands reported by Stats.
+const DefaultSlowCommandCount = 10
+
+// CommandRedactor rewrites command text before it is logged or recorded in statistics, for example to remove player
+// names or passwords from arguments.
+type CommandRedactor func(command string) string
+
+// CommandTiming is the duration of a completed command.
+type CommandTiming struct {
+	// Command is the command text, passed through the configured CommandRedactor.
+	Command  string
+	Duration time.Duration
+	At       time.Time
+	Err      bool
+
+	// Actor is the actor the command was executed on behalf of. See WithActor.
+	Actor string
+
+	// IdempotencyKey is the key the command was executed with by ExecCommandIdempotent.
+	IdempotencyKey string
+
+	// Labels are the Labels of the client which executed the command. The map is shared and must not be modified.
+	Labels map[string]string
+}
+
+// Stats are command statistics.
+type Stats struct {
+	// InFlight is the number of commands currently being executed.
+	InFlight int64
+
+	// PeakInFlight is the highest number of commands executed at the same time.
+	PeakInFlight int64
+
+	// Commands is the number of completed commands.
+	Commands uint64
+
+	// SlowCommands is the number of commands which took longer than SlowCommandThreshold.
+	SlowCommands uint64
+
+	// LateResponses is the number of response packets which arrived after their command was cancelled or timed out.
+	LateResponses uint64
+
+	// ExpiredMailboxes is the number of mailboxes removed because their response was not collected within MailboxTTL.
+	ExpiredMailboxes uint64
+
+	// Slowest are the slowest recently completed commands, slowest first. Only available for single clients.
+	Slowest []CommandTiming
+
+	// Labels are the Labels of the client. The map is shared and must not be modified. Only available for single
+	// clients.
+	Labels map[string]string
+}
+
+// commandStats holds the counters behind Stats.
+type commandStats struct {
+	inFlight      int64
+	peakInFlight  int64
+	commands      uint64
+	slowCommands  uint64
+	lateResponses uint64
+	expired       uint64
+}
+
+func (s *commandStats) begin() {
+	n := atomic.AddInt64(&s.inFlight, 1)
+
+	for {
+		peak := atomic.LoadInt64(&s.peakInFlight)
+		if n <= peak || atomic.CompareAndSwapInt64(&s.peakInFlight, peak, n) {
+			return
+		}
+	}
+}
+
+func (s *commandStats) end(slow bool) {
+	atomic.AddInt64(&s.inFlight, -1)
+	atomic.AddUint64(&s.commands, 1)
+
+	if slow {
+		atomic.AddUint64(&s.slowCommands, 1)
+	}
+}
+
+func (s *commandStats) lateResponse() {
+	atomic.AddUint64(&s.lateResponses, 1)
+}
+
+func (s *commandStats) expiredMailboxes(n int) {
+	atomic.AddUint64(&s.expired, uint64(n))
+}
+
+func (s *commandStats) snapshot() Stats {
+	return Stats{
+		InFlight:         atomic.LoadInt64(&s.inFlight),
+		PeakInFlight:     atomic.LoadInt64(&s.peakInFlight),
+		Commands:         atomic.LoadUint64(&s.commands),
+		SlowCommands:     atomic.LoadUint64(&s.slowCommands),
+		LateResponses:    atomic.LoadUint64(&s.lateResponses),
+		ExpiredMailboxes: atomic.LoadUint64(&s.expired),
+	}
+}
+
+// globalStats aggregates the statistics of every client in the process.
+var globalStats commandStats
+
+// GlobalStats returns the command statistics of all clients in the process combined.
+func GlobalStats() Stats {
+	return globalStats.snapshot()
+}
+
+// recentCommands is a ring buffer of recently completed commands.
+type recentCommands struct {
+	lock    sync.Mutex
+	timings []CommandTiming
+	next    int
+}
+
+func (r *recentCommands) add(t CommandTiming) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.timings) < recentCommandsSize {
+		r.timings = append(r.timings, t)
+		return
+	}
+
+	r.timings[r.next] = t
+	r.next = (r.next + 1) % recentCommandsSize
+}
+
+func (r *recentCommands) slowest(n int) []CommandTiming {
+	r.lock.Lock()
+	sorted := make([]CommandTiming, len(r.timings))
+	copy(sorted, r.timings)
+	r.lock.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	return sorted
+}
+
+// Stats returns the command statistics of this client, including its slowest recent commands.
+func (c *Client) Stats() Stats {
+	stats := c.stats.snapshot()
+	stats.Slowest = c.recentCommands.slowest(c.SlowCommandCount)
+	stats.Labels = c.labels
+
+	return stats
+}
+
+// redact applies the configured CommandRedactor to command.
+func (c *Client) redact(command string) string {
+	if c.CommandRedactor == nil {
+		return command
+	}
+
+	return c.CommandRedactor(command)
+}
+
+// trackCommand records the start of command. The returned function must be called with the command's error once it
+// completed. response is false for commands which don't wait for a response.
+func (c *Client) trackCommand(ctx context.Context, command string, response bool) func(err error) {
+	start := time.Now()
+	at := c.Clock()
+	actor, _ := ActorFromContext(ctx)
+	key, _ := IdempotencyKeyFromContext(ctx)
+
+	c.stats.begin()
+	globalStats.begin()
+	c.metrics().CommandSent()
+
+	return func(err error) {
+		d := time.Since(start)
+		slow := c.SlowCommandThreshold > 0 && d > c.SlowCommandThreshold
+
+		if response && (err == nil || answered(err)) {
+			c.metrics().ResponseReceived(d)
+		}
+
+		c.stats.end(slow)
+		globalStats.end(slow)
+
+		redacted := c.redact(command)
+
+		c.recentCommands.add(CommandTiming{
+			Command:        redacted,
+			Duration:       d,
+			At:             at,
+			Err:            err != nil,
+			Actor:          actor,
+			IdempotencyKey: key,
+			Labels:         c.labels,
+		})
+
+		if slow {
+			if actor != "" {
+				c.log.Info("Slow command (", d, ") by ", actor, ": ", redacted)
+			} else {
+				c.log.Info("Slow command (", d, "): ", redacted)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package rcon_test
+
+import (
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowCommands(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("SlowCommandThreshold", func() {
+		var client *rcon.Client
+		var logger *recordingLogger
+
+		g.BeforeEach(func() {
+			config := &rcon.Config{SlowCommandThreshold: time.Millisecond * 50}
+			server := newTestServer(t, config)
+			server.Handle("slow", func(string) string {
+				time.Sleep(time.Millisecond * 100)
+				return "done"
+			})
+
+			logger = &recordingLogger{}
+			client = rcon.NewClient(config, logger)
+			t.Cleanup(func() { _ = client.Close() })
+
+			Expect(client.Connect()).To(BeNil())
+		})
+
+		// slowEntries returns the slow command entries logged so far.
+		slowEntries := func() []string {
+			var entries []string
+			for _, entry := range logger.Entries() {
+				if strings.HasPrefix(entry, "INFO: Slow command") {
+					entries = append(entries, entry)
+				}
+			}
+
+			return entries
+		}
+
+		g.It("Should report commands which take longer than the threshold", func() {
+			_, err := client.ExecCommand("slow")
+			Expect(err).To(BeNil())
+
+			stats := client.Stats()
+			Expect(stats.Commands).To(Equal(uint64(1)))
+			Expect(stats.SlowCommands).To(Equal(uint64(1)))
+
+			entries := slowEntries()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0]).To(HavePrefix("INFO: Slow command ("))
+			Expect(entries[0]).To(HaveSuffix("): slow"))
+		})
+
+		g.It("Should not report commands which are faster than the threshold", func() {
+			_, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+
+			stats := client.Stats()
+			Expect(stats.Commands).To(Equal(uint64(1)))
+			Expect(stats.SlowCommands).To(BeZero())
+			Expect(slowEntries()).To(BeEmpty())
+		})
+
+		g.It("Should name the actor of slow commands", func() {
+			_, err := client.ExecCommandContext(rcon.WithActor(context.Background(), "alice"), "slow")
+			Expect(err).To(BeNil())
+
+			entries := slowEntries()
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0]).To(HaveSuffix(") by alice: slow"))
+		})
+	})
+}
@@ -0,0 +1,117 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"sync"
+)
+
+// streamMailboxSize is the number of packets buffered for a stream whose consumer is falling behind. Packets arriving
+// while the buffer is full are dropped.
+const streamMailboxSize = 256
+
+// Stream sends command and returns a channel receiving the body of every packet the server answers it with, for
+// commands which produce continuous output such as console tailing. Unlike ExecCommand, the command's mailbox is kept
+// open until the returned cancel func is called, which closes the channel. The channel is also closed if the mailbox
+// is closed by the client, for example because the packet ID was restricted.
+//
+// Only packets carrying the command's packet ID are streamed, including follow-up packets sent with that ID after the
+// first response. Output the server sends with other IDs, such as broadcasts, is not part of the stream and is routed
+// like any other packet.
+//
+// Streams are exempt from MailboxTTL. Packets arriving after cancel was called within LateResponseGrace are counted as
+// late responses. Streaming is only supported over Source RCON connections without ConnectionPerCommand.
+//
+// The command passes through the middleware added with Use, which is handed an empty response. If the middleware does
+// not call the next ExecFunc, no stream is started and an error is returned.
+func (c *Client) Stream(command string) (<-chan string, func(), error) {
+	if c.Transport != nil || c.Protocol == ProtocolBattlEye || c.ConnectionPerCommand {
+		return nil, nil, errors.New("streaming is not supported by this connection mode")
+	}
+
+	var p packet.Packet
+	var mailbox chan packet.Packet
+
+	_, err := c.wrap(func(ctx context.Context, command string) (string, error) {
+		// Middleware calling next more than once starts the stream again.
+		if p != nil {
+			c.abandonMailbox(p.ID())
+			p, mailbox = nil, nil
+		}
+
+		if err := c.checkCommandSize(command); err != nil {
+			return "", err
+		}
+
+		if err := c.checkClosing(); err != nil {
+			return "", err
+		}
+
+		sp := c.newClientPacket(packet.TypeCommand, command)
+
+		c.log.Debug("Streaming command: ", command)
+
+		if err := c.enqueuePacket(ctx, sp, streamMailboxSize); err != nil {
+			return "", fmt.Errorf("could not enqueue command packet: %w", err)
+		}
+
+		mb := c.mailboxes.keep(sp.ID())
+		if mb == nil {
+			return "", fmt.Errorf("mailbox was closed before the stream started: %w", errs.ErrMailboxClosed)
+		}
+
+		p, mailbox = sp, mb
+
+		return "", nil
+	})(context.Background(), command)
+	if err != nil || p == nil {
+		if p != nil {
+			c.abandonMailbox(p.ID())
+		}
+
+		if err == nil {
+			err = errors.New("middleware did not start the stream")
+		}
+
+		return nil, nil, err
+	}
+
+	out := make(chan string)
+	stop := make(chan struct{})
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(stop)
+			c.abandonMailbox(p.ID())
+		})
+	}
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case res, ok := <-mailbox:
+				if !ok {
+					return
+				}
+
+				body := res.Body()
+
+				select {
+				case out <- string(body[:len(body)-1]):
+				case <-stop:
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return out, cancel, nil
+}
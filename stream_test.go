@@ -0,0 +1,115 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/packet"
+	"github.com/refractorgscm/rcon/rcontest"
+	"testing"
+	"time"
+)
+
+// stubTransport is a CommandTransport which is never used.
+type stubTransport struct {
+	rcon.CommandTransport
+}
+
+func TestStream(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Stream()", func() {
+		var server *rcontest.Server
+		var client *rcon.Client
+		var ids chan int32
+
+		// start connects a client created from config and starts streaming "tail". It returns the stream and the ID of
+		// the command's packet.
+		start := func(config *rcon.Config) (<-chan string, func(), int32) {
+			ids = make(chan int32, 1)
+			config.PacketHooks.OnSend = func(p packet.Packet, _ []byte) {
+				if p.Type() == packet.TypeCommand {
+					ids <- p.ID()
+				}
+			}
+
+			server, client = newTestClient(t, config)
+			server.Handle("tail", func(string) string { return "line 1" })
+			Expect(client.Connect()).To(BeNil())
+
+			out, cancel, err := client.Stream("tail")
+			Expect(err).To(BeNil())
+
+			var id int32
+			Eventually(ids).Should(Receive(&id))
+
+			return out, cancel, id
+		}
+
+		g.It("Should deliver every packet answering the command in order", func() {
+			out, cancel, id := start(&rcon.Config{})
+			defer cancel()
+
+			Eventually(out).Should(Receive(Equal("line 1")))
+
+			server.Send(id, packet.TypeCommandRes, "line 2")
+			server.Send(id, packet.TypeCommandRes, "line 3")
+			server.Send(id, packet.TypeCommandRes, "line 4")
+
+			for _, want := range []string{"line 2", "line 3", "line 4"} {
+				var line string
+				Eventually(out).Should(Receive(&line))
+				Expect(line).To(Equal(want))
+			}
+		})
+
+		g.It("Should close the channel and free the mailbox when cancelled", func() {
+			out, cancel, id := start(&rcon.Config{LateResponseGrace: time.Second * 2})
+			Eventually(out).Should(Receive(Equal("line 1")))
+
+			cancel()
+			Eventually(out).Should(BeClosed())
+
+			server.Send(id, packet.TypeCommandRes, "line 2")
+			Eventually(func() uint64 { return client.Stats().LateResponses }).Should(BeEquivalentTo(1))
+		})
+
+		g.It("Should be safe to cancel twice", func() {
+			out, cancel, _ := start(&rcon.Config{})
+
+			cancel()
+			cancel()
+			Eventually(out).Should(BeClosed())
+		})
+
+		g.It("Should keep its mailbox open past MailboxTTL", func() {
+			out, cancel, id := start(&rcon.Config{MailboxTTL: time.Millisecond * 50})
+			defer cancel()
+
+			Eventually(out).Should(Receive(Equal("line 1")))
+			time.Sleep(time.Millisecond * 300)
+
+			server.Send(id, packet.TypeCommandRes, "line 2")
+			Eventually(out).Should(Receive(Equal("line 2")))
+			Expect(client.Stats().ExpiredMailboxes).To(BeZero())
+		})
+
+		g.It("Should not be supported by other connection modes", func() {
+			configs := []*rcon.Config{
+				{Transport: stubTransport{}},
+				{Protocol: rcon.ProtocolBattlEye},
+				{ConnectionPerCommand: true},
+			}
+
+			for _, config := range configs {
+				_, cancel, err := rcon.NewClient(config, nil).Stream("tail")
+				Expect(cancel).To(BeNil())
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(ContainSubstring("not supported"))
+			}
+		})
+	})
+}
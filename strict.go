@@ -0,0 +1,29 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/packet"
+)
+
+// ProtocolViolationHandler is called with every protocol violation detected in Strict mode. The error wraps
+// packet.ErrProtocolViolation.
+type ProtocolViolationHandler func(err error)
+
+// protocolViolation reports a protocol violation detected in Strict mode.
+func (c *Client) protocolViolation(err error) {
+	c.log.Error("Protocol violation: ", err)
+
+	if c.ProtocolViolationHandler != nil {
+		c.ProtocolViolationHandler(err)
+	}
+}
+
+// unexpectedPacket reports a packet which could not be delivered to a mailbox to the UnhandledPacketHandler and, in
+// Strict mode, as a protocol violation.
+func (c *Client) unexpectedPacket(p packet.Packet, reason string) {
+	c.unhandledPacket(p, reason)
+
+	if c.Strict {
+		c.protocolViolation(fmt.Errorf("packet %d %s: %w", p.ID(), reason, packet.ErrProtocolViolation))
+	}
+}
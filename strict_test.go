@@ -0,0 +1,163 @@
+package rcon_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/endian"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/packet"
+	"testing"
+	"time"
+)
+
+// unterminatedPacket returns a response packet whose body is followed by a single null byte and a stray character
+// instead of two null bytes.
+func unterminatedPacket(id int32, body string) []byte {
+	raw := make([]byte, 12, 12+len(body)+2)
+	binary.LittleEndian.PutUint32(raw[0:], uint32(4+4+len(body)+2))
+	binary.LittleEndian.PutUint32(raw[4:], uint32(id))
+	binary.LittleEndian.PutUint32(raw[8:], uint32(packet.TypeCommandRes))
+
+	return append(append(raw, body...), '\x00', 'x')
+}
+
+// unterminatedCodec is a Source codec which encodes every packet like unterminatedPacket.
+type unterminatedCodec struct {
+	*packet.SourceCodec
+}
+
+func (c unterminatedCodec) Encode(p packet.Packet) ([]byte, error) {
+	raw, err := c.SourceCodec.Encode(p)
+	if err != nil {
+		return nil, err
+	}
+
+	raw[len(raw)-1] = 'x'
+
+	return raw, nil
+}
+
+func TestStrict(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Strict", func() {
+		var violations chan error
+		var disconnects chan error
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			violations = make(chan error, 4)
+			disconnects = make(chan error, 1)
+
+			config = &rcon.Config{
+				QueueReadTimeout:         time.Second,
+				ProtocolViolationHandler: func(err error) { violations <- err },
+				DisconnectHandler: func(err error, expected bool) {
+					if !expected {
+						disconnects <- err
+					}
+				},
+			}
+		})
+
+		g.It("Should disconnect on a malformed packet", func() {
+			config.Strict = true
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+			server.GarbageOn("status", unterminatedPacket(99, "noise"))
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("status")
+			Expect(err).ToNot(BeNil())
+
+			var violation error
+			Eventually(violations).Should(Receive(&violation))
+			Expect(errors.Is(violation, packet.ErrProtocolViolation)).To(BeTrue())
+
+			var disconnectErr error
+			Eventually(disconnects).Should(Receive(&disconnectErr))
+			Expect(errors.Is(disconnectErr, packet.ErrProtocolViolation)).To(BeTrue())
+			Expect(client.Status()).To(Equal(rcon.StateDisconnected))
+		})
+
+		g.It("Should tolerate a malformed packet otherwise", func() {
+			server, client := newTestClient(t, config)
+			server.SetResponse("status", "ok")
+			server.GarbageOn("status", unterminatedPacket(99, "noise"))
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+
+			Consistently(violations).ShouldNot(Receive())
+			Expect(disconnects).ToNot(Receive())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should disconnect on a packet of an unknown type", func() {
+			config.Strict = true
+			server, client := newTestClient(t, config)
+
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(7, packet.PacketType(5), "telemetry")
+
+			var violation error
+			Eventually(violations).Should(Receive(&violation))
+			Expect(violation.Error()).To(ContainSubstring("unknown type 5"))
+			Eventually(disconnects).Should(Receive())
+		})
+
+		g.It("Should not treat packets of a type with a handler as violations", func() {
+			config.Strict = true
+			handled := make(chan packet.Packet, 1)
+			config.PacketHandlers = map[packet.PacketType]rcon.PacketHandler{
+				5: func(p packet.Packet) { handled <- p },
+			}
+			server, client := newTestClient(t, config)
+
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(7, packet.PacketType(5), "telemetry")
+
+			Eventually(handled).Should(Receive())
+			Consistently(violations).ShouldNot(Receive())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should only report packets which don't belong to a pending command", func() {
+			config.Strict = true
+			server, client := newTestClient(t, config)
+
+			Expect(client.Connect()).To(BeNil())
+
+			server.Send(99, packet.TypeCommandRes, "stray")
+
+			var violation error
+			Eventually(violations).Should(Receive(&violation))
+			Expect(errors.Is(violation, packet.ErrProtocolViolation)).To(BeTrue())
+
+			Consistently(disconnects).ShouldNot(Receive())
+			Expect(client.Status()).To(Equal(rcon.StateConnected))
+		})
+
+		g.It("Should return violations during authentication as the error", func() {
+			config.Strict = true
+			server, client := newTestClient(t, config)
+			server.SetCodec(unterminatedCodec{packet.NewSourceCodec(endian.Little)})
+
+			err := client.Connect()
+			Expect(errors.Is(err, packet.ErrProtocolViolation)).To(BeTrue())
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,229 @@
+// Package table parses tabular command responses, such as player lists and status output, into rows. Responses can
+// be separated by runs of whitespace, tabs or any other delimiter, optionally with a header line naming the columns.
+package table
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrColumnCount is returned when a row has more columns than the schema allows.
+var ErrColumnCount = errors.New("unexpected column count")
+
+type Schema struct {
+	// Columns names the columns in order. If empty, the first line after SkipLines is used as the header.
+	Columns []string
+
+	// Separator separates columns. Delimited rows are parsed like CSV, so fields may be quoted. If zero, columns are
+	// separated by runs of whitespace, and the last column takes the remainder of the line so that it may contain
+	// spaces, for example a player name at the end of a row.
+	Separator rune
+
+	// SkipLines is the number of leading lines to skip, for example a title above the table.
+	SkipLines int
+}
+
+// Parse parses response into one map per row, keyed by column name. Empty lines are ignored. Rows with fewer columns
+// than the schema get empty values for the missing columns.
+func Parse(response string, schema Schema) ([]map[string]string, error) {
+	lines := strings.Split(strings.ReplaceAll(response, "\r\n", "\n"), "\n")
+	if schema.SkipLines > len(lines) {
+		return nil, nil
+	}
+	lines = lines[schema.SkipLines:]
+
+	columns := schema.Columns
+	var rows []map[string]string
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		limit := len(columns)
+		if columns == nil {
+			limit = -1
+		}
+
+		fields, err := split(line, schema.Separator, limit)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", schema.SkipLines+i+1, err)
+		}
+
+		if columns == nil {
+			columns = fields
+			continue
+		}
+
+		if len(fields) > len(columns) {
+			return nil, fmt.Errorf("line %d has %d columns, expected %d: %w", schema.SkipLines+i+1,
+				len(fields), len(columns), ErrColumnCount)
+		}
+
+		row := make(map[string]string, len(columns))
+		for j, column := range columns {
+			if j < len(fields) {
+				row[column] = fields[j]
+			} else {
+				row[column] = ""
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// split splits line into at most limit fields. A negative limit means no limit.
+func split(line string, separator rune, limit int) ([]string, error) {
+	if separator != 0 {
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = separator
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		r.TrimLeadingSpace = true
+
+		fields, err := r.Read()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		return fields, nil
+	}
+
+	var fields []string
+	rest := strings.TrimSpace(line)
+
+	for rest != "" {
+		if limit > 0 && len(fields) == limit-1 {
+			fields = append(fields, rest)
+			break
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			fields = append(fields, rest)
+			break
+		}
+
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+	}
+
+	return fields, nil
+}
+
+// Unmarshal parses response and stores the rows in the slice of structs out points to. Struct fields are matched to
+// columns using the "table" tag, or the field name if no tag is set. Fields tagged "-" are ignored. Supported field
+// types are strings, bools, integers, floats and time.Duration.
+func Unmarshal(response string, schema Schema, out interface{}) error {
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice ||
+		slice.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("out must be a pointer to a slice of structs, got %T", out)
+	}
+
+	rows, err := Parse(response, schema)
+	if err != nil {
+		return err
+	}
+
+	elemType := slice.Elem().Type().Elem()
+	result := reflect.MakeSlice(slice.Elem().Type(), 0, len(rows))
+
+	for i, row := range rows {
+		elem := reflect.New(elemType).Elem()
+
+		for f := 0; f < elemType.NumField(); f++ {
+			field := elemType.Field(f)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			column := field.Name
+			if tag, ok := field.Tag.Lookup("table"); ok {
+				column = tag
+			}
+
+			if column == "-" {
+				continue
+			}
+
+			value, ok := row[column]
+			if !ok {
+				continue
+			}
+
+			if err := setField(elem.Field(f), value); err != nil {
+				return fmt.Errorf("row %d, column %s: %w", i+1, column, err)
+			}
+		}
+
+		result = reflect.Append(result, elem)
+	}
+
+	slice.Elem().Set(result)
+
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
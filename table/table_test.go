@@ -0,0 +1,68 @@
+package table
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Parse()", func() {
+		g.It("Should use the header line as column names", func() {
+			rows, err := Parse("id  score  name\n1   10     Some Player\n\n2   7      Other\n", Schema{})
+
+			Expect(err).To(BeNil())
+			Expect(rows).To(Equal([]map[string]string{
+				{"id": "1", "score": "10", "name": "Some Player"},
+				{"id": "2", "score": "7", "name": "Other"},
+			}))
+		})
+
+		g.It("Should parse delimited rows with configured columns", func() {
+			rows, err := Parse("Players:\n1,\"Name, with comma\",76561198000000000\n", Schema{
+				Columns:   []string{"id", "name", "steamid"},
+				Separator: ',',
+				SkipLines: 1,
+			})
+
+			Expect(err).To(BeNil())
+			Expect(rows).To(Equal([]map[string]string{
+				{"id": "1", "name": "Name, with comma", "steamid": "76561198000000000"},
+			}))
+		})
+
+		g.It("Should return ErrColumnCount for rows with too many columns", func() {
+			_, err := Parse("a\tb\tc", Schema{Columns: []string{"x", "y"}, Separator: '\t'})
+
+			Expect(errors.Is(err, ErrColumnCount)).To(BeTrue())
+		})
+	})
+
+	g.Describe("Unmarshal()", func() {
+		g.It("Should populate struct fields by tag", func() {
+			type player struct {
+				ID    int    `table:"id"`
+				Name  string `table:"name"`
+				Ping  float64
+				Admin bool `table:"-"`
+			}
+
+			var players []player
+			err := Unmarshal("id Ping name\n3 42.5 Player Three", Schema{}, &players)
+
+			Expect(err).To(BeNil())
+			Expect(players).To(Equal([]player{{ID: 3, Name: "Player Three", Ping: 42.5}}))
+		})
+
+		g.It("Should reject non-slice outputs", func() {
+			var out struct{}
+			Expect(Unmarshal("a\n1", Schema{}, &out)).ToNot(BeNil())
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package rcon
+
+import (
+	"github.com/refractorgscm/rcon/packet"
+	"time"
+)
+
+// TeeDirection tells a TeeHandler which way a packet was travelling.
+type TeeDirection uint8
+
+const (
+	TeeInbound TeeDirection = iota
+	TeeOutbound
+)
+
+func (d TeeDirection) String() string {
+	if d == TeeOutbound {
+		return "outbound"
+	}
+
+	return "inbound"
+}
+
+// TeeHandler receives a copy of packets flowing through the client. It is called synchronously from the reader and
+// writer paths, so it should return quickly. Packets must not be modified.
+type TeeHandler func(p packet.Packet, direction TeeDirection)
+
+// TeeEvent is a packet mirrored by a TeeHandler created with TeeToChannel.
+type TeeEvent struct {
+	Packet    packet.Packet
+	Direction TeeDirection
+	Time      time.Time
+}
+
+// TeeToChannel returns a TeeHandler which sends every mirrored packet to ch. Sends never block: if ch is full, the
+// event is dropped so that the client's routing is never affected by a slow consumer.
+func TeeToChannel(ch chan<- TeeEvent) TeeHandler {
+	return func(p packet.Packet, direction TeeDirection) {
+		select {
+		case ch <- TeeEvent{Packet: p, Direction: direction, Time: time.Now()}:
+		default:
+		}
+	}
+}
+
+func (c *Client) teeInbound(p packet.Packet) {
+	if c.TeeHandler != nil {
+		c.TeeHandler(p, TeeInbound)
+	}
+}
+
+func (c *Client) teeOutbound(p packet.Packet) {
+	if c.TeeHandler == nil || !c.TeeOutbound {
+		return
+	}
+
+	// Never leak the RCON password to the sink.
+	if p.Type() == packet.TypeAuth {
+		p = packet.NewPacketWithID(c.EndianMode, p.ID(), p.Type(), "")
+	}
+
+	c.TeeHandler(p, TeeOutbound)
+}
+
+// PacketHooks are called with every Source RCON packet the client sends or receives, along with its raw wire bytes.
+// They are called synchronously from the reader and writer paths, so they should return quickly. Neither the packets
+// nor the bytes may be modified or retained after the hook returns without copying them.
+type PacketHooks struct {
+	// OnSend is called after a packet was written. The body of authentication packets is removed, from both the
+	// packet and the bytes, so that the password never reaches the hook.
+	OnSend func(p packet.Packet, raw []byte)
+
+	// OnReceive is called after a packet was read, before it is routed. raw holds the bytes exactly as received.
+	OnReceive func(p packet.Packet, raw []byte)
+}
+
+func (c *Client) hookSend(p packet.Packet, raw []byte) {
+	if c.PacketHooks.OnSend == nil {
+		return
+	}
+
+	c.PacketHooks.OnSend(redactAuth(c, p, raw))
+}
+
+// redactAuth removes the body of p and raw if p is an authentication packet.
+func redactAuth(c *Client, p packet.Packet, raw []byte) (packet.Packet, []byte) {
+	if p.Type() != packet.TypeAuth {
+		return p, raw
+	}
+
+	p = packet.NewPacketWithID(c.EndianMode, p.ID(), p.Type(), "")
+
+	redacted, err := c.codec().Encode(p)
+	if err != nil {
+		return p, nil
+	}
+
+	return p, redacted
+}
@@ -0,0 +1,155 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/demo"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+	"testing"
+	"time"
+)
+
+// teeRecord is a mirrored packet reduced to what the tests compare.
+type teeRecord struct {
+	Direction rcon.TeeDirection
+	Type      packet.PacketType
+	Body      string
+}
+
+func TestTee(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Tee", func() {
+		var server *demo.Server
+		var config *rcon.Config
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = demo.NewServer("password")
+			host, port, err := server.Start()
+			Expect(err).To(BeNil())
+
+			config = &rcon.Config{
+				Host:             host,
+				Port:             port,
+				Password:         "password",
+				QueueReadTimeout: time.Second,
+				BroadcastChecker: func(p packet.Packet) bool { return p.ID() == demo.ChatBroadcastID },
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+			_ = server.Close()
+		})
+
+		// drain returns the records of all events received on events so far.
+		drain := func(events chan rcon.TeeEvent) []teeRecord {
+			var records []teeRecord
+
+			for {
+				select {
+				case e := <-events:
+					records = append(records, teeRecord{
+						Direction: e.Direction,
+						Type:      e.Packet.Type(),
+						Body:      strings.TrimRight(string(e.Packet.Body()), "\x00"),
+					})
+				case <-time.After(time.Millisecond * 100):
+					return records
+				}
+			}
+		}
+
+		g.It("Should mirror inbound packets only by default", func() {
+			events := make(chan rcon.TeeEvent, 16)
+			config.TeeHandler = rcon.TeeToChannel(events)
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hello"))
+
+			records := drain(events)
+			Expect(records).To(ContainElement(teeRecord{
+				Direction: rcon.TeeInbound,
+				Type:      packet.TypeCommandRes,
+				Body:      "hello",
+			}))
+
+			for _, r := range records {
+				Expect(r.Direction).To(Equal(rcon.TeeInbound))
+			}
+		})
+
+		g.It("Should mirror outbound packets without the password", func() {
+			events := make(chan rcon.TeeEvent, 16)
+			config.TeeHandler = rcon.TeeToChannel(events)
+			config.TeeOutbound = true
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("echo hello")
+			Expect(err).To(BeNil())
+
+			records := drain(events)
+			Expect(records).To(ContainElement(teeRecord{Direction: rcon.TeeOutbound, Type: packet.TypeAuth, Body: ""}))
+			Expect(records).To(ContainElement(teeRecord{
+				Direction: rcon.TeeOutbound,
+				Type:      packet.TypeCommand,
+				Body:      "echo hello",
+			}))
+			Expect(records).To(ContainElement(teeRecord{
+				Direction: rcon.TeeInbound,
+				Type:      packet.TypeCommandRes,
+				Body:      "hello",
+			}))
+
+			for _, r := range records {
+				Expect(r.Body).ToNot(ContainSubstring("password"))
+			}
+		})
+
+		g.It("Should mirror broadcasts without consuming them", func() {
+			events := make(chan rcon.TeeEvent, 16)
+			broadcasts := make(chan string, 1)
+			config.TeeHandler = rcon.TeeToChannel(events)
+			config.BroadcastHandler = func(message string) { broadcasts <- message }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			server.Broadcast(demo.ChatBroadcastID, "Chat: hello")
+
+			Eventually(broadcasts).Should(Receive(Equal("Chat: hello")))
+			Expect(drain(events)).To(ContainElement(teeRecord{
+				Direction: rcon.TeeInbound,
+				Type:      packet.TypeCommandRes,
+				Body:      "Chat: hello",
+			}))
+		})
+
+		g.It("Should drop events instead of blocking when the channel is full", func() {
+			events := make(chan rcon.TeeEvent)
+			config.TeeHandler = rcon.TeeToChannel(events)
+			config.TeeOutbound = true
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			for i := 0; i < 3; i++ {
+				res, err := client.ExecCommand("echo hello")
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal("hello"))
+			}
+		})
+	})
+}
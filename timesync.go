@@ -0,0 +1,92 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"time"
+)
+
+// TimeParser parses the response of a game's time command into the server's current wall clock time.
+type TimeParser func(response string) (time.Time, error)
+
+// ClockOffset is an estimate of the difference between the server's clock and the local clock.
+type ClockOffset struct {
+	// Offset is the estimated server time minus local time. Add it to a local timestamp to get the server timestamp.
+	Offset time.Duration
+
+	// RoundTrip is the round trip time of the command used to take the measurement. The estimate is accurate to within
+	// half of RoundTrip.
+	RoundTrip time.Duration
+
+	// MeasuredAt is the local time at which the measurement was taken.
+	MeasuredAt time.Time
+}
+
+// EstimateClockOffset executes command, parses the response with parse, and estimates the clock offset between the
+// local machine and the game server. The server is assumed to have generated its response halfway through the round
+// trip. The estimate is stored on the client and can be retrieved later with ClockOffset.
+func (c *Client) EstimateClockOffset(command string, parse TimeParser) (ClockOffset, error) {
+	sent := time.Now()
+
+	res, err := c.ExecCommand(command)
+	if err != nil {
+		return ClockOffset{}, fmt.Errorf("could not execute time command: %w", err)
+	}
+
+	received := time.Now()
+
+	serverTime, err := parse(res)
+	if err != nil {
+		return ClockOffset{}, fmt.Errorf("could not parse time command response: %w", err)
+	}
+
+	rtt := received.Sub(sent)
+	midpoint := sent.Add(rtt / 2)
+
+	offset := ClockOffset{
+		Offset:     serverTime.Sub(midpoint),
+		RoundTrip:  rtt,
+		MeasuredAt: midpoint,
+	}
+
+	c.clockLock.Lock()
+	c.clockOffset = &offset
+	c.clockLock.Unlock()
+
+	return offset, nil
+}
+
+// SyncClock estimates the clock offset like EstimateClockOffset, using the TimeCommand and TimeParser of the dialect.
+// It returns errs.ErrTimeUnsupported if the dialect declares no time command.
+func (c *Client) SyncClock() (ClockOffset, error) {
+	f := c.Features()
+	if f.TimeCommand == "" || f.TimeParser == nil {
+		return ClockOffset{}, fmt.Errorf("dialect declares no time command: %w", errs.ErrTimeUnsupported)
+	}
+
+	return c.EstimateClockOffset(f.TimeCommand, f.TimeParser)
+}
+
+// ClockOffset returns the most recent clock offset estimate. The second return value is false if no estimate has been
+// taken yet.
+func (c *Client) ClockOffset() (ClockOffset, bool) {
+	c.clockLock.RLock()
+	defer c.clockLock.RUnlock()
+
+	if c.clockOffset == nil {
+		return ClockOffset{}, false
+	}
+
+	return *c.clockOffset, true
+}
+
+// ServerTime converts a local timestamp to the equivalent server timestamp using the most recent clock offset
+// estimate. If no estimate has been taken, local is returned unchanged.
+func (c *Client) ServerTime(local time.Time) time.Time {
+	offset, ok := c.ClockOffset()
+	if !ok {
+		return local
+	}
+
+	return local.Add(offset.Offset)
+}
@@ -0,0 +1,113 @@
+package rcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/presets"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateClockOffset(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	parse := func(response string) (time.Time, error) {
+		return time.Parse(time.RFC3339Nano, response)
+	}
+
+	g.Describe("EstimateClockOffset()", func() {
+		g.It("Should estimate the offset of the server's clock within half the round trip", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.SetDelay(time.Millisecond * 20)
+			server.Handle("time", func(string) string {
+				// The server's clock is an hour ahead.
+				return time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			_, ok := client.ClockOffset()
+			Expect(ok).To(BeFalse())
+
+			local := time.Now()
+			Expect(client.ServerTime(local)).To(Equal(local))
+
+			offset, err := client.EstimateClockOffset("time", parse)
+			Expect(err).To(BeNil())
+			Expect(offset.RoundTrip).To(BeNumerically(">=", time.Millisecond*20))
+			Expect(offset.Offset).To(BeNumerically("~", time.Hour, offset.RoundTrip/2+time.Millisecond*5))
+
+			stored, ok := client.ClockOffset()
+			Expect(ok).To(BeTrue())
+			Expect(stored).To(Equal(offset))
+			Expect(client.ServerTime(local)).To(Equal(local.Add(offset.Offset)))
+		})
+
+		g.It("Should fail and keep the previous estimate if the response can't be parsed", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.Handle("time", func(string) string { return time.Now().Format(time.RFC3339Nano) })
+			Expect(client.Connect()).To(BeNil())
+
+			previous, err := client.EstimateClockOffset("time", parse)
+			Expect(err).To(BeNil())
+
+			_, err = client.EstimateClockOffset("uptime", parse)
+			Expect(err).To(MatchError(ContainSubstring("could not parse time command response")))
+
+			stored, _ := client.ClockOffset()
+			Expect(stored).To(Equal(previous))
+		})
+
+		g.It("Should fail if the command fails", func() {
+			_, client := newTestClient(t, &rcon.Config{
+				ResponseErrorChecker: func(_, response string) bool {
+					return strings.HasPrefix(response, "Unknown command")
+				},
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.EstimateClockOffset("time", parse)
+
+			var serverErr *errs.ServerCommandError
+			Expect(errors.As(err, &serverErr)).To(BeTrue())
+		})
+	})
+
+	g.Describe("SyncClock()", func() {
+		g.It("Should use the time command of the dialect", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				Dialect: presets.WithTimeCommand(presets.SourceDialect, "date", parse),
+			})
+			server.Handle("date", func(string) string {
+				return time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+			})
+			Expect(client.Connect()).To(BeNil())
+
+			offset, err := client.SyncClock()
+			Expect(err).To(BeNil())
+			Expect(offset.Offset).To(BeNumerically("~", time.Hour, offset.RoundTrip/2+time.Millisecond*5))
+			Expect(server.Commands()).To(Equal([]string{"date"}))
+
+			// The rest of the dialect is kept.
+			Expect(client.Features().MultiPacket).To(BeTrue())
+			Expect(client.Dialect.Name()).To(Equal("source"))
+		})
+
+		g.It("Should fail with ErrTimeUnsupported if the dialect declares no time command", func() {
+			for _, config := range []*rcon.Config{{}, {Dialect: presets.MordhauDialect}} {
+				server, client := newTestClient(t, config)
+				Expect(client.Connect()).To(BeNil())
+
+				_, err := client.SyncClock()
+				Expect(errors.Is(err, errs.ErrTimeUnsupported)).To(BeTrue())
+				Expect(server.Commands()).To(BeEmpty())
+			}
+		})
+	})
+}
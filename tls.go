@@ -0,0 +1,76 @@
+package rcon
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"net"
+)
+
+// TLSTransport is a Transport which wraps connections in TLS, for RCON endpoints behind stunnel or server wrappers
+// which terminate TLS themselves. Certificate verification failures are returned as *errs.CertificateError.
+type TLSTransport struct {
+	// Config is the TLS configuration. If its ServerName is empty, the host being dialed is used.
+	Config *tls.Config
+
+	// Dialer is used to open the underlying TCP connections. If nil, a zero net.Dialer is used.
+	Dialer *net.Dialer
+}
+
+func (t *TLSTransport) Dial(ctx context.Context, address string) (Conn, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	raw, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{}
+	if t.Config != nil {
+		config = t.Config.Clone()
+	}
+
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			_ = raw.Close()
+			return nil, fmt.Errorf("invalid address: %w", err)
+		}
+
+		config.ServerName = host
+	}
+
+	conn := tls.Client(raw, config)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := conn.Handshake(); err != nil {
+		_ = raw.Close()
+
+		if isCertificateError(err) {
+			return nil, &errs.CertificateError{Err: err}
+		}
+
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
+	}
+
+	return conn, nil
+}
+
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var system x509.SystemRootsError
+
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid) ||
+		errors.As(err, &system)
+}
@@ -0,0 +1,146 @@
+package rcon_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// startTLSProxy terminates TLS with the certificate of an httptest TLS server and forwards connections to target, like
+// stunnel in front of a game server. It returns the proxy's address and a pool trusting its certificate.
+func startTLSProxy(t *testing.T, target string) (string, *x509.CertPool) {
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(certServer.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(certServer.Certificate())
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", certServer.TLS.Clone())
+	if err != nil {
+		t.Fatalf("could not start tls proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+
+				go func() { _, _ = io.Copy(upstream, conn) }()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), pool
+}
+
+func TestTLSTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("TLSTransport", func() {
+		var config *rcon.Config
+		var proxy string
+		var pool *x509.CertPool
+
+		g.BeforeEach(func() {
+			config = &rcon.Config{}
+			server := newTestServer(t, config)
+			server.SetResponse("status", "ok")
+
+			host, port := server.Addr()
+			proxy, pool = startTLSProxy(t, net.JoinHostPort(host, strconv.Itoa(int(port))))
+
+			proxyHost, proxyPort, _ := net.SplitHostPort(proxy)
+			p, _ := strconv.Atoi(proxyPort)
+			config.Host, config.Port = proxyHost, uint16(p)
+		})
+
+		g.It("Should execute commands over a verified connection", func() {
+			// The httptest certificate is issued for example.com and 127.0.0.1.
+			config.TLSConfig = &tls.Config{RootCAs: pool}
+
+			client := rcon.NewClient(config, nil)
+			defer client.Close()
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+
+		g.It("Should reject certificates from an unknown authority", func() {
+			config.TLSConfig = &tls.Config{}
+
+			err := rcon.NewClient(config, nil).Connect()
+
+			var certErr *errs.CertificateError
+			Expect(errors.As(err, &certErr)).To(BeTrue())
+
+			var unknownAuthority x509.UnknownAuthorityError
+			Expect(errors.As(err, &unknownAuthority)).To(BeTrue())
+		})
+
+		g.It("Should reject certificates for another host", func() {
+			config.TLSConfig = &tls.Config{RootCAs: pool, ServerName: "rcon.example.org"}
+
+			err := rcon.NewClient(config, nil).Connect()
+
+			var certErr *errs.CertificateError
+			Expect(errors.As(err, &certErr)).To(BeTrue())
+
+			var hostname x509.HostnameError
+			Expect(errors.As(err, &hostname)).To(BeTrue())
+		})
+
+		g.It("Should not report failed handshakes with servers not speaking TLS as certificate errors", func() {
+			plain, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			defer plain.Close()
+
+			go func() {
+				conn, err := plain.Accept()
+				if err == nil {
+					_, _ = conn.Write([]byte("not tls at all\n"))
+					_ = conn.Close()
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			transport := &rcon.TLSTransport{Config: &tls.Config{RootCAs: pool}}
+			_, err = transport.Dial(ctx, plain.Addr().String())
+			Expect(err).To(MatchError(ContainSubstring("tls handshake failed")))
+
+			var certErr *errs.CertificateError
+			Expect(errors.As(err, &certErr)).To(BeFalse())
+		})
+	})
+}
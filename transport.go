@@ -0,0 +1,111 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"net"
+	"time"
+)
+
+// Conn is a connection opened by a Transport. Every net.Conn is a Conn.
+type Conn interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+
+	// SetDeadline sets the deadline for reads and writes. A zero value disables the deadline.
+	SetDeadline(t time.Time) error
+}
+
+// Transport opens the byte streams Source RCON packets are exchanged over. Replacing the transport allows tunnelling
+// the protocol through TLS, proxies or in-memory pipes for tests without touching client logic.
+//
+// Dial is called for every connection the client opens, including reconnects and, in ConnectionPerCommand mode, for
+// every command. Each call must return a new, independent Conn.
+type Transport interface {
+	Dial(ctx context.Context, address string) (Conn, error)
+}
+
+// TCPTransport is the default Transport. It connects over plain TCP.
+type TCPTransport struct {
+	// Dialer is used to open connections. If nil, a zero net.Dialer is used.
+	Dialer *net.Dialer
+}
+
+func (t *TCPTransport) Dial(ctx context.Context, address string) (Conn, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// TransportFunc adapts a function to the Transport interface.
+type TransportFunc func(ctx context.Context, address string) (Conn, error)
+
+func (f TransportFunc) Dial(ctx context.Context, address string) (Conn, error) {
+	return f(ctx, address)
+}
+
+// TransportHandlers are the callbacks a CommandTransport uses to report events to the client.
+type TransportHandlers struct {
+	// Message must be called with every message the server sends which is not a command response, such as console
+	// output or chat. Messages are delivered to the client's BroadcastHandler.
+	Message func(message string)
+
+	// Disconnect must be called if the connection is lost without Close being called.
+	Disconnect func(err error)
+}
+
+// CommandTransport executes commands over a wire format other than Source RCON, such as Rust's WebSocket based
+// WebRCON. If a transport is configured, the client delegates connecting, command execution and closing to it, while
+// still applying features such as response error checking, pausing and broadcast handling.
+type CommandTransport interface {
+	// Connect establishes and authenticates a connection.
+	Connect(ctx context.Context, config *Config, handlers TransportHandlers) error
+
+	// Exec executes command and returns its response. It must return when ctx is done; the client bounds ctx by its
+	// read timeout.
+	Exec(ctx context.Context, command string) (string, error)
+
+	Close() error
+}
+
+func (c *Client) connectTransport() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.ConnTimeout)
+	defer cancel()
+
+	return c.Transport.Connect(ctx, c.Config, TransportHandlers{
+		Message: func(message string) {
+			c.dispatchBroadcast(BroadcastSourceRCON, message, nil)
+		},
+		Disconnect: func(err error) {
+			c.log.Error("Transport disconnected. Error: ", err)
+			c.notifyDisconnect(err)
+		},
+	})
+}
+
+// execTransport executes command using the configured transport, bounded by the client's read timeout.
+func (c *Client) execTransport(ctx context.Context, command string) (string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.readTimeout())
+	defer cancel()
+
+	start := time.Now()
+
+	res, err := c.Transport.Exec(timeoutCtx, command)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return "", fmt.Errorf("command response timed out: %w", errs.ErrReadTimeout)
+		}
+
+		return "", err
+	}
+
+	c.latencies.add(time.Since(start))
+
+	return res, nil
+}
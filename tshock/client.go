@@ -0,0 +1,206 @@
+// Package tshock provides an rcon.Commander for Terraria servers running TShock. TShock does not speak RCON, but
+// exposes a REST API which can execute raw console commands. This adapter allows multi-game panels built on this
+// package to treat Terraria servers like any other RCON server.
+package tshock
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the default HTTP request timeout.
+const DefaultTimeout = time.Second * 10
+
+type Config struct {
+	Host string
+	Port uint16
+
+	// Username and Password are the credentials of a TShock user with REST permissions. They are used to create a
+	// token when Connect is called. They may be left empty if Token is set.
+	Username string
+	Password string
+
+	// Token is an application REST token configured on the server. If set, Connect does not create a user token.
+	Token string
+
+	// UseHTTPS makes the adapter use https instead of http.
+	UseHTTPS bool
+
+	// HTTPClient is the client used for requests.
+	//
+	// Default: an http.Client with DefaultTimeout
+	HTTPClient *http.Client
+}
+
+// Client executes commands on a TShock server through its REST API.
+type Client struct {
+	*Config
+
+	tokenLock    sync.RWMutex
+	token        string
+	ownsToken    bool
+	baseEndpoint string
+}
+
+var _ rcon.Commander = (*Client)(nil)
+
+func NewClient(config *Config) *Client {
+	c := &Client{
+		Config: config,
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	scheme := "http"
+	if c.UseHTTPS {
+		scheme = "https"
+	}
+	c.baseEndpoint = fmt.Sprintf("%s://%s:%d", scheme, c.Host, c.Port)
+
+	return c
+}
+
+// status is the status field of TShock responses. Depending on the TShock version it is either a string or a number.
+type status string
+
+func (s *status) UnmarshalJSON(data []byte) error {
+	*s = status(strings.Trim(string(data), `"`))
+	return nil
+}
+
+type response struct {
+	Status   status          `json:"status"`
+	Error    string          `json:"error"`
+	Token    string          `json:"token"`
+	Response json.RawMessage `json:"response"`
+}
+
+func (c *Client) get(path string, query url.Values) (*response, error) {
+	res, err := c.HTTPClient.Get(c.baseEndpoint + path + "?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	var out response
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	if out.Status != "200" {
+		if out.Status == "401" || out.Status == "403" {
+			return nil, fmt.Errorf("%s: %w", out.Error, errs.ErrAuthentication)
+		}
+
+		return nil, fmt.Errorf("tshock returned status %s: %s", out.Status, out.Error)
+	}
+
+	return &out, nil
+}
+
+// Connect creates a REST token using the configured credentials. If a Token is configured, it is used instead and
+// Connect only verifies that the server is reachable.
+func (c *Client) Connect() error {
+	if c.Token != "" {
+		c.tokenLock.Lock()
+		c.token = c.Token
+		c.tokenLock.Unlock()
+
+		if _, err := c.get("/tokentest", url.Values{"token": {c.Token}}); err != nil {
+			return fmt.Errorf("could not verify token: %w", err)
+		}
+
+		return nil
+	}
+
+	res, err := c.get("/v2/token/create", url.Values{"username": {c.Username}, "password": {c.Password}})
+	if err != nil {
+		return fmt.Errorf("could not create token: %w", err)
+	}
+
+	c.tokenLock.Lock()
+	c.token = res.Token
+	c.ownsToken = true
+	c.tokenLock.Unlock()
+
+	return nil
+}
+
+func (c *Client) currentToken() (string, error) {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+
+	if c.token == "" {
+		return "", errs.ErrNotConnected
+	}
+
+	return c.token, nil
+}
+
+// ExecCommand executes a console command. A leading slash is added if missing. The response lines are joined with
+// newlines.
+func (c *Client) ExecCommand(command string) (string, error) {
+	token, err := c.currentToken()
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(command, "/") {
+		command = "/" + command
+	}
+
+	res, err := c.get("/v2/server/rawcmd", url.Values{"cmd": {command}, "token": {token}})
+	if err != nil {
+		return "", fmt.Errorf("could not execute command: %w", err)
+	}
+
+	var lines []string
+	if err := json.Unmarshal(res.Response, &lines); err != nil {
+		// Older TShock versions return a single string.
+		var line string
+		if err := json.Unmarshal(res.Response, &line); err != nil {
+			return "", fmt.Errorf("could not decode command response: %w", err)
+		}
+
+		return line, nil
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (c *Client) ExecCommandNoResponse(command string) error {
+	_, err := c.ExecCommand(command)
+	return err
+}
+
+// Close destroys the token created by Connect. Configured application tokens are left untouched.
+func (c *Client) Close() error {
+	c.tokenLock.Lock()
+	token, owns := c.token, c.ownsToken
+	c.token = ""
+	c.ownsToken = false
+	c.tokenLock.Unlock()
+
+	if token == "" {
+		return errs.ErrNotConnected
+	}
+
+	if !owns {
+		return nil
+	}
+
+	if _, err := c.get("/token/destroy/"+url.PathEscape(token), url.Values{"token": {token}}); err != nil {
+		return fmt.Errorf("could not destroy token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package tshock_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/tshock"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer emulates the REST API of a TShock server with the user "admin" and the application token "apptoken".
+type fakeServer struct {
+	web *httptest.Server
+
+	lock      sync.Mutex
+	tokens    map[string]bool
+	destroyed []string
+	commands  []string
+
+	// legacy makes the server answer like older TShock versions, with numeric statuses and a single response string.
+	legacy bool
+}
+
+func startFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{tokens: map[string]bool{"apptoken": true}}
+	s.web = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.web.Close)
+
+	return s
+}
+
+func (s *fakeServer) serve(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	query := req.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	reply := func(status int, fields string) {
+		if s.legacy {
+			_, _ = fmt.Fprintf(w, `{"status":%d%s}`, status, fields)
+		} else {
+			_, _ = fmt.Fprintf(w, `{"status":"%d"%s}`, status, fields)
+		}
+	}
+
+	if req.URL.Path == "/v2/token/create" {
+		if query.Get("username") != "admin" || query.Get("password") != "secret" {
+			reply(403, `,"error":"Invalid username/password combination provided"`)
+			return
+		}
+
+		s.tokens["usertoken"] = true
+		reply(200, `,"token":"usertoken"`)
+		return
+	}
+
+	if !s.tokens[query.Get("token")] {
+		reply(401, `,"error":"Not authorized. The specified API endpoint requires a token."`)
+		return
+	}
+
+	switch {
+	case req.URL.Path == "/tokentest":
+		reply(200, `,"response":"Token is valid and was passed through correctly."`)
+	case req.URL.Path == "/v2/server/rawcmd":
+		command := query.Get("cmd")
+		s.commands = append(s.commands, command)
+
+		switch {
+		case command == "/fail":
+			reply(400, `,"error":"Missing or empty cmd parameter"`)
+		case s.legacy:
+			reply(200, `,"response":"Current players: Guide."`)
+		default:
+			reply(200, `,"response":["Online Players (1/8):","Guide"]`)
+		}
+	case strings.HasPrefix(req.URL.Path, "/token/destroy/"):
+		token := strings.TrimPrefix(req.URL.Path, "/token/destroy/")
+		delete(s.tokens, token)
+		s.destroyed = append(s.destroyed, token)
+		reply(200, `,"response":"Destroyed token"`)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (s *fakeServer) received() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.commands...)
+}
+
+func (s *fakeServer) destroyedTokens() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.destroyed...)
+}
+
+func (s *fakeServer) config() *tshock.Config {
+	host, port, _ := net.SplitHostPort(s.web.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	return &tshock.Config{Host: host, Port: uint16(p)}
+}
+
+func TestClient(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Client", func() {
+		var server *fakeServer
+
+		g.BeforeEach(func() {
+			server = startFakeServer(t)
+		})
+
+		g.It("Should create a token, execute commands with it and destroy it on Close", func() {
+			config := server.config()
+			config.Username, config.Password = "admin", "secret"
+
+			client := tshock.NewClient(config)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("who")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Online Players (1/8):\nGuide"))
+			Expect(server.received()).To(Equal([]string{"/who"}))
+
+			Expect(client.Close()).To(BeNil())
+			Expect(server.destroyedTokens()).To(Equal([]string{"usertoken"}))
+
+			_, err = client.ExecCommand("who")
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+		})
+
+		g.It("Should use application tokens without destroying them", func() {
+			config := server.config()
+			config.Token = "apptoken"
+
+			client := tshock.NewClient(config)
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("/who")
+			Expect(err).To(BeNil())
+			Expect(server.received()).To(Equal([]string{"/who"}))
+
+			Expect(client.Close()).To(BeNil())
+			Expect(server.destroyedTokens()).To(BeEmpty())
+		})
+
+		g.It("Should return ErrAuthentication for rejected credentials and tokens", func() {
+			config := server.config()
+			config.Username, config.Password = "admin", "wrong"
+
+			err := tshock.NewClient(config).Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+
+			config = server.config()
+			config.Token = "revoked"
+
+			err = tshock.NewClient(config).Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should return other error statuses with the server's error", func() {
+			config := server.config()
+			config.Token = "apptoken"
+
+			client := tshock.NewClient(config)
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecCommand("fail")
+			Expect(err).ToNot(BeNil())
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeFalse())
+			Expect(err.Error()).To(ContainSubstring("status 400: Missing or empty cmd parameter"))
+		})
+
+		g.It("Should decode the numeric statuses and single string responses of older versions", func() {
+			server.lock.Lock()
+			server.legacy = true
+			server.lock.Unlock()
+
+			config := server.config()
+			config.Token = "apptoken"
+
+			client := tshock.NewClient(config)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("who")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("Current players: Guide."))
+		})
+	})
+}
@@ -0,0 +1,377 @@
+// Package udprcon implements the connectionless UDP RCON protocol of older engines such as GoldSrc (Half-Life
+// dedicated servers), Quake 3 and Call of Duty. Every command is a single datagram carrying the password, and responses
+// may span several datagrams. Set a Transport as the client's Transport to use it:
+//
+//	client := rcon.NewClient(&rcon.Config{
+//	    Host:      host,
+//	    Port:      port,
+//	    Password:  password,
+//	    Transport: udprcon.NewTransport(udprcon.GoldSrc),
+//	}, nil)
+//
+// The protocol has no sessions, so the password is only checked when a command is executed; a wrong password makes
+// ExecCommand return an error wrapping errs.ErrAuthentication. Responses carry no IDs either, so commands are executed
+// one at a time.
+package udprcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dialect selects the flavour of the protocol a server speaks.
+type Dialect int
+
+const (
+	// GoldSrc servers require a challenge number, which is requested when connecting, to be sent with every command.
+	// Long responses are split into datagrams carrying sequence numbers.
+	GoldSrc Dialect = iota
+
+	// Quake servers, including Call of Duty, accept commands without a challenge. Long responses are sent as several
+	// independent print datagrams.
+	Quake
+)
+
+// DefaultRetransmitInterval is the default time after which a command is sent again if no response arrived.
+const DefaultRetransmitInterval = time.Second
+
+// DefaultRetries is the default number of times a command is sent again if no response arrived.
+const DefaultRetries = 2
+
+// DefaultQuietPeriod is the default time to wait for further datagrams of a response after one arrived.
+const DefaultQuietPeriod = time.Millisecond * 100
+
+// maxDatagram is the largest datagram the transport accepts.
+const maxDatagram = 65535
+
+// ErrBadChallenge is returned if a GoldSrc server keeps rejecting the challenge number.
+var ErrBadChallenge = errors.New("bad challenge")
+
+// header prefixes every connectionless datagram.
+var header = []byte{0xff, 0xff, 0xff, 0xff}
+
+// splitHeader prefixes the parts of a GoldSrc response split across several datagrams.
+var splitHeader = []byte{0xfe, 0xff, 0xff, 0xff}
+
+// authFailures are the responses servers send when the password is wrong.
+var authFailures = []string{"Bad rcon_password", "Bad rconpassword", "Invalid password", "No rconpassword set"}
+
+// Transport is an rcon.CommandTransport for UDP RCON.
+type Transport struct {
+	Dialect Dialect
+
+	// RetransmitInterval is the time after which a command is sent again if no part of its response arrived. UDP
+	// datagrams may be lost, but a command is also sent again if only its response was lost, so commands which must
+	// not run twice should be used with care.
+	//
+	// Default: DefaultRetransmitInterval
+	RetransmitInterval time.Duration
+
+	// Retries is the number of times a command is sent again.
+	//
+	// Default: DefaultRetries
+	Retries int
+
+	// QuietPeriod is how long to wait for further datagrams of a response after one arrived. Responses end once no
+	// datagram arrived within it, so it adds to the latency of every command.
+	//
+	// Default: DefaultQuietPeriod
+	QuietPeriod time.Duration
+
+	// execLock serializes commands, since responses can't be told apart.
+	execLock sync.Mutex
+
+	connLock  sync.Mutex
+	conn      net.Conn
+	password  string
+	challenge string
+}
+
+var _ rcon.CommandTransport = (*Transport)(nil)
+
+func NewTransport(dialect Dialect) *Transport {
+	return &Transport{
+		Dialect:            dialect,
+		RetransmitInterval: DefaultRetransmitInterval,
+		Retries:            DefaultRetries,
+		QuietPeriod:        DefaultQuietPeriod,
+	}
+}
+
+// Connect opens the UDP socket. For GoldSrc servers, it also requests the challenge number, which verifies that the
+// server is reachable. Quake servers can't be reached without executing a command, so connecting to them always
+// succeeds.
+func (t *Transport) Connect(ctx context.Context, config *rcon.Config, _ rcon.TransportHandlers) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(config.Host, fmt.Sprint(config.Port)))
+	if err != nil {
+		return fmt.Errorf("udp dial failure: %w", err)
+	}
+
+	t.execLock.Lock()
+	defer t.execLock.Unlock()
+
+	t.connLock.Lock()
+	if t.conn != nil {
+		_ = t.conn.Close()
+	}
+	t.conn = conn
+	t.password = config.Password
+	t.challenge = ""
+	t.connLock.Unlock()
+
+	if t.Dialect == GoldSrc {
+		if err := t.requestChallenge(ctx, conn); err != nil {
+			_ = t.Close()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requestChallenge requests a new challenge number from a GoldSrc server. It must be called with execLock held.
+func (t *Transport) requestChallenge(ctx context.Context, conn net.Conn) error {
+	res, err := t.roundTrip(ctx, conn, "challenge rcon\n")
+	if err != nil {
+		return fmt.Errorf("could not request challenge: %w", err)
+	}
+
+	fields := strings.Fields(res)
+	if len(fields) != 3 || fields[0] != "challenge" || fields[1] != "rcon" {
+		return fmt.Errorf("unexpected challenge response %q", res)
+	}
+
+	t.connLock.Lock()
+	t.challenge = fields[2]
+	t.connLock.Unlock()
+
+	return nil
+}
+
+func (t *Transport) Exec(ctx context.Context, command string) (string, error) {
+	t.execLock.Lock()
+	defer t.execLock.Unlock()
+
+	t.connLock.Lock()
+	conn, password := t.conn, t.password
+	t.connLock.Unlock()
+
+	if conn == nil {
+		return "", errs.ErrNotConnected
+	}
+
+	res, err := t.roundTrip(ctx, conn, t.commandPayload(password, command))
+	if err != nil {
+		return "", err
+	}
+
+	// GoldSrc challenges expire, for example when the map changes. Request a new one and try again once.
+	if t.Dialect == GoldSrc && strings.HasPrefix(res, "Bad challenge") {
+		if err := t.requestChallenge(ctx, conn); err != nil {
+			return "", err
+		}
+
+		if res, err = t.roundTrip(ctx, conn, t.commandPayload(password, command)); err != nil {
+			return "", err
+		}
+
+		if strings.HasPrefix(res, "Bad challenge") {
+			return "", fmt.Errorf("%s: %w", strings.TrimSpace(res), ErrBadChallenge)
+		}
+	}
+
+	for _, failure := range authFailures {
+		if strings.HasPrefix(res, failure) {
+			return "", fmt.Errorf("%s: %w", strings.TrimSpace(res), errs.ErrAuthentication)
+		}
+	}
+
+	return res, nil
+}
+
+func (t *Transport) commandPayload(password string, command string) string {
+	if t.Dialect == Quake {
+		return fmt.Sprintf("rcon %s %s", password, command)
+	}
+
+	t.connLock.Lock()
+	challenge := t.challenge
+	t.connLock.Unlock()
+
+	return fmt.Sprintf("rcon %s \"%s\" %s\n", challenge, password, command)
+}
+
+// roundTrip sends payload, resending it until a response arrives or the retries are used up, and returns the
+// assembled response text.
+func (t *Transport) roundTrip(ctx context.Context, conn net.Conn, payload string) (string, error) {
+	out := append(append([]byte{}, header...), payload...)
+	buf := make([]byte, maxDatagram)
+
+	// Discard datagrams left over from a previous command which gave up early.
+	_ = conn.SetReadDeadline(time.Now())
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+
+	for attempt := 0; attempt <= t.Retries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("command cancelled: %w", err)
+		}
+
+		if _, err := conn.Write(out); err != nil {
+			return "", fmt.Errorf("could not send command datagram: %w", err)
+		}
+
+		res, err := t.readResponse(ctx, conn, buf)
+		if err == nil {
+			return res, nil
+		}
+
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			return "", fmt.Errorf("could not read response datagram: %w", err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("command cancelled: %w", err)
+	}
+
+	return "", fmt.Errorf("no response datagram arrived: %w", errs.ErrReadTimeout)
+}
+
+// readResponse reads the datagrams of one response. It returns a timeout error if no datagram arrived within the
+// retransmit interval.
+func (t *Transport) readResponse(ctx context.Context, conn net.Conn, buf []byte) (string, error) {
+	body := &bytes.Buffer{}
+	split := map[int32]*splitResponse{}
+	received := false
+
+	for {
+		wait := t.QuietPeriod
+		if !received {
+			wait = t.RetransmitInterval
+		}
+
+		deadline := time.Now().Add(wait)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return "", err
+		}
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && received && !incomplete(split) {
+				return body.String(), nil
+			}
+
+			return "", err
+		}
+
+		datagram := buf[:n]
+
+		if bytes.HasPrefix(datagram, splitHeader) {
+			if text, ok := t.addSplit(split, datagram); ok {
+				body.WriteString(text)
+			}
+			received = true
+			continue
+		}
+
+		if bytes.HasPrefix(datagram, header) {
+			body.WriteString(t.text(datagram[len(header):]))
+			received = true
+		}
+	}
+}
+
+// text returns the text of a datagram's payload, stripped of the print prefix.
+func (t *Transport) text(payload []byte) string {
+	switch {
+	case bytes.HasPrefix(payload, []byte("print\n")):
+		payload = payload[len("print\n"):]
+	case t.Dialect == GoldSrc && len(payload) > 0 && payload[0] == 'l':
+		payload = payload[1:]
+	}
+
+	return string(bytes.TrimRight(payload, "\x00"))
+}
+
+// splitResponse collects the parts of a GoldSrc response split across several datagrams.
+type splitResponse struct {
+	parts    [][]byte
+	received int
+}
+
+func incomplete(split map[int32]*splitResponse) bool {
+	for _, s := range split {
+		if s.received < len(s.parts) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addSplit adds a split datagram to its response. Once all parts arrived, it returns the text of the assembled
+// response.
+func (t *Transport) addSplit(split map[int32]*splitResponse, datagram []byte) (string, bool) {
+	if len(datagram) < 9 {
+		return "", false
+	}
+
+	id := int32(binary.LittleEndian.Uint32(datagram[4:8]))
+	total, index := int(datagram[8]&0x0f), int(datagram[8]>>4)
+	if total == 0 || index >= total {
+		return "", false
+	}
+
+	s, ok := split[id]
+	if !ok {
+		s = &splitResponse{parts: make([][]byte, total)}
+		split[id] = s
+	}
+
+	if len(s.parts) != total || s.parts[index] != nil {
+		return "", false
+	}
+
+	s.parts[index] = append([]byte{}, datagram[9:]...)
+	s.received++
+
+	if s.received < total {
+		return "", false
+	}
+
+	assembled := bytes.Join(s.parts, nil)
+	delete(split, id)
+
+	return t.text(bytes.TrimPrefix(assembled, header)), true
+}
+
+func (t *Transport) Close() error {
+	t.connLock.Lock()
+	conn := t.conn
+	t.conn = nil
+	t.connLock.Unlock()
+
+	if conn == nil {
+		return errs.ErrNotConnected
+	}
+
+	return conn.Close()
+}
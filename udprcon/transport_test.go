@@ -0,0 +1,263 @@
+package udprcon_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/udprcon"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var header = []byte{0xff, 0xff, 0xff, 0xff}
+
+// fakeServer is a loopback UDP server. respond returns the datagrams answering a received payload, which has the
+// connectionless header removed.
+type fakeServer struct {
+	conn *net.UDPConn
+
+	lock     sync.Mutex
+	payloads []string
+	respond  func(payload string) [][]byte
+}
+
+func startFakeServer(t *testing.T, respond func(payload string) [][]byte) *fakeServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeServer{conn: conn, respond: respond}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			if !bytes.HasPrefix(buf[:n], header) {
+				continue
+			}
+			payload := string(buf[len(header):n])
+
+			s.lock.Lock()
+			s.payloads = append(s.payloads, payload)
+			respond := s.respond
+			s.lock.Unlock()
+
+			for _, datagram := range respond(payload) {
+				_, _ = conn.WriteToUDP(datagram, addr)
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) config(password string) *rcon.Config {
+	addr := s.conn.LocalAddr().(*net.UDPAddr)
+	return &rcon.Config{Host: addr.IP.String(), Port: uint16(addr.Port), Password: password}
+}
+
+func (s *fakeServer) received() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.payloads...)
+}
+
+// reply returns a connectionless datagram carrying payload.
+func reply(payload string) []byte {
+	return append(append([]byte{}, header...), payload...)
+}
+
+// split returns the datagrams of a GoldSrc response split into the given parts.
+func split(id int32, parts ...string) [][]byte {
+	var datagrams [][]byte
+
+	for i, part := range parts {
+		d := []byte{0xfe, 0xff, 0xff, 0xff, 0, 0, 0, 0, byte(i<<4 | len(parts))}
+		binary.LittleEndian.PutUint32(d[4:8], uint32(id))
+		datagrams = append(datagrams, append(d, part...))
+	}
+
+	return datagrams
+}
+
+// goldSrc answers like a HLDS server with the given challenge and password, echoing commands.
+func goldSrc(challenge string, password string) func(string) [][]byte {
+	return func(payload string) [][]byte {
+		if payload == "challenge rcon\n" {
+			return [][]byte{reply("challenge rcon " + challenge + "\n")}
+		}
+
+		prefix := "rcon " + challenge + " \"" + password + "\" "
+		if !strings.HasPrefix(payload, "rcon "+challenge+" ") {
+			return [][]byte{reply("lBad challenge.\n")}
+		}
+		if !strings.HasPrefix(payload, prefix) {
+			return [][]byte{reply("lBad rcon_password.\n")}
+		}
+
+		return [][]byte{reply("l" + strings.TrimSuffix(strings.TrimPrefix(payload, prefix), "\n") + "\n")}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	ctx := context.Background()
+
+	newTransport := func(dialect udprcon.Dialect) *udprcon.Transport {
+		transport := udprcon.NewTransport(dialect)
+		transport.RetransmitInterval = time.Millisecond * 100
+		transport.QuietPeriod = time.Millisecond * 30
+		t.Cleanup(func() { _ = transport.Close() })
+
+		return transport
+	}
+
+	g.Describe("GoldSrc", func() {
+		g.It("Should request a challenge and send it with commands", func() {
+			server := startFakeServer(t, goldSrc("1234567", "password"))
+			transport := newTransport(udprcon.GoldSrc)
+
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			res, err := transport.Exec(ctx, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("status\n"))
+			Expect(server.received()).To(Equal([]string{"challenge rcon\n", "rcon 1234567 \"password\" status\n"}))
+		})
+
+		g.It("Should request a new challenge once the server rejects it", func() {
+			challenge := "1111"
+			var lock sync.Mutex
+
+			server := startFakeServer(t, func(payload string) [][]byte {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return goldSrc(challenge, "password")(payload)
+			})
+			transport := newTransport(udprcon.GoldSrc)
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			// Challenges expire, for example when the map changes.
+			lock.Lock()
+			challenge = "2222"
+			lock.Unlock()
+
+			res, err := transport.Exec(ctx, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("status\n"))
+			Expect(server.received()).To(Equal([]string{
+				"challenge rcon\n",
+				"rcon 1111 \"password\" status\n",
+				"challenge rcon\n",
+				"rcon 2222 \"password\" status\n",
+			}))
+		})
+
+		g.It("Should return ErrAuthentication for a wrong password", func() {
+			server := startFakeServer(t, goldSrc("1234567", "password"))
+			transport := newTransport(udprcon.GoldSrc)
+			Expect(transport.Connect(ctx, server.config("wrong"), rcon.TransportHandlers{})).To(BeNil())
+
+			_, err := transport.Exec(ctx, "status")
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should assemble responses split across datagrams", func() {
+			server := startFakeServer(t, func(payload string) [][]byte {
+				if payload == "challenge rcon\n" {
+					return [][]byte{reply("challenge rcon 1234567\n")}
+				}
+
+				parts := split(7, string(header)+"lhostname: Half-Life\n", "map: crossfire\n", "players: 0 (16 max)\n")
+				return [][]byte{parts[1], parts[2], parts[0]}
+			})
+			transport := newTransport(udprcon.GoldSrc)
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			res, err := transport.Exec(ctx, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("hostname: Half-Life\nmap: crossfire\nplayers: 0 (16 max)\n"))
+		})
+
+		g.It("Should fail to connect to servers which don't answer", func() {
+			server := startFakeServer(t, func(string) [][]byte { return nil })
+			transport := newTransport(udprcon.GoldSrc)
+			transport.Retries = 1
+
+			err := transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(server.received()).To(Equal([]string{"challenge rcon\n", "challenge rcon\n"}))
+		})
+	})
+
+	g.Describe("Quake", func() {
+		g.It("Should send commands without a challenge and join print datagrams", func() {
+			server := startFakeServer(t, func(payload string) [][]byte {
+				return [][]byte{reply("print\nmap: q3dm17\n"), reply("print\nnum score ping name\n")}
+			})
+			transport := newTransport(udprcon.Quake)
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			res, err := transport.Exec(ctx, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("map: q3dm17\nnum score ping name\n"))
+			Expect(server.received()).To(Equal([]string{"rcon password status"}))
+		})
+
+		g.It("Should resend commands whose response was lost", func() {
+			var lock sync.Mutex
+			attempts := 0
+
+			server := startFakeServer(t, func(payload string) [][]byte {
+				lock.Lock()
+				defer lock.Unlock()
+
+				if attempts++; attempts == 1 {
+					return nil
+				}
+
+				return [][]byte{reply("print\nok\n")}
+			})
+			transport := newTransport(udprcon.Quake)
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			res, err := transport.Exec(ctx, "status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok\n"))
+			Expect(server.received()).To(HaveLen(2))
+		})
+
+		g.It("Should return ErrReadTimeout once the retries are used up", func() {
+			server := startFakeServer(t, func(string) [][]byte { return nil })
+			transport := newTransport(udprcon.Quake)
+			Expect(transport.Connect(ctx, server.config("password"), rcon.TransportHandlers{})).To(BeNil())
+
+			start := time.Now()
+			_, err := transport.Exec(ctx, "status")
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*300))
+			Expect(server.received()).To(HaveLen(udprcon.DefaultRetries + 1))
+		})
+	})
+}
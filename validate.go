@@ -0,0 +1,55 @@
+package rcon
+
+import (
+	"context"
+	"github.com/refractorgscm/rcon/errs"
+	"time"
+)
+
+// ResponseValidator checks whether a response is semantically valid for the command which produced it. It returns a
+// non-nil error describing the problem if it is not.
+type ResponseValidator func(response string) error
+
+// ExecValidated executes command and passes its response to validator. If validator rejects the response, the command
+// is executed again up to ValidationRetries times, waiting ValidationRetryDelay in between. If every attempt is
+// rejected, an *errs.ValidationError wrapping the validator's last error is returned.
+//
+// This is useful for commands which occasionally return bogus responses on busy servers, such as an empty "status".
+func (c *Client) ExecValidated(command string, validator ResponseValidator) (string, error) {
+	return c.ExecValidatedContext(context.Background(), command, validator)
+}
+
+// ExecValidatedContext is the context aware equivalent of ExecValidated.
+func (c *Client) ExecValidatedContext(ctx context.Context, command string, validator ResponseValidator) (string, error) {
+	var validationErr *errs.ValidationError
+
+	for attempt := 0; attempt <= c.ValidationRetries; attempt++ {
+		if attempt > 0 {
+			c.log.Debug("Response to ", command, " failed validation, retrying. Error: ", validationErr.Err)
+
+			select {
+			case <-time.After(c.ValidationRetryDelay):
+			case <-ctx.Done():
+				return "", validationErr
+			}
+		}
+
+		res, err := c.ExecCommandContext(ctx, command)
+		if err != nil {
+			return "", err
+		}
+
+		verr := validator(res)
+		if verr == nil {
+			return res, nil
+		}
+
+		validationErr = &errs.ValidationError{
+			Command:  command,
+			Response: res,
+			Err:      verr,
+		}
+	}
+
+	return "", validationErr
+}
@@ -0,0 +1,128 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"testing"
+	"time"
+)
+
+func TestExecValidated(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	errEmpty := errors.New("empty response")
+
+	nonEmpty := func(response string) error {
+		if response == "" {
+			return errEmpty
+		}
+		return nil
+	}
+
+	g.Describe("ExecValidated()", func() {
+		g.It("Should return a response which passes validation", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.SetResponse("status", "players: 3")
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecValidated("status", nonEmpty)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("players: 3"))
+		})
+
+		g.It("Should return a ValidationError if the validator rejects the response", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.SetResponse("status", "")
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecValidated("status", nonEmpty)
+			Expect(res).To(Equal(""))
+
+			var validationErr *errs.ValidationError
+			Expect(errors.As(err, &validationErr)).To(BeTrue())
+			Expect(validationErr.Command).To(Equal("status"))
+			Expect(validationErr.Response).To(Equal(""))
+			Expect(errors.Is(err, errEmpty)).To(BeTrue())
+			Expect(err.Error()).To(Equal("response to command status failed validation: empty response"))
+		})
+
+		g.It("Should retry rejected responses", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				ValidationRetries:    2,
+				ValidationRetryDelay: time.Millisecond * 10,
+			})
+
+			calls := 0
+			server.Handle("status", func(string) string {
+				if calls++; calls < 3 {
+					return ""
+				}
+				return "players: 3"
+			})
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecValidated("status", nonEmpty)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("players: 3"))
+			Expect(calls).To(Equal(3))
+		})
+
+		g.It("Should give up after ValidationRetries", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				ValidationRetries:    1,
+				ValidationRetryDelay: time.Millisecond * 10,
+			})
+			server.SetResponse("status", "")
+
+			Expect(client.Connect()).To(BeNil())
+
+			_, err := client.ExecValidated("status", nonEmpty)
+			Expect(errors.Is(err, errEmpty)).To(BeTrue())
+			Expect(server.Commands()).To(Equal([]string{"status", "status"}))
+		})
+
+		g.It("Should return the last ValidationError when cancelled while waiting to retry", func() {
+			server, client := newTestClient(t, &rcon.Config{
+				ValidationRetries:    3,
+				ValidationRetryDelay: time.Hour,
+			})
+			server.SetResponse("status", "")
+
+			Expect(client.Connect()).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+
+			_, err := client.ExecValidatedContext(ctx, "status", nonEmpty)
+
+			var validationErr *errs.ValidationError
+			Expect(errors.As(err, &validationErr)).To(BeTrue())
+			Expect(server.Commands()).To(HaveLen(1))
+		})
+
+		g.It("Should not validate if the command fails", func() {
+			server, client := newTestClient(t, &rcon.Config{})
+			server.SetDelay(time.Millisecond * 300)
+
+			Expect(client.Connect()).To(BeNil())
+
+			validated := false
+			_, err := client.ExecValidated("status", func(string) error {
+				validated = true
+				return nil
+			})
+			Expect(errors.Is(err, errs.ErrReadTimeout)).To(BeTrue())
+			Expect(validated).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,13 @@
+module github.com/refractorgscm/rcon/webrcon
+
+go 1.16
+
+require (
+	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
+	github.com/onsi/gomega v1.16.0
+	github.com/refractorgscm/rcon v0.0.0
+	golang.org/x/net v0.0.0-20210428140749-89ef3d95e781
+)
+
+// The client is developed alongside the transport in the same repository.
+replace github.com/refractorgscm/rcon => ../
@@ -0,0 +1,215 @@
+// Package webrcon implements Rust's WebRCON protocol, which exchanges JSON messages over a WebSocket instead of
+// speaking Source RCON. Set a Transport as the client's Transport to use it:
+//
+//	client := rcon.NewClient(&rcon.Config{
+//	    Host:      host,
+//	    Port:      port,
+//	    Password:  password,
+//	    Transport: webrcon.NewTransport(),
+//	}, nil)
+package webrcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"golang.org/x/net/websocket"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// Name is sent as the name of every command message.
+const Name = "WebRcon"
+
+// Message is a WebRCON message. Commands are sent with a positive Identifier, which the server echoes in the response.
+// Messages the server sends on its own, such as console output and chat, carry an identifier of zero or less.
+type Message struct {
+	Identifier int    `json:"Identifier"`
+	Message    string `json:"Message"`
+	Name       string `json:"Name,omitempty"`
+	Type       string `json:"Type,omitempty"`
+	Stacktrace string `json:"Stacktrace,omitempty"`
+}
+
+// Transport is an rcon.CommandTransport for WebRCON.
+type Transport struct {
+	// UseTLS makes the transport connect using wss instead of ws.
+	UseTLS bool
+
+	conn      *websocket.Conn
+	connLock  sync.Mutex
+	writeLock sync.Mutex
+	closed    bool
+
+	idLock sync.Mutex
+	nextID int
+
+	pendingLock sync.Mutex
+	pending     map[int]chan string
+
+	handlers rcon.TransportHandlers
+}
+
+var _ rcon.CommandTransport = (*Transport)(nil)
+
+func NewTransport() *Transport {
+	return &Transport{
+		pending: map[int]chan string{},
+	}
+}
+
+// Connect opens the WebSocket. WebRCON authenticates using the password as the URL path; servers reject the WebSocket
+// handshake if it is wrong.
+func (t *Transport) Connect(ctx context.Context, config *rcon.Config, handlers rcon.TransportHandlers) error {
+	scheme, origin := "ws", "http"
+	if t.UseTLS {
+		scheme, origin = "wss", "https"
+	}
+
+	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
+
+	wsConfig, err := websocket.NewConfig(fmt.Sprintf("%s://%s/%s", scheme, address, url.PathEscape(config.Password)),
+		fmt.Sprintf("%s://%s/", origin, address))
+	if err != nil {
+		return fmt.Errorf("invalid webrcon address: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		wsConfig.Dialer = &net.Dialer{Deadline: deadline}
+	}
+
+	conn, err := websocket.DialConfig(wsConfig)
+	if err != nil {
+		if dialErr, ok := err.(*websocket.DialError); ok && dialErr.Err == websocket.ErrBadStatus {
+			return fmt.Errorf("%s: %w", err.Error(), errs.ErrAuthentication)
+		}
+
+		return fmt.Errorf("websocket dial failure: %w", err)
+	}
+
+	t.connLock.Lock()
+	t.conn = conn
+	t.closed = false
+	t.handlers = handlers
+	t.connLock.Unlock()
+
+	go t.read(conn)
+
+	return nil
+}
+
+func (t *Transport) read(conn *websocket.Conn) {
+	for {
+		var msg Message
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.connLock.Lock()
+			closed := t.closed
+			t.closed = true
+			t.connLock.Unlock()
+
+			t.failPending()
+
+			if !closed && t.handlers.Disconnect != nil {
+				t.handlers.Disconnect(err)
+			}
+
+			return
+		}
+
+		if msg.Identifier > 0 {
+			t.pendingLock.Lock()
+			res, ok := t.pending[msg.Identifier]
+			delete(t.pending, msg.Identifier)
+			t.pendingLock.Unlock()
+
+			if ok {
+				res <- msg.Message
+				continue
+			}
+		}
+
+		if t.handlers.Message != nil {
+			t.handlers.Message(msg.Message)
+		}
+	}
+}
+
+// failPending closes the channels of all commands waiting for a response.
+func (t *Transport) failPending() {
+	t.pendingLock.Lock()
+	defer t.pendingLock.Unlock()
+
+	for id, res := range t.pending {
+		close(res)
+		delete(t.pending, id)
+	}
+}
+
+func (t *Transport) id() int {
+	t.idLock.Lock()
+	defer t.idLock.Unlock()
+
+	t.nextID++
+	if t.nextID <= 0 {
+		t.nextID = 1
+	}
+
+	return t.nextID
+}
+
+func (t *Transport) Exec(ctx context.Context, command string) (string, error) {
+	t.connLock.Lock()
+	conn, closed := t.conn, t.closed
+	t.connLock.Unlock()
+
+	if conn == nil || closed {
+		return "", errs.ErrNotConnected
+	}
+
+	id := t.id()
+	res := make(chan string, 1)
+
+	t.pendingLock.Lock()
+	t.pending[id] = res
+	t.pendingLock.Unlock()
+
+	defer func() {
+		t.pendingLock.Lock()
+		delete(t.pending, id)
+		t.pendingLock.Unlock()
+	}()
+
+	t.writeLock.Lock()
+	err := websocket.JSON.Send(conn, Message{Identifier: id, Message: command, Name: Name})
+	t.writeLock.Unlock()
+
+	if err != nil {
+		return "", fmt.Errorf("could not send command message: %w", err)
+	}
+
+	select {
+	case msg, ok := <-res:
+		if !ok {
+			return "", fmt.Errorf("connection closed before a response arrived: %w", errs.ErrNotConnected)
+		}
+
+		return msg, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("command cancelled: %w", ctx.Err())
+	}
+}
+
+func (t *Transport) Close() error {
+	t.connLock.Lock()
+	conn, closed := t.conn, t.closed
+	t.closed = true
+	t.connLock.Unlock()
+
+	if conn == nil || closed {
+		return errs.ErrNotConnected
+	}
+
+	return conn.Close()
+}
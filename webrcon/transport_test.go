@@ -0,0 +1,241 @@
+package webrcon_test
+
+import (
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/webrcon"
+	"golang.org/x/net/websocket"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is a WebRCON server on an httptest server. Every command is answered by respond, and the messages it
+// received are recorded.
+type fakeServer struct {
+	web *httptest.Server
+
+	lock     sync.Mutex
+	conns    []*websocket.Conn
+	received []webrcon.Message
+	respond  func(conn *websocket.Conn, msg webrcon.Message)
+}
+
+func startFakeServer(t *testing.T, password string) *fakeServer {
+	s := &fakeServer{
+		respond: func(conn *websocket.Conn, msg webrcon.Message) {
+			_ = websocket.JSON.Send(conn, webrcon.Message{
+				Identifier: msg.Identifier,
+				Message:    "echo: " + msg.Message,
+				Type:       "Generic",
+			})
+		},
+	}
+
+	ws := websocket.Handler(func(conn *websocket.Conn) {
+		s.lock.Lock()
+		s.conns = append(s.conns, conn)
+		s.lock.Unlock()
+
+		for {
+			var msg webrcon.Message
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				return
+			}
+
+			s.lock.Lock()
+			s.received = append(s.received, msg)
+			respond := s.respond
+			s.lock.Unlock()
+
+			respond(conn, msg)
+		}
+	})
+
+	// WebRCON servers authenticate with the password as the path and reject the handshake if it is wrong.
+	s.web = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/"+password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ws.ServeHTTP(w, req)
+	}))
+	t.Cleanup(s.web.Close)
+
+	return s
+}
+
+func (s *fakeServer) config(password string) *rcon.Config {
+	host, port, _ := net.SplitHostPort(s.web.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	return &rcon.Config{
+		Host:             host,
+		Port:             uint16(p),
+		Password:         password,
+		Transport:        webrcon.NewTransport(),
+		ConnTimeout:      time.Second,
+		QueueReadTimeout: time.Millisecond * 500,
+	}
+}
+
+func (s *fakeServer) setRespond(respond func(conn *websocket.Conn, msg webrcon.Message)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.respond = respond
+}
+
+func (s *fakeServer) messages() []webrcon.Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]webrcon.Message{}, s.received...)
+}
+
+// drop closes the server side of all connections.
+func (s *fakeServer) drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func TestTransport(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Transport", func() {
+		var server *fakeServer
+		var client *rcon.Client
+
+		g.BeforeEach(func() {
+			server = startFakeServer(t, "password")
+		})
+
+		g.AfterEach(func() {
+			_ = client.Close()
+		})
+
+		g.It("Should send commands in the JSON envelope and return the response", func() {
+			client = rcon.NewClient(server.config("password"), nil)
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("echo: status"))
+
+			messages := server.messages()
+			Expect(messages).To(HaveLen(1))
+			Expect(messages[0].Identifier).To(BeNumerically(">", 0))
+			Expect(messages[0].Message).To(Equal("status"))
+			Expect(messages[0].Name).To(Equal(webrcon.Name))
+		})
+
+		g.It("Should return ErrAuthentication if the server rejects the password", func() {
+			client = rcon.NewClient(server.config("wrong"), nil)
+
+			err := client.Connect()
+			Expect(errors.Is(err, errs.ErrAuthentication)).To(BeTrue())
+		})
+
+		g.It("Should match responses by identifier and deliver other messages as broadcasts", func() {
+			var lock sync.Mutex
+			var held []webrcon.Message
+
+			// Answer the first command only after the second, with console output in between.
+			server.setRespond(func(conn *websocket.Conn, msg webrcon.Message) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				held = append(held, msg)
+				if len(held) < 2 {
+					return
+				}
+
+				_ = websocket.JSON.Send(conn, webrcon.Message{Identifier: held[1].Identifier, Message: "second"})
+				_ = websocket.JSON.Send(conn, webrcon.Message{Identifier: 0, Message: "[CHAT] Survivor: hi"})
+				_ = websocket.JSON.Send(conn, webrcon.Message{Identifier: -1, Message: "Saving complete"})
+				_ = websocket.JSON.Send(conn, webrcon.Message{Identifier: held[0].Identifier, Message: "first"})
+			})
+
+			config := server.config("password")
+			broadcasts := make(chan string, 8)
+			config.BroadcastHandler = func(message string) { broadcasts <- message }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			first := make(chan string, 1)
+			go func() {
+				res, _ := client.ExecCommand("first")
+				first <- res
+			}()
+			Eventually(server.messages).Should(HaveLen(1))
+
+			res, err := client.ExecCommand("second")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("second"))
+			Eventually(first).Should(Receive(Equal("first")))
+
+			Eventually(broadcasts).Should(Receive(Equal("[CHAT] Survivor: hi")))
+			Eventually(broadcasts).Should(Receive(Equal("Saving complete")))
+		})
+
+		g.It("Should fail waiting commands and report the disconnect when the server drops the connection", func() {
+			server.setRespond(func(*websocket.Conn, webrcon.Message) {})
+
+			config := server.config("password")
+			disconnected := make(chan error, 1)
+			config.DisconnectHandler = func(err error, expected bool) { disconnected <- err }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			failed := make(chan error, 1)
+			go func() {
+				_, err := client.ExecCommand("status")
+				failed <- err
+			}()
+			Eventually(server.messages).Should(HaveLen(1))
+
+			server.drop()
+
+			var err error
+			Eventually(failed).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrNotConnected)).To(BeTrue())
+			Eventually(disconnected).Should(Receive())
+		})
+
+		g.It("Should execute commands again after reconnecting", func() {
+			config := server.config("password")
+			disconnected := make(chan error, 1)
+			config.DisconnectHandler = func(err error, expected bool) { disconnected <- err }
+
+			client = rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+
+			server.drop()
+			Eventually(disconnected).Should(Receive())
+
+			Expect(client.Connect()).To(BeNil())
+
+			res, err := client.ExecCommand("status")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("echo: status"))
+		})
+	})
+}
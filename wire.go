@@ -0,0 +1,41 @@
+package rcon
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/refractorgscm/rcon/packet"
+	"strings"
+)
+
+// traceWire logs a hex dump of raw, the wire bytes of p, if TraceWire is enabled.
+func (c *Client) traceWire(p packet.Packet, raw []byte, direction TeeDirection) {
+	if !c.TraceWire {
+		return
+	}
+
+	if direction == TeeOutbound {
+		p, raw = redactAuth(c, p, raw)
+	}
+
+	header := fmt.Sprintf("%s packet ID: %d, Type: %d (%s), Size: %d, Bytes: %d\n", direction, p.ID(), p.Type(),
+		wireTypeName(p.Type(), direction), p.Size(), len(raw))
+
+	traceLog(c.log, header+strings.TrimSuffix(hex.Dump(raw), "\n"))
+}
+
+// wireTypeName names a Source RCON packet type. Type values are shared between requests and responses, so the name
+// depends on the direction the packet travelled in.
+func wireTypeName(t packet.PacketType, direction TeeDirection) string {
+	switch {
+	case direction == TeeOutbound && t == packet.TypeAuth:
+		return "auth"
+	case direction == TeeOutbound && t == packet.TypeCommand:
+		return "command"
+	case direction == TeeInbound && t == packet.TypeAuthRes:
+		return "auth response"
+	case t == packet.TypeCommandRes:
+		return "response value"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,89 @@
+package rcon_test
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"strings"
+	"testing"
+)
+
+// traceLogger is a recordingLogger which implements rcon.TraceLogger.
+type traceLogger struct {
+	recordingLogger
+}
+
+func (l *traceLogger) Trace(args ...interface{}) { l.record("TRACE", args...) }
+
+func TestTraceWire(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// dumps are the wire dumps of authenticating and executing "status", answered with "ok".
+	dumps := []string{
+		"outbound packet ID: 1, Type: 3 (auth), Size: 10, Bytes: 14\n" +
+			"00000000  0a 00 00 00 01 00 00 00  03 00 00 00 00 00        |..............|",
+		"inbound packet ID: 1, Type: 2 (auth response), Size: 10, Bytes: 14\n" +
+			"00000000  0a 00 00 00 01 00 00 00  02 00 00 00 00 00        |..............|",
+		"outbound packet ID: 2, Type: 2 (command), Size: 16, Bytes: 20\n" +
+			"00000000  10 00 00 00 02 00 00 00  02 00 00 00 73 74 61 74  |............stat|\n" +
+			"00000010  75 73 00 00                                       |us..|",
+		"inbound packet ID: 2, Type: 0 (response value), Size: 12, Bytes: 16\n" +
+			"00000000  0c 00 00 00 02 00 00 00  00 00 00 00 6f 6b 00 00  |............ok..|",
+	}
+
+	// run authenticates and executes "status" with a client logging to logger, and returns the entries logged at level
+	// which are wire dumps.
+	run := func(config *rcon.Config, logger interface {
+		rcon.Logger
+		Entries() []string
+	}, level string) []string {
+		server := newTestServer(t, config)
+		server.SetResponse("status", "ok")
+
+		client := rcon.NewClient(config, logger)
+		defer client.Close()
+
+		Expect(client.Connect()).To(BeNil())
+
+		res, err := client.ExecCommand("status")
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal("ok"))
+
+		var traced []string
+		for _, entry := range logger.Entries() {
+			if strings.Contains(entry, "bound packet ID") {
+				Expect(entry).To(HavePrefix(level + ": "))
+				traced = append(traced, strings.TrimPrefix(entry, level+": "))
+			}
+		}
+
+		return traced
+	}
+
+	g.Describe("TraceWire", func() {
+		g.It("Should log a hex dump of every packet at Trace level", func() {
+			Expect(run(&rcon.Config{TraceWire: true}, &traceLogger{}, "TRACE")).To(ConsistOf(dumps))
+		})
+
+		g.It("Should fall back to Debug level", func() {
+			Expect(run(&rcon.Config{TraceWire: true}, &recordingLogger{}, "DEBUG")).To(ConsistOf(dumps))
+		})
+
+		g.It("Should never dump the password", func() {
+			logger := &traceLogger{}
+			run(&rcon.Config{TraceWire: true}, logger, "TRACE")
+
+			for _, entry := range logger.Entries() {
+				Expect(entry).ToNot(ContainSubstring("password"))
+				Expect(entry).ToNot(ContainSubstring("70 61 73 73"))
+			}
+		})
+
+		g.It("Should not log dumps if disabled", func() {
+			Expect(run(&rcon.Config{}, &traceLogger{}, "TRACE")).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package rcon
+
+import (
+	"fmt"
+	"github.com/refractorgscm/rcon/errs"
+	"sync/atomic"
+)
+
+// WriteQueuePolicy determines what happens to a packet which is queued while the write queue is full.
+type WriteQueuePolicy uint8
+
+const (
+	// WriteQueueBlock makes the packet wait for up to QueueWriteTimeout for space in the queue. This is the default.
+	WriteQueueBlock WriteQueuePolicy = iota
+
+	// WriteQueueDropOldest drops the oldest queued packet to make space. The command which sent the dropped packet
+	// fails with errs.ErrMailboxClosed.
+	WriteQueueDropOldest
+
+	// WriteQueueError makes the packet fail immediately with errs.ErrQueueFull.
+	WriteQueueError
+)
+
+// QueueDepth returns the number of packets waiting in the write queue.
+func (c *Client) QueueDepth() int {
+	return len(c.writeQueue)
+}
+
+// tryEnqueue applies the WriteQueuePolicy if the write queue is full. It returns true if p was queued, false if it
+// should wait for space like with WriteQueueBlock, or an error if it must not be queued.
+func (c *Client) tryEnqueue(q queuedPacket) (bool, error) {
+	if c.WriteQueueSize <= 0 || c.WriteQueuePolicy == WriteQueueBlock {
+		return false, nil
+	}
+
+	for {
+		select {
+		case c.writeQueue <- q:
+			return true, nil
+		default:
+		}
+
+		if c.WriteQueuePolicy == WriteQueueError {
+			return false, fmt.Errorf("%d packets are queued: %w", c.WriteQueueSize, errs.ErrQueueFull)
+		}
+
+		select {
+		case old := <-c.writeQueue:
+			c.queued.remove(old.packet.ID())
+			c.log.Info("Write queue full, dropping oldest packet ", old.packet.ID())
+			atomic.AddInt64(&c.pendingWrites, -1)
+			c.removeMailbox(old.packet.ID())
+		default:
+		}
+	}
+}
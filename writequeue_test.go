@@ -0,0 +1,207 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/refractorgscm/rcon"
+	"github.com/refractorgscm/rcon/errs"
+	"github.com/refractorgscm/rcon/rcontest"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stallingConn blocks writes while its transport is stalled, which keeps the client's writer busy so that packets
+// pile up in the write queue.
+type stallingConn struct {
+	net.Conn
+	transport *stallingTransport
+}
+
+func (c *stallingConn) Write(p []byte) (int, error) {
+	c.transport.wait()
+	return c.Conn.Write(p)
+}
+
+type stallingTransport struct {
+	lock    sync.Mutex
+	release chan struct{}
+	blocked chan struct{}
+}
+
+func (t *stallingTransport) Dial(ctx context.Context, address string) (rcon.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stallingConn{Conn: conn, transport: t}, nil
+}
+
+func (t *stallingTransport) stall() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.release = make(chan struct{})
+	t.blocked = make(chan struct{}, 1)
+}
+
+func (t *stallingTransport) resume() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.release != nil {
+		close(t.release)
+		t.release = nil
+	}
+}
+
+func (t *stallingTransport) wait() {
+	t.lock.Lock()
+	release, blocked := t.release, t.blocked
+	t.lock.Unlock()
+
+	if release == nil {
+		return
+	}
+
+	select {
+	case blocked <- struct{}{}:
+	default:
+	}
+
+	<-release
+}
+
+func TestWriteQueue(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Write queue", func() {
+		var server *rcontest.Server
+		var transport *stallingTransport
+		var config *rcon.Config
+
+		g.BeforeEach(func() {
+			server = rcontest.StartServer(t, "password")
+			server.SetResponse("status", "ok")
+			host, port := server.Addr()
+
+			transport = &stallingTransport{}
+			config = &rcon.Config{
+				Host:              host,
+				Port:              port,
+				Password:          "password",
+				StreamTransport:   transport,
+				QueueWriteTimeout: time.Millisecond * 100,
+				WriteQueueSize:    2,
+			}
+		})
+
+		// fill stalls the writer on one command and queues two more behind it. It returns the errors of the three
+		// commands, which are sent once the transport resumes.
+		fill := func(client *rcon.Client) []chan error {
+			transport.stall()
+
+			results := make([]chan error, 3)
+			for i := range results {
+				results[i] = make(chan error, 1)
+				go func(res chan error) {
+					_, err := client.ExecCommand("status")
+					res <- err
+				}(results[i])
+
+				if i == 0 {
+					Eventually(transport.blocked, time.Second).Should(Receive())
+				} else {
+					Eventually(client.QueueDepth, time.Second).Should(Equal(i))
+				}
+			}
+
+			return results
+		}
+
+		g.It("Should buffer WriteQueueSize packets", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			results := fill(client)
+			Expect(client.QueueDepth()).To(Equal(2))
+
+			transport.resume()
+			for _, res := range results {
+				Eventually(res, time.Second).Should(Receive(BeNil()))
+			}
+			Expect(client.QueueDepth()).To(Equal(0))
+		})
+
+		g.It("Should block until QueueWriteTimeout by default", func() {
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			results := fill(client)
+
+			start := time.Now()
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrQueueTimeout)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically(">=", config.QueueWriteTimeout))
+
+			transport.resume()
+			for _, res := range results {
+				Eventually(res, time.Second).Should(Receive(BeNil()))
+			}
+		})
+
+		g.It("Should fail immediately with WriteQueueError", func() {
+			config.WriteQueuePolicy = rcon.WriteQueueError
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			results := fill(client)
+
+			start := time.Now()
+			_, err := client.ExecCommand("status")
+			Expect(errors.Is(err, errs.ErrQueueFull)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", config.QueueWriteTimeout))
+
+			transport.resume()
+			for _, res := range results {
+				Eventually(res, time.Second).Should(Receive(BeNil()))
+			}
+		})
+
+		g.It("Should drop the oldest queued packet with WriteQueueDropOldest", func() {
+			config.WriteQueuePolicy = rcon.WriteQueueDropOldest
+			client := rcon.NewClient(config, nil)
+			Expect(client.Connect()).To(BeNil())
+			defer client.Close()
+
+			results := fill(client)
+
+			latest := make(chan error, 1)
+			go func() {
+				_, err := client.ExecCommand("status")
+				latest <- err
+			}()
+
+			var err error
+			Eventually(results[1], time.Second).Should(Receive(&err))
+			Expect(errors.Is(err, errs.ErrMailboxClosed)).To(BeTrue())
+			Expect(client.QueueDepth()).To(Equal(2))
+
+			transport.resume()
+			Eventually(results[0], time.Second).Should(Receive(BeNil()))
+			Eventually(results[2], time.Second).Should(Receive(BeNil()))
+			Eventually(latest, time.Second).Should(Receive(BeNil()))
+			Expect(server.Commands()).To(HaveLen(3))
+		})
+	})
+}